@@ -0,0 +1,193 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/insert"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	"github.com/daniil11ru/egts/cli/receiver/dto/other"
+	"github.com/daniil11ru/egts/cli/receiver/server/domain"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// apiKeyReloadInterval — как часто ключи перечитываются из базы данных, а время их последнего
+// использования сохраняется в нее. Изменения через API применяются сразу, а изменения, сделанные
+// другим экземпляром API или вручную в базе, — не позже чем через этот интервал.
+const apiKeyReloadInterval = 30 * time.Second
+
+const apiKeyContextKey = "api_key"
+
+type apiKeyStore struct {
+	repository ApiKeysRepository
+
+	mu       sync.Mutex
+	byHash   map[string]out.ApiKey
+	loadedAt time.Time
+	lastUsed map[int32]time.Time
+}
+
+func newApiKeyStore(repository ApiKeysRepository) (*apiKeyStore, error) {
+	s := &apiKeyStore{repository: repository, lastUsed: make(map[int32]time.Time)}
+	if err := s.reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *apiKeyStore) reload() error {
+	apiKeys, err := s.repository.GetApiKeys()
+	if err != nil {
+		return fmt.Errorf("ошибка получения информации об API-ключах из базы данных: %w", err)
+	}
+	byHash := make(map[string]out.ApiKey, len(apiKeys))
+	for _, apiKey := range apiKeys {
+		byHash[strings.ToLower(apiKey.Hash)] = apiKey
+	}
+
+	s.mu.Lock()
+	s.byHash = byHash
+	s.loadedAt = time.Now()
+	s.mu.Unlock()
+	return nil
+}
+
+// refresh сохраняет время использования ключей и перечитывает их, если с прошлой загрузки прошло
+// больше apiKeyReloadInterval. При ошибке продолжают действовать ранее загруженные ключи.
+func (s *apiKeyStore) refresh(now time.Time) {
+	s.mu.Lock()
+	if now.Sub(s.loadedAt) < apiKeyReloadInterval {
+		s.mu.Unlock()
+		return
+	}
+	s.loadedAt = now
+	lastUsed := s.lastUsed
+	s.lastUsed = make(map[int32]time.Time)
+	s.mu.Unlock()
+
+	if err := s.repository.TouchApiKeys(lastUsed); err != nil {
+		logrus.Errorf("Не удалось сохранить время использования API-ключей: %v", err)
+	}
+	if err := s.reload(); err != nil {
+		logrus.Errorf("Не удалось обновить API-ключи: %v", err)
+	}
+}
+
+func (s *apiKeyStore) authenticate(key string, now time.Time) (out.ApiKey, bool) {
+	s.refresh(now)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	apiKey, ok := s.byHash[domain.HashApiKey(key)]
+	if !ok || !domain.ApiKeyActive(apiKey, now) {
+		return out.ApiKey{}, false
+	}
+	s.lastUsed[apiKey.ID] = now
+	return apiKey, true
+}
+
+func (s *apiKeyStore) middleware(c *gin.Context) {
+	apiKey, ok := s.authenticate(c.GetHeader("X-API-Key"), time.Now())
+	if !ok {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+	c.Set(apiKeyContextKey, apiKey)
+	c.Next()
+}
+
+func requestApiKey(c *gin.Context) out.ApiKey {
+	if v, ok := c.Get(apiKeyContextKey); ok {
+		return v.(out.ApiKey)
+	}
+	return out.ApiKey{}
+}
+
+// changeAuthor указывает в журнале изменений транспорта ключ, с которым выполнен запрос
+func changeAuthor(c *gin.Context) insert.VehicleChangeAuthor {
+	author := insert.VehicleChangeAuthor{Source: other.VehicleChangeSourceApi}
+	if id := requestApiKey(c).ID; id != 0 {
+		author.ApiKeyId = &id
+	}
+	return author
+}
+
+func checkScope(c *gin.Context, scope other.ApiKeyScope) bool {
+	if !requestApiKey(c).Scopes.Has(scope) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("Для запроса нужна область доступа %s", scope)})
+		return false
+	}
+	return true
+}
+
+// requireScope пропускает запрос с ключом, у которого есть область scope. Ключ, ограниченный
+// провайдером, не допускается: маршрут не умеет ограничивать данные провайдером.
+func requireScope(scope other.ApiKeyScope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !checkScope(c, scope) {
+			return
+		}
+		if providerId := requestApiKey(c).ProviderId; providerId != nil {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("Маршрут недоступен для ключа, ограниченного провайдером с ID %d", *providerId)})
+			return
+		}
+		c.Next()
+	}
+}
+
+// requireProviderScope пропускает запрос с ключом, у которого есть область scope, в том числе
+// ключ, ограниченный провайдером. Обработчик сам ограничивает данные провайдером ключа.
+func requireProviderScope(scope other.ApiKeyScope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !checkScope(c, scope) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// restrictProvider подставляет в фильтр провайдера, которым ограничен ключ. Если в запросе указан
+// другой провайдер, отвечает 403.
+func restrictProvider(c *gin.Context, providerId **int32) bool {
+	keyProviderId := requestApiKey(c).ProviderId
+	if keyProviderId == nil {
+		return true
+	}
+	if *providerId != nil && **providerId != *keyProviderId {
+		c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("Ключ ограничен провайдером с ID %d", *keyProviderId)})
+		return false
+	}
+	*providerId = keyProviderId
+	return true
+}
+
+// restrictVehicle отвечает 404 на запрос к транспорту чужого провайдера, если ключ ограничен
+// провайдером
+func (h *Handler) restrictVehicle(c *gin.Context) {
+	keyProviderId := requestApiKey(c).ProviderId
+	if keyProviderId == nil {
+		c.Next()
+		return
+	}
+
+	vehicleId, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Некорректный ID транспорта"})
+		return
+	}
+	vehicle, err := h.Repository.GetVehicle(int32(vehicleId))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if vehicle.ID == 0 || vehicle.ProviderId != *keyProviderId {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Транспорт не найден"})
+		return
+	}
+	c.Next()
+}
@@ -1,42 +1,60 @@
 package api
 
 import (
-	"crypto/sha256"
-	"crypto/subtle"
-	"encoding/hex"
 	"fmt"
-	"net/http"
 	"strconv"
+	"time"
 
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/insert"
 	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
-	"github.com/daniil11ru/egts/cli/receiver/util"
+	"github.com/daniil11ru/egts/cli/receiver/dto/other"
+	"github.com/daniil11ru/egts/cli/receiver/metrics"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
-	"github.com/sirupsen/logrus"
 )
 
 type Controller struct {
 	Handler           *Handler
 	Router            *gin.Engine
 	ApiKeysRepository ApiKeysRepository
-	ApiKeyHashes      []string
 }
 
 type ApiKeysRepository interface {
 	GetApiKeys() ([]out.ApiKey, error)
+	AddApiKey(apiKey insert.ApiKey) (int32, error)
+	RotateApiKey(id int32, hash string) error
+	RevokeApiKey(id int32, revokedAt time.Time) error
+	TouchApiKeys(lastUsed map[int32]time.Time) error
 }
 
 func NewController(handler *Handler, apiKeysRepository ApiKeysRepository) (*Controller, error) {
-	ApiKeyAttributes, err := apiKeysRepository.GetApiKeys()
+	apiKeys, err := newApiKeyStore(apiKeysRepository)
 	if err != nil {
-		return nil, fmt.Errorf("ошибка получения информации об API-ключах из базы данных: %w", err)
+		return nil, err
 	}
-	apiKeyHashes := util.Map(ApiKeyAttributes, func(item out.ApiKey) string {
-		return item.Hash
-	})
+	handler.apiKeys = apiKeys
 
 	router := gin.Default()
 
+	router.Use(func(c *gin.Context) {
+		startedAt := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		metrics.ApiRequestDuration.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).Observe(time.Since(startedAt).Seconds())
+	})
+
+	router.Use(timeFormatMiddleware(handler.TimeZone))
+
+	// Метрики и проверки состояния отдаются без API-ключа, чтобы их могли опрашивать Prometheus и
+	// оркестратор контейнеров
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
+	router.GET("/health/live", handler.GetLiveness)
+	router.GET("/health/ready", handler.GetReadiness)
+
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
 		AllowMethods:     []string{"GET"},
@@ -44,46 +62,126 @@ func NewController(handler *Handler, apiKeysRepository ApiKeysRepository) (*Cont
 		AllowCredentials: false,
 	}))
 
-	router.Use(func(c *gin.Context) {
-		apiKey := c.GetHeader("X-API-Key")
-
-		for _, apiKeyHashString := range apiKeyHashes {
-			apiKeyHash, err := hex.DecodeString(apiKeyHashString)
-			if err != nil {
-				logrus.Errorf("ошибка декодирования хеш-строки API-ключа: %v", err)
-				c.AbortWithStatus(http.StatusInternalServerError)
-				return
-			}
-
-			receivedApiKeyHash := sha256.Sum256([]byte(apiKey))
-			computedReceivedApiKeyHash := receivedApiKeyHash[:]
-
-			if subtle.ConstantTimeCompare(computedReceivedApiKeyHash, apiKeyHash) == 1 {
-				c.Next()
-				return
-			}
-		}
+	router.Use(apiKeys.middleware)
 
-		c.AbortWithStatus(http.StatusUnauthorized)
-	})
+	readVehicles := requireProviderScope(other.ApiKeyScopeReadVehicles)
+	readLocations := requireProviderScope(other.ApiKeyScopeReadLocations)
+	moderate := requireProviderScope(other.ApiKeyScopeModerate)
+	admin := requireScope(other.ApiKeyScopeAdmin)
 
 	api := router.Group("/api/v1")
 
 	vehicles := api.Group("/vehicles")
 	{
-		vehicles.GET("/", handler.GetVehicles)
-		vehicles.GET("/:id", handler.GetVehicle)
-		vehicles.GET("/excel", handler.GetVehiclesExcel)
-		vehicles.PATCH("/", handler.UpdateVehicleByImei)
-		vehicles.PATCH("/:id", handler.UpdateVehicleById)
+		vehicles.GET("/", readVehicles, handler.GetVehicles)
+		vehicles.GET("/:id", readVehicles, handler.restrictVehicle, handler.GetVehicle)
+		vehicles.GET("/excel", readVehicles, handler.GetVehiclesExcel)
+		vehicles.GET("/statuses", readVehicles, handler.GetVehicleStatuses)
+		vehicles.PATCH("/", moderate, handler.UpdateVehicleByImei)
+		vehicles.PATCH("/:id", moderate, handler.restrictVehicle, handler.UpdateVehicleById)
+		vehicles.DELETE("/:id", admin, handler.DeleteVehicle)
+		vehicles.POST("/:id/merge", moderate, handler.restrictVehicle, handler.MergeVehicles)
+		vehicles.POST("/", moderate, handler.AddVehicle)
+		vehicles.POST("/import", moderate, handler.ImportVehicles)
+		vehicles.POST("/moderation", moderate, handler.ModerateVehicles)
+		vehicles.GET("/:id/track", readLocations, handler.restrictVehicle, handler.GetVehicleTrack)
+		vehicles.GET("/:id/trips", readLocations, handler.restrictVehicle, handler.GetTrips)
+		vehicles.GET("/:id/stops", readLocations, handler.restrictVehicle, handler.GetStops)
+		vehicles.GET("/:id/geofence-events", readLocations, handler.restrictVehicle, handler.GetVehicleGeofenceEvents)
+		vehicles.GET("/:id/status", readVehicles, handler.restrictVehicle, handler.GetVehicleStatus)
+		vehicles.GET("/:id/history", moderate, handler.restrictVehicle, handler.GetVehicleHistory)
 	}
 
-	locations := api.Group("/locations")
+	locations := api.Group("/locations", readLocations)
 	{
 		locations.GET("/", handler.GetLocations)
+		locations.GET("/history-backlog", handler.GetHistoryBacklog)
+		locations.GET("/stream", handler.StreamLocations)
+	}
+
+	quarantine := api.Group("/quarantine")
+	{
+		quarantine.GET("/", requireScope(other.ApiKeyScopeReadLocations), handler.GetQuarantinedLocations)
+		quarantine.GET("/groups", requireScope(other.ApiKeyScopeReadLocations), handler.GetQuarantineGroups)
+		quarantine.POST("/reprocess", requireScope(other.ApiKeyScopeModerate), handler.ReprocessQuarantine)
+	}
+
+	providers := api.Group("/providers", admin)
+	{
+		providers.GET("/", handler.GetProviders)
+		providers.POST("/", handler.AddProvider)
+		providers.GET("/:id", handler.GetProvider)
+		providers.PATCH("/:id", handler.UpdateProvider)
+		providers.DELETE("/:id", handler.DeleteProvider)
+		providers.GET("/:id/allowed-networks", handler.GetProviderAllowedNetworks)
+		providers.POST("/:id/allowed-networks", handler.AddProviderAllowedNetwork)
+		providers.DELETE("/:id/allowed-networks/:network_id", handler.DeleteProviderAllowedNetwork)
+		providers.GET("/:id/resolution-rules", handler.GetOidResolutionRules)
+		providers.POST("/:id/resolution-rules", handler.AddOidResolutionRule)
+		providers.POST("/:id/resolution-rules/dry-run", handler.DryRunOidResolution)
+		providers.PATCH("/:id/resolution-rules/:rule_id", handler.UpdateOidResolutionRule)
+		providers.DELETE("/:id/resolution-rules/:rule_id", handler.DeleteOidResolutionRule)
+		providers.GET("/:id/gps-filter", handler.GetGpsFilterSettings)
+		providers.PUT("/:id/gps-filter", handler.SaveGpsFilterSettings)
+		providers.DELETE("/:id/gps-filter", handler.DeleteGpsFilterSettings)
+		providers.GET("/:id/track-simplification", handler.GetTrackSimplificationSettings)
+		providers.PUT("/:id/track-simplification", handler.SaveTrackSimplificationSettings)
+		providers.DELETE("/:id/track-simplification", handler.DeleteTrackSimplificationSettings)
+		providers.POST("/:id/track-simplification/dry-run", handler.DryRunTrackSimplification)
+	}
+
+	rejectedLocations := api.Group("/rejected-locations", requireScope(other.ApiKeyScopeReadLocations))
+	{
+		rejectedLocations.GET("/", handler.GetRejectedLocations)
+	}
+
+	vehicleGroups := api.Group("/vehicle-groups")
+	{
+		vehicleGroups.GET("/", requireScope(other.ApiKeyScopeReadVehicles), handler.GetVehicleGroups)
+		vehicleGroups.POST("/", admin, handler.AddVehicleGroup)
+		vehicleGroups.DELETE("/:id", admin, handler.DeleteVehicleGroup)
+	}
+
+	geofences := api.Group("/geofences")
+	{
+		geofences.GET("/", requireScope(other.ApiKeyScopeReadLocations), handler.GetGeofences)
+		geofences.POST("/", admin, handler.AddGeofence)
+		geofences.POST("/import", admin, handler.ImportGeofences)
+		geofences.GET("/:id", requireScope(other.ApiKeyScopeReadLocations), handler.GetGeofence)
+		geofences.PUT("/:id", admin, handler.ReplaceGeofence)
+		geofences.DELETE("/:id", admin, handler.DeleteGeofence)
+		geofences.GET("/:id/events", requireScope(other.ApiKeyScopeReadLocations), handler.GetGeofenceEvents)
+	}
+
+	reports := api.Group("/reports", readLocations)
+	{
+		reports.GET("/mileage", handler.GetDailyMileage)
+		reports.GET("/mileage/providers", handler.GetProviderDailyMileage)
+		reports.GET("/mileage/excel", handler.GetDailyMileageExcel)
+	}
+
+	terminalSessions := api.Group("/terminal-sessions", readVehicles)
+	{
+		terminalSessions.GET("/", handler.GetTerminalSessions)
+	}
+
+	acceptanceSchedules := api.Group("/acceptance-schedules", admin)
+	{
+		acceptanceSchedules.GET("/", handler.GetAcceptanceSchedules)
+		acceptanceSchedules.POST("/", handler.AddAcceptanceSchedule)
+		acceptanceSchedules.PUT("/:id", handler.ReplaceAcceptanceSchedule)
+		acceptanceSchedules.DELETE("/:id", handler.DeleteAcceptanceSchedule)
+	}
+
+	apiKeysGroup := api.Group("/api-keys", admin)
+	{
+		apiKeysGroup.GET("/", handler.GetApiKeys)
+		apiKeysGroup.POST("/", handler.AddApiKey)
+		apiKeysGroup.POST("/:id/rotate", handler.RotateApiKey)
+		apiKeysGroup.DELETE("/:id", handler.RevokeApiKey)
 	}
 
-	return &Controller{Handler: handler, Router: router, ApiKeysRepository: apiKeysRepository, ApiKeyHashes: apiKeyHashes}, nil
+	return &Controller{Handler: handler, Router: router, ApiKeysRepository: apiKeysRepository}, nil
 }
 
 func (c *Controller) Run(port int) error {
@@ -2,29 +2,93 @@ package api
 
 import (
 	"bytes"
-	"fmt"
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/daniil11ru/egts/cli/receiver/api/repository"
+	"github.com/daniil11ru/egts/cli/receiver/broker"
 	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/filter"
 	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/update"
 	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
 	"github.com/daniil11ru/egts/cli/receiver/dto/other"
 	"github.com/daniil11ru/egts/cli/receiver/dto/request"
 	"github.com/daniil11ru/egts/cli/receiver/dto/response"
+	"github.com/daniil11ru/egts/cli/receiver/server/domain"
 	"github.com/daniil11ru/egts/cli/receiver/util"
 	"github.com/gin-gonic/gin"
 	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
 )
 
+type QuarantineReprocessor interface {
+	Run(providerId *int32, oid *int64) (domain.ReprocessQuarantineResult, error)
+}
+
+type LastPositionInvalidator interface {
+	Invalidate(vehicleId int32)
+}
+
+type IngestCacheInvalidator interface {
+	InvalidateGpsFilterSettings(providerId int32)
+	InvalidateGeofences()
+	InvalidateAcceptanceSchedules()
+	InvalidateVehicles(providerId int32)
+	InvalidateAllVehicles()
+}
+
+type TrackSimplifier interface {
+	DryRun(tracksFilter filter.Tracks, settings *out.TrackSimplificationSettings) ([]domain.VehicleTrackSimplification, error)
+}
+
+type LocationSubscriber interface {
+	Subscribe(filter broker.LocationFilter) *broker.LocationSubscription
+	Unsubscribe(subscription *broker.LocationSubscription)
+}
+
+type TerminalStatusResolver interface {
+	Resolve(connection out.VehicleConnection, now time.Time) other.TerminalStatus
+}
+
+type HealthChecker interface {
+	Liveness(now time.Time) domain.HealthReport
+	Readiness(now time.Time) domain.HealthReport
+}
+
+type ProviderChangeNotifier interface {
+	Notify()
+}
+
 type Handler struct {
-	Repository repository.BusinessData
+	Repository              repository.BusinessData
+	QuarantineReprocessor   QuarantineReprocessor
+	LastPositionInvalidator LastPositionInvalidator
+	IngestCacheInvalidator  IngestCacheInvalidator
+	TrackSimplifier         TrackSimplifier
+	LocationSubscriber      LocationSubscriber
+	TerminalStatusResolver  TerminalStatusResolver
+	HealthChecker           HealthChecker
+	ProviderChangeNotifier  ProviderChangeNotifier
+	TimeZone                *time.Location
+
+	// apiKeys задается контроллером, который проверяет ключи
+	apiKeys *apiKeyStore
 }
 
-func NewHandler(repository repository.BusinessData) *Handler {
-	return &Handler{Repository: repository}
+func NewHandler(repository repository.BusinessData, quarantineReprocessor QuarantineReprocessor, lastPositionInvalidator LastPositionInvalidator, ingestCacheInvalidator IngestCacheInvalidator, trackSimplifier TrackSimplifier, locationSubscriber LocationSubscriber, terminalStatusResolver TerminalStatusResolver, healthChecker HealthChecker, providerChangeNotifier ProviderChangeNotifier, timeZone *time.Location) *Handler {
+	return &Handler{
+		Repository:              repository,
+		QuarantineReprocessor:   quarantineReprocessor,
+		LastPositionInvalidator: lastPositionInvalidator,
+		IngestCacheInvalidator:  ingestCacheInvalidator,
+		TrackSimplifier:         trackSimplifier,
+		LocationSubscriber:      locationSubscriber,
+		TerminalStatusResolver:  terminalStatusResolver,
+		HealthChecker:           healthChecker,
+		ProviderChangeNotifier:  providerChangeNotifier,
+		TimeZone:                timeZone,
+	}
 }
 
 func (h *Handler) GetVehicles(c *gin.Context) {
@@ -44,6 +108,9 @@ func (h *Handler) GetVehicles(c *gin.Context) {
 			return
 		}
 	}
+	if !restrictProvider(c, &getVehiclesFilter.ProviderId) {
+		return
+	}
 
 	if moderationStatusStr := c.Query("moderation_status"); moderationStatusStr != "" {
 		moderationStatus := other.ModerationStatus(moderationStatusStr)
@@ -58,12 +125,40 @@ func (h *Handler) GetVehicles(c *gin.Context) {
 		getVehiclesFilter.IMEI = &imei
 	}
 
+	if vehicleGroupIdStr := c.Query("vehicle_group_id"); vehicleGroupIdStr != "" {
+		vehicleGroupId, err := strconv.ParseInt(vehicleGroupIdStr, 10, 32)
+		if err != nil || vehicleGroupId < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный vehicle_group_id"})
+			return
+		}
+		vehicleGroupId32 := int32(vehicleGroupId)
+		getVehiclesFilter.VehicleGroupId = &vehicleGroupId32
+	}
+
+	page, paginated, ok := parsePage(c, filter.VehicleSortFields, "id")
+	if !ok {
+		return
+	}
+	getVehiclesFilter.Page = page
+
+	fields, ok := parseFields(c, vehicleFields)
+	if !ok {
+		return
+	}
+
 	vehicles, err := h.Repository.GetVehicles(getVehiclesFilter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	var next *pageCursor
+	if paginated && len(vehicles) > page.Limit {
+		vehicles = vehicles[:page.Limit]
+		last := vehicles[len(vehicles)-1]
+		next = &pageCursor{Sort: c.DefaultQuery("sort", "id"), Value: vehicleSortValue(last, page.Sort), ID: int64(last.ID)}
+	}
+
 	resp := response.GetVehicles(util.Map(vehicles, func(item out.Vehicle) response.GetVehicle {
 		return response.GetVehicle{
 			ID:               item.ID,
@@ -72,10 +167,21 @@ func (h *Handler) GetVehicles(c *gin.Context) {
 			Name:             item.Name,
 			ProviderID:       item.ProviderId,
 			ModerationStatus: item.ModerationStatus.String(),
+			VehicleGroupID:   item.VehicleGroupId,
 		}
 	}))
 
-	c.JSON(http.StatusOK, resp)
+	items, err := selectFields(resp, fields)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if paginated {
+		writePage(c, items, next)
+		return
+	}
+	c.JSON(http.StatusOK, items)
 }
 
 func (h *Handler) GetVehicle(c *gin.Context) {
@@ -98,6 +204,7 @@ func (h *Handler) GetVehicle(c *gin.Context) {
 		Name:             vehicle.Name,
 		ProviderID:       vehicle.ProviderId,
 		ModerationStatus: vehicle.ModerationStatus.String(),
+		VehicleGroupID:   vehicle.VehicleGroupId,
 	}
 
 	c.JSON(http.StatusOK, resp)
@@ -119,6 +226,9 @@ func (h *Handler) GetVehiclesExcel(c *gin.Context) {
 		id := int32(v)
 		getVehiclesFilter.ProviderId = &id
 	}
+	if !restrictProvider(c, &getVehiclesFilter.ProviderId) {
+		return
+	}
 
 	if s := c.Query("moderation_status"); s != "" {
 		ms := other.ModerationStatus(s)
@@ -135,48 +245,52 @@ func (h *Handler) GetVehiclesExcel(c *gin.Context) {
 		return
 	}
 
+	rows := make([][]any, 0, len(vehicles))
+	for _, v := range vehicles {
+		row := []any{v.ID, v.IMEI, nil, nil, v.ProviderId, v.ModerationStatus}
+		if v.OID != nil {
+			row[2] = *v.OID
+		}
+		if v.Name != nil {
+			row[3] = *v.Name
+		}
+		rows = append(rows, row)
+	}
+
 	f := excelize.NewFile()
-	sheet := "Sheet1"
 	headers := []string{"ID", "IMEI", "OID", "Название", "ID провайдера", "Статус модерации"}
+	if err := writeExcelSheet(f, "Sheet1", headers, rows); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	sendExcel(c, f, "vehicles.xlsx")
+}
+
+// writeExcelSheet записывает на лист строку заголовков и строки данных. Ячейки со значением nil
+// остаются пустыми.
+func writeExcelSheet(f *excelize.File, sheet string, headers []string, rows [][]any) error {
 	for i, hName := range headers {
 		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
 		if err := f.SetCellValue(sheet, cell, hName); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
+			return err
 		}
 	}
-	for i, v := range vehicles {
-		row := i + 2
-		if err := f.SetCellValue(sheet, fmt.Sprintf("A%d", row), v.ID); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-		if err := f.SetCellValue(sheet, fmt.Sprintf("B%d", row), v.IMEI); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-		if v.OID != nil {
-			if err := f.SetCellValue(sheet, fmt.Sprintf("C%d", row), *v.OID); err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-				return
+	for i, row := range rows {
+		for j, value := range row {
+			if value == nil {
+				continue
 			}
-		}
-		if v.Name != nil {
-			if err := f.SetCellValue(sheet, fmt.Sprintf("D%d", row), *v.Name); err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-				return
+			cell, _ := excelize.CoordinatesToCellName(j+1, i+2)
+			if err := f.SetCellValue(sheet, cell, value); err != nil {
+				return err
 			}
 		}
-		if err := f.SetCellValue(sheet, fmt.Sprintf("E%d", row), v.ProviderId); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-		if err := f.SetCellValue(sheet, fmt.Sprintf("F%d", row), v.ModerationStatus); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
 	}
+	return nil
+}
 
+func sendExcel(c *gin.Context, f *excelize.File, filename string) {
 	var buf bytes.Buffer
 	if err := f.Write(&buf); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -184,15 +298,14 @@ func (h *Handler) GetVehiclesExcel(c *gin.Context) {
 	}
 
 	c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
-	c.Header("Content-Disposition", "attachment; filename=vehicles.xlsx")
+	c.Header("Content-Disposition", "attachment; filename="+filename)
 	c.Data(http.StatusOK, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", buf.Bytes())
 }
 
 func (h *Handler) GetLocations(c *gin.Context) {
+	tf := requestTimeFormat(c)
 	getLocationsFilter := filter.Locations{}
 
-	const timeLayout = "02.01.2006 15:04:05"
-
 	if locationsLimitStr := c.Query("locations_limit"); locationsLimitStr != "" {
 		locationsLimit, err := strconv.Atoi(locationsLimitStr)
 		if err == nil {
@@ -223,43 +336,60 @@ func (h *Handler) GetLocations(c *gin.Context) {
 			return
 		}
 	}
+	var ok bool
+	if getLocationsFilter.ProviderId, ok = parseOptionalId(c, "provider_id"); !ok || !restrictProvider(c, &getLocationsFilter.ProviderId) {
+		return
+	}
 	if sentBeforeStr := c.Query("sent_before"); sentBeforeStr != "" {
-		sentBefore, err := time.Parse(timeLayout, sentBeforeStr)
+		sentBefore, err := tf.parse(sentBeforeStr)
 		if err == nil {
 			getLocationsFilter.SentBefore = &sentBefore
 		} else {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Дата должна быть в формате DD.MM.YYYY HH:MM:SS"})
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Дата должна быть в формате RFC 3339 или DD.MM.YYYY HH:MM:SS"})
 			return
 		}
 	}
 	if sentAfterStr := c.Query("sent_after"); sentAfterStr != "" {
-		sentAfter, err := time.Parse(timeLayout, sentAfterStr)
+		sentAfter, err := tf.parse(sentAfterStr)
 		if err == nil {
 			getLocationsFilter.SentAfter = &sentAfter
 		} else {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Дата должна быть в формате DD.MM.YYYY HH:MM:SS"})
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Дата должна быть в формате RFC 3339 или DD.MM.YYYY HH:MM:SS"})
 			return
 		}
 	}
 	if receivedBeforeStr := c.Query("received_before"); receivedBeforeStr != "" {
-		receivedBefore, err := time.Parse(timeLayout, receivedBeforeStr)
+		receivedBefore, err := tf.parse(receivedBeforeStr)
 		if err == nil {
 			getLocationsFilter.ReceivedBefore = &receivedBefore
 		} else {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Дата должна быть в формате DD.MM.YYYY HH:MM:SS"})
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Дата должна быть в формате RFC 3339 или DD.MM.YYYY HH:MM:SS"})
 			return
 		}
 	}
 	if receivedAfterStr := c.Query("received_after"); receivedAfterStr != "" {
-		receivedAfter, err := time.Parse(timeLayout, receivedAfterStr)
+		receivedAfter, err := tf.parse(receivedAfterStr)
 		if err == nil {
 			getLocationsFilter.ReceivedAfter = &receivedAfter
 		} else {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Дата должна быть в формате DD.MM.YYYY HH:MM:SS"})
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Дата должна быть в формате RFC 3339 или DD.MM.YYYY HH:MM:SS"})
 			return
 		}
 	}
 
+	page, paginated, ok := parsePage(c, filter.LocationSortFields, "-sent_at")
+	if !ok {
+		return
+	}
+	if paginated {
+		h.getLocationsPage(c, getLocationsFilter, page)
+		return
+	}
+	if c.Query("sort") != "" || c.Query("fields") != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Параметры sort и fields поддерживаются только вместе с limit или cursor"})
+		return
+	}
+
 	locations, err := h.Repository.GetLocations(getLocationsFilter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -276,22 +406,7 @@ func (h *Handler) GetLocations(c *gin.Context) {
 			}
 		}
 
-		track.Locations = append(track.Locations, response.Location{
-			OID:            loc.OID,
-			Latitude:       loc.Latitude,
-			Longitude:      loc.Longitude,
-			Altitude:       loc.Altitude,
-			Direction:      loc.Direction,
-			Speed:          loc.Speed,
-			SatelliteCount: loc.SatelliteCount,
-			SentAt: func() *string {
-				if loc.SentAt == nil {
-					return nil
-				}
-				s := loc.SentAt.Format(timeLayout)
-				return &s
-			}(),
-			ReceivedAt: loc.ReceivedAt.Format(timeLayout)})
+		track.Locations = append(track.Locations, toLocationResponse(loc, tf))
 		tracks[loc.VehicleId] = track
 	}
 
@@ -303,6 +418,54 @@ func (h *Handler) GetLocations(c *gin.Context) {
 	c.JSON(http.StatusOK, response.GetLocations(vehicleTracks))
 }
 
+func toLocationResponse(loc out.Location, tf timeFormat) response.Location {
+	return response.Location{
+		OID:            loc.OID,
+		Latitude:       loc.Latitude,
+		Longitude:      loc.Longitude,
+		Altitude:       loc.Altitude,
+		Direction:      loc.Direction,
+		Speed:          loc.Speed,
+		SatelliteCount: loc.SatelliteCount,
+		SentAt:         tf.formatOptional(loc.SentAt),
+		ReceivedAt:     tf.format(loc.ReceivedAt),
+		IsHistory:      loc.IsHistory,
+	}
+}
+
+// getLocationsPage отвечает страницей общего списка местоположений всех транспортных средств
+func (h *Handler) getLocationsPage(c *gin.Context, getLocationsFilter filter.Locations, page *filter.Page) {
+	tf := requestTimeFormat(c)
+	fields, ok := parseFields(c, locationFields)
+	if !ok {
+		return
+	}
+
+	getLocationsFilter.Page = page
+	locations, err := h.Repository.GetLocations(getLocationsFilter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var next *pageCursor
+	if len(locations) > page.Limit {
+		locations = locations[:page.Limit]
+		last := locations[len(locations)-1]
+		next = &pageCursor{Sort: c.DefaultQuery("sort", "-sent_at"), Value: locationSortValue(last, page.Sort), ID: int64(last.ID)}
+	}
+
+	items, err := selectFields(util.Map(locations, func(loc out.Location) response.PagedLocation {
+		return response.PagedLocation{ID: loc.ID, VehicleId: loc.VehicleId, Location: toLocationResponse(loc, tf)}
+	}), fields)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	writePage(c, items, next)
+}
+
 func (h *Handler) UpdateVehicleByImei(c *gin.Context) {
 	var req request.UpdateVehicle
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -317,13 +480,36 @@ func (h *Handler) UpdateVehicleByImei(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Нечего обновлять"})
 		return
 	}
+	if keyProviderId := requestApiKey(c).ProviderId; keyProviderId != nil {
+		vehicles, err := h.Repository.GetVehicles(filter.Vehicles{IMEI: req.IMEI})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		for _, vehicle := range vehicles {
+			if vehicle.ProviderId != *keyProviderId {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Транспорт не найден"})
+				return
+			}
+		}
+	}
 	if err := h.Repository.UpdateVehicleByImei(*req.IMEI, update.VehicleByImei{
 		Name:             req.Name,
 		ModerationStatus: req.ModerationStatus,
-	}); err != nil {
+	}, changeAuthor(c)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Транспорт не найден"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	if vehicles, err := h.Repository.GetVehicles(filter.Vehicles{IMEI: req.IMEI}); err == nil {
+		for _, vehicle := range vehicles {
+			h.LastPositionInvalidator.Invalidate(vehicle.ID)
+		}
+	}
+	h.IngestCacheInvalidator.InvalidateAllVehicles()
 	c.Status(http.StatusOK)
 }
 
@@ -341,7 +527,7 @@ func (h *Handler) UpdateVehicleById(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	if req.IMEI == nil && req.Name == nil && req.ModerationStatus == nil {
+	if req.IMEI == nil && req.Name == nil && req.ModerationStatus == nil && req.VehicleGroupID == nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Нечего обновлять"})
 		return
 	}
@@ -349,9 +535,16 @@ func (h *Handler) UpdateVehicleById(c *gin.Context) {
 		Name:             req.Name,
 		ModerationStatus: req.ModerationStatus,
 		IMEI:             req.IMEI,
-	}); err != nil {
+		VehicleGroupId:   req.VehicleGroupID,
+	}, changeAuthor(c)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Транспорт не найден"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	h.LastPositionInvalidator.Invalidate(int32(vehicleId))
+	h.IngestCacheInvalidator.InvalidateAllVehicles()
 	c.Status(http.StatusOK)
 }
@@ -0,0 +1,190 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/filter"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/insert"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	"github.com/daniil11ru/egts/cli/receiver/dto/other"
+	"github.com/daniil11ru/egts/cli/receiver/dto/request"
+	"github.com/daniil11ru/egts/cli/receiver/dto/response"
+	"github.com/daniil11ru/egts/cli/receiver/server/domain"
+	"github.com/daniil11ru/egts/cli/receiver/util"
+	"github.com/gin-gonic/gin"
+)
+
+func toAcceptanceScheduleResponse(schedule out.AcceptanceSchedule) response.AcceptanceSchedule {
+	return response.AcceptanceSchedule{
+		ID:              schedule.ID,
+		ProviderID:      schedule.ProviderId,
+		VehicleGroupID:  schedule.VehicleGroupId,
+		DateFrom:        schedule.DateFrom,
+		DateTo:          schedule.DateTo,
+		RecurringYearly: schedule.RecurringYearly,
+		WeekdayMask:     schedule.WeekdayMask,
+		HourMask:        schedule.HourMask,
+		TimeZone:        schedule.TimeZone,
+		TimeBasis:       schedule.TimeBasis.String(),
+	}
+}
+
+func parseAcceptanceScheduleId(c *gin.Context) (int32, bool) {
+	scheduleId, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil || scheduleId <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный ID расписания"})
+		return 0, false
+	}
+	return int32(scheduleId), true
+}
+
+// bindAcceptanceSchedule читает расписание из тела запроса, подставляет значения по умолчанию для
+// незаданных полей и проверяет результат
+func bindAcceptanceSchedule(c *gin.Context) (insert.AcceptanceSchedule, bool) {
+	var req request.AcceptanceSchedule
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return insert.AcceptanceSchedule{}, false
+	}
+
+	schedule := out.AcceptanceSchedule{
+		ProviderId:      req.ProviderID,
+		VehicleGroupId:  req.VehicleGroupID,
+		DateFrom:        req.DateFrom,
+		DateTo:          req.DateTo,
+		RecurringYearly: req.RecurringYearly,
+		WeekdayMask:     1<<7 - 1,
+		HourMask:        1<<24 - 1,
+		TimeZone:        "Europe/Moscow",
+		TimeBasis:       other.AcceptanceTimeBasisBoth,
+	}
+	if req.WeekdayMask != nil {
+		schedule.WeekdayMask = *req.WeekdayMask
+	}
+	if req.HourMask != nil {
+		schedule.HourMask = *req.HourMask
+	}
+	if req.TimeZone != nil {
+		schedule.TimeZone = *req.TimeZone
+	}
+	if req.TimeBasis != nil {
+		schedule.TimeBasis = *req.TimeBasis
+	}
+
+	if err := domain.ValidateAcceptanceSchedule(&schedule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return insert.AcceptanceSchedule{}, false
+	}
+
+	return insert.AcceptanceSchedule{
+		ProviderId:      schedule.ProviderId,
+		VehicleGroupId:  schedule.VehicleGroupId,
+		DateFrom:        schedule.DateFrom,
+		DateTo:          schedule.DateTo,
+		RecurringYearly: schedule.RecurringYearly,
+		WeekdayMask:     schedule.WeekdayMask,
+		HourMask:        schedule.HourMask,
+		TimeZone:        schedule.TimeZone,
+		TimeBasis:       schedule.TimeBasis,
+	}, true
+}
+
+func (h *Handler) acceptanceScheduleExists(c *gin.Context, id int32) bool {
+	schedules, err := h.Repository.GetAcceptanceSchedules(filter.AcceptanceSchedules{ID: &id})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+	if len(schedules) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Расписание не найдено"})
+		return false
+	}
+	return true
+}
+
+func (h *Handler) GetAcceptanceSchedules(c *gin.Context) {
+	schedulesFilter := filter.AcceptanceSchedules{}
+
+	if providerIdStr := c.Query("provider_id"); providerIdStr != "" {
+		providerId, err := strconv.ParseInt(providerIdStr, 10, 32)
+		if err != nil || providerId < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный provider_id"})
+			return
+		}
+		providerId32 := int32(providerId)
+		schedulesFilter.ProviderId = &providerId32
+	}
+
+	if vehicleGroupIdStr := c.Query("vehicle_group_id"); vehicleGroupIdStr != "" {
+		vehicleGroupId, err := strconv.ParseInt(vehicleGroupIdStr, 10, 32)
+		if err != nil || vehicleGroupId < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный vehicle_group_id"})
+			return
+		}
+		vehicleGroupId32 := int32(vehicleGroupId)
+		schedulesFilter.VehicleGroupId = &vehicleGroupId32
+	}
+
+	schedules, err := h.Repository.GetAcceptanceSchedules(schedulesFilter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response.GetAcceptanceSchedules(util.Map(schedules, toAcceptanceScheduleResponse)))
+}
+
+func (h *Handler) AddAcceptanceSchedule(c *gin.Context) {
+	schedule, ok := bindAcceptanceSchedule(c)
+	if !ok {
+		return
+	}
+
+	id, err := h.Repository.AddAcceptanceSchedule(schedule)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.IngestCacheInvalidator.InvalidateAcceptanceSchedules()
+	c.JSON(http.StatusCreated, gin.H{"id": id})
+}
+
+func (h *Handler) ReplaceAcceptanceSchedule(c *gin.Context) {
+	scheduleId, ok := parseAcceptanceScheduleId(c)
+	if !ok {
+		return
+	}
+	schedule, ok := bindAcceptanceSchedule(c)
+	if !ok {
+		return
+	}
+	if !h.acceptanceScheduleExists(c, scheduleId) {
+		return
+	}
+
+	if err := h.Repository.ReplaceAcceptanceSchedule(scheduleId, schedule); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.IngestCacheInvalidator.InvalidateAcceptanceSchedules()
+	c.Status(http.StatusOK)
+}
+
+func (h *Handler) DeleteAcceptanceSchedule(c *gin.Context) {
+	scheduleId, ok := parseAcceptanceScheduleId(c)
+	if !ok {
+		return
+	}
+	if !h.acceptanceScheduleExists(c, scheduleId) {
+		return
+	}
+
+	if err := h.Repository.DeleteAcceptanceSchedule(scheduleId); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.IngestCacheInvalidator.InvalidateAcceptanceSchedules()
+	c.Status(http.StatusOK)
+}
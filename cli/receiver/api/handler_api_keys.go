@@ -0,0 +1,155 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/insert"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	"github.com/daniil11ru/egts/cli/receiver/dto/other"
+	"github.com/daniil11ru/egts/cli/receiver/dto/request"
+	"github.com/daniil11ru/egts/cli/receiver/dto/response"
+	"github.com/daniil11ru/egts/cli/receiver/server/domain"
+	"github.com/daniil11ru/egts/cli/receiver/util"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+func parseApiKeyId(c *gin.Context) (int32, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный ID ключа"})
+		return 0, false
+	}
+	return int32(id), true
+}
+
+// reloadApiKeys применяет изменения ключей сразу, не дожидаясь периодического обновления
+func (h *Handler) reloadApiKeys() {
+	if err := h.apiKeys.reload(); err != nil {
+		logrus.Errorf("Не удалось обновить API-ключи: %v", err)
+	}
+}
+
+func (h *Handler) GetApiKeys(c *gin.Context) {
+	tf := requestTimeFormat(c)
+	apiKeys, err := h.apiKeys.repository.GetApiKeys()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	now := time.Now()
+	c.JSON(http.StatusOK, response.GetApiKeys(util.Map(apiKeys, func(item out.ApiKey) response.ApiKey {
+		return response.ApiKey{
+			ID:         item.ID,
+			Name:       item.Name,
+			Scopes:     util.Map(item.Scopes, other.ApiKeyScope.String),
+			ProviderID: item.ProviderId,
+			ExpiresAt:  tf.formatOptional(item.ExpiresAt),
+			RevokedAt:  tf.formatOptional(item.RevokedAt),
+			CreatedAt:  tf.format(item.CreatedAt),
+			LastUsedAt: tf.formatOptional(item.LastUsedAt),
+			Active:     domain.ApiKeyActive(item, now),
+		}
+	})))
+}
+
+func (h *Handler) AddApiKey(c *gin.Context) {
+	var req request.AddApiKey
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := domain.ValidateApiKey(req.Name, req.Scopes, req.ProviderID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	apiKey := insert.ApiKey{Name: req.Name, Scopes: req.Scopes, ProviderId: req.ProviderID}
+	if req.ExpiresAt != nil {
+		expiresAt, err := requestTimeFormat(c).parse(*req.ExpiresAt)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Дата должна быть в формате RFC 3339 или DD.MM.YYYY HH:MM:SS"})
+			return
+		}
+		if !expiresAt.After(time.Now()) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Срок действия ключа должен быть в будущем"})
+			return
+		}
+		apiKey.ExpiresAt = &expiresAt
+	}
+	if req.ProviderID != nil {
+		if _, err := h.Repository.GetProvider(*req.ProviderID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Провайдер не найден"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	key, hash, err := domain.NewApiKey()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	apiKey.Hash = hash
+
+	id, err := h.apiKeys.repository.AddApiKey(apiKey)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.reloadApiKeys()
+
+	c.JSON(http.StatusCreated, response.IssuedApiKey{ID: id, Key: key})
+}
+
+// RotateApiKey выпускает для ключа новое значение; прежнее перестает действовать сразу
+func (h *Handler) RotateApiKey(c *gin.Context) {
+	id, ok := parseApiKeyId(c)
+	if !ok {
+		return
+	}
+
+	key, hash, err := domain.NewApiKey()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.apiKeys.repository.RotateApiKey(id, hash); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Действующий ключ не найден"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.reloadApiKeys()
+
+	c.JSON(http.StatusOK, response.IssuedApiKey{ID: id, Key: key})
+}
+
+func (h *Handler) RevokeApiKey(c *gin.Context) {
+	id, ok := parseApiKeyId(c)
+	if !ok {
+		return
+	}
+
+	if err := h.apiKeys.repository.RevokeApiKey(id, time.Now()); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Действующий ключ не найден"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.reloadApiKeys()
+
+	c.Status(http.StatusOK)
+}
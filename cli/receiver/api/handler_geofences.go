@@ -0,0 +1,333 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/filter"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/insert"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	"github.com/daniil11ru/egts/cli/receiver/dto/other"
+	"github.com/daniil11ru/egts/cli/receiver/dto/request"
+	"github.com/daniil11ru/egts/cli/receiver/dto/response"
+	"github.com/daniil11ru/egts/cli/receiver/server/domain"
+	"github.com/daniil11ru/egts/cli/receiver/util"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const maxGeoJSONSize = 16 << 20
+
+func toGeofenceResponse(geofence out.Geofence) response.Geofence {
+	return response.Geofence{
+		ID:              geofence.ID,
+		Name:            geofence.Name,
+		Type:            geofence.Type.String(),
+		Polygon:         geofence.Polygon,
+		CenterLatitude:  geofence.CenterLatitude,
+		CenterLongitude: geofence.CenterLongitude,
+		RadiusMeters:    geofence.RadiusMeters,
+	}
+}
+
+func toGeofenceInsert(geofence out.Geofence) insert.Geofence {
+	return insert.Geofence{
+		Name:            geofence.Name,
+		Type:            geofence.Type,
+		Polygon:         geofence.Polygon,
+		CenterLatitude:  geofence.CenterLatitude,
+		CenterLongitude: geofence.CenterLongitude,
+		RadiusMeters:    geofence.RadiusMeters,
+	}
+}
+
+func parseGeofenceId(c *gin.Context) (int32, bool) {
+	geofenceId, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil || geofenceId <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный ID геозоны"})
+		return 0, false
+	}
+	return int32(geofenceId), true
+}
+
+// bindGeofence читает геозону из тела запроса и проверяет ее. Поля, не относящиеся к типу геозоны,
+// отбрасываются.
+func bindGeofence(c *gin.Context) (insert.Geofence, bool) {
+	var req request.Geofence
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return insert.Geofence{}, false
+	}
+
+	geofence := out.Geofence{Name: req.Name, Type: *req.Type}
+	if geofence.Type == other.GeofenceTypePolygon {
+		geofence.Polygon = req.Polygon
+	} else {
+		geofence.CenterLatitude = req.CenterLatitude
+		geofence.CenterLongitude = req.CenterLongitude
+		geofence.RadiusMeters = req.RadiusMeters
+	}
+
+	if err := domain.ValidateGeofence(geofence); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return insert.Geofence{}, false
+	}
+	return toGeofenceInsert(geofence), true
+}
+
+func (h *Handler) geofenceExists(c *gin.Context, id int32) bool {
+	_, err := h.Repository.GetGeofence(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Геозона не найдена"})
+		return false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
+}
+
+func (h *Handler) GetGeofences(c *gin.Context) {
+	geofences, err := h.Repository.GetGeofences()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response.GetGeofences(util.Map(geofences, toGeofenceResponse)))
+}
+
+func (h *Handler) GetGeofence(c *gin.Context) {
+	geofenceId, ok := parseGeofenceId(c)
+	if !ok {
+		return
+	}
+
+	geofence, err := h.Repository.GetGeofence(geofenceId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Геозона не найдена"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, toGeofenceResponse(geofence))
+}
+
+func (h *Handler) AddGeofence(c *gin.Context) {
+	geofence, ok := bindGeofence(c)
+	if !ok {
+		return
+	}
+
+	ids, err := h.Repository.AddGeofences([]insert.Geofence{geofence})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.IngestCacheInvalidator.InvalidateGeofences()
+	c.JSON(http.StatusCreated, gin.H{"id": ids[0]})
+}
+
+func (h *Handler) ReplaceGeofence(c *gin.Context) {
+	geofenceId, ok := parseGeofenceId(c)
+	if !ok {
+		return
+	}
+	geofence, ok := bindGeofence(c)
+	if !ok {
+		return
+	}
+	if !h.geofenceExists(c, geofenceId) {
+		return
+	}
+
+	if err := h.Repository.ReplaceGeofence(geofenceId, geofence); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.IngestCacheInvalidator.InvalidateGeofences()
+	c.Status(http.StatusOK)
+}
+
+func (h *Handler) DeleteGeofence(c *gin.Context) {
+	geofenceId, ok := parseGeofenceId(c)
+	if !ok {
+		return
+	}
+	if !h.geofenceExists(c, geofenceId) {
+		return
+	}
+
+	if err := h.Repository.DeleteGeofence(geofenceId); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.IngestCacheInvalidator.InvalidateGeofences()
+	c.Status(http.StatusOK)
+}
+
+// readGeoJSON читает GeoJSON из поля file формы или, если запрос не является формой, из тела запроса
+func readGeoJSON(c *gin.Context) ([]byte, error) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxGeoJSONSize)
+
+	if !strings.HasPrefix(c.ContentType(), "multipart/form-data") {
+		return io.ReadAll(c.Request.Body)
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return nil, err
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(file)
+}
+
+func (h *Handler) ImportGeofences(c *gin.Context) {
+	data, err := readGeoJSON(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Не удалось прочитать GeoJSON: " + err.Error()})
+		return
+	}
+
+	geofences, err := domain.ParseGeoJSONGeofences(data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ids, err := h.Repository.AddGeofences(util.Map(geofences, toGeofenceInsert))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.IngestCacheInvalidator.InvalidateGeofences()
+	c.JSON(http.StatusCreated, gin.H{"ids": ids})
+}
+
+func parseGeofenceEventsFilter(c *gin.Context) (filter.GeofenceEvents, bool) {
+	tf := requestTimeFormat(c)
+	eventsFilter := filter.GeofenceEvents{Limit: 1000}
+
+	if typeStr := c.Query("type"); typeStr != "" {
+		eventType := other.GeofenceEventType(typeStr)
+		if !eventType.IsValid() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "type должен быть enter или exit"})
+			return eventsFilter, false
+		}
+		eventsFilter.Type = &eventType
+	}
+
+	if afterStr := c.Query("after"); afterStr != "" {
+		after, err := tf.parse(afterStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Дата должна быть в формате RFC 3339 или DD.MM.YYYY HH:MM:SS"})
+			return eventsFilter, false
+		}
+		eventsFilter.After = &after
+	}
+
+	if beforeStr := c.Query("before"); beforeStr != "" {
+		before, err := tf.parse(beforeStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Дата должна быть в формате RFC 3339 или DD.MM.YYYY HH:MM:SS"})
+			return eventsFilter, false
+		}
+		eventsFilter.Before = &before
+	}
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err := strconv.ParseInt(limitStr, 10, 64)
+		if err != nil || limit < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный limit"})
+			return eventsFilter, false
+		}
+		eventsFilter.Limit = limit
+	}
+
+	return eventsFilter, true
+}
+
+func (h *Handler) sendGeofenceEvents(c *gin.Context, eventsFilter filter.GeofenceEvents) {
+	tf := requestTimeFormat(c)
+	events, err := h.Repository.GetGeofenceEvents(eventsFilter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response.GetGeofenceEvents(util.Map(events, func(item out.GeofenceEvent) response.GeofenceEvent {
+		return response.GeofenceEvent{
+			ID:         item.ID,
+			GeofenceID: item.GeofenceId,
+			VehicleID:  item.VehicleId,
+			Type:       item.Type.String(),
+			LocationID: item.LocationId,
+			Latitude:   item.Latitude,
+			Longitude:  item.Longitude,
+			OccurredAt: tf.format(item.OccurredAt),
+		}
+	})))
+}
+
+func (h *Handler) GetGeofenceEvents(c *gin.Context) {
+	geofenceId, ok := parseGeofenceId(c)
+	if !ok {
+		return
+	}
+	eventsFilter, ok := parseGeofenceEventsFilter(c)
+	if !ok {
+		return
+	}
+	eventsFilter.GeofenceId = &geofenceId
+
+	if vehicleIdStr := c.Query("vehicle_id"); vehicleIdStr != "" {
+		vehicleId, err := strconv.ParseInt(vehicleIdStr, 10, 32)
+		if err != nil || vehicleId < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный vehicle_id"})
+			return
+		}
+		vehicleId32 := int32(vehicleId)
+		eventsFilter.VehicleId = &vehicleId32
+	}
+
+	h.sendGeofenceEvents(c, eventsFilter)
+}
+
+func (h *Handler) GetVehicleGeofenceEvents(c *gin.Context) {
+	vehicleId, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil || vehicleId <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный ID транспорта"})
+		return
+	}
+	eventsFilter, ok := parseGeofenceEventsFilter(c)
+	if !ok {
+		return
+	}
+	vehicleId32 := int32(vehicleId)
+	eventsFilter.VehicleId = &vehicleId32
+
+	if geofenceIdStr := c.Query("geofence_id"); geofenceIdStr != "" {
+		geofenceId, err := strconv.ParseInt(geofenceIdStr, 10, 32)
+		if err != nil || geofenceId < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный geofence_id"})
+			return
+		}
+		geofenceId32 := int32(geofenceId)
+		eventsFilter.GeofenceId = &geofenceId32
+	}
+
+	h.sendGeofenceEvents(c, eventsFilter)
+}
@@ -0,0 +1,169 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/filter"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/insert"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	"github.com/daniil11ru/egts/cli/receiver/dto/other"
+	"github.com/daniil11ru/egts/cli/receiver/dto/request"
+	"github.com/daniil11ru/egts/cli/receiver/dto/response"
+	"github.com/daniil11ru/egts/cli/receiver/server/domain"
+	"github.com/daniil11ru/egts/cli/receiver/util"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func (h *Handler) GetGpsFilterSettings(c *gin.Context) {
+	providerId, ok := parseProviderId(c)
+	if !ok {
+		return
+	}
+
+	isDefault := false
+	settings, err := h.Repository.GetGpsFilterSettings(providerId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		settings, isDefault = domain.DefaultGpsFilterSettings(providerId), true
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response.GpsFilterSettings{
+		ProviderID:       providerId,
+		Default:          isDefault,
+		RequireValid:     settings.RequireValid,
+		Require3dFix:     settings.Require3dFix,
+		MaxHdop:          settings.MaxHdop,
+		MaxFutureSeconds: settings.MaxFutureSeconds,
+		MaxSpeedKmh:      settings.MaxSpeedKmh,
+	})
+}
+
+func (h *Handler) SaveGpsFilterSettings(c *gin.Context) {
+	providerId, ok := parseProviderId(c)
+	if !ok {
+		return
+	}
+
+	var req request.GpsFilterSettings
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	settings := out.GpsFilterSettings{
+		ProviderId:       providerId,
+		RequireValid:     *req.RequireValid,
+		Require3dFix:     *req.Require3dFix,
+		MaxHdop:          req.MaxHdop,
+		MaxFutureSeconds: req.MaxFutureSeconds,
+		MaxSpeedKmh:      req.MaxSpeedKmh,
+	}
+	if err := domain.ValidateGpsFilterSettings(settings); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.Repository.SaveGpsFilterSettings(insert.GpsFilterSettings{
+		ProviderId:       settings.ProviderId,
+		RequireValid:     settings.RequireValid,
+		Require3dFix:     settings.Require3dFix,
+		MaxHdop:          settings.MaxHdop,
+		MaxFutureSeconds: settings.MaxFutureSeconds,
+		MaxSpeedKmh:      settings.MaxSpeedKmh,
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.IngestCacheInvalidator.InvalidateGpsFilterSettings(providerId)
+	c.Status(http.StatusOK)
+}
+
+func (h *Handler) DeleteGpsFilterSettings(c *gin.Context) {
+	providerId, ok := parseProviderId(c)
+	if !ok {
+		return
+	}
+
+	if err := h.Repository.DeleteGpsFilterSettings(providerId); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.IngestCacheInvalidator.InvalidateGpsFilterSettings(providerId)
+	c.Status(http.StatusOK)
+}
+
+func (h *Handler) GetRejectedLocations(c *gin.Context) {
+	tf := requestTimeFormat(c)
+	rejectedFilter := filter.RejectedLocations{Limit: 1000}
+
+	if providerIdStr := c.Query("provider_id"); providerIdStr != "" {
+		providerId, err := strconv.ParseInt(providerIdStr, 10, 32)
+		if err != nil || providerId < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный provider_id"})
+			return
+		}
+		providerId32 := int32(providerId)
+		rejectedFilter.ProviderId = &providerId32
+	}
+
+	if vehicleIdStr := c.Query("vehicle_id"); vehicleIdStr != "" {
+		vehicleId, err := strconv.ParseInt(vehicleIdStr, 10, 32)
+		if err != nil || vehicleId < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный vehicle_id"})
+			return
+		}
+		vehicleId32 := int32(vehicleId)
+		rejectedFilter.VehicleId = &vehicleId32
+	}
+
+	if ruleStr := c.Query("rule"); ruleStr != "" {
+		rule := other.GpsFilterRule(ruleStr)
+		if !rule.IsValid() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный rule"})
+			return
+		}
+		rejectedFilter.Rule = &rule
+	}
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err := strconv.ParseInt(limitStr, 10, 64)
+		if err != nil || limit < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный limit"})
+			return
+		}
+		rejectedFilter.Limit = limit
+	}
+
+	locations, err := h.Repository.GetRejectedLocations(rejectedFilter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response.GetRejectedLocations(util.Map(locations, func(item out.RejectedLocation) response.RejectedLocation {
+		resp := response.RejectedLocation{
+			ID:         item.ID,
+			ProviderID: item.ProviderId,
+			OID:        item.OID,
+			VehicleID:  item.VehicleId,
+			Rule:       item.Rule.String(),
+			Detail:     item.Detail,
+			Latitude:   item.Latitude,
+			Longitude:  item.Longitude,
+			Altitude:   item.Altitude,
+			Speed:      item.Speed,
+			Hdop:       item.Hdop,
+			Valid:      item.Valid,
+			Fix3d:      item.Fix3d,
+			SentAt:     tf.formatOptional(item.SentAt),
+			ReceivedAt: tf.format(item.ReceivedAt),
+			RejectedAt: tf.format(item.RejectedAt),
+		}
+		return resp
+	})))
+}
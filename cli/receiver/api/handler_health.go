@@ -0,0 +1,69 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/response"
+	"github.com/daniil11ru/egts/cli/receiver/server/domain"
+	"github.com/daniil11ru/egts/cli/receiver/util"
+	"github.com/gin-gonic/gin"
+)
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func writeHealth(c *gin.Context, report domain.HealthReport) {
+	tf := requestTimeFormat(c)
+	result := response.Health{
+		Status:    "ok",
+		Problems:  report.Problems,
+		StartedAt: tf.format(report.StartedAt),
+		CheckedAt: tf.format(report.CheckedAt),
+		Listeners: util.Map(report.Listeners, func(item domain.ListenerHealth) response.HealthListener {
+			return response.HealthListener{
+				ProviderID: item.ProviderId,
+				Address:    item.Address,
+				State:      item.State.String(),
+				Error:      optionalString(item.Error),
+				Since:      tf.format(item.Since),
+			}
+		}),
+		SaveQueue: report.SaveQueue,
+		Jobs: util.Map(report.Jobs, func(item domain.JobHealth) response.HealthJob {
+			return response.HealthJob{Job: item.Job, LastSuccessAt: tf.format(item.LastSuccessAt)}
+		}),
+	}
+	if result.Problems == nil {
+		result.Problems = []string{}
+	}
+	if report.Database.Checked {
+		result.Database = &response.HealthDatabase{
+			Available:        report.Database.Available,
+			Error:            optionalString(report.Database.Error),
+			MigrationVersion: report.Database.MigrationVersion,
+			MigrationDirty:   report.Database.MigrationDirty,
+		}
+	}
+
+	status := http.StatusOK
+	if !report.Healthy {
+		result.Status = "fail"
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, result)
+}
+
+// GetLiveness отвечает, пока процесс обрабатывает запросы, и не зависит от базы данных
+func (h *Handler) GetLiveness(c *gin.Context) {
+	writeHealth(c, h.HealthChecker.Liveness(time.Now()))
+}
+
+// GetReadiness отвечает 503, если приемник не может принимать и сохранять данные
+func (h *Handler) GetReadiness(c *gin.Context) {
+	writeHealth(c, h.HealthChecker.Readiness(time.Now()))
+}
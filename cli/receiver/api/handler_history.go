@@ -0,0 +1,68 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/filter"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	"github.com/daniil11ru/egts/cli/receiver/dto/response"
+	"github.com/daniil11ru/egts/cli/receiver/util"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) GetHistoryBacklog(c *gin.Context) {
+	tf := requestTimeFormat(c)
+	backlogFilter := filter.HistoryBacklog{}
+
+	if providerIdStr := c.Query("provider_id"); providerIdStr != "" {
+		providerId, err := strconv.ParseInt(providerIdStr, 10, 32)
+		if err != nil || providerId < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный provider_id"})
+			return
+		}
+		providerId32 := int32(providerId)
+		backlogFilter.ProviderId = &providerId32
+	}
+	if !restrictProvider(c, &backlogFilter.ProviderId) {
+		return
+	}
+
+	if vehicleIdStr := c.Query("vehicle_id"); vehicleIdStr != "" {
+		vehicleId, err := strconv.ParseInt(vehicleIdStr, 10, 32)
+		if err != nil || vehicleId < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный vehicle_id"})
+			return
+		}
+		vehicleId32 := int32(vehicleId)
+		backlogFilter.VehicleId = &vehicleId32
+	}
+
+	if receivedAfterStr := c.Query("received_after"); receivedAfterStr != "" {
+		receivedAfter, err := tf.parse(receivedAfterStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Дата должна быть в формате RFC 3339 или DD.MM.YYYY HH:MM:SS"})
+			return
+		}
+		backlogFilter.ReceivedAfter = &receivedAfter
+	}
+
+	backlog, err := h.Repository.GetHistoryBacklog(backlogFilter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response.GetHistoryBacklog(util.Map(backlog, func(item out.HistoryBacklog) response.HistoryBacklog {
+		return response.HistoryBacklog{
+			VehicleID:         item.VehicleId,
+			ProviderID:        item.ProviderId,
+			Count:             item.Count,
+			OldestSentAt:      tf.format(item.OldestSentAt),
+			NewestSentAt:      tf.format(item.NewestSentAt),
+			FirstReceivedAt:   tf.format(item.FirstReceivedAt),
+			LastReceivedAt:    tf.format(item.LastReceivedAt),
+			CurrentLagSeconds: item.CurrentLagSeconds,
+		}
+	})))
+}
@@ -0,0 +1,283 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/filter"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/insert"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/update"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	"github.com/daniil11ru/egts/cli/receiver/dto/other"
+	"github.com/daniil11ru/egts/cli/receiver/dto/request"
+	"github.com/daniil11ru/egts/cli/receiver/dto/response"
+	"github.com/daniil11ru/egts/cli/receiver/server/domain"
+	"github.com/daniil11ru/egts/cli/receiver/util"
+	"github.com/gin-gonic/gin"
+)
+
+func toOidResolutionRuleResponse(rule out.OidResolutionRule) response.OidResolutionRule {
+	resp := response.OidResolutionRule{
+		ID:         rule.ID,
+		ProviderID: rule.ProviderId,
+		Priority:   rule.Priority,
+		Type:       rule.Type.String(),
+		Length:     rule.Length,
+	}
+	if rule.Position != nil {
+		position := rule.Position.String()
+		resp.Position = &position
+	}
+	return resp
+}
+
+func parseProviderId(c *gin.Context) (int32, bool) {
+	providerId, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil || providerId <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный ID провайдера"})
+		return 0, false
+	}
+	return int32(providerId), true
+}
+
+func parseOidResolutionRuleId(c *gin.Context) (int32, bool) {
+	ruleId, err := strconv.ParseInt(c.Param("rule_id"), 10, 32)
+	if err != nil || ruleId <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный ID правила"})
+		return 0, false
+	}
+	return int32(ruleId), true
+}
+
+func (h *Handler) getOidResolutionRule(c *gin.Context, providerId, ruleId int32) (out.OidResolutionRule, bool) {
+	rules, err := h.Repository.GetOidResolutionRules(filter.OidResolutionRules{ID: &ruleId, ProviderId: &providerId})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return out.OidResolutionRule{}, false
+	}
+	if len(rules) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Правило не найдено"})
+		return out.OidResolutionRule{}, false
+	}
+	return rules[0], true
+}
+
+func (h *Handler) GetOidResolutionRules(c *gin.Context) {
+	providerId, ok := parseProviderId(c)
+	if !ok {
+		return
+	}
+
+	rules, err := h.Repository.GetOidResolutionRules(filter.OidResolutionRules{ProviderId: &providerId})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response.GetOidResolutionRules(util.Map(rules, toOidResolutionRuleResponse)))
+}
+
+func (h *Handler) AddOidResolutionRule(c *gin.Context) {
+	providerId, ok := parseProviderId(c)
+	if !ok {
+		return
+	}
+
+	var req request.AddOidResolutionRule
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := domain.ValidateOidResolutionRule(req.Type, req.Position, req.Length); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule := insert.OidResolutionRule{
+		ProviderId: providerId,
+		Priority:   req.Priority,
+		Type:       req.Type,
+		Position:   req.Position,
+		Length:     req.Length,
+	}
+	if !domain.OidResolutionRuleUsesPosition(rule.Type) {
+		rule.Position = nil
+	}
+	if !domain.OidResolutionRuleUsesLength(rule.Type) {
+		rule.Length = nil
+	}
+	id, err := h.Repository.AddOidResolutionRule(rule)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.IngestCacheInvalidator.InvalidateVehicles(providerId)
+
+	c.JSON(http.StatusCreated, gin.H{"id": id})
+}
+
+func (h *Handler) UpdateOidResolutionRule(c *gin.Context) {
+	providerId, ok := parseProviderId(c)
+	if !ok {
+		return
+	}
+	ruleId, ok := parseOidResolutionRuleId(c)
+	if !ok {
+		return
+	}
+
+	var req request.UpdateOidResolutionRule
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Priority == nil && req.Type == nil && req.Position == nil && req.Length == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Нечего обновлять"})
+		return
+	}
+
+	rule, ok := h.getOidResolutionRule(c, providerId, ruleId)
+	if !ok {
+		return
+	}
+	if req.Type != nil {
+		rule.Type = *req.Type
+	}
+	if req.Position != nil {
+		rule.Position = req.Position
+	}
+	if req.Length != nil {
+		rule.Length = req.Length
+	}
+	if err := domain.ValidateOidResolutionRule(rule.Type, rule.Position, rule.Length); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	fields := update.OidResolutionRule{
+		Priority: req.Priority,
+		Type:     req.Type,
+		Position: req.Position,
+		Length:   req.Length,
+	}
+	// Позиция и длина, которые новый тип правила не использует, снимаются
+	if !domain.OidResolutionRuleUsesPosition(rule.Type) && rule.Position != nil {
+		none := other.ImeiSegmentPosition("")
+		fields.Position = &none
+	}
+	if !domain.OidResolutionRuleUsesLength(rule.Type) && rule.Length != nil {
+		none := int16(0)
+		fields.Length = &none
+	}
+	if err := h.Repository.UpdateOidResolutionRule(ruleId, fields); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.IngestCacheInvalidator.InvalidateVehicles(providerId)
+	c.Status(http.StatusOK)
+}
+
+func (h *Handler) DeleteOidResolutionRule(c *gin.Context) {
+	providerId, ok := parseProviderId(c)
+	if !ok {
+		return
+	}
+	ruleId, ok := parseOidResolutionRuleId(c)
+	if !ok {
+		return
+	}
+
+	if _, ok := h.getOidResolutionRule(c, providerId, ruleId); !ok {
+		return
+	}
+	if err := h.Repository.DeleteOidResolutionRule(ruleId); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.IngestCacheInvalidator.InvalidateVehicles(providerId)
+	c.Status(http.StatusOK)
+}
+
+func (h *Handler) DryRunOidResolution(c *gin.Context) {
+	providerId, ok := parseProviderId(c)
+	if !ok {
+		return
+	}
+
+	var req request.DryRunOidResolution
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var rules []out.OidResolutionRule
+	if req.Rules != nil {
+		for _, r := range req.Rules {
+			if err := domain.ValidateOidResolutionRule(r.Type, r.Position, r.Length); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			rules = append(rules, out.OidResolutionRule{
+				ProviderId: providerId,
+				Priority:   r.Priority,
+				Type:       r.Type,
+				Position:   r.Position,
+				Length:     r.Length,
+			})
+		}
+	} else {
+		var err error
+		rules, err = h.Repository.GetOidResolutionRules(filter.OidResolutionRules{ProviderId: &providerId})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	vehicles, err := h.Repository.GetVehicles(filter.Vehicles{ProviderId: &providerId})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	imei := ""
+	if req.IMEI != nil {
+		imei = *req.IMEI
+	}
+	// Тот же индекс, что и при приеме данных, чтобы результат совпадал с сохранением точки
+	resolution := domain.NewVehicleIndex(rules, vehicles).Resolve(req.OID, imei)
+
+	resp := response.DryRunOidResolution{
+		Result: string(resolution.Result),
+		Steps:  []response.OidResolutionStep{},
+	}
+	// Без правил точка сохраняется за транспортом, если OID совпал точно ровно у одного
+	if len(resolution.Vehicles) == 1 {
+		v := resolution.Vehicles[0]
+		resp.Vehicle = &response.GetVehicle{
+			ID:               v.ID,
+			IMEI:             v.IMEI,
+			OID:              v.OID,
+			Name:             v.Name,
+			ProviderID:       v.ProviderId,
+			ModerationStatus: v.ModerationStatus.String(),
+			VehicleGroupID:   v.VehicleGroupId,
+		}
+		if resolution.Rule != nil && resolution.Rule.ID != 0 {
+			resp.RuleID = &resolution.Rule.ID
+		}
+	}
+	for _, step := range resolution.Steps {
+		s := response.OidResolutionStep{
+			Rule:       toOidResolutionRuleResponse(step.Rule),
+			VehicleIDs: util.Map(step.Vehicles, func(v out.Vehicle) int32 { return v.ID }),
+		}
+		if step.Err != nil {
+			msg := step.Err.Error()
+			s.Error = &msg
+		}
+		resp.Steps = append(resp.Steps, s)
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
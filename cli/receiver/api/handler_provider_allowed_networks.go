@@ -0,0 +1,129 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/filter"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/insert"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	"github.com/daniil11ru/egts/cli/receiver/dto/request"
+	"github.com/daniil11ru/egts/cli/receiver/dto/response"
+	"github.com/daniil11ru/egts/cli/receiver/server/domain"
+	"github.com/daniil11ru/egts/cli/receiver/util"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func toProviderAllowedNetworkResponse(network out.ProviderAllowedNetwork) response.ProviderAllowedNetwork {
+	return response.ProviderAllowedNetwork{
+		ID:          network.ID,
+		ProviderID:  network.ProviderId,
+		Network:     network.Network,
+		Description: network.Description,
+	}
+}
+
+func parseProviderAllowedNetworkId(c *gin.Context) (int32, bool) {
+	networkId, err := strconv.ParseInt(c.Param("network_id"), 10, 32)
+	if err != nil || networkId <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный ID сети"})
+		return 0, false
+	}
+	return int32(networkId), true
+}
+
+func (h *Handler) GetProviderAllowedNetworks(c *gin.Context) {
+	providerId, ok := parseProviderId(c)
+	if !ok {
+		return
+	}
+
+	networks, err := h.Repository.GetProviderAllowedNetworks(filter.ProviderAllowedNetworks{ProviderId: &providerId})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response.GetProviderAllowedNetworks(util.Map(networks, toProviderAllowedNetworkResponse)))
+}
+
+func (h *Handler) AddProviderAllowedNetwork(c *gin.Context) {
+	providerId, ok := parseProviderId(c)
+	if !ok {
+		return
+	}
+
+	var req request.AddProviderAllowedNetwork
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	network, err := domain.ParseAllowedNetwork(req.Network)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, err := h.Repository.GetProvider(providerId); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Провайдер не найден"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	existing, err := h.Repository.GetProviderAllowedNetworks(filter.ProviderAllowedNetworks{ProviderId: &providerId})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	for _, item := range existing {
+		if parsed, err := domain.ParseAllowedNetwork(item.Network); err == nil && parsed.String() == network.String() {
+			c.JSON(http.StatusConflict, gin.H{"error": "Сеть " + network.String() + " уже разрешена для провайдера"})
+			return
+		}
+	}
+
+	id, err := h.Repository.AddProviderAllowedNetwork(insert.ProviderAllowedNetwork{
+		ProviderId:  providerId,
+		Network:     network.String(),
+		Description: req.Description,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.notifyProviderChanges()
+
+	c.JSON(http.StatusCreated, gin.H{"id": id})
+}
+
+func (h *Handler) DeleteProviderAllowedNetwork(c *gin.Context) {
+	providerId, ok := parseProviderId(c)
+	if !ok {
+		return
+	}
+	networkId, ok := parseProviderAllowedNetworkId(c)
+	if !ok {
+		return
+	}
+
+	networks, err := h.Repository.GetProviderAllowedNetworks(filter.ProviderAllowedNetworks{ID: &networkId, ProviderId: &providerId})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(networks) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Сеть не найдена"})
+		return
+	}
+	if err := h.Repository.DeleteProviderAllowedNetwork(networkId); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.notifyProviderChanges()
+
+	c.Status(http.StatusOK)
+}
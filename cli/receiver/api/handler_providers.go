@@ -0,0 +1,266 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/filter"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/insert"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/update"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	"github.com/daniil11ru/egts/cli/receiver/dto/other"
+	"github.com/daniil11ru/egts/cli/receiver/dto/request"
+	"github.com/daniil11ru/egts/cli/receiver/dto/response"
+	"github.com/daniil11ru/egts/cli/receiver/server/domain"
+	"github.com/daniil11ru/egts/cli/receiver/util"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func toProviderResponse(provider out.Provider) response.Provider {
+	return response.Provider{
+		ID:           provider.ID,
+		Name:         provider.Name,
+		Port:         provider.Port,
+		Transport:    provider.Transport.String(),
+		AuthRequired: provider.AuthRequired,
+		Enabled:      provider.Enabled,
+
+		MaxPacketsPerMinute:   provider.MaxPacketsPerMinute,
+		MaxNewVehiclesPerHour: provider.MaxNewVehiclesPerHour,
+	}
+}
+
+// checkProviderPort отвечает 409, если порт провайдера уже назначен другому провайдеру
+func (h *Handler) checkProviderPort(c *gin.Context, provider out.Provider) bool {
+	if provider.Port == nil {
+		return true
+	}
+	providers, err := h.Repository.GetProviders()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+	if owner, ok := domain.FindProviderByPort(providers, *provider.Port, provider.ID); ok {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Порт %d уже назначен провайдеру с ID %d", *provider.Port, owner.ID)})
+		return false
+	}
+	return true
+}
+
+func (h *Handler) notifyProviderChanges() {
+	if h.ProviderChangeNotifier != nil {
+		h.ProviderChangeNotifier.Notify()
+	}
+}
+
+func (h *Handler) GetProviders(c *gin.Context) {
+	providers, err := h.Repository.GetProviders()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response.GetProviders(util.Map(providers, toProviderResponse)))
+}
+
+func (h *Handler) GetProvider(c *gin.Context) {
+	providerId, ok := parseProviderId(c)
+	if !ok {
+		return
+	}
+
+	provider, err := h.Repository.GetProvider(providerId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Провайдер не найден"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	rules, err := h.Repository.GetOidResolutionRules(filter.OidResolutionRules{ProviderId: &providerId})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp := toProviderResponse(provider)
+	resp.ResolutionRules = util.Map(rules, toOidResolutionRuleResponse)
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handler) AddProvider(c *gin.Context) {
+	var req request.AddProvider
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	provider := out.Provider{
+		Name:         req.Name,
+		Port:         req.Port,
+		Transport:    other.ProviderTransportTcp,
+		AuthRequired: req.AuthRequired,
+		Enabled:      true,
+
+		MaxPacketsPerMinute:   req.MaxPacketsPerMinute,
+		MaxNewVehiclesPerHour: req.MaxNewVehiclesPerHour,
+	}
+	if req.Transport != nil {
+		provider.Transport = *req.Transport
+	}
+	if req.Enabled != nil {
+		provider.Enabled = *req.Enabled
+	}
+	if err := domain.ValidateProvider(provider); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rules := make([]insert.OidResolutionRule, 0, len(req.ResolutionRules))
+	for _, r := range req.ResolutionRules {
+		if err := domain.ValidateOidResolutionRule(r.Type, r.Position, r.Length); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		rules = append(rules, insert.OidResolutionRule{
+			Priority: r.Priority,
+			Type:     r.Type,
+			Position: r.Position,
+			Length:   r.Length,
+		})
+	}
+
+	if !h.checkProviderPort(c, provider) {
+		return
+	}
+
+	id, err := h.Repository.AddProvider(insert.Provider{
+		Name:         provider.Name,
+		Port:         provider.Port,
+		Transport:    provider.Transport,
+		AuthRequired: provider.AuthRequired,
+		Enabled:      provider.Enabled,
+
+		MaxPacketsPerMinute:   provider.MaxPacketsPerMinute,
+		MaxNewVehiclesPerHour: provider.MaxNewVehiclesPerHour,
+	}, rules)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.notifyProviderChanges()
+
+	c.JSON(http.StatusCreated, gin.H{"id": id})
+}
+
+func (h *Handler) UpdateProvider(c *gin.Context) {
+	providerId, ok := parseProviderId(c)
+	if !ok {
+		return
+	}
+
+	var req request.UpdateProvider
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Name == nil && req.Port == nil && req.Transport == nil && req.AuthRequired == nil && req.Enabled == nil &&
+		req.MaxPacketsPerMinute == nil && req.MaxNewVehiclesPerHour == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Нечего обновлять"})
+		return
+	}
+
+	provider, err := h.Repository.GetProvider(providerId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Провайдер не найден"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Name != nil {
+		provider.Name = *req.Name
+	}
+	if req.Port != nil {
+		provider.Port = req.Port
+		if *req.Port == 0 {
+			provider.Port = nil
+		}
+	}
+	if req.Transport != nil {
+		provider.Transport = *req.Transport
+	}
+	if req.MaxPacketsPerMinute != nil {
+		provider.MaxPacketsPerMinute = req.MaxPacketsPerMinute
+		if *req.MaxPacketsPerMinute == 0 {
+			provider.MaxPacketsPerMinute = nil
+		}
+	}
+	if req.MaxNewVehiclesPerHour != nil {
+		provider.MaxNewVehiclesPerHour = req.MaxNewVehiclesPerHour
+		if *req.MaxNewVehiclesPerHour == 0 {
+			provider.MaxNewVehiclesPerHour = nil
+		}
+	}
+	if err := domain.ValidateProvider(provider); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Port != nil && !h.checkProviderPort(c, provider) {
+		return
+	}
+
+	if err := h.Repository.UpdateProvider(providerId, update.Provider{
+		Name:         req.Name,
+		Port:         req.Port,
+		Transport:    req.Transport,
+		AuthRequired: req.AuthRequired,
+		Enabled:      req.Enabled,
+
+		MaxPacketsPerMinute:   req.MaxPacketsPerMinute,
+		MaxNewVehiclesPerHour: req.MaxNewVehiclesPerHour,
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.notifyProviderChanges()
+
+	c.Status(http.StatusOK)
+}
+
+func (h *Handler) DeleteProvider(c *gin.Context) {
+	providerId, ok := parseProviderId(c)
+	if !ok {
+		return
+	}
+
+	if _, err := h.Repository.GetProvider(providerId); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Провайдер не найден"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	vehicles, err := h.Repository.GetVehicles(filter.Vehicles{ProviderId: &providerId})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(vehicles) > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("У провайдера есть транспорт (%d), удаление невозможно", len(vehicles))})
+		return
+	}
+
+	if err := h.Repository.DeleteProvider(providerId); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.notifyProviderChanges()
+
+	c.Status(http.StatusOK)
+}
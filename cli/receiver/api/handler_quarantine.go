@@ -0,0 +1,152 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/filter"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	"github.com/daniil11ru/egts/cli/receiver/dto/other"
+	"github.com/daniil11ru/egts/cli/receiver/dto/request"
+	"github.com/daniil11ru/egts/cli/receiver/dto/response"
+	"github.com/daniil11ru/egts/cli/receiver/util"
+	"github.com/gin-gonic/gin"
+)
+
+func parseQuarantineFilter(c *gin.Context) (filter.QuarantinedLocations, bool) {
+	quarantineFilter := filter.QuarantinedLocations{}
+
+	if providerIdStr := c.Query("provider_id"); providerIdStr != "" {
+		providerId, err := strconv.ParseInt(providerIdStr, 10, 32)
+		if err != nil || providerId < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный provider_id"})
+			return quarantineFilter, false
+		}
+		providerId32 := int32(providerId)
+		quarantineFilter.ProviderId = &providerId32
+	}
+
+	if oidStr := c.Query("oid"); oidStr != "" {
+		oid, err := strconv.ParseInt(oidStr, 10, 64)
+		if err != nil || oid < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный oid"})
+			return quarantineFilter, false
+		}
+		quarantineFilter.OID = &oid
+	}
+
+	if reasonStr := c.Query("reason"); reasonStr != "" {
+		reason := other.QuarantineReason(reasonStr)
+		if !reason.IsValid() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный reason"})
+			return quarantineFilter, false
+		}
+		quarantineFilter.Reason = &reason
+	}
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err := strconv.ParseInt(limitStr, 10, 64)
+		if err != nil || limit < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный limit"})
+			return quarantineFilter, false
+		}
+		quarantineFilter.Limit = limit
+	} else {
+		quarantineFilter.Limit = 1000
+	}
+
+	return quarantineFilter, true
+}
+
+func (h *Handler) GetQuarantinedLocations(c *gin.Context) {
+	tf := requestTimeFormat(c)
+	quarantineFilter, ok := parseQuarantineFilter(c)
+	if !ok {
+		return
+	}
+
+	locations, err := h.Repository.GetQuarantinedLocations(quarantineFilter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response.GetQuarantinedLocations(util.Map(locations, func(item out.QuarantinedLocation) response.QuarantinedLocation {
+		resp := response.QuarantinedLocation{
+			ID:             item.ID,
+			ProviderID:     item.ProviderId,
+			OID:            item.OID,
+			VehicleID:      item.VehicleId,
+			Reason:         item.Reason.String(),
+			Latitude:       item.Latitude,
+			Longitude:      item.Longitude,
+			Altitude:       item.Altitude,
+			Direction:      item.Direction,
+			Speed:          item.Speed,
+			SatelliteCount: item.SatelliteCount,
+			Hdop:           item.Hdop,
+			Valid:          item.Valid,
+			Fix3d:          item.Fix3d,
+			IsHistory:      item.IsHistory,
+			OdometerMeters: item.OdometerMeters,
+			SentAt:         tf.formatOptional(item.SentAt),
+			ReceivedAt:     tf.format(item.ReceivedAt),
+			QuarantinedAt:  tf.format(item.QuarantinedAt),
+		}
+		if item.TerminalIMEI != "" {
+			resp.TerminalIMEI = &item.TerminalIMEI
+		}
+		return resp
+	})))
+}
+
+func (h *Handler) GetQuarantineGroups(c *gin.Context) {
+	tf := requestTimeFormat(c)
+	quarantineFilter, ok := parseQuarantineFilter(c)
+	if !ok {
+		return
+	}
+
+	groups, err := h.Repository.GetQuarantineGroups(quarantineFilter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response.GetQuarantineGroups(util.Map(groups, func(item out.QuarantineGroup) response.QuarantineGroup {
+		resp := response.QuarantineGroup{
+			ProviderID:      item.ProviderId,
+			OID:             item.OID,
+			VehicleID:       item.VehicleId,
+			Reason:          item.Reason.String(),
+			Count:           item.Count,
+			FirstReceivedAt: tf.format(item.FirstReceivedAt),
+			LastReceivedAt:  tf.format(item.LastReceivedAt),
+		}
+		if item.TerminalIMEI != "" {
+			resp.TerminalIMEI = &item.TerminalIMEI
+		}
+		return resp
+	})))
+}
+
+func (h *Handler) ReprocessQuarantine(c *gin.Context) {
+	var req request.ReprocessQuarantine
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.QuarantineReprocessor.Run(req.ProviderID, req.OID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response.ReprocessQuarantine{
+		Moved:     result.Moved,
+		Skipped:   result.Skipped,
+		Discarded: result.Discarded,
+		Remaining: result.Remaining,
+	})
+}
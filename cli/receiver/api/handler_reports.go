@@ -0,0 +1,212 @@
+package api
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/filter"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	"github.com/daniil11ru/egts/cli/receiver/dto/response"
+	"github.com/daniil11ru/egts/cli/receiver/server/domain"
+	"github.com/daniil11ru/egts/cli/receiver/util"
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	dateLayout        = "02.01.2006"
+	storageDateLayout = "2006-01-02"
+	maxReportDays     = 366
+)
+
+func parseDailyMileageFilter(c *gin.Context) (filter.DailyMileage, bool) {
+	mileageFilter := filter.DailyMileage{}
+
+	if providerIdStr := c.Query("provider_id"); providerIdStr != "" {
+		providerId, err := strconv.ParseInt(providerIdStr, 10, 32)
+		if err != nil || providerId < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный provider_id"})
+			return mileageFilter, false
+		}
+		providerId32 := int32(providerId)
+		mileageFilter.ProviderId = &providerId32
+	}
+	if !restrictProvider(c, &mileageFilter.ProviderId) {
+		return mileageFilter, false
+	}
+
+	if vehicleIdStr := c.Query("vehicle_id"); vehicleIdStr != "" {
+		vehicleId, err := strconv.ParseInt(vehicleIdStr, 10, 32)
+		if err != nil || vehicleId < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный vehicle_id"})
+			return mileageFilter, false
+		}
+		vehicleId32 := int32(vehicleId)
+		mileageFilter.VehicleId = &vehicleId32
+	}
+
+	dateFrom, errFrom := time.Parse(dateLayout, c.Query("date_from"))
+	dateTo, errTo := time.Parse(dateLayout, c.Query("date_to"))
+	if errFrom != nil || errTo != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Параметры date_from и date_to обязательны и должны быть в формате DD.MM.YYYY"})
+		return mileageFilter, false
+	}
+	if dateTo.Before(dateFrom) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "date_from не может быть позже date_to"})
+		return mileageFilter, false
+	}
+	if dateTo.Sub(dateFrom) >= maxReportDays*24*time.Hour {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Период отчета не может превышать 366 дней"})
+		return mileageFilter, false
+	}
+	mileageFilter.DayFrom = dateFrom.Format(storageDateLayout)
+	mileageFilter.DayTo = dateTo.Format(storageDateLayout)
+
+	return mileageFilter, true
+}
+
+func formatReportDate(day string) string {
+	t, err := time.Parse(storageDateLayout, day)
+	if err != nil {
+		return day
+	}
+	return t.Format(dateLayout)
+}
+
+func metersToKm(meters float64) float64 {
+	return math.Round(meters) / 1000
+}
+
+func toDailyMileageResponse(item out.DailyMileage) response.DailyMileage {
+	resp := response.DailyMileage{
+		VehicleID:                item.VehicleId,
+		ProviderID:               item.ProviderId,
+		Date:                     formatReportDate(item.Day),
+		DistanceKm:               metersToKm(item.DistanceMeters),
+		OdometerDeviationPercent: domain.OdometerDeviationPercent(item),
+		MovingSeconds:            item.MovingSeconds,
+		IdleSeconds:              item.IdleSeconds,
+		PointCount:               item.PointCount,
+	}
+	if odometer := domain.OdometerDistance(item); odometer != nil {
+		km := metersToKm(*odometer)
+		resp.OdometerDistanceKm = &km
+	}
+	return resp
+}
+
+func toProviderDailyMileageResponse(item domain.ProviderDailyMileage) response.ProviderDailyMileage {
+	return response.ProviderDailyMileage{
+		ProviderID:    item.ProviderId,
+		Date:          formatReportDate(item.Day),
+		VehicleCount:  item.VehicleCount,
+		DistanceKm:    metersToKm(item.DistanceMeters),
+		MovingSeconds: item.MovingSeconds,
+		IdleSeconds:   item.IdleSeconds,
+	}
+}
+
+func (h *Handler) GetDailyMileage(c *gin.Context) {
+	mileageFilter, ok := parseDailyMileageFilter(c)
+	if !ok {
+		return
+	}
+
+	mileage, err := h.Repository.GetDailyMileage(mileageFilter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response.GetDailyMileage(util.Map(mileage, toDailyMileageResponse)))
+}
+
+func (h *Handler) GetProviderDailyMileage(c *gin.Context) {
+	mileageFilter, ok := parseDailyMileageFilter(c)
+	if !ok {
+		return
+	}
+
+	mileage, err := h.Repository.GetDailyMileage(mileageFilter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	summary := domain.SummarizeMileageByProvider(mileage)
+	c.JSON(http.StatusOK, response.GetProviderDailyMileage(util.Map(summary, toProviderDailyMileageResponse)))
+}
+
+func (h *Handler) GetDailyMileageExcel(c *gin.Context) {
+	mileageFilter, ok := parseDailyMileageFilter(c)
+	if !ok {
+		return
+	}
+
+	mileage, err := h.Repository.GetDailyMileage(mileageFilter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	vehicleRows := make([][]any, 0, len(mileage))
+	for _, item := range mileage {
+		resp := toDailyMileageResponse(item)
+		row := []any{
+			resp.Date, resp.VehicleID, resp.ProviderID, resp.DistanceKm, nil, nil,
+			hoursFromSeconds(resp.MovingSeconds), hoursFromSeconds(resp.IdleSeconds), resp.PointCount,
+		}
+		if resp.OdometerDistanceKm != nil {
+			row[4] = *resp.OdometerDistanceKm
+		}
+		if resp.OdometerDeviationPercent != nil {
+			row[5] = *resp.OdometerDeviationPercent
+		}
+		vehicleRows = append(vehicleRows, row)
+	}
+
+	summary := domain.SummarizeMileageByProvider(mileage)
+	providerRows := make([][]any, 0, len(summary))
+	for _, item := range summary {
+		resp := toProviderDailyMileageResponse(item)
+		providerRows = append(providerRows, []any{
+			resp.Date, resp.ProviderID, resp.VehicleCount, resp.DistanceKm,
+			hoursFromSeconds(resp.MovingSeconds), hoursFromSeconds(resp.IdleSeconds),
+		})
+	}
+
+	f := excelize.NewFile()
+	vehicleSheet, providerSheet := "По транспорту", "По провайдерам"
+	if err := f.SetSheetName("Sheet1", vehicleSheet); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if _, err := f.NewSheet(providerSheet); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	vehicleHeaders := []string{
+		"Дата", "ID транспорта", "ID провайдера", "Пробег, км", "Пробег по одометру, км",
+		"Расхождение с одометром, %", "Время движения, ч", "Время простоя, ч", "Количество точек",
+	}
+	if err := writeExcelSheet(f, vehicleSheet, vehicleHeaders, vehicleRows); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	providerHeaders := []string{
+		"Дата", "ID провайдера", "Количество транспорта", "Пробег, км", "Время движения, ч", "Время простоя, ч",
+	}
+	if err := writeExcelSheet(f, providerSheet, providerHeaders, providerRows); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	sendExcel(c, f, "mileage.xlsx")
+}
+
+func hoursFromSeconds(seconds int64) float64 {
+	return math.Round(float64(seconds)/36) / 100
+}
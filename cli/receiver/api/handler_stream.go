@@ -0,0 +1,144 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/daniil11ru/egts/cli/receiver/broker"
+	"github.com/daniil11ru/egts/cli/receiver/dto/response"
+	"github.com/gin-gonic/gin"
+)
+
+const streamHeartbeatInterval = 15 * time.Second
+
+// parseBoundingBox разбирает прямоугольник в порядке GeoJSON: min_lon,min_lat,max_lon,max_lat
+func parseBoundingBox(s string) (*broker.BoundingBox, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return nil, fmt.Errorf("bbox должен состоять из четырех чисел: min_lon,min_lat,max_lon,max_lat")
+	}
+
+	values := make([]float64, 4)
+	for i, part := range parts {
+		value, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, fmt.Errorf("некорректное значение bbox: %q", part)
+		}
+		values[i] = value
+	}
+
+	box := &broker.BoundingBox{MinLongitude: values[0], MinLatitude: values[1], MaxLongitude: values[2], MaxLatitude: values[3]}
+	if box.MinLatitude > box.MaxLatitude || !isValidLatitude(box.MinLatitude) || !isValidLatitude(box.MaxLatitude) ||
+		!isValidLongitude(box.MinLongitude) || !isValidLongitude(box.MaxLongitude) {
+		return nil, fmt.Errorf("недопустимые границы bbox")
+	}
+	return box, nil
+}
+
+func isValidLatitude(v float64) bool {
+	return v >= -90 && v <= 90
+}
+
+func isValidLongitude(v float64) bool {
+	return v >= -180 && v <= 180
+}
+
+func parseLocationStreamFilter(c *gin.Context) (broker.LocationFilter, bool) {
+	locationFilter := broker.LocationFilter{}
+
+	if vehicleIdStr := c.Query("vehicle_id"); vehicleIdStr != "" {
+		vehicleId, err := strconv.ParseInt(vehicleIdStr, 10, 32)
+		if err != nil || vehicleId < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный vehicle_id"})
+			return locationFilter, false
+		}
+		vehicleId32 := int32(vehicleId)
+		locationFilter.VehicleId = &vehicleId32
+	}
+
+	if providerIdStr := c.Query("provider_id"); providerIdStr != "" {
+		providerId, err := strconv.ParseInt(providerIdStr, 10, 32)
+		if err != nil || providerId < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный provider_id"})
+			return locationFilter, false
+		}
+		providerId32 := int32(providerId)
+		locationFilter.ProviderId = &providerId32
+	}
+	if !restrictProvider(c, &locationFilter.ProviderId) {
+		return locationFilter, false
+	}
+
+	if bboxStr := c.Query("bbox"); bboxStr != "" {
+		box, err := parseBoundingBox(bboxStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return locationFilter, false
+		}
+		locationFilter.BoundingBox = box
+	}
+
+	return locationFilter, true
+}
+
+// StreamLocations передает принятые местоположения в формате Server-Sent Events. Соединение
+// поддерживается комментариями раз в streamHeartbeatInterval; если клиент не успевает читать,
+// часть местоположений отбрасывается, о чем сообщает событие dropped.
+func (h *Handler) StreamLocations(c *gin.Context) {
+	locationFilter, ok := parseLocationStreamFilter(c)
+	if !ok {
+		return
+	}
+
+	tf := requestTimeFormat(c)
+	subscription := h.LocationSubscriber.Subscribe(locationFilter)
+	defer h.LocationSubscriber.Unsubscribe(subscription)
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	_, _ = io.WriteString(c.Writer, ": connected\n\n")
+	c.Writer.Flush()
+
+	var reportedDropped uint64
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case location, ok := <-subscription.C:
+			if !ok {
+				return false
+			}
+			if dropped := subscription.Dropped(); dropped > reportedDropped {
+				c.SSEvent("dropped", response.LiveLocationsDropped{Count: dropped - reportedDropped})
+				reportedDropped = dropped
+			}
+			c.SSEvent("location", response.LiveLocation{
+				LocationID: location.LocationId,
+				VehicleID:  location.VehicleId,
+				ProviderID: location.ProviderId,
+				OID:        location.OID,
+				Latitude:   location.Latitude,
+				Longitude:  location.Longitude,
+				Altitude:   location.Altitude,
+				Direction:  location.Direction,
+				Speed:      location.Speed,
+				SentAt:     tf.format(location.SentAt),
+				ReceivedAt: tf.format(location.ReceivedAt),
+			})
+			return true
+		case <-heartbeat.C:
+			_, err := io.WriteString(w, ": ping\n\n")
+			return err == nil
+		}
+	})
+}
@@ -0,0 +1,167 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/filter"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	"github.com/daniil11ru/egts/cli/receiver/dto/other"
+	"github.com/daniil11ru/egts/cli/receiver/dto/response"
+	"github.com/daniil11ru/egts/cli/receiver/util"
+	"github.com/gin-gonic/gin"
+)
+
+func parseOptionalId(c *gin.Context, key string) (*int32, bool) {
+	idStr := c.Query(key)
+	if idStr == "" {
+		return nil, true
+	}
+	id, err := strconv.ParseInt(idStr, 10, 32)
+	if err != nil || id < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный " + key})
+		return nil, false
+	}
+	id32 := int32(id)
+	return &id32, true
+}
+
+func toTerminalSessionResponse(session out.TerminalSession, tf timeFormat) response.TerminalSession {
+	resp := response.TerminalSession{
+		ID:               session.ID,
+		ProviderID:       session.ProviderId,
+		RemoteAddress:    session.RemoteAddress,
+		TerminalIMEI:     session.TerminalIMEI,
+		OID:              session.OID,
+		VehicleID:        session.VehicleId,
+		ConnectedAt:      tf.format(session.ConnectedAt),
+		DisconnectedAt:   tf.formatOptional(session.DisconnectedAt),
+		LastPacketAt:     tf.formatOptional(session.LastPacketAt),
+		PacketCount:      session.PacketCount,
+		RecordCount:      session.RecordCount,
+		DecodeErrorCount: session.DecodeErrorCount,
+	}
+	if session.CloseReason != nil {
+		reason := session.CloseReason.String()
+		resp.CloseReason = &reason
+	}
+	return resp
+}
+
+func (h *Handler) GetTerminalSessions(c *gin.Context) {
+	sessionsFilter := filter.TerminalSessions{Limit: 1000}
+
+	var ok bool
+	if sessionsFilter.ProviderId, ok = parseOptionalId(c, "provider_id"); !ok || !restrictProvider(c, &sessionsFilter.ProviderId) {
+		return
+	}
+	if sessionsFilter.VehicleId, ok = parseOptionalId(c, "vehicle_id"); !ok {
+		return
+	}
+
+	if activeStr := c.Query("active"); activeStr != "" {
+		active, err := strconv.ParseBool(activeStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "active должен быть true или false"})
+			return
+		}
+		sessionsFilter.Active = &active
+	}
+
+	if closeReasonStr := c.Query("close_reason"); closeReasonStr != "" {
+		closeReason := other.SessionCloseReason(closeReasonStr)
+		if !closeReason.IsValid() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный close_reason"})
+			return
+		}
+		sessionsFilter.CloseReason = &closeReason
+	}
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err := strconv.ParseInt(limitStr, 10, 64)
+		if err != nil || limit < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный limit"})
+			return
+		}
+		sessionsFilter.Limit = limit
+	}
+
+	sessions, err := h.Repository.GetTerminalSessions(sessionsFilter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	tf := requestTimeFormat(c)
+	c.JSON(http.StatusOK, response.GetTerminalSessions(util.Map(sessions, func(session out.TerminalSession) response.TerminalSession {
+		return toTerminalSessionResponse(session, tf)
+	})))
+}
+
+func (h *Handler) toVehicleStatusResponse(connection out.VehicleConnection, now time.Time, tf timeFormat) response.VehicleStatus {
+	return response.VehicleStatus{
+		VehicleID:  connection.VehicleId,
+		ProviderID: connection.ProviderId,
+		Status:     h.TerminalStatusResolver.Resolve(connection, now).String(),
+		LastSeenAt: tf.formatOptional(connection.LastSeenAt),
+	}
+}
+
+func (h *Handler) GetVehicleStatuses(c *gin.Context) {
+	connectionsFilter := filter.VehicleConnections{}
+
+	var ok bool
+	if connectionsFilter.ProviderId, ok = parseOptionalId(c, "provider_id"); !ok || !restrictProvider(c, &connectionsFilter.ProviderId) {
+		return
+	}
+
+	var status *other.TerminalStatus
+	if statusStr := c.Query("status"); statusStr != "" {
+		s := other.TerminalStatus(statusStr)
+		if !s.IsValid() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "status должен быть online, stale или offline"})
+			return
+		}
+		status = &s
+	}
+
+	connections, err := h.Repository.GetVehicleConnections(connectionsFilter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	now, tf := time.Now(), requestTimeFormat(c)
+	statuses := make(response.GetVehicleStatuses, 0, len(connections))
+	for _, connection := range connections {
+		item := h.toVehicleStatusResponse(connection, now, tf)
+		if status != nil && item.Status != status.String() {
+			continue
+		}
+		statuses = append(statuses, item)
+	}
+
+	c.JSON(http.StatusOK, statuses)
+}
+
+func (h *Handler) GetVehicleStatus(c *gin.Context) {
+	vehicleId, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil || vehicleId <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный ID транспорта"})
+		return
+	}
+	vehicleId32 := int32(vehicleId)
+
+	connections, err := h.Repository.GetVehicleConnections(filter.VehicleConnections{VehicleId: &vehicleId32})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(connections) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Транспорт не найден"})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.toVehicleStatusResponse(connections[0], time.Now(), requestTimeFormat(c)))
+}
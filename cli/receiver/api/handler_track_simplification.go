@@ -0,0 +1,177 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/filter"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/insert"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	"github.com/daniil11ru/egts/cli/receiver/dto/request"
+	"github.com/daniil11ru/egts/cli/receiver/dto/response"
+	"github.com/daniil11ru/egts/cli/receiver/server/domain"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func toTrackSimplificationSettings(providerId int32, req request.TrackSimplificationSettings) out.TrackSimplificationSettings {
+	return out.TrackSimplificationSettings{
+		ProviderId:       providerId,
+		Enabled:          *req.Enabled,
+		ToleranceMeters:  *req.ToleranceMeters,
+		MaxGapSeconds:    *req.MaxGapSeconds,
+		StopRadiusMeters: *req.StopRadiusMeters,
+		StopMinSeconds:   *req.StopMinSeconds,
+	}
+}
+
+func (h *Handler) GetTrackSimplificationSettings(c *gin.Context) {
+	providerId, ok := parseProviderId(c)
+	if !ok {
+		return
+	}
+
+	isDefault := false
+	settings, err := h.Repository.GetTrackSimplificationSettings(providerId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		settings, isDefault = domain.DefaultTrackSimplificationSettings(providerId), true
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response.TrackSimplificationSettings{
+		ProviderID:       providerId,
+		Default:          isDefault,
+		Enabled:          settings.Enabled,
+		ToleranceMeters:  settings.ToleranceMeters,
+		MaxGapSeconds:    settings.MaxGapSeconds,
+		StopRadiusMeters: settings.StopRadiusMeters,
+		StopMinSeconds:   settings.StopMinSeconds,
+	})
+}
+
+func (h *Handler) SaveTrackSimplificationSettings(c *gin.Context) {
+	providerId, ok := parseProviderId(c)
+	if !ok {
+		return
+	}
+
+	var req request.TrackSimplificationSettings
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	settings := toTrackSimplificationSettings(providerId, req)
+	if err := domain.ValidateTrackSimplificationSettings(settings); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.Repository.SaveTrackSimplificationSettings(insert.TrackSimplificationSettings{
+		ProviderId:       settings.ProviderId,
+		Enabled:          settings.Enabled,
+		ToleranceMeters:  settings.ToleranceMeters,
+		MaxGapSeconds:    settings.MaxGapSeconds,
+		StopRadiusMeters: settings.StopRadiusMeters,
+		StopMinSeconds:   settings.StopMinSeconds,
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusOK)
+}
+
+func (h *Handler) DeleteTrackSimplificationSettings(c *gin.Context) {
+	providerId, ok := parseProviderId(c)
+	if !ok {
+		return
+	}
+
+	if err := h.Repository.DeleteTrackSimplificationSettings(providerId); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusOK)
+}
+
+func (h *Handler) DryRunTrackSimplification(c *gin.Context) {
+	tf := requestTimeFormat(c)
+	providerId, ok := parseProviderId(c)
+	if !ok {
+		return
+	}
+
+	var req request.DryRunTrackSimplification
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	now := time.Now()
+	tracksFilter := filter.Tracks{
+		ProviderId:     &providerId,
+		VehicleId:      req.VehicleID,
+		ReceivedAfter:  now.Add(-24 * time.Hour),
+		ReceivedBefore: now,
+	}
+	if req.ReceivedAfter != nil {
+		receivedAfter, err := tf.parse(*req.ReceivedAfter)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Дата должна быть в формате RFC 3339 или DD.MM.YYYY HH:MM:SS"})
+			return
+		}
+		tracksFilter.ReceivedAfter = receivedAfter
+	}
+	if req.ReceivedBefore != nil {
+		receivedBefore, err := tf.parse(*req.ReceivedBefore)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Дата должна быть в формате RFC 3339 или DD.MM.YYYY HH:MM:SS"})
+			return
+		}
+		tracksFilter.ReceivedBefore = receivedBefore
+	}
+
+	var settings *out.TrackSimplificationSettings
+	if req.Settings != nil {
+		if req.Settings.Enabled == nil || req.Settings.ToleranceMeters == nil || req.Settings.MaxGapSeconds == nil ||
+			req.Settings.StopRadiusMeters == nil || req.Settings.StopMinSeconds == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Необходимо указать все поля настроек"})
+			return
+		}
+		s := toTrackSimplificationSettings(providerId, *req.Settings)
+		if err := domain.ValidateTrackSimplificationSettings(s); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		// При пробном запуске переданные настройки применяются даже к выключенному упрощению
+		s.Enabled = true
+		settings = &s
+	}
+
+	plan, err := h.TrackSimplifier.DryRun(tracksFilter, settings)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp := response.DryRunTrackSimplification{Vehicles: []response.VehicleTrackSimplification{}}
+	for _, vehicle := range plan {
+		resp.Total += vehicle.Total
+		resp.Kept += len(vehicle.Kept)
+		resp.Archived += len(vehicle.Archived)
+		resp.Vehicles = append(resp.Vehicles, response.VehicleTrackSimplification{
+			VehicleID: vehicle.VehicleId,
+			Total:     vehicle.Total,
+			Kept:      len(vehicle.Kept),
+			Archived:  len(vehicle.Archived),
+			Segments:  vehicle.Segments,
+			Stops:     vehicle.Stops,
+		})
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
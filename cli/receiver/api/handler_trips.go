@@ -0,0 +1,112 @@
+package api
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/filter"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	"github.com/daniil11ru/egts/cli/receiver/dto/response"
+	"github.com/daniil11ru/egts/cli/receiver/util"
+	"github.com/gin-gonic/gin"
+)
+
+func parseTripsFilter(c *gin.Context) (filter.Trips, bool) {
+	tf := requestTimeFormat(c)
+	tripsFilter := filter.Trips{Limit: 1000}
+
+	vehicleId, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil || vehicleId <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный ID транспорта"})
+		return tripsFilter, false
+	}
+	tripsFilter.VehicleId = int32(vehicleId)
+
+	if afterStr := c.Query("after"); afterStr != "" {
+		after, err := tf.parse(afterStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Дата должна быть в формате RFC 3339 или DD.MM.YYYY HH:MM:SS"})
+			return tripsFilter, false
+		}
+		tripsFilter.After = &after
+	}
+
+	if beforeStr := c.Query("before"); beforeStr != "" {
+		before, err := tf.parse(beforeStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Дата должна быть в формате RFC 3339 или DD.MM.YYYY HH:MM:SS"})
+			return tripsFilter, false
+		}
+		tripsFilter.Before = &before
+	}
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err := strconv.ParseInt(limitStr, 10, 64)
+		if err != nil || limit < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный limit"})
+			return tripsFilter, false
+		}
+		tripsFilter.Limit = limit
+	}
+
+	return tripsFilter, true
+}
+
+func (h *Handler) GetTrips(c *gin.Context) {
+	tf := requestTimeFormat(c)
+	tripsFilter, ok := parseTripsFilter(c)
+	if !ok {
+		return
+	}
+
+	trips, err := h.Repository.GetTrips(tripsFilter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response.GetTrips(util.Map(trips, func(item out.Trip) response.Trip {
+		return response.Trip{
+			ID:              item.ID,
+			VehicleID:       item.VehicleId,
+			StartedAt:       tf.format(item.StartedAt),
+			EndedAt:         tf.format(item.EndedAt),
+			DurationSeconds: int64(item.EndedAt.Sub(item.StartedAt).Seconds()),
+			StartLatitude:   item.StartLatitude,
+			StartLongitude:  item.StartLongitude,
+			EndLatitude:     item.EndLatitude,
+			EndLongitude:    item.EndLongitude,
+			DistanceMeters:  math.Round(item.DistanceMeters),
+			MaxSpeed:        item.MaxSpeed,
+			PointCount:      item.PointCount,
+		}
+	})))
+}
+
+func (h *Handler) GetStops(c *gin.Context) {
+	tf := requestTimeFormat(c)
+	stopsFilter, ok := parseTripsFilter(c)
+	if !ok {
+		return
+	}
+
+	stops, err := h.Repository.GetStops(stopsFilter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response.GetStops(util.Map(stops, func(item out.Stop) response.Stop {
+		return response.Stop{
+			ID:              item.ID,
+			VehicleID:       item.VehicleId,
+			StartedAt:       tf.format(item.StartedAt),
+			EndedAt:         tf.format(item.EndedAt),
+			DurationSeconds: int64(item.EndedAt.Sub(item.StartedAt).Seconds()),
+			Latitude:        item.Latitude,
+			Longitude:       item.Longitude,
+			PointCount:      item.PointCount,
+		}
+	})))
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/insert"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	"github.com/daniil11ru/egts/cli/receiver/dto/request"
+	"github.com/daniil11ru/egts/cli/receiver/dto/response"
+	"github.com/daniil11ru/egts/cli/receiver/util"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) GetVehicleGroups(c *gin.Context) {
+	groups, err := h.Repository.GetVehicleGroups()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response.GetVehicleGroups(util.Map(groups, func(item out.VehicleGroup) response.VehicleGroup {
+		return response.VehicleGroup{ID: item.ID, Name: item.Name}
+	})))
+}
+
+func (h *Handler) AddVehicleGroup(c *gin.Context) {
+	var req request.AddVehicleGroup
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	id, err := h.Repository.AddVehicleGroup(insert.VehicleGroup{Name: req.Name})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"id": id})
+}
+
+func (h *Handler) DeleteVehicleGroup(c *gin.Context) {
+	groupId, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil || groupId <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный ID группы"})
+		return
+	}
+
+	if err := h.Repository.DeleteVehicleGroup(int32(groupId)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusOK)
+}
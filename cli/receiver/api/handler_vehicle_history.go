@@ -0,0 +1,72 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/filter"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	"github.com/daniil11ru/egts/cli/receiver/dto/response"
+	"github.com/daniil11ru/egts/cli/receiver/util"
+	"github.com/gin-gonic/gin"
+)
+
+// GetVehicleHistory возвращает журнал изменений транспорта. Журнал сохраняется и после удаления
+// транспорта, поэтому его наличие не проверяется.
+func (h *Handler) GetVehicleHistory(c *gin.Context) {
+	tf := requestTimeFormat(c)
+
+	vehicleId, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный ID транспорта"})
+		return
+	}
+	changesFilter := filter.VehicleChanges{VehicleId: int32(vehicleId), Limit: 1000}
+
+	if afterStr := c.Query("after"); afterStr != "" {
+		after, err := tf.parse(afterStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Дата должна быть в формате RFC 3339 или DD.MM.YYYY HH:MM:SS"})
+			return
+		}
+		changesFilter.After = &after
+	}
+
+	if beforeStr := c.Query("before"); beforeStr != "" {
+		before, err := tf.parse(beforeStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Дата должна быть в формате RFC 3339 или DD.MM.YYYY HH:MM:SS"})
+			return
+		}
+		changesFilter.Before = &before
+	}
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err := strconv.ParseInt(limitStr, 10, 64)
+		if err != nil || limit < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный limit"})
+			return
+		}
+		changesFilter.Limit = limit
+	}
+
+	changes, err := h.Repository.GetVehicleChanges(changesFilter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response.GetVehicleHistory(util.Map(changes, func(item out.VehicleChange) response.VehicleChange {
+		return response.VehicleChange{
+			ID:         item.ID,
+			Action:     item.Action.String(),
+			Source:     item.Source.String(),
+			ApiKeyID:   item.ApiKeyId,
+			ApiKeyName: item.ApiKeyName,
+			OldValues:  json.RawMessage(item.OldValues),
+			NewValues:  json.RawMessage(item.NewValues),
+			ChangedAt:  tf.format(item.ChangedAt),
+		}
+	})))
+}
@@ -0,0 +1,266 @@
+package api
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/filter"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/insert"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/update"
+	"github.com/daniil11ru/egts/cli/receiver/dto/other"
+	"github.com/daniil11ru/egts/cli/receiver/dto/response"
+	"github.com/daniil11ru/egts/cli/receiver/server/domain"
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+)
+
+const maxVehicleImportSize = 20 << 20
+
+// Способы вычисления OID называются так же, как в tools/fill_database_with_vehicles_from_xlsx.py
+var vehicleImportOidTypes = map[string]other.OidResolutionRuleType{
+	"digits":     other.OidResolutionRuleTypeImeiDigits,
+	"bytes":      other.OidResolutionRuleTypeImeiBytes,
+	"max_digits": other.OidResolutionRuleTypeImeiMaxDigits,
+}
+
+// readImportTable читает все строки первого листа xlsx (или листа sheet) либо csv-файла.
+// Разделитель csv определяется по первой строке: запятая или точка с запятой, как сохраняет Excel.
+func readImportTable(r io.Reader, filename, sheet string) ([][]string, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".xlsx":
+		f, err := excelize.OpenReader(r)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		if sheet == "" {
+			sheet = f.GetSheetName(0)
+		}
+		return f.GetRows(sheet)
+	case ".csv":
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+		header, _, _ := bytes.Cut(data, []byte("\n"))
+
+		reader := csv.NewReader(bytes.NewReader(data))
+		reader.FieldsPerRecord = -1
+		reader.LazyQuotes = true
+		if bytes.Count(header, []byte(";")) > bytes.Count(header, []byte(",")) {
+			reader.Comma = ';'
+		}
+		return reader.ReadAll()
+	}
+	return nil, fmt.Errorf("поддерживаются только файлы xlsx и csv")
+}
+
+func parseVehicleImportOptions(c *gin.Context) (domain.VehicleImportOptions, bool) {
+	options := domain.VehicleImportOptions{
+		IMEIColumn: strings.TrimSpace(c.PostForm("imei_column")),
+		NameColumn: strings.TrimSpace(c.PostForm("name_column")),
+		OIDColumn:  strings.TrimSpace(c.PostForm("oid_column")),
+	}
+
+	providerId, err := strconv.ParseInt(c.PostForm("provider_id"), 10, 32)
+	if err != nil || providerId <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный ID провайдера"})
+		return options, false
+	}
+	options.ProviderId = int32(providerId)
+
+	if options.IMEIColumn == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "imei_column обязателен для указания"})
+		return options, false
+	}
+
+	oidTypeStr := c.PostForm("oid_type")
+	if oidTypeStr == "" {
+		return options, true
+	}
+	oidType, ok := vehicleImportOidTypes[oidTypeStr]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "oid_type должен быть digits, bytes или max_digits"})
+		return options, false
+	}
+	position := other.ImeiSegmentPosition(c.PostForm("oid_from"))
+	var length *int16
+	if countStr := c.PostForm("oid_count"); countStr != "" {
+		count, err := strconv.ParseInt(countStr, 10, 16)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный oid_count"})
+			return options, false
+		}
+		count16 := int16(count)
+		length = &count16
+	}
+	if err := domain.ValidateOidResolutionRule(oidType, &position, length); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректные параметры вычисления OID: " + err.Error()})
+		return options, false
+	}
+
+	options.Oid = &domain.VehicleImportOid{Type: oidType, Position: position}
+	if length != nil {
+		options.Oid.Length = int(*length)
+	}
+	return options, true
+}
+
+func toVehicleImportResponse(plan domain.VehicleImportPlan, dryRun bool) response.VehicleImport {
+	resp := response.VehicleImport{
+		DryRun: dryRun,
+		Summary: response.VehicleImportSummary{
+			Insert:    plan.Count(domain.VehicleImportActionInsert),
+			Update:    plan.Count(domain.VehicleImportActionUpdate),
+			Unchanged: plan.Count(domain.VehicleImportActionUnchanged),
+			Conflict:  plan.Count(domain.VehicleImportActionConflict),
+		},
+		Rows: make([]response.VehicleImportRow, 0, len(plan.Items)),
+	}
+	for _, item := range plan.Items {
+		row := response.VehicleImportRow{
+			Row:    item.Row,
+			Action: string(item.Action),
+			IMEI:   item.IMEI,
+			OID:    item.OID,
+			Name:   item.Name,
+		}
+		if item.VehicleId != 0 {
+			vehicleId := item.VehicleId
+			row.VehicleID = &vehicleId
+		}
+		if item.Reason != "" {
+			reason := item.Reason
+			row.Reason = &reason
+		}
+		resp.Rows = append(resp.Rows, row)
+	}
+	return resp
+}
+
+// ImportVehicles загружает реестр транспорта из xlsx или csv. По умолчанию только показывает,
+// какие строки будут добавлены, обновлены или конфликтуют; изменения применяются при dry_run=false
+// и только если в файле нет конфликтов.
+func (h *Handler) ImportVehicles(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxVehicleImportSize)
+
+	options, ok := parseVehicleImportOptions(c)
+	if !ok {
+		return
+	}
+	providerId := &options.ProviderId
+	if !restrictProvider(c, &providerId) {
+		return
+	}
+
+	dryRun := true
+	if dryRunStr := c.PostForm("dry_run"); dryRunStr != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(dryRunStr); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "dry_run должен быть true или false"})
+			return
+		}
+	}
+
+	moderationStatus := other.ModerationStatusPending
+	if statusStr := c.PostForm("moderation_status"); statusStr != "" {
+		moderationStatus = other.ModerationStatus(statusStr)
+		if !moderationStatus.IsValid() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный moderation_status"})
+			return
+		}
+	}
+
+	if _, err := h.Repository.GetProvider(options.ProviderId); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Провайдер не найден"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Не удалось прочитать файл: " + err.Error()})
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Не удалось прочитать файл: " + err.Error()})
+		return
+	}
+	defer file.Close()
+
+	table, err := readImportTable(file, fileHeader.Filename, c.PostForm("sheet"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Не удалось прочитать файл: " + err.Error()})
+		return
+	}
+
+	existing, err := h.Repository.GetVehicles(filter.Vehicles{})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	plan, err := domain.PlanVehicleImport(table, options, existing)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp := toVehicleImportResponse(plan, dryRun)
+	if dryRun {
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+	if resp.Summary.Conflict > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Реестр содержит конфликтующие строки, изменения не применены", "import": resp})
+		return
+	}
+
+	var inserts []insert.Vehicle
+	var updates []update.VehicleImport
+	var insertRows []int
+	for i, item := range plan.Items {
+		switch item.Action {
+		case domain.VehicleImportActionInsert:
+			inserts = append(inserts, insert.Vehicle{
+				IMEI:             item.IMEI,
+				OID:              item.OID,
+				Name:             item.Name,
+				ProviderId:       options.ProviderId,
+				ModerationStatus: moderationStatus,
+			})
+			insertRows = append(insertRows, i)
+		case domain.VehicleImportActionUpdate:
+			updates = append(updates, update.VehicleImport{ID: item.VehicleId, OID: item.OID, Name: item.Name})
+		}
+	}
+
+	ids, err := h.Repository.ImportVehicles(inserts, updates, changeAuthor(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	for i, row := range insertRows {
+		id := ids[i]
+		resp.Rows[row].VehicleID = &id
+	}
+	for _, u := range updates {
+		h.LastPositionInvalidator.Invalidate(u.ID)
+	}
+	h.IngestCacheInvalidator.InvalidateVehicles(options.ProviderId)
+
+	resp.Applied = true
+	c.JSON(http.StatusOK, resp)
+}
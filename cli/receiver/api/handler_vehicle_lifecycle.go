@@ -0,0 +1,244 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/filter"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/insert"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	"github.com/daniil11ru/egts/cli/receiver/dto/other"
+	"github.com/daniil11ru/egts/cli/receiver/dto/request"
+	"github.com/daniil11ru/egts/cli/receiver/dto/response"
+	"github.com/daniil11ru/egts/cli/receiver/server/domain"
+	"github.com/daniil11ru/egts/cli/receiver/util"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func parseVehicleId(c *gin.Context) (int32, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный ID транспорта"})
+		return 0, false
+	}
+	return int32(id), true
+}
+
+func (h *Handler) AddVehicle(c *gin.Context) {
+	var req request.AddVehicle
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	providerId := &req.ProviderID
+	if !restrictProvider(c, &providerId) {
+		return
+	}
+
+	vehicle := insert.Vehicle{
+		IMEI:             strings.TrimSpace(req.IMEI),
+		OID:              req.OID,
+		Name:             req.Name,
+		ProviderId:       req.ProviderID,
+		ModerationStatus: other.ModerationStatusPending,
+		VehicleGroupId:   req.VehicleGroupID,
+	}
+	if req.ModerationStatus != nil {
+		vehicle.ModerationStatus = *req.ModerationStatus
+	}
+	if vehicle.VehicleGroupId != nil && *vehicle.VehicleGroupId == 0 {
+		vehicle.VehicleGroupId = nil
+	}
+	if err := domain.ValidateVehicle(vehicle); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, err := h.Repository.GetProvider(vehicle.ProviderId); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Провайдер не найден"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if vehicle.VehicleGroupId != nil {
+		groups, err := h.Repository.GetVehicleGroups()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		found := false
+		for _, group := range groups {
+			if group.ID == *vehicle.VehicleGroupId {
+				found = true
+				break
+			}
+		}
+		if !found {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Группа транспорта не найдена"})
+			return
+		}
+	}
+
+	existing, err := h.Repository.GetVehicles(filter.Vehicles{IMEI: &vehicle.IMEI})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(existing) > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Транспорт с таким IMEI уже существует", "id": existing[0].ID})
+		return
+	}
+
+	id, err := h.Repository.AddVehicle(vehicle, changeAuthor(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.IngestCacheInvalidator.InvalidateVehicles(vehicle.ProviderId)
+	c.JSON(http.StatusCreated, gin.H{"id": id})
+}
+
+// DeleteVehicle удаляет транспорт вместе с его местоположениями, поездками и пробегом. Чтобы
+// сохранить точки ошибочно созданного транспорта, его следует объединить с настоящим.
+func (h *Handler) DeleteVehicle(c *gin.Context) {
+	id, ok := parseVehicleId(c)
+	if !ok {
+		return
+	}
+
+	if err := h.Repository.DeleteVehicle(id, changeAuthor(c)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Транспорт не найден"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.LastPositionInvalidator.Invalidate(id)
+	h.IngestCacheInvalidator.InvalidateAllVehicles()
+	c.Status(http.StatusOK)
+}
+
+// ModerateVehicles устанавливает статус модерации сразу нескольким транспортным средствам,
+// выбранным списком ID или фильтром. Все изменения применяются в одной транзакции.
+func (h *Handler) ModerateVehicles(c *gin.Context) {
+	var req request.ModerateVehicles
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if (len(req.IDs) > 0) == (req.Filter != nil) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Нужно указать либо ids, либо filter"})
+		return
+	}
+
+	vehiclesFilter := filter.Vehicles{IDs: req.IDs}
+	if req.Filter != nil {
+		if req.Filter.ProviderID == nil && req.Filter.ModerationStatus == nil && req.Filter.VehicleGroupID == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Фильтр не может быть пустым"})
+			return
+		}
+		vehiclesFilter.ProviderId = req.Filter.ProviderID
+		vehiclesFilter.ModerationStatus = req.Filter.ModerationStatus
+		vehiclesFilter.VehicleGroupId = req.Filter.VehicleGroupID
+	}
+	if !restrictProvider(c, &vehiclesFilter.ProviderId) {
+		return
+	}
+
+	vehicles, err := h.Repository.GetVehicles(vehiclesFilter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.IDs) > 0 {
+		found := make(map[int32]bool, len(vehicles))
+		for _, vehicle := range vehicles {
+			found[vehicle.ID] = true
+		}
+		var missing []int32
+		for _, id := range req.IDs {
+			if !found[id] {
+				missing = append(missing, id)
+			}
+		}
+		if len(missing) > 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Транспорт не найден", "ids": missing})
+			return
+		}
+	}
+
+	ids := util.Map(vehicles, func(vehicle out.Vehicle) int32 { return vehicle.ID })
+	updated, err := h.Repository.ModerateVehicles(ids, req.ModerationStatus, changeAuthor(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	for _, id := range updated {
+		h.LastPositionInvalidator.Invalidate(id)
+	}
+	h.IngestCacheInvalidator.InvalidateAllVehicles()
+
+	if updated == nil {
+		updated = []int32{}
+	}
+	c.JSON(http.StatusOK, response.ModerateVehicles{Matched: len(ids), Updated: updated})
+}
+
+// MergeVehicles переносит данные дубликата, например созданного приемником по незнакомому OID,
+// в транспорт из пути запроса и удаляет дубликат. Точки дубликата с временем отправки, которое у
+// транспорта уже есть, удаляются вместе с дубликатом; их количество возвращается в ответе.
+func (h *Handler) MergeVehicles(c *gin.Context) {
+	targetId, ok := parseVehicleId(c)
+	if !ok {
+		return
+	}
+	var req request.MergeVehicles
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	target, err := h.Repository.GetVehicle(targetId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if target.ID == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Транспорт не найден"})
+		return
+	}
+	duplicate, err := h.Repository.GetVehicle(req.DuplicateID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if duplicate.ID == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Дубликат не найден"})
+		return
+	}
+	if err := domain.ValidateVehicleMerge(target, duplicate); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	moved, skipped, err := h.Repository.MergeVehicles(targetId, req.DuplicateID, changeAuthor(c))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Транспорт не найден"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.LastPositionInvalidator.Invalidate(targetId)
+	h.LastPositionInvalidator.Invalidate(req.DuplicateID)
+	h.IngestCacheInvalidator.InvalidateVehicles(target.ProviderId)
+
+	c.JSON(http.StatusOK, response.MergeVehicles{MovedLocations: moved, SkippedLocations: skipped})
+}
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/filter"
+	"github.com/daniil11ru/egts/cli/receiver/export"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// trackBufferSize — размер буфера, которым выгрузка трека отправляется клиенту
+const trackBufferSize = 64 * 1024
+
+// GetVehicleTrack выгружает трек транспорта в формате GeoJSON, GPX, KML, CSV или XLSX. Точки
+// читаются из базы данных и отправляются клиенту потоком, поэтому размер трека не ограничен памятью.
+func (h *Handler) GetVehicleTrack(c *gin.Context) {
+	tf := requestTimeFormat(c)
+
+	vehicleId, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil || vehicleId <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный ID транспорта"})
+		return
+	}
+	trackFilter := filter.VehicleTrack{VehicleId: int32(vehicleId)}
+
+	if afterStr := c.Query("after"); afterStr != "" {
+		after, err := tf.parse(afterStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Дата должна быть в формате RFC 3339 или DD.MM.YYYY HH:MM:SS"})
+			return
+		}
+		trackFilter.SentAfter = &after
+	}
+	if beforeStr := c.Query("before"); beforeStr != "" {
+		before, err := tf.parse(beforeStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Дата должна быть в формате RFC 3339 или DD.MM.YYYY HH:MM:SS"})
+			return
+		}
+		trackFilter.SentBefore = &before
+	}
+	if trackFilter.SentAfter != nil && trackFilter.SentBefore != nil && trackFilter.SentAfter.After(*trackFilter.SentBefore) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "after не может быть позже before"})
+		return
+	}
+
+	format := export.Format(c.DefaultQuery("format", string(export.FormatGeoJSON)))
+	if !format.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format должен быть geojson, gpx, kml, csv или xlsx"})
+		return
+	}
+
+	vehicle, err := h.Repository.GetVehicle(trackFilter.VehicleId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if vehicle.ID == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Транспорт не найден"})
+		return
+	}
+
+	name := vehicle.IMEI
+	if vehicle.Name != nil && *vehicle.Name != "" {
+		name = *vehicle.Name
+	}
+
+	c.Header("Content-Type", format.ContentType())
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=track_%d.%s", vehicle.ID, format))
+
+	buf := bufio.NewWriterSize(c.Writer, trackBufferSize)
+	writer, err := export.NewTrackWriter(format, buf, name, tf.format)
+	if err == nil {
+		err = h.Repository.StreamVehicleTrack(trackFilter, writer.WritePoint)
+	}
+	if err == nil {
+		err = writer.Close()
+	}
+	if err == nil {
+		err = buf.Flush()
+	}
+	if err == nil {
+		return
+	}
+
+	if !c.Writer.Written() {
+		c.Writer.Header().Del("Content-Type")
+		c.Writer.Header().Del("Content-Disposition")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	// Заголовки и часть трека уже отправлены, поэтому клиент получит оборванный файл
+	logrus.Errorf("Ошибка выгрузки трека транспорта с ID %d: %v", vehicle.ID, err)
+	_ = c.Error(err)
+}
@@ -0,0 +1,192 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/filter"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	"github.com/daniil11ru/egts/cli/receiver/dto/response"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPageLimit = 100
+	maxPageLimit     = 1000
+)
+
+// pageCursor — содержимое курсора: сортировка, для которой он выдан, и ключ последней записи страницы
+type pageCursor struct {
+	Sort  string `json:"sort"`
+	Value string `json:"value,omitempty"`
+	ID    int64  `json:"id"`
+}
+
+func encodeCursor(cursor pageCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string) (pageCursor, error) {
+	var cursor pageCursor
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return cursor, err
+	}
+	err = json.Unmarshal(data, &cursor)
+	return cursor, err
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// parsePage разбирает параметры sort, limit и cursor. Пагинация включается параметром limit или
+// cursor, без них возвращается только порядок сортировки без ограничения количества записей.
+func parsePage(c *gin.Context, sortFields []string, defaultSort string) (*filter.Page, bool, bool) {
+	sort := c.DefaultQuery("sort", defaultSort)
+	page := &filter.Page{Sort: strings.TrimPrefix(sort, "-"), Desc: strings.HasPrefix(sort, "-")}
+	if !contains(sortFields, page.Sort) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("sort должен быть одним из полей %s, для обратного порядка перед полем указывается «-»", strings.Join(sortFields, ", "))})
+		return nil, false, false
+	}
+
+	limitStr, cursorStr := c.Query("limit"), c.Query("cursor")
+	if limitStr == "" && cursorStr == "" {
+		return page, false, true
+	}
+
+	page.Limit = defaultPageLimit
+	if limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > maxPageLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit должен быть в пределах от 1 до %d", maxPageLimit)})
+			return nil, false, false
+		}
+		page.Limit = limit
+	}
+
+	if cursorStr != "" {
+		cursor, err := decodeCursor(cursorStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный cursor"})
+			return nil, false, false
+		}
+		if cursor.Sort != sort {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "cursor выдан для другого значения sort"})
+			return nil, false, false
+		}
+		page.After = &filter.PageKey{Value: cursor.Value, ID: cursor.ID}
+	}
+
+	return page, true, true
+}
+
+// parseFields разбирает параметр fields — список полей элемента ответа через запятую
+func parseFields(c *gin.Context, allowed []string) ([]string, bool) {
+	fieldsStr := c.Query("fields")
+	if fieldsStr == "" {
+		return nil, true
+	}
+
+	var fields []string
+	for _, field := range strings.Split(fieldsStr, ",") {
+		field = strings.TrimSpace(field)
+		if !contains(allowed, field) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Неизвестное поле %q, допустимые поля: %s", field, strings.Join(allowed, ", "))})
+			return nil, false
+		}
+		fields = append(fields, field)
+	}
+	return fields, true
+}
+
+// selectFields оставляет в элементах ответа только поля fields. Поле без значения возвращается
+// как null. Если поля не заданы, элементы возвращаются без изменений.
+func selectFields[T any](items []T, fields []string) (interface{}, error) {
+	if len(fields) == 0 {
+		return items, nil
+	}
+
+	selected := make([]map[string]json.RawMessage, 0, len(items))
+	for _, item := range items {
+		data, err := json.Marshal(item)
+		if err != nil {
+			return nil, err
+		}
+		var all map[string]json.RawMessage
+		if err := json.Unmarshal(data, &all); err != nil {
+			return nil, err
+		}
+
+		values := make(map[string]json.RawMessage, len(fields))
+		for _, field := range fields {
+			value, ok := all[field]
+			if !ok {
+				value = json.RawMessage("null")
+			}
+			values[field] = value
+		}
+		selected = append(selected, values)
+	}
+	return selected, nil
+}
+
+// writePage отвечает страницей списка. Ссылка на следующую страницу повторяет параметры запроса
+// с курсором последней записи.
+func writePage(c *gin.Context, items interface{}, next *pageCursor) {
+	resp := response.Page{Items: items}
+	if next != nil {
+		cursor := encodeCursor(*next)
+		query := c.Request.URL.Query()
+		query.Set("cursor", cursor)
+		link := c.Request.URL.Path + "?" + query.Encode()
+		resp.NextCursor = &cursor
+		resp.Next = &link
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+var vehicleFields = []string{"id", "imei", "oid", "name", "provider_id", "moderation_status", "vehicle_group_id"}
+
+var locationFields = []string{"id", "vehicle_id", "oid", "latitude", "longitude", "altitude", "direction", "speed", "satellite_count", "sent_at", "received_at", "is_history"}
+
+func vehicleSortValue(vehicle out.Vehicle, sort string) string {
+	switch sort {
+	case "imei":
+		return vehicle.IMEI
+	case "name":
+		if vehicle.Name == nil {
+			return ""
+		}
+		return *vehicle.Name
+	case "provider_id":
+		return strconv.FormatInt(int64(vehicle.ProviderId), 10)
+	case "moderation_status":
+		return vehicle.ModerationStatus.String()
+	}
+	return ""
+}
+
+func locationSortValue(location out.Location, sort string) string {
+	switch sort {
+	case "sent_at":
+		if location.SentAt == nil {
+			return ""
+		}
+		return location.SentAt.UTC().Format(time.RFC3339Nano)
+	case "received_at":
+		return location.ReceivedAt.UTC().Format(time.RFC3339Nano)
+	}
+	return ""
+}
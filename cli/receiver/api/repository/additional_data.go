@@ -1,12 +1,19 @@
 package repository
 
 import (
+	"time"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/insert"
 	output "github.com/daniil11ru/egts/cli/receiver/dto/db/out"
 	"github.com/daniil11ru/egts/cli/receiver/source"
 )
 
 type AdditionalData interface {
 	GetApiKeys() ([]output.ApiKey, error)
+	AddApiKey(apiKey insert.ApiKey) (int32, error)
+	RotateApiKey(id int32, hash string) error
+	RevokeApiKey(id int32, revokedAt time.Time) error
+	TouchApiKeys(lastUsed map[int32]time.Time) error
 }
 
 type AdditionalDataDefault struct {
@@ -20,3 +27,19 @@ func NewAdditionalDataDefault(source source.Primary) *AdditionalDataDefault {
 func (r *AdditionalDataDefault) GetApiKeys() ([]output.ApiKey, error) {
 	return r.Source.GetApiKeys()
 }
+
+func (r *AdditionalDataDefault) AddApiKey(apiKey insert.ApiKey) (int32, error) {
+	return r.Source.AddApiKey(apiKey)
+}
+
+func (r *AdditionalDataDefault) RotateApiKey(id int32, hash string) error {
+	return r.Source.RotateApiKey(id, hash)
+}
+
+func (r *AdditionalDataDefault) RevokeApiKey(id int32, revokedAt time.Time) error {
+	return r.Source.RevokeApiKey(id, revokedAt)
+}
+
+func (r *AdditionalDataDefault) TouchApiKeys(lastUsed map[int32]time.Time) error {
+	return r.Source.TouchApiKeys(lastUsed)
+}
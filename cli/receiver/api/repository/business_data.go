@@ -2,8 +2,10 @@ package repository
 
 import (
 	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/filter"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/insert"
 	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/update"
 	output "github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	"github.com/daniil11ru/egts/cli/receiver/dto/other"
 	"github.com/daniil11ru/egts/cli/receiver/source"
 )
 
@@ -11,8 +13,65 @@ type BusinessData interface {
 	GetVehicle(vehicleId int32) (output.Vehicle, error)
 	GetVehicles(filter filter.Vehicles) ([]output.Vehicle, error)
 	GetLocations(filter filter.Locations) ([]output.Location, error)
-	UpdateVehicleByImei(imei string, update update.VehicleByImei) error
-	UpdateVehicleById(vehicleId int32, update update.VehicleById) error
+	StreamVehicleTrack(filter filter.VehicleTrack, fn func(output.Location) error) error
+	GetHistoryBacklog(filter filter.HistoryBacklog) ([]output.HistoryBacklog, error)
+	GetTrips(filter filter.Trips) ([]output.Trip, error)
+	GetStops(filter filter.Trips) ([]output.Stop, error)
+	GetDailyMileage(filter filter.DailyMileage) ([]output.DailyMileage, error)
+
+	GetGeofences() ([]output.Geofence, error)
+	GetGeofence(id int32) (output.Geofence, error)
+	AddGeofences(geofences []insert.Geofence) ([]int32, error)
+	ReplaceGeofence(id int32, geofence insert.Geofence) error
+	DeleteGeofence(id int32) error
+	GetGeofenceEvents(filter filter.GeofenceEvents) ([]output.GeofenceEvent, error)
+
+	GetTerminalSessions(filter filter.TerminalSessions) ([]output.TerminalSession, error)
+	GetVehicleConnections(filter filter.VehicleConnections) ([]output.VehicleConnection, error)
+	UpdateVehicleByImei(imei string, update update.VehicleByImei, author insert.VehicleChangeAuthor) error
+	UpdateVehicleById(vehicleId int32, update update.VehicleById, author insert.VehicleChangeAuthor) error
+	ImportVehicles(inserts []insert.Vehicle, updates []update.VehicleImport, author insert.VehicleChangeAuthor) ([]int32, error)
+	AddVehicle(v insert.Vehicle, author insert.VehicleChangeAuthor) (int32, error)
+	ModerateVehicles(ids []int32, status other.ModerationStatus, author insert.VehicleChangeAuthor) ([]int32, error)
+	DeleteVehicle(id int32, author insert.VehicleChangeAuthor) error
+	MergeVehicles(targetId, duplicateId int32, author insert.VehicleChangeAuthor) (int64, int64, error)
+	GetVehicleChanges(filter filter.VehicleChanges) ([]output.VehicleChange, error)
+
+	GetProviders() ([]output.Provider, error)
+	GetProvider(id int32) (output.Provider, error)
+	AddProvider(provider insert.Provider, rules []insert.OidResolutionRule) (int32, error)
+	UpdateProvider(id int32, update update.Provider) error
+	DeleteProvider(id int32) error
+
+	GetProviderAllowedNetworks(filter filter.ProviderAllowedNetworks) ([]output.ProviderAllowedNetwork, error)
+	AddProviderAllowedNetwork(network insert.ProviderAllowedNetwork) (int32, error)
+	DeleteProviderAllowedNetwork(id int32) error
+
+	GetOidResolutionRules(filter filter.OidResolutionRules) ([]output.OidResolutionRule, error)
+	AddOidResolutionRule(rule insert.OidResolutionRule) (int32, error)
+	UpdateOidResolutionRule(id int32, update update.OidResolutionRule) error
+	DeleteOidResolutionRule(id int32) error
+
+	GetQuarantinedLocations(filter filter.QuarantinedLocations) ([]output.QuarantinedLocation, error)
+	GetQuarantineGroups(filter filter.QuarantinedLocations) ([]output.QuarantineGroup, error)
+
+	GetVehicleGroups() ([]output.VehicleGroup, error)
+	AddVehicleGroup(group insert.VehicleGroup) (int32, error)
+	DeleteVehicleGroup(id int32) error
+
+	GetAcceptanceSchedules(filter filter.AcceptanceSchedules) ([]output.AcceptanceSchedule, error)
+	AddAcceptanceSchedule(schedule insert.AcceptanceSchedule) (int32, error)
+	ReplaceAcceptanceSchedule(id int32, schedule insert.AcceptanceSchedule) error
+	DeleteAcceptanceSchedule(id int32) error
+
+	GetGpsFilterSettings(providerId int32) (output.GpsFilterSettings, error)
+	SaveGpsFilterSettings(settings insert.GpsFilterSettings) error
+	DeleteGpsFilterSettings(providerId int32) error
+	GetRejectedLocations(filter filter.RejectedLocations) ([]output.RejectedLocation, error)
+
+	GetTrackSimplificationSettings(providerId int32) (output.TrackSimplificationSettings, error)
+	SaveTrackSimplificationSettings(settings insert.TrackSimplificationSettings) error
+	DeleteTrackSimplificationSettings(providerId int32) error
 }
 
 type BusinessDataDefault struct {
@@ -35,10 +94,198 @@ func (r *BusinessDataDefault) GetLocations(filter filter.Locations) ([]output.Lo
 	return r.PostgreSource.GetLocations(filter)
 }
 
-func (r *BusinessDataDefault) UpdateVehicleById(vehicleId int32, update update.VehicleById) error {
-	return r.PostgreSource.UpdateVehicleById(vehicleId, update)
+func (r *BusinessDataDefault) StreamVehicleTrack(filter filter.VehicleTrack, fn func(output.Location) error) error {
+	return r.PostgreSource.StreamVehicleTrack(filter, fn)
+}
+
+func (r *BusinessDataDefault) GetHistoryBacklog(filter filter.HistoryBacklog) ([]output.HistoryBacklog, error) {
+	return r.PostgreSource.GetHistoryBacklog(filter)
+}
+
+func (r *BusinessDataDefault) GetTrips(filter filter.Trips) ([]output.Trip, error) {
+	return r.PostgreSource.GetTrips(filter)
+}
+
+func (r *BusinessDataDefault) GetStops(filter filter.Trips) ([]output.Stop, error) {
+	return r.PostgreSource.GetStops(filter)
+}
+
+func (r *BusinessDataDefault) GetDailyMileage(filter filter.DailyMileage) ([]output.DailyMileage, error) {
+	return r.PostgreSource.GetDailyMileage(filter)
+}
+
+func (r *BusinessDataDefault) UpdateVehicleById(vehicleId int32, update update.VehicleById, author insert.VehicleChangeAuthor) error {
+	return r.PostgreSource.UpdateVehicleById(vehicleId, update, author)
+}
+
+func (r *BusinessDataDefault) ImportVehicles(inserts []insert.Vehicle, updates []update.VehicleImport, author insert.VehicleChangeAuthor) ([]int32, error) {
+	return r.PostgreSource.ImportVehicles(inserts, updates, author)
+}
+
+func (r *BusinessDataDefault) UpdateVehicleByImei(imei string, update update.VehicleByImei, author insert.VehicleChangeAuthor) error {
+	return r.PostgreSource.UpdateVehicleByImei(imei, update, author)
+}
+
+func (r *BusinessDataDefault) AddVehicle(v insert.Vehicle, author insert.VehicleChangeAuthor) (int32, error) {
+	return r.PostgreSource.AddVehicle(v, author)
+}
+
+func (r *BusinessDataDefault) ModerateVehicles(ids []int32, status other.ModerationStatus, author insert.VehicleChangeAuthor) ([]int32, error) {
+	return r.PostgreSource.ModerateVehicles(ids, status, author)
+}
+
+func (r *BusinessDataDefault) DeleteVehicle(id int32, author insert.VehicleChangeAuthor) error {
+	return r.PostgreSource.DeleteVehicle(id, author)
+}
+
+func (r *BusinessDataDefault) MergeVehicles(targetId, duplicateId int32, author insert.VehicleChangeAuthor) (int64, int64, error) {
+	return r.PostgreSource.MergeVehicles(targetId, duplicateId, author)
+}
+
+func (r *BusinessDataDefault) GetVehicleChanges(filter filter.VehicleChanges) ([]output.VehicleChange, error) {
+	return r.PostgreSource.GetVehicleChanges(filter)
+}
+
+func (r *BusinessDataDefault) GetProviders() ([]output.Provider, error) {
+	return r.PostgreSource.GetProviders()
+}
+
+func (r *BusinessDataDefault) GetProvider(id int32) (output.Provider, error) {
+	return r.PostgreSource.GetProvider(id)
+}
+
+func (r *BusinessDataDefault) AddProvider(provider insert.Provider, rules []insert.OidResolutionRule) (int32, error) {
+	return r.PostgreSource.AddProvider(provider, rules)
+}
+
+func (r *BusinessDataDefault) UpdateProvider(id int32, update update.Provider) error {
+	return r.PostgreSource.UpdateProvider(id, update)
+}
+
+func (r *BusinessDataDefault) DeleteProvider(id int32) error {
+	return r.PostgreSource.DeleteProvider(id)
+}
+
+func (r *BusinessDataDefault) GetProviderAllowedNetworks(filter filter.ProviderAllowedNetworks) ([]output.ProviderAllowedNetwork, error) {
+	return r.PostgreSource.GetProviderAllowedNetworks(filter)
+}
+
+func (r *BusinessDataDefault) AddProviderAllowedNetwork(network insert.ProviderAllowedNetwork) (int32, error) {
+	return r.PostgreSource.AddProviderAllowedNetwork(network)
+}
+
+func (r *BusinessDataDefault) DeleteProviderAllowedNetwork(id int32) error {
+	return r.PostgreSource.DeleteProviderAllowedNetwork(id)
+}
+
+func (r *BusinessDataDefault) GetOidResolutionRules(filter filter.OidResolutionRules) ([]output.OidResolutionRule, error) {
+	return r.PostgreSource.GetOidResolutionRules(filter)
+}
+
+func (r *BusinessDataDefault) AddOidResolutionRule(rule insert.OidResolutionRule) (int32, error) {
+	return r.PostgreSource.AddOidResolutionRule(rule)
+}
+
+func (r *BusinessDataDefault) UpdateOidResolutionRule(id int32, update update.OidResolutionRule) error {
+	return r.PostgreSource.UpdateOidResolutionRule(id, update)
+}
+
+func (r *BusinessDataDefault) DeleteOidResolutionRule(id int32) error {
+	return r.PostgreSource.DeleteOidResolutionRule(id)
+}
+
+func (r *BusinessDataDefault) GetQuarantinedLocations(filter filter.QuarantinedLocations) ([]output.QuarantinedLocation, error) {
+	return r.PostgreSource.GetQuarantinedLocations(filter)
+}
+
+func (r *BusinessDataDefault) GetQuarantineGroups(filter filter.QuarantinedLocations) ([]output.QuarantineGroup, error) {
+	return r.PostgreSource.GetQuarantineGroups(filter)
+}
+
+func (r *BusinessDataDefault) GetVehicleGroups() ([]output.VehicleGroup, error) {
+	return r.PostgreSource.GetVehicleGroups()
+}
+
+func (r *BusinessDataDefault) AddVehicleGroup(group insert.VehicleGroup) (int32, error) {
+	return r.PostgreSource.AddVehicleGroup(group)
+}
+
+func (r *BusinessDataDefault) DeleteVehicleGroup(id int32) error {
+	return r.PostgreSource.DeleteVehicleGroup(id)
+}
+
+func (r *BusinessDataDefault) GetAcceptanceSchedules(filter filter.AcceptanceSchedules) ([]output.AcceptanceSchedule, error) {
+	return r.PostgreSource.GetAcceptanceSchedules(filter)
+}
+
+func (r *BusinessDataDefault) AddAcceptanceSchedule(schedule insert.AcceptanceSchedule) (int32, error) {
+	return r.PostgreSource.AddAcceptanceSchedule(schedule)
+}
+
+func (r *BusinessDataDefault) ReplaceAcceptanceSchedule(id int32, schedule insert.AcceptanceSchedule) error {
+	return r.PostgreSource.ReplaceAcceptanceSchedule(id, schedule)
+}
+
+func (r *BusinessDataDefault) DeleteAcceptanceSchedule(id int32) error {
+	return r.PostgreSource.DeleteAcceptanceSchedule(id)
+}
+
+func (r *BusinessDataDefault) GetGpsFilterSettings(providerId int32) (output.GpsFilterSettings, error) {
+	return r.PostgreSource.GetGpsFilterSettings(providerId)
+}
+
+func (r *BusinessDataDefault) SaveGpsFilterSettings(settings insert.GpsFilterSettings) error {
+	return r.PostgreSource.SaveGpsFilterSettings(settings)
+}
+
+func (r *BusinessDataDefault) DeleteGpsFilterSettings(providerId int32) error {
+	return r.PostgreSource.DeleteGpsFilterSettings(providerId)
+}
+
+func (r *BusinessDataDefault) GetRejectedLocations(filter filter.RejectedLocations) ([]output.RejectedLocation, error) {
+	return r.PostgreSource.GetRejectedLocations(filter)
+}
+
+func (r *BusinessDataDefault) GetTrackSimplificationSettings(providerId int32) (output.TrackSimplificationSettings, error) {
+	return r.PostgreSource.GetTrackSimplificationSettings(providerId)
+}
+
+func (r *BusinessDataDefault) SaveTrackSimplificationSettings(settings insert.TrackSimplificationSettings) error {
+	return r.PostgreSource.SaveTrackSimplificationSettings(settings)
+}
+
+func (r *BusinessDataDefault) DeleteTrackSimplificationSettings(providerId int32) error {
+	return r.PostgreSource.DeleteTrackSimplificationSettings(providerId)
+}
+
+func (r *BusinessDataDefault) GetGeofences() ([]output.Geofence, error) {
+	return r.PostgreSource.GetGeofences()
+}
+
+func (r *BusinessDataDefault) GetGeofence(id int32) (output.Geofence, error) {
+	return r.PostgreSource.GetGeofence(id)
+}
+
+func (r *BusinessDataDefault) AddGeofences(geofences []insert.Geofence) ([]int32, error) {
+	return r.PostgreSource.AddGeofences(geofences)
+}
+
+func (r *BusinessDataDefault) ReplaceGeofence(id int32, geofence insert.Geofence) error {
+	return r.PostgreSource.ReplaceGeofence(id, geofence)
+}
+
+func (r *BusinessDataDefault) DeleteGeofence(id int32) error {
+	return r.PostgreSource.DeleteGeofence(id)
+}
+
+func (r *BusinessDataDefault) GetGeofenceEvents(filter filter.GeofenceEvents) ([]output.GeofenceEvent, error) {
+	return r.PostgreSource.GetGeofenceEvents(filter)
+}
+
+func (r *BusinessDataDefault) GetTerminalSessions(filter filter.TerminalSessions) ([]output.TerminalSession, error) {
+	return r.PostgreSource.GetTerminalSessions(filter)
 }
 
-func (r *BusinessDataDefault) UpdateVehicleByImei(imei string, update update.VehicleByImei) error {
-	return r.PostgreSource.UpdateVehicleByImei(imei, update)
+func (r *BusinessDataDefault) GetVehicleConnections(filter filter.VehicleConnections) ([]output.VehicleConnection, error) {
+	return r.PostgreSource.GetVehicleConnections(filter)
 }
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// timeLayout — прежний формат временных меток без временной зоны, оставленный для совместимости
+	timeLayout = "02.01.2006 15:04:05"
+
+	timeFormatLegacy  = "legacy"
+	timeFormatRfc3339 = "rfc3339"
+
+	timeFormatContextKey = "time_format"
+)
+
+// timeFormat определяет, как разбираются и выводятся временные метки запроса. Значения в RFC 3339
+// принимаются всегда, значения в прежнем формате DD.MM.YYYY HH:MM:SS считаются временем зоны loc.
+// Выводятся метки в формате layout во временной зоне loc.
+type timeFormat struct {
+	layout string
+	loc    *time.Location
+}
+
+func (f timeFormat) format(t time.Time) string {
+	return t.In(f.loc).Format(f.layout)
+}
+
+func (f timeFormat) formatOptional(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	formatted := f.format(*t)
+	return &formatted
+}
+
+func (f timeFormat) parse(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation(timeLayout, s, f.loc)
+}
+
+// timeFormatMiddleware разбирает параметры запроса time_format и tz. По умолчанию временные метки
+// выводятся в прежнем формате во временной зоне defaultLoc, в формате RFC 3339 — в UTC.
+func timeFormatMiddleware(defaultLoc *time.Location) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		f := timeFormat{layout: timeLayout, loc: defaultLoc}
+
+		switch c.DefaultQuery("time_format", timeFormatLegacy) {
+		case timeFormatLegacy:
+		case timeFormatRfc3339:
+			f = timeFormat{layout: time.RFC3339, loc: time.UTC}
+		default:
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "time_format должен быть legacy или rfc3339"})
+			return
+		}
+
+		if tz := c.Query("tz"); tz != "" {
+			loc, err := time.LoadLocation(tz)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Неизвестная временная зона tz, ожидается имя из базы IANA, например Europe/Moscow или UTC"})
+				return
+			}
+			f.loc = loc
+		}
+
+		c.Set(timeFormatContextKey, f)
+		c.Next()
+	}
+}
+
+// requestTimeFormat возвращает формат временных меток, выбранный параметрами запроса
+func requestTimeFormat(c *gin.Context) timeFormat {
+	if f, ok := c.Get(timeFormatContextKey); ok {
+		return f.(timeFormat)
+	}
+	return timeFormat{layout: timeLayout, loc: time.UTC}
+}
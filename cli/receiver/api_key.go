@@ -0,0 +1,65 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/insert"
+	"github.com/daniil11ru/egts/cli/receiver/dto/other"
+	"github.com/daniil11ru/egts/cli/receiver/server/domain"
+	"github.com/daniil11ru/egts/cli/receiver/source"
+	log "github.com/sirupsen/logrus"
+)
+
+// runCreateApiKey создает API-ключ без обращения к API, например первый ключ с областью admin:
+//
+//	receiver -c config.yaml create-api-key -name "Администратор" -scopes admin
+//	receiver -c config.yaml create-api-key -name "Диспетчер" -scopes read_vehicles,read_locations -provider 1 -expires 2026-01-01T00:00:00+03:00
+func runCreateApiKey(source source.Primary, timeZone *time.Location, args []string) {
+	flags := flag.NewFlagSet("create-api-key", flag.ExitOnError)
+	name := flags.String("name", "", "название ключа")
+	scopesStr := flags.String("scopes", "", "области доступа через запятую: read_vehicles, read_locations, moderate, admin")
+	providerId := flags.Int("provider", 0, "ID провайдера, которым ограничен ключ")
+	expiresStr := flags.String("expires", "", "окончание срока действия в RFC 3339 или DD.MM.YYYY HH:MM:SS по времени зоны time_zone")
+	_ = flags.Parse(args)
+
+	apiKey := insert.ApiKey{Name: strings.TrimSpace(*name)}
+	for _, scope := range strings.Split(*scopesStr, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			apiKey.Scopes = append(apiKey.Scopes, other.ApiKeyScope(scope))
+		}
+	}
+	if *providerId > 0 {
+		providerId32 := int32(*providerId)
+		apiKey.ProviderId = &providerId32
+	}
+	if err := domain.ValidateApiKey(apiKey.Name, apiKey.Scopes, apiKey.ProviderId); err != nil {
+		log.Fatalf("Некорректные параметры ключа: %v", err)
+		return
+	}
+	if *expiresStr != "" {
+		expiresAt, err := parseReplayTime(*expiresStr, timeZone)
+		if err != nil {
+			log.Fatal("Окончание срока действия -expires должно быть в формате RFC 3339 или DD.MM.YYYY HH:MM:SS")
+			return
+		}
+		apiKey.ExpiresAt = &expiresAt
+	}
+
+	key, hash, err := domain.NewApiKey()
+	if err != nil {
+		log.Fatal(err)
+		return
+	}
+	apiKey.Hash = hash
+
+	id, err := source.AddApiKey(apiKey)
+	if err != nil {
+		log.Fatalf("Не удалось сохранить API-ключ: %v", err)
+		return
+	}
+	log.Infof("Создан API-ключ с ID %d. Сохраните его, повторно он не показывается", id)
+	fmt.Println(key)
+}
@@ -0,0 +1,102 @@
+package archive
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func readAll(t *testing.T, root string, query Query) []Frame {
+	var frames []Frame
+	assert.NoError(t, Read(root, query, func(frame Frame) error {
+		frames = append(frames, frame)
+		return nil
+	}))
+	return frames
+}
+
+func TestWriteAndRead(t *testing.T) {
+	root := t.TempDir()
+	writer, err := NewWriter(root, time.Hour)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	start := time.Date(2025, 7, 1, 23, 59, 0, 0, time.UTC)
+	for i, providerId := range []int32{1, 2, 1, 1} {
+		assert.NoError(t, writer.Write(Frame{
+			ReceivedAt:    start.Add(time.Duration(i) * time.Minute),
+			ProviderId:    providerId,
+			RemoteAddress: "10.0.0.1:5000",
+			Data:          []byte{0x01, byte(i)},
+			Response:      []byte{0x01},
+		}))
+	}
+	assert.NoError(t, writer.Close())
+
+	frames := readAll(t, root, Query{From: start, To: start.Add(time.Hour)})
+	if assert.Len(t, frames, 4) {
+		assert.Equal(t, []byte{0x01, 0x00}, frames[0].Data)
+		assert.Equal(t, int32(1), frames[1].ProviderId, "файлы одного часа читаются по порядку провайдеров")
+		assert.Equal(t, []byte{0x01, 0x01}, frames[3].Data)
+		assert.True(t, frames[0].ReceivedAt.Equal(start))
+	}
+
+	providerId := int32(1)
+	frames = readAll(t, root, Query{From: start.Add(time.Minute), To: start.Add(3 * time.Minute), ProviderId: &providerId})
+	if assert.Len(t, frames, 1) {
+		assert.Equal(t, []byte{0x01, 0x02}, frames[0].Data)
+	}
+
+	assert.FileExists(t, filepath.Join(root, "2025-07-01", "23-provider1-1"+segmentSuffix))
+	assert.FileExists(t, filepath.Join(root, "2025-07-02", "00-provider1-1"+segmentSuffix))
+}
+
+func TestReadTruncatedSegment(t *testing.T) {
+	root := t.TempDir()
+	receivedAt := time.Date(2025, 7, 1, 10, 0, 0, 0, time.UTC)
+
+	writer, err := NewWriter(root, time.Hour)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, writer.Write(Frame{ReceivedAt: receivedAt, ProviderId: 1, Data: []byte{0x01}}))
+	assert.NoError(t, writer.Close())
+
+	path := filepath.Join(root, "2025-07-01", "10-provider1-1"+segmentSuffix)
+	data, err := os.ReadFile(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, os.WriteFile(path, data[:len(data)-4], 0o644))
+
+	// Повторный запуск создает новый файл, а не дописывает оборванный
+	writer, err = NewWriter(root, time.Hour)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, writer.Write(Frame{ReceivedAt: receivedAt.Add(time.Minute), ProviderId: 1, Data: []byte{0x02}}))
+	assert.NoError(t, writer.Close())
+
+	frames := readAll(t, root, Query{From: receivedAt, To: receivedAt.Add(time.Hour)})
+	if assert.Len(t, frames, 2) {
+		assert.Equal(t, []byte{0x02}, frames[1].Data)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"2025-06-30", "2025-07-01", "2025-07-02", "other"} {
+		assert.NoError(t, os.Mkdir(filepath.Join(root, name), 0o755))
+	}
+
+	removed, err := Prune(root, time.Date(2025, 7, 2, 5, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, removed)
+	assert.NoDirExists(t, filepath.Join(root, "2025-07-01"))
+	assert.DirExists(t, filepath.Join(root, "2025-07-02"))
+	assert.DirExists(t, filepath.Join(root, "other"))
+}
@@ -0,0 +1,156 @@
+package archive
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Query задает кадры, принятые в промежутке [From, To), и, если указан ProviderId, только от этого провайдера
+type Query struct {
+	From       time.Time
+	To         time.Time
+	ProviderId *int32
+}
+
+type segmentFile struct {
+	path       string
+	hour       time.Time
+	providerId int32
+	number     int
+}
+
+// parseSegmentName разбирает имя файла вида HH-provider<ID>-<N>.jsonl.gz
+func parseSegmentName(day time.Time, name string) (segmentFile, bool) {
+	parts := strings.Split(strings.TrimSuffix(name, segmentSuffix), "-")
+	if !strings.HasSuffix(name, segmentSuffix) || len(parts) != 3 || !strings.HasPrefix(parts[1], "provider") {
+		return segmentFile{}, false
+	}
+
+	hour, err := strconv.Atoi(parts[0])
+	if err != nil || hour < 0 || hour > 23 {
+		return segmentFile{}, false
+	}
+	providerId, err := strconv.ParseInt(strings.TrimPrefix(parts[1], "provider"), 10, 32)
+	if err != nil {
+		return segmentFile{}, false
+	}
+	number, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return segmentFile{}, false
+	}
+
+	return segmentFile{
+		hour:       day.Add(time.Duration(hour) * time.Hour),
+		providerId: int32(providerId),
+		number:     number,
+	}, true
+}
+
+func listSegments(root string, query Query) ([]segmentFile, error) {
+	from := query.From.UTC().Truncate(time.Hour)
+	to := query.To.UTC()
+
+	var segments []segmentFile
+	for day := from.Truncate(24 * time.Hour); day.Before(to); day = day.Add(24 * time.Hour) {
+		dir := filepath.Join(root, day.Format(dayLayout))
+		entries, err := os.ReadDir(dir)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("не удалось прочитать каталог архива %s: %w", dir, err)
+		}
+
+		for _, entry := range entries {
+			s, ok := parseSegmentName(day, entry.Name())
+			if !ok || s.hour.Before(from) || !s.hour.Before(to) {
+				continue
+			}
+			if query.ProviderId != nil && s.providerId != *query.ProviderId {
+				continue
+			}
+			s.path = filepath.Join(dir, entry.Name())
+			segments = append(segments, s)
+		}
+	}
+
+	sort.Slice(segments, func(i, j int) bool {
+		if !segments[i].hour.Equal(segments[j].hour) {
+			return segments[i].hour.Before(segments[j].hour)
+		}
+		if segments[i].providerId != segments[j].providerId {
+			return segments[i].providerId < segments[j].providerId
+		}
+		return segments[i].number < segments[j].number
+	})
+	return segments, nil
+}
+
+// readSegment читает кадры одного файла. Файл, оборванный аварийной остановкой сервера или еще
+// записываемый, читается до места обрыва.
+func readSegment(path string, query Query, fn func(Frame) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("не удалось открыть файл архива %s: %w", path, err)
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("не удалось прочитать файл архива %s: %w", path, err)
+	}
+	defer gz.Close()
+
+	decoder := json.NewDecoder(gz)
+	for {
+		var frame Frame
+		err := decoder.Decode(&frame)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			logrus.Warnf("Файл архива %s оборван, прочитаны кадры до места обрыва", path)
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("не удалось прочитать кадр из файла архива %s: %w", path, err)
+		}
+
+		if frame.ReceivedAt.Before(query.From) || !frame.ReceivedAt.Before(query.To) {
+			continue
+		}
+		if err := fn(frame); err != nil {
+			return err
+		}
+	}
+}
+
+// Read передает fn кадры из архива в каталоге root, подходящие под query, по порядку часов. Если fn
+// возвращает ошибку, чтение прекращается.
+func Read(root string, query Query, fn func(Frame) error) error {
+	segments, err := listSegments(root, query)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range segments {
+		if err := readSegment(s.path, query, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
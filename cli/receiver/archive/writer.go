@@ -0,0 +1,203 @@
+package archive
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	dayLayout     = "2006-01-02"
+	segmentSuffix = ".jsonl.gz"
+)
+
+// Frame — кадр EGTS в том виде, в котором он был принят, вместе с результатом разбора и ответом сервера
+type Frame struct {
+	ReceivedAt    time.Time `json:"received_at"`
+	ProviderId    int32     `json:"provider_id"`
+	SessionId     int32     `json:"session_id,omitempty"`
+	RemoteAddress string    `json:"remote_address"`
+	Data          []byte    `json:"data"`
+	ResultCode    uint8     `json:"result_code"`
+	DecodeError   string    `json:"decode_error,omitempty"`
+	Response      []byte    `json:"response,omitempty"`
+}
+
+type segment struct {
+	hour    time.Time
+	file    *os.File
+	gz      *gzip.Writer
+	encoder *json.Encoder
+}
+
+func (s *segment) flush() error {
+	return s.gz.Flush()
+}
+
+func (s *segment) close() error {
+	gzErr := s.gz.Close()
+	fileErr := s.file.Close()
+	return errors.Join(gzErr, fileErr)
+}
+
+// Writer записывает кадры в архив, разбитый по дням и часам UTC:
+// <root>/YYYY-MM-DD/HH-provider<ID>-<N>.jsonl.gz. Каждый файл — сжатые gzip строки JSON. При каждом
+// открытии часа создается новый файл с очередным N, поэтому файл, оборванный аварийной остановкой
+// сервера, не мешает читать следующие.
+type Writer struct {
+	root string
+
+	mu       sync.Mutex
+	segments map[int32]*segment
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewWriter создает архив в каталоге root. Накопленные кадры сбрасываются на диск каждые
+// flushInterval, файлы прошедших часов при этом закрываются.
+func NewWriter(root string, flushInterval time.Duration) (*Writer, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("не удалось создать каталог архива %s: %w", root, err)
+	}
+
+	w := &Writer{
+		root:     root,
+		segments: make(map[int32]*segment),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go w.run(flushInterval)
+	return w, nil
+}
+
+func (w *Writer) run(flushInterval time.Duration) {
+	defer close(w.done)
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case now := <-ticker.C:
+			w.flush(now)
+		}
+	}
+}
+
+func (w *Writer) flush(now time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	currentHour := now.UTC().Truncate(time.Hour)
+	for providerId, s := range w.segments {
+		if s.hour.Before(currentHour) {
+			if err := s.close(); err != nil {
+				logrus.Warnf("Не удалось закрыть файл архива кадров %s: %v", s.file.Name(), err)
+			}
+			delete(w.segments, providerId)
+			continue
+		}
+		if err := s.flush(); err != nil {
+			logrus.Warnf("Не удалось записать кадры в файл архива %s: %v", s.file.Name(), err)
+		}
+	}
+}
+
+func (w *Writer) openSegment(providerId int32, hour time.Time) (*segment, error) {
+	dir := filepath.Join(w.root, hour.Format(dayLayout))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("не удалось создать каталог архива %s: %w", dir, err)
+	}
+
+	for n := 1; ; n++ {
+		path := filepath.Join(dir, fmt.Sprintf("%s-provider%d-%d%s", hour.Format("15"), providerId, n, segmentSuffix))
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("не удалось создать файл архива %s: %w", path, err)
+		}
+
+		gz := gzip.NewWriter(file)
+		return &segment{hour: hour, file: file, gz: gz, encoder: json.NewEncoder(gz)}, nil
+	}
+}
+
+func (w *Writer) Write(frame Frame) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	hour := frame.ReceivedAt.UTC().Truncate(time.Hour)
+	s, ok := w.segments[frame.ProviderId]
+	if ok && !s.hour.Equal(hour) {
+		if err := s.close(); err != nil {
+			logrus.Warnf("Не удалось закрыть файл архива кадров %s: %v", s.file.Name(), err)
+		}
+		delete(w.segments, frame.ProviderId)
+		ok = false
+	}
+	if !ok {
+		var err error
+		if s, err = w.openSegment(frame.ProviderId, hour); err != nil {
+			return err
+		}
+		w.segments[frame.ProviderId] = s
+	}
+
+	if err := s.encoder.Encode(frame); err != nil {
+		return fmt.Errorf("не удалось записать кадр в архив: %w", err)
+	}
+	return nil
+}
+
+// Close сбрасывает накопленные кадры на диск и закрывает все файлы архива
+func (w *Writer) Close() error {
+	close(w.stop)
+	<-w.done
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var errs []error
+	for providerId, s := range w.segments {
+		errs = append(errs, s.close())
+		delete(w.segments, providerId)
+	}
+	return errors.Join(errs...)
+}
+
+// Prune удаляет из архива дни, предшествующие дню before по UTC, и возвращает количество удаленных дней
+func Prune(root string, before time.Time) (int, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return 0, fmt.Errorf("не удалось прочитать каталог архива %s: %w", root, err)
+	}
+
+	boundary := before.UTC().Format(dayLayout)
+	removed := 0
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if _, err := time.Parse(dayLayout, entry.Name()); err != nil || entry.Name() >= boundary {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(root, entry.Name())); err != nil {
+			return removed, fmt.Errorf("не удалось удалить каталог архива %s: %w", entry.Name(), err)
+		}
+		removed++
+	}
+	return removed, nil
+}
@@ -0,0 +1,142 @@
+package broker
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Location — принятое местоположение транспорта, рассылаемое подписчикам
+type Location struct {
+	LocationId int32
+	VehicleId  int32
+	ProviderId int32
+	OID        int64
+	Latitude   float64
+	Longitude  float64
+	Altitude   int64
+	Direction  int16
+	Speed      int32
+	SentAt     time.Time
+	ReceivedAt time.Time
+}
+
+type BoundingBox struct {
+	MinLatitude  float64
+	MinLongitude float64
+	MaxLatitude  float64
+	MaxLongitude float64
+}
+
+// Contains проверяет вхождение точки в прямоугольник. Если MinLongitude больше MaxLongitude,
+// прямоугольник пересекает 180-й меридиан.
+func (b BoundingBox) Contains(latitude, longitude float64) bool {
+	if latitude < b.MinLatitude || latitude > b.MaxLatitude {
+		return false
+	}
+	if b.MinLongitude <= b.MaxLongitude {
+		return longitude >= b.MinLongitude && longitude <= b.MaxLongitude
+	}
+	return longitude >= b.MinLongitude || longitude <= b.MaxLongitude
+}
+
+type LocationFilter struct {
+	VehicleId   *int32
+	ProviderId  *int32
+	BoundingBox *BoundingBox
+}
+
+func (f LocationFilter) Matches(location Location) bool {
+	if f.VehicleId != nil && *f.VehicleId != location.VehicleId {
+		return false
+	}
+	if f.ProviderId != nil && *f.ProviderId != location.ProviderId {
+		return false
+	}
+	if f.BoundingBox != nil && !f.BoundingBox.Contains(location.Latitude, location.Longitude) {
+		return false
+	}
+	return true
+}
+
+type LocationSubscription struct {
+	C <-chan Location
+
+	ch      chan Location
+	filter  LocationFilter
+	dropped atomic.Uint64
+}
+
+// Dropped возвращает количество местоположений, не доставленных из-за переполнения буфера
+func (s *LocationSubscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Locations рассылает принятые местоположения подписчикам внутри процесса. Публикация никогда не
+// блокирует прием данных: если подписчик не успевает читать и его буфер заполнен, местоположение
+// для него отбрасывается.
+type Locations struct {
+	mu          sync.RWMutex
+	subscribers map[*LocationSubscription]struct{}
+	bufferSize  int
+
+	published atomic.Uint64
+}
+
+func NewLocations(bufferSize int) *Locations {
+	if bufferSize < 1 {
+		bufferSize = 1
+	}
+	return &Locations{subscribers: make(map[*LocationSubscription]struct{}), bufferSize: bufferSize}
+}
+
+func (b *Locations) Subscribe(filter LocationFilter) *LocationSubscription {
+	ch := make(chan Location, b.bufferSize)
+	subscription := &LocationSubscription{C: ch, ch: ch, filter: filter}
+
+	b.mu.Lock()
+	b.subscribers[subscription] = struct{}{}
+	b.mu.Unlock()
+
+	return subscription
+}
+
+// Unsubscribe отменяет подписку и закрывает ее канал. Повторный вызов ничего не делает.
+func (b *Locations) Unsubscribe(subscription *LocationSubscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.subscribers[subscription]; !ok {
+		return
+	}
+	delete(b.subscribers, subscription)
+	close(subscription.ch)
+}
+
+func (b *Locations) Publish(location Location) {
+	b.published.Add(1)
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for subscription := range b.subscribers {
+		if !subscription.filter.Matches(location) {
+			continue
+		}
+		select {
+		case subscription.ch <- location:
+		default:
+			subscription.dropped.Add(1)
+		}
+	}
+}
+
+func (b *Locations) SubscriberCount() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subscribers)
+}
+
+func (b *Locations) Published() uint64 {
+	return b.published.Load()
+}
@@ -0,0 +1,82 @@
+package broker
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func int32Ptr(v int32) *int32 {
+	return &v
+}
+
+func TestLocationFilterMatches(t *testing.T) {
+	location := Location{VehicleId: 1, ProviderId: 2, Latitude: 55.75, Longitude: 37.62}
+
+	assert.True(t, LocationFilter{}.Matches(location))
+	assert.True(t, LocationFilter{VehicleId: int32Ptr(1), ProviderId: int32Ptr(2)}.Matches(location))
+	assert.False(t, LocationFilter{VehicleId: int32Ptr(3)}.Matches(location))
+	assert.False(t, LocationFilter{ProviderId: int32Ptr(3)}.Matches(location))
+
+	box := BoundingBox{MinLatitude: 55, MinLongitude: 37, MaxLatitude: 56, MaxLongitude: 38}
+	assert.True(t, LocationFilter{BoundingBox: &box}.Matches(location))
+	box.MaxLongitude = 37.5
+	assert.False(t, LocationFilter{BoundingBox: &box}.Matches(location))
+}
+
+func TestBoundingBoxAcrossAntimeridian(t *testing.T) {
+	box := BoundingBox{MinLatitude: 60, MinLongitude: 170, MaxLatitude: 70, MaxLongitude: -170}
+
+	assert.True(t, box.Contains(65, 175))
+	assert.True(t, box.Contains(65, -175))
+	assert.False(t, box.Contains(65, 0))
+	assert.False(t, box.Contains(75, 175))
+}
+
+func TestLocationsPublish(t *testing.T) {
+	b := NewLocations(2)
+	all := b.Subscribe(LocationFilter{})
+	vehicle := b.Subscribe(LocationFilter{VehicleId: int32Ptr(2)})
+	assert.Equal(t, 2, b.SubscriberCount())
+
+	b.Publish(Location{VehicleId: 1})
+	b.Publish(Location{VehicleId: 2})
+	// Буфер подписчика на все местоположения заполнен, третье отбрасывается без блокировки
+	b.Publish(Location{VehicleId: 2, LocationId: 3})
+
+	assert.Equal(t, int32(1), (<-all.C).VehicleId)
+	assert.Equal(t, int32(2), (<-all.C).VehicleId)
+	assert.Equal(t, uint64(1), all.Dropped())
+
+	assert.Equal(t, int32(0), (<-vehicle.C).LocationId)
+	assert.Equal(t, int32(3), (<-vehicle.C).LocationId)
+	assert.Equal(t, uint64(0), vehicle.Dropped())
+	assert.Equal(t, uint64(3), b.Published())
+
+	b.Unsubscribe(all)
+	b.Unsubscribe(all)
+	_, ok := <-all.C
+	assert.False(t, ok)
+	assert.Equal(t, 1, b.SubscriberCount())
+}
+
+func TestLocationsConcurrentUnsubscribe(t *testing.T) {
+	b := NewLocations(1)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				s := b.Subscribe(LocationFilter{})
+				b.Publish(Location{VehicleId: int32(j)})
+				b.Unsubscribe(s)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 0, b.SubscriberCount())
+}
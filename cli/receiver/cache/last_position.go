@@ -0,0 +1,202 @@
+package cache
+
+import (
+	"container/list"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	"gorm.io/gorm"
+)
+
+const shardCount = 32
+
+type LastPositionLoader func(vehicleId int32) (out.Point, error)
+
+type LastPositionStats struct {
+	Hits       uint64
+	Misses     uint64
+	LoadErrors uint64
+	Evictions  uint64
+	Size       int
+}
+
+type lastPositionEntry struct {
+	vehicleId int32
+	point     out.Point
+	exists    bool
+	storedAt  time.Time
+}
+
+// lastPositionShard хранит записи в списке по давности обращения: в начале списка — последняя
+// запрошенная, в конце — вытесняемая первой. generation растет с каждым сбросом записей сегмента.
+type lastPositionShard struct {
+	mu         sync.Mutex
+	entries    map[int32]*list.Element
+	order      *list.List
+	generation uint64
+}
+
+func newLastPositionShard() *lastPositionShard {
+	return &lastPositionShard{entries: make(map[int32]*list.Element), order: list.New()}
+}
+
+func (s *lastPositionShard) remove(element *list.Element) {
+	s.order.Remove(element)
+	delete(s.entries, element.Value.(*lastPositionEntry).vehicleId)
+}
+
+// LastPosition хранит последнее сохраненное местоположение каждого транспорта. Записи загружаются
+// из базы данных при первом обращении, устаревают через ttl и вытесняются по давности обращения,
+// когда в сегменте больше capacity записей.
+type LastPosition struct {
+	shards   [shardCount]*lastPositionShard
+	loader   LastPositionLoader
+	ttl      time.Duration
+	capacity int
+
+	hits       atomic.Uint64
+	misses     atomic.Uint64
+	loadErrors atomic.Uint64
+	evictions  atomic.Uint64
+}
+
+func NewLastPosition(loader LastPositionLoader, ttl time.Duration, capacity int) *LastPosition {
+	c := &LastPosition{loader: loader, ttl: ttl, capacity: capacity / shardCount}
+	if c.capacity < 1 {
+		c.capacity = 1
+	}
+	for i := range c.shards {
+		c.shards[i] = newLastPositionShard()
+	}
+	return c
+}
+
+func (c *LastPosition) shard(vehicleId int32) *lastPositionShard {
+	return c.shards[uint32(vehicleId)%shardCount]
+}
+
+func (c *LastPosition) expired(entry *lastPositionEntry, now time.Time) bool {
+	return c.ttl > 0 && now.Sub(entry.storedAt) > c.ttl
+}
+
+func (c *LastPosition) store(shard *lastPositionShard, vehicleId int32, point out.Point, exists bool) {
+	entry := &lastPositionEntry{vehicleId: vehicleId, point: point, exists: exists, storedAt: time.Now()}
+	if element, ok := shard.entries[vehicleId]; ok {
+		element.Value = entry
+		shard.order.MoveToFront(element)
+		return
+	}
+	if len(shard.entries) >= c.capacity {
+		shard.remove(shard.order.Back())
+		c.evictions.Add(1)
+	}
+	shard.entries[vehicleId] = shard.order.PushFront(entry)
+}
+
+// Get возвращает последнее местоположение транспорта. Второе значение равно false, если у транспорта
+// еще нет сохраненных местоположений.
+func (c *LastPosition) Get(vehicleId int32) (out.Point, bool, error) {
+	shard := c.shard(vehicleId)
+
+	shard.mu.Lock()
+	if element, ok := shard.entries[vehicleId]; ok && !c.expired(element.Value.(*lastPositionEntry), time.Now()) {
+		shard.order.MoveToFront(element)
+		entry := element.Value.(*lastPositionEntry)
+		point, exists := entry.point, entry.exists
+		shard.mu.Unlock()
+		c.hits.Add(1)
+		return point, exists, nil
+	}
+	generation := shard.generation
+	shard.mu.Unlock()
+	c.misses.Add(1)
+
+	point, err := c.loader(vehicleId)
+	exists := true
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			c.loadErrors.Add(1)
+			return out.Point{}, false, err
+		}
+		point, exists = out.Point{}, false
+	}
+
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	if element, ok := shard.entries[vehicleId]; ok && !c.expired(element.Value.(*lastPositionEntry), time.Now()) {
+		entry := element.Value.(*lastPositionEntry)
+		return entry.point, entry.exists, nil
+	}
+	// Если за время загрузки записи сбрасывались, например после удаления точек, загруженное
+	// местоположение могло устареть и в кэш не попадает
+	if shard.generation == generation {
+		c.store(shard, vehicleId, point, exists)
+	}
+	return point, exists, nil
+}
+
+func (c *LastPosition) Set(vehicleId int32, point out.Point) {
+	shard := c.shard(vehicleId)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	c.store(shard, vehicleId, point, true)
+}
+
+func (c *LastPosition) Invalidate(vehicleId int32) {
+	shard := c.shard(vehicleId)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	if element, ok := shard.entries[vehicleId]; ok {
+		shard.remove(element)
+	}
+	shard.generation++
+}
+
+func (c *LastPosition) InvalidateAll() {
+	for _, shard := range c.shards {
+		shard.mu.Lock()
+		shard.entries = make(map[int32]*list.Element)
+		shard.order.Init()
+		shard.generation++
+		shard.mu.Unlock()
+	}
+}
+
+// EvictExpired удаляет устаревшие записи и возвращает их количество
+func (c *LastPosition) EvictExpired() int {
+	now := time.Now()
+	count := 0
+	for _, shard := range c.shards {
+		shard.mu.Lock()
+		for element := shard.order.Front(); element != nil; {
+			next := element.Next()
+			if c.expired(element.Value.(*lastPositionEntry), now) {
+				shard.remove(element)
+				count++
+			}
+			element = next
+		}
+		shard.mu.Unlock()
+	}
+	c.evictions.Add(uint64(count))
+	return count
+}
+
+func (c *LastPosition) Stats() LastPositionStats {
+	size := 0
+	for _, shard := range c.shards {
+		shard.mu.Lock()
+		size += len(shard.entries)
+		shard.mu.Unlock()
+	}
+	return LastPositionStats{
+		Hits:       c.hits.Load(),
+		Misses:     c.misses.Load(),
+		LoadErrors: c.loadErrors.Load(),
+		Evictions:  c.evictions.Load(),
+		Size:       size,
+	}
+}
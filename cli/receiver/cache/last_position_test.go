@@ -0,0 +1,152 @@
+package cache
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestLastPositionLazyLoad(t *testing.T) {
+	loads := 0
+	c := NewLastPosition(func(vehicleId int32) (out.Point, error) {
+		loads++
+		if vehicleId == 2 {
+			return out.Point{}, gorm.ErrRecordNotFound
+		}
+		return out.Point{LocationId: vehicleId * 10, Latitude: 55, Longitude: 37}, nil
+	}, time.Hour, 1000)
+
+	point, ok, err := c.Get(1)
+	if assert.NoError(t, err) && assert.True(t, ok) {
+		assert.Equal(t, int32(10), point.LocationId)
+	}
+	_, _, _ = c.Get(1)
+
+	_, ok, err = c.Get(2)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	_, _, _ = c.Get(2)
+
+	assert.Equal(t, 2, loads)
+	stats := c.Stats()
+	assert.Equal(t, uint64(2), stats.Hits)
+	assert.Equal(t, uint64(2), stats.Misses)
+
+	c.Set(2, out.Point{LocationId: 7})
+	point, ok, _ = c.Get(2)
+	assert.True(t, ok)
+	assert.Equal(t, int32(7), point.LocationId)
+
+	c.Invalidate(1)
+	_, _, _ = c.Get(1)
+	assert.Equal(t, 3, loads)
+}
+
+func TestLastPositionLoadError(t *testing.T) {
+	c := NewLastPosition(func(int32) (out.Point, error) {
+		return out.Point{}, errors.New("нет соединения")
+	}, time.Hour, 1000)
+
+	_, ok, err := c.Get(1)
+	assert.Error(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, uint64(1), c.Stats().LoadErrors)
+	assert.Equal(t, 0, c.Stats().Size)
+}
+
+func TestLastPositionEviction(t *testing.T) {
+	c := NewLastPosition(func(int32) (out.Point, error) {
+		return out.Point{}, gorm.ErrRecordNotFound
+	}, time.Hour, shardCount)
+
+	c.Set(1, out.Point{})
+	c.Set(1+shardCount, out.Point{})
+	assert.Equal(t, 1, c.Stats().Size)
+	assert.Equal(t, uint64(1), c.Stats().Evictions)
+
+	c.ttl = time.Nanosecond
+	time.Sleep(time.Millisecond)
+	assert.Equal(t, 1, c.EvictExpired())
+	assert.Equal(t, 0, c.Stats().Size)
+}
+
+func TestLastPositionEvictsLeastRecentlyUsed(t *testing.T) {
+	loads := 0
+	c := NewLastPosition(func(vehicleId int32) (out.Point, error) {
+		loads++
+		return out.Point{LocationId: vehicleId}, nil
+	}, time.Hour, 2*shardCount)
+
+	// Все ID попадают в один сегмент вместимостью 2
+	c.Set(1, out.Point{LocationId: 1})
+	c.Set(1+shardCount, out.Point{LocationId: 1 + shardCount})
+	_, _, _ = c.Get(1)
+	c.Set(1+2*shardCount, out.Point{LocationId: 1 + 2*shardCount})
+
+	assert.Equal(t, 2, c.Stats().Size)
+	_, _, _ = c.Get(1)
+	_, _, _ = c.Get(1 + 2*shardCount)
+	assert.Equal(t, 0, loads)
+	_, _, _ = c.Get(1 + shardCount)
+	assert.Equal(t, 1, loads)
+}
+
+func TestLastPositionInvalidateDuringLoad(t *testing.T) {
+	loading, release := make(chan struct{}), make(chan struct{})
+	locationId := int32(1)
+	c := NewLastPosition(func(int32) (out.Point, error) {
+		point := out.Point{LocationId: locationId}
+		if locationId == 1 {
+			close(loading)
+			<-release
+		}
+		return point, nil
+	}, time.Hour, 1000)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		point, _, _ := c.Get(1)
+		assert.Equal(t, int32(1), point.LocationId)
+	}()
+
+	// Точки удалили, пока шла загрузка
+	<-loading
+	locationId = 2
+	c.Invalidate(1)
+	close(release)
+	<-done
+
+	point, _, _ := c.Get(1)
+	assert.Equal(t, int32(2), point.LocationId)
+}
+
+func TestLastPositionConcurrentAccess(t *testing.T) {
+	c := NewLastPosition(func(vehicleId int32) (out.Point, error) {
+		return out.Point{LocationId: vehicleId}, nil
+	}, time.Hour, 64)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				id := int32((i*j)%200 + 1)
+				c.Get(id)
+				c.Set(id, out.Point{LocationId: id})
+				if j%50 == 0 {
+					c.Invalidate(id)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	assert.LessOrEqual(t, c.Stats().Size, 64)
+}
@@ -0,0 +1,66 @@
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+type Loader[K comparable, V any] func(key K) (V, error)
+
+type loadingEntry[V any] struct {
+	value    V
+	storedAt time.Time
+}
+
+// Loading хранит значения, загружаемые из базы данных при первом обращении. Записи устаревают через
+// ttl, чтобы подхватить изменения, внесенные в обход API; изменения через API сбрасывают записи
+// сразу. Значение, загрузка которого началась до сброса, в кэш не попадает.
+type Loading[K comparable, V any] struct {
+	loader Loader[K, V]
+	ttl    time.Duration
+
+	mu         sync.Mutex
+	entries    map[K]loadingEntry[V]
+	generation uint64
+}
+
+func NewLoading[K comparable, V any](loader Loader[K, V], ttl time.Duration) *Loading[K, V] {
+	return &Loading[K, V]{loader: loader, ttl: ttl, entries: make(map[K]loadingEntry[V])}
+}
+
+// Get возвращает значение из кэша или загружает его. Ошибки загрузки не кэшируются.
+func (c *Loading[K, V]) Get(key K) (V, error) {
+	c.mu.Lock()
+	if entry, ok := c.entries[key]; ok && (c.ttl <= 0 || time.Since(entry.storedAt) <= c.ttl) {
+		c.mu.Unlock()
+		return entry.value, nil
+	}
+	generation := c.generation
+	c.mu.Unlock()
+
+	value, err := c.loader(key)
+	if err != nil {
+		return value, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.generation == generation {
+		c.entries[key] = loadingEntry[V]{value: value, storedAt: time.Now()}
+	}
+	return value, nil
+}
+
+func (c *Loading[K, V]) Invalidate(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+	c.generation++
+}
+
+func (c *Loading[K, V]) InvalidateAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[K]loadingEntry[V])
+	c.generation++
+}
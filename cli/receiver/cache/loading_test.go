@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoading(t *testing.T) {
+	loads := 0
+	c := NewLoading(func(key int32) (int32, error) {
+		loads++
+		return key * 10, nil
+	}, time.Hour)
+
+	value, err := c.Get(1)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(10), value)
+	_, _ = c.Get(1)
+	_, _ = c.Get(2)
+	assert.Equal(t, 2, loads)
+
+	c.Invalidate(1)
+	_, _ = c.Get(1)
+	_, _ = c.Get(2)
+	assert.Equal(t, 3, loads)
+
+	c.InvalidateAll()
+	_, _ = c.Get(1)
+	_, _ = c.Get(2)
+	assert.Equal(t, 5, loads)
+}
+
+func TestLoadingExpiry(t *testing.T) {
+	loads := 0
+	c := NewLoading(func(struct{}) (int, error) {
+		loads++
+		return loads, nil
+	}, time.Millisecond)
+
+	_, _ = c.Get(struct{}{})
+	time.Sleep(5 * time.Millisecond)
+	value, _ := c.Get(struct{}{})
+	assert.Equal(t, 2, value)
+}
+
+func TestLoadingError(t *testing.T) {
+	loads := 0
+	c := NewLoading(func(int32) (int32, error) {
+		loads++
+		return 0, errors.New("нет соединения")
+	}, time.Hour)
+
+	_, err := c.Get(1)
+	assert.Error(t, err)
+	_, err = c.Get(1)
+	assert.Error(t, err)
+	assert.Equal(t, 2, loads)
+}
+
+func TestLoadingInvalidateDuringLoad(t *testing.T) {
+	var c *Loading[int32, int32]
+	version := int32(0)
+	c = NewLoading(func(key int32) (int32, error) {
+		version++
+		if version == 1 {
+			// Значение изменили через API, пока шла загрузка
+			c.Invalidate(key)
+		}
+		return version, nil
+	}, time.Hour)
+
+	value, _ := c.Get(1)
+	assert.Equal(t, int32(1), value)
+	value, _ = c.Get(1)
+	assert.Equal(t, int32(2), value)
+}
@@ -1,39 +1,124 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"net/url"
 	"os"
-
-	log "github.com/sirupsen/logrus"
+	"strconv"
 
 	"gopkg.in/yaml.v2"
 )
 
+// Port — номер порта, который в YAML можно указать как числом, так и строкой
+type Port int
+
+func (p *Port) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	port, err := strconv.Atoi(s)
+	if err != nil {
+		return &yaml.TypeError{Errors: []string{fmt.Sprintf("некорректный номер порта %q", s)}}
+	}
+	*p = Port(port)
+	return nil
+}
+
+type StorageConfig struct {
+	Driver   string `yaml:"driver"`
+	Host     string `yaml:"host"`
+	Port     Port   `yaml:"port"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+	Database string `yaml:"database"`
+	SSLMode  string `yaml:"sslmode"`
+}
+
+// DSN возвращает строку подключения к базе данных для GORM
+func (s StorageConfig) DSN() string {
+	return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%d sslmode=%s", s.Host, s.User, s.Password, s.Database, s.Port, s.SSLMode)
+}
+
+// URL возвращает адрес базы данных для golang-migrate
+func (s StorageConfig) URL() string {
+	u := url.URL{
+		Scheme: "postgres",
+		User:   url.UserPassword(s.User, s.Password),
+		Host:   s.Host + ":" + strconv.Itoa(int(s.Port)),
+		Path:   s.Database,
+	}
+	if s.SSLMode != "" {
+		u.RawQuery = url.Values{"sslmode": {s.SSLMode}}.Encode()
+	}
+	return u.String()
+}
+
 type Config struct {
-	Host                           string            `yaml:"host"`
-	ProviderIdToPort               map[int32]int     `yaml:"provider_id_to_port"`
-	ApiPort                        int               `yaml:"api_port"`
-	ConnectionTtl                  int               `yaml:"connection_ttl"`
-	LogLevel                       string            `yaml:"log_level"`
-	LogFilePath                    string            `yaml:"log_file_path"`
-	LogMaxAgeDays                  int               `yaml:"log_max_age_days"`
-	Store                          map[string]string `yaml:"storage"`
-	SaveTelematicsDataMonthStart   int               `yaml:"save_telematics_data_month_start"`
-	SaveTelematicsDataMonthEnd     int               `yaml:"save_telematics_data_month_end"`
-	OptimizeGeometryCronExpression string            `yaml:"optimize_geometry_cron_expression"`
-	MigrationsPath                 string            `yaml:"migrations_path"`
+	Host                           string        `yaml:"host"`
+	ProviderIdToPort               map[int32]int `yaml:"provider_id_to_port"`
+	ApiPort                        int           `yaml:"api_port"`
+	ConnectionTtl                  int           `yaml:"connection_ttl"`
+	LogLevel                       string        `yaml:"log_level"`
+	LogFilePath                    string        `yaml:"log_file_path"`
+	LogMaxAgeDays                  int           `yaml:"log_max_age_days"`
+	Storage                        StorageConfig `yaml:"storage"`
+	SaveTelematicsDataMonthStart   int           `yaml:"save_telematics_data_month_start"`
+	SaveTelematicsDataMonthEnd     int           `yaml:"save_telematics_data_month_end"`
+	OptimizeGeometryCronExpression string        `yaml:"optimize_geometry_cron_expression"`
+	MigrationsPath                 string        `yaml:"migrations_path"`
+	LastPositionCacheTtl           int           `yaml:"last_position_cache_ttl"`
+	LastPositionCacheCapacity      int           `yaml:"last_position_cache_capacity"`
+	TripDetectionCronExpression    string        `yaml:"trip_detection_cron_expression"`
+	TripStopSpeedKmh               float64       `yaml:"trip_stop_speed_kmh"`
+	TripStopRadiusMeters           float64       `yaml:"trip_stop_radius_meters"`
+	TripStopMinSeconds             int32         `yaml:"trip_stop_min_seconds"`
+	TripMaxGapSeconds              int32         `yaml:"trip_max_gap_seconds"`
+	TripMinDistanceMeters          float64       `yaml:"trip_min_distance_meters"`
+	LocationStreamBufferSize       int           `yaml:"location_stream_buffer_size"`
+	TerminalSessionFlushSeconds    int           `yaml:"terminal_session_flush_seconds"`
+	TerminalStaleSeconds           int           `yaml:"terminal_stale_seconds"`
+	PacketArchivePath              string        `yaml:"packet_archive_path"`
+	PacketArchiveRetentionDays     int           `yaml:"packet_archive_retention_days"`
+	ReadinessMaxSaveQueue          int           `yaml:"readiness_max_save_queue"`
+	TimeZone                       string        `yaml:"time_zone"`
 }
 
+// NewConfig читает конфиг из YAML-файла, переопределяет параметры переменными окружения с
+// префиксом EnvPrefix, подставляет значения по умолчанию и проверяет результат. Ошибка содержит
+// все найденные проблемы сразу.
 func NewConfig(configPath string) (Config, error) {
-	c := Config{}
 	data, err := os.ReadFile(configPath)
 	if err != nil {
-		return c, err
+		return Config{}, err
 	}
-	err = yaml.Unmarshal(data, &c)
-	if err != nil {
-		return c, err
+	return parse(data, os.LookupEnv)
+}
+
+func parse(data []byte, lookupEnv func(string) (string, bool)) (Config, error) {
+	c := Config{}
+	var problems []string
+
+	if err := yaml.UnmarshalStrict(data, &c); err != nil {
+		var typeErr *yaml.TypeError
+		if !errors.As(err, &typeErr) {
+			return c, err
+		}
+		problems = append(problems, typeErr.Errors...)
 	}
 
+	problems = append(problems, applyEnv(&c, lookupEnv)...)
+	c.setDefaults()
+	problems = append(problems, c.validate()...)
+
+	if len(problems) > 0 {
+		return c, &ValidationError{Problems: problems}
+	}
+	return c, nil
+}
+
+func (c *Config) setDefaults() {
 	if c.SaveTelematicsDataMonthStart == 0 {
 		c.SaveTelematicsDataMonthStart = 5 // Май
 	}
@@ -41,17 +126,55 @@ func NewConfig(configPath string) (Config, error) {
 		c.SaveTelematicsDataMonthEnd = 9 // Сентябрь
 	}
 
-	if c.SaveTelematicsDataMonthStart < 1 || c.SaveTelematicsDataMonthStart > 12 || c.SaveTelematicsDataMonthEnd < 1 || c.SaveTelematicsDataMonthEnd > 12 {
-		log.Errorf("Некорректное значение SaveTelematicsDataMonthStart (%d) или SaveTelematicsDataMonthEnd (%d). Значение не должно быть меньше 1 и превышать 12. В качестве значений по умолчению взяты май (5) и сентябрь (9).", c.SaveTelematicsDataMonthStart, c.SaveTelematicsDataMonthEnd)
-		c.SaveTelematicsDataMonthStart = 5
-		c.SaveTelematicsDataMonthEnd = 9
+	if c.LastPositionCacheTtl == 0 {
+		c.LastPositionCacheTtl = 86400
+	}
+	if c.LastPositionCacheCapacity == 0 {
+		c.LastPositionCacheCapacity = 100000
 	}
 
-	if c.SaveTelematicsDataMonthStart > c.SaveTelematicsDataMonthEnd {
-		log.Errorf("SaveTelematicsDataMonthStart (%d) не может превышать SaveTelematicsDataMonthEnd (%d). В качестве значений по умолчению взяты май (5) и сентябрь (9).", c.SaveTelematicsDataMonthStart, c.SaveTelematicsDataMonthEnd)
-		c.SaveTelematicsDataMonthStart = 5
-		c.SaveTelematicsDataMonthEnd = 9
+	if c.TripDetectionCronExpression == "" {
+		c.TripDetectionCronExpression = "0 */5 * * * *"
+	}
+	if c.TripStopSpeedKmh == 0 {
+		c.TripStopSpeedKmh = 5
+	}
+	if c.TripStopRadiusMeters == 0 {
+		c.TripStopRadiusMeters = 50
+	}
+	if c.TripStopMinSeconds == 0 {
+		c.TripStopMinSeconds = 300
+	}
+	if c.TripMaxGapSeconds == 0 {
+		c.TripMaxGapSeconds = 600
+	}
+	if c.TripMinDistanceMeters == 0 {
+		c.TripMinDistanceMeters = 200
 	}
 
-	return c, err
+	if c.LocationStreamBufferSize == 0 {
+		c.LocationStreamBufferSize = 256
+	}
+
+	if c.TerminalSessionFlushSeconds == 0 {
+		c.TerminalSessionFlushSeconds = 30
+	}
+	if c.TerminalStaleSeconds == 0 {
+		c.TerminalStaleSeconds = 300
+	}
+
+	if c.ReadinessMaxSaveQueue == 0 {
+		c.ReadinessMaxSaveQueue = 10000
+	}
+
+	if c.TimeZone == "" {
+		c.TimeZone = "Europe/Moscow"
+	}
+
+	if c.Storage.Driver == "" {
+		c.Storage.Driver = "postgres"
+	}
+	if c.Storage.Port == 0 {
+		c.Storage.Port = 5432
+	}
 }
@@ -0,0 +1,77 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// EnvPrefix — префикс переменных окружения, переопределяющих параметры конфига. Имя переменной
+// составляется из ключей YAML через подчеркивание в верхнем регистре, например
+// EGTS_STORAGE_PASSWORD или EGTS_LOG_LEVEL. Значения нестроковых параметров разбираются как YAML:
+// EGTS_PROVIDER_ID_TO_PORT="{1: 7000, 2: 7001}".
+const EnvPrefix = "EGTS_"
+
+// walkFields вызывает fn для каждого конечного параметра конфига с его ключом вида storage.password
+func walkFields(v reflect.Value, prefix string, fn func(key string, field reflect.Value)) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		tag := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+		if tag == "" || tag == "-" {
+			continue
+		}
+		key := prefix + tag
+		field := v.Field(i)
+		if field.Kind() == reflect.Struct {
+			walkFields(field, key+".", fn)
+			continue
+		}
+		fn(key, field)
+	}
+}
+
+// EnvName возвращает имя переменной окружения для ключа конфига
+func EnvName(key string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+func applyEnv(c *Config, lookupEnv func(string) (string, bool)) []string {
+	var problems []string
+	walkFields(reflect.ValueOf(c).Elem(), "", func(key string, field reflect.Value) {
+		name := EnvName(key)
+		value, ok := lookupEnv(name)
+		if !ok {
+			return
+		}
+		if field.Kind() == reflect.String {
+			field.SetString(value)
+			return
+		}
+
+		parsed := reflect.New(field.Type())
+		if err := yaml.UnmarshalStrict([]byte(value), parsed.Interface()); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: некорректное значение переменной окружения %s: %v", key, name, err))
+			return
+		}
+		field.Set(parsed.Elem())
+	})
+	return problems
+}
+
+// Changed возвращает ключи параметров, значения которых в конфигах различаются
+func Changed(previous, next Config) []string {
+	nextValues := make(map[string]reflect.Value)
+	walkFields(reflect.ValueOf(next), "", func(key string, field reflect.Value) {
+		nextValues[key] = field
+	})
+
+	var changed []string
+	walkFields(reflect.ValueOf(previous), "", func(key string, field reflect.Value) {
+		if !reflect.DeepEqual(field.Interface(), nextValues[key].Interface()) {
+			changed = append(changed, key)
+		}
+	})
+	return changed
+}
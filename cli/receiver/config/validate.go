@@ -0,0 +1,154 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron"
+)
+
+// ValidationError перечисляет все проблемы, найденные в конфиге
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "некорректный конфиг:\n- " + strings.Join(e.Problems, "\n- ")
+}
+
+var logLevels = []string{"DEBUG", "INFO", "WARN", "ERROR"}
+
+var sslModes = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func validPort(port int) bool {
+	return port >= 1 && port <= 65535
+}
+
+func (c *Config) validate() []string {
+	var problems []string
+	addf := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	providerIds := make([]int32, 0, len(c.ProviderIdToPort))
+	for providerId := range c.ProviderIdToPort {
+		providerIds = append(providerIds, providerId)
+	}
+	sort.Slice(providerIds, func(i, j int) bool { return providerIds[i] < providerIds[j] })
+	portOwners := make(map[int]int32)
+	for _, providerId := range providerIds {
+		port := c.ProviderIdToPort[providerId]
+		if providerId <= 0 {
+			addf("provider_id_to_port: ID провайдера должен быть положительным, получено %d", providerId)
+		}
+		if !validPort(port) {
+			addf("provider_id_to_port: порт %d провайдера с ID %d должен быть в пределах от 1 до 65535", port, providerId)
+			continue
+		}
+		if owner, ok := portOwners[port]; ok {
+			addf("provider_id_to_port: порт %d указан для провайдеров с ID %d и %d", port, owner, providerId)
+		}
+		portOwners[port] = providerId
+	}
+
+	if !validPort(c.ApiPort) {
+		addf("api_port: порт должен быть в пределах от 1 до 65535, получено %d", c.ApiPort)
+	} else if owner, ok := portOwners[c.ApiPort]; ok {
+		addf("api_port: порт %d уже указан для провайдера с ID %d", c.ApiPort, owner)
+	}
+
+	if c.ConnectionTtl < 0 {
+		addf("connection_ttl: значение не может быть отрицательным, получено %d", c.ConnectionTtl)
+	}
+	if c.LogLevel != "" && !contains(logLevels, c.LogLevel) {
+		addf("log_level: допустимые значения %s, получено %q", strings.Join(logLevels, ", "), c.LogLevel)
+	}
+	if c.LogMaxAgeDays < 0 {
+		addf("log_max_age_days: значение не может быть отрицательным, получено %d", c.LogMaxAgeDays)
+	}
+
+	monthsValid := true
+	if c.SaveTelematicsDataMonthStart < 1 || c.SaveTelematicsDataMonthStart > 12 {
+		addf("save_telematics_data_month_start: месяц должен быть в пределах от 1 до 12, получено %d", c.SaveTelematicsDataMonthStart)
+		monthsValid = false
+	}
+	if c.SaveTelematicsDataMonthEnd < 1 || c.SaveTelematicsDataMonthEnd > 12 {
+		addf("save_telematics_data_month_end: месяц должен быть в пределах от 1 до 12, получено %d", c.SaveTelematicsDataMonthEnd)
+		monthsValid = false
+	}
+	if monthsValid && c.SaveTelematicsDataMonthStart > c.SaveTelematicsDataMonthEnd {
+		addf("save_telematics_data_month_start (%d) не может превышать save_telematics_data_month_end (%d)", c.SaveTelematicsDataMonthStart, c.SaveTelematicsDataMonthEnd)
+	}
+
+	if c.OptimizeGeometryCronExpression == "" {
+		addf("optimize_geometry_cron_expression: не задано cron-выражение")
+	} else if _, err := cron.Parse(c.OptimizeGeometryCronExpression); err != nil {
+		addf("optimize_geometry_cron_expression: некорректное cron-выражение %q: %v", c.OptimizeGeometryCronExpression, err)
+	}
+	if _, err := cron.Parse(c.TripDetectionCronExpression); err != nil {
+		addf("trip_detection_cron_expression: некорректное cron-выражение %q: %v", c.TripDetectionCronExpression, err)
+	}
+	if _, err := time.LoadLocation(c.TimeZone); err != nil {
+		addf("time_zone: неизвестная временная зона %q, ожидается имя из базы IANA, например Europe/Moscow или UTC", c.TimeZone)
+	}
+	if c.MigrationsPath == "" {
+		addf("migrations_path: не задан путь до директории с файлами миграций")
+	}
+
+	positive := []struct {
+		key   string
+		value float64
+	}{
+		{"last_position_cache_ttl", float64(c.LastPositionCacheTtl)},
+		{"last_position_cache_capacity", float64(c.LastPositionCacheCapacity)},
+		{"trip_stop_speed_kmh", c.TripStopSpeedKmh},
+		{"trip_stop_radius_meters", c.TripStopRadiusMeters},
+		{"trip_stop_min_seconds", float64(c.TripStopMinSeconds)},
+		{"trip_max_gap_seconds", float64(c.TripMaxGapSeconds)},
+		{"trip_min_distance_meters", c.TripMinDistanceMeters},
+		{"location_stream_buffer_size", float64(c.LocationStreamBufferSize)},
+		{"terminal_session_flush_seconds", float64(c.TerminalSessionFlushSeconds)},
+		{"terminal_stale_seconds", float64(c.TerminalStaleSeconds)},
+		{"readiness_max_save_queue", float64(c.ReadinessMaxSaveQueue)},
+	}
+	for _, item := range positive {
+		if item.value <= 0 {
+			addf("%s: значение должно быть положительным, получено %v", item.key, item.value)
+		}
+	}
+	if c.PacketArchiveRetentionDays < 0 {
+		addf("packet_archive_retention_days: значение не может быть отрицательным, получено %d", c.PacketArchiveRetentionDays)
+	}
+
+	if c.Storage.Driver != "postgres" {
+		addf("storage.driver: поддерживается только postgres, получено %q", c.Storage.Driver)
+	}
+	if c.Storage.Host == "" {
+		addf("storage.host: не задан адрес базы данных")
+	}
+	if !validPort(int(c.Storage.Port)) {
+		addf("storage.port: порт должен быть в пределах от 1 до 65535, получено %d", c.Storage.Port)
+	}
+	if c.Storage.User == "" {
+		addf("storage.user: не задан пользователь базы данных")
+	}
+	if c.Storage.Database == "" {
+		addf("storage.database: не задано имя базы данных")
+	}
+	if c.Storage.SSLMode != "" && !contains(sslModes, c.Storage.SSLMode) {
+		addf("storage.sslmode: допустимые значения %s, получено %q", strings.Join(sslModes, ", "), c.Storage.SSLMode)
+	}
+
+	return problems
+}
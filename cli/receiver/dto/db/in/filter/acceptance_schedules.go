@@ -0,0 +1,7 @@
+package filter
+
+type AcceptanceSchedules struct {
+	ID             *int32
+	ProviderId     *int32
+	VehicleGroupId *int32
+}
@@ -0,0 +1,9 @@
+package filter
+
+// DailyMileage ограничивает отчет днями в формате ГГГГ-ММ-ДД включительно
+type DailyMileage struct {
+	ProviderId *int32
+	VehicleId  *int32
+	DayFrom    string
+	DayTo      string
+}
@@ -0,0 +1,16 @@
+package filter
+
+import (
+	"time"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/other"
+)
+
+type GeofenceEvents struct {
+	GeofenceId *int32
+	VehicleId  *int32
+	Type       *other.GeofenceEventType
+	After      *time.Time
+	Before     *time.Time
+	Limit      int64
+}
@@ -0,0 +1,9 @@
+package filter
+
+import "time"
+
+type HistoryBacklog struct {
+	ProviderId    *int32
+	VehicleId     *int32
+	ReceivedAfter *time.Time
+}
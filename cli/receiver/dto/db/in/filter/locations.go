@@ -4,9 +4,14 @@ import "time"
 
 type Locations struct {
 	VehicleId      *int32
+	ProviderId     *int32
 	SentBefore     *time.Time
 	SentAfter      *time.Time
 	ReceivedBefore *time.Time
 	ReceivedAfter  *time.Time
 	LocationsLimit int64
+
+	// Page включает пагинацию: местоположения возвращаются общим списком без ограничения
+	// LocationsLimit на каждый транспорт
+	Page *Page
 }
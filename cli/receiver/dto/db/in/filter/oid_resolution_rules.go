@@ -0,0 +1,6 @@
+package filter
+
+type OidResolutionRules struct {
+	ID         *int32
+	ProviderId *int32
+}
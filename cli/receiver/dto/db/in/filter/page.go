@@ -0,0 +1,23 @@
+package filter
+
+// Page задает порядок и страницу списка при пагинации по ключу. Записи упорядочиваются по полю
+// Sort, а при равенстве — по ID. After — значения этих полей у последней записи предыдущей
+// страницы: значение поля сортировки передается строкой и приводится к типу столбца в запросе.
+// Limit, равный 0, снимает ограничение на количество записей.
+type Page struct {
+	Sort  string
+	Desc  bool
+	After *PageKey
+	Limit int
+}
+
+type PageKey struct {
+	Value string
+	ID    int64
+}
+
+// VehicleSortFields — поля, по которым можно упорядочить транспорт
+var VehicleSortFields = []string{"id", "imei", "name", "provider_id", "moderation_status"}
+
+// LocationSortFields — поля, по которым можно упорядочить местоположения
+var LocationSortFields = []string{"id", "sent_at", "received_at"}
@@ -0,0 +1,6 @@
+package filter
+
+type ProviderAllowedNetworks struct {
+	ID         *int32
+	ProviderId *int32
+}
@@ -0,0 +1,11 @@
+package filter
+
+import "github.com/daniil11ru/egts/cli/receiver/dto/other"
+
+type QuarantinedLocations struct {
+	ProviderId   *int32
+	OID          *int64
+	TerminalIMEI *string
+	Reason       *other.QuarantineReason
+	Limit        int64
+}
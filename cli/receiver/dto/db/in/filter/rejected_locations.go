@@ -0,0 +1,10 @@
+package filter
+
+import "github.com/daniil11ru/egts/cli/receiver/dto/other"
+
+type RejectedLocations struct {
+	ProviderId *int32
+	VehicleId  *int32
+	Rule       *other.GpsFilterRule
+	Limit      int64
+}
@@ -0,0 +1,16 @@
+package filter
+
+import "github.com/daniil11ru/egts/cli/receiver/dto/other"
+
+type TerminalSessions struct {
+	ProviderId  *int32
+	VehicleId   *int32
+	Active      *bool
+	CloseReason *other.SessionCloseReason
+	Limit       int64
+}
+
+type VehicleConnections struct {
+	ProviderId *int32
+	VehicleId  *int32
+}
@@ -0,0 +1,10 @@
+package filter
+
+import "time"
+
+type Tracks struct {
+	ProviderId     *int32
+	VehicleId      *int32
+	ReceivedAfter  time.Time
+	ReceivedBefore time.Time
+}
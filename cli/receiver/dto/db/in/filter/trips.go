@@ -0,0 +1,11 @@
+package filter
+
+import "time"
+
+// Trips используется и для поездок, и для стоянок: выбираются интервалы, пересекающиеся с периодом
+type Trips struct {
+	VehicleId int32
+	After     *time.Time
+	Before    *time.Time
+	Limit     int64
+}
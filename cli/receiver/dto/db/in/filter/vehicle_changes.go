@@ -0,0 +1,10 @@
+package filter
+
+import "time"
+
+type VehicleChanges struct {
+	VehicleId int32
+	After     *time.Time
+	Before    *time.Time
+	Limit     int64
+}
@@ -0,0 +1,10 @@
+package filter
+
+import "time"
+
+// VehicleTrack выбирает точки трека транспорта с временем отправки в заданном периоде
+type VehicleTrack struct {
+	VehicleId  int32
+	SentAfter  *time.Time
+	SentBefore *time.Time
+}
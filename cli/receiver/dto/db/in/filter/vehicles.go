@@ -5,8 +5,11 @@ import (
 )
 
 type Vehicles struct {
+	IDs              []int32
 	ProviderId       *int32
 	ModerationStatus *other.ModerationStatus
 	IMEI             *string
 	OID              *int64
+	VehicleGroupId   *int32
+	Page             *Page
 }
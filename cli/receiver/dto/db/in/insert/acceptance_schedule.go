@@ -0,0 +1,15 @@
+package insert
+
+import "github.com/daniil11ru/egts/cli/receiver/dto/other"
+
+type AcceptanceSchedule struct {
+	ProviderId      *int32                    `json:"provider_id"`
+	VehicleGroupId  *int32                    `json:"vehicle_group_id"`
+	DateFrom        *string                   `json:"date_from"`
+	DateTo          *string                   `json:"date_to"`
+	RecurringYearly bool                      `json:"recurring_yearly"`
+	WeekdayMask     int16                     `json:"weekday_mask"`
+	HourMask        int32                     `json:"hour_mask"`
+	TimeZone        string                    `json:"time_zone"`
+	TimeBasis       other.AcceptanceTimeBasis `json:"time_basis"`
+}
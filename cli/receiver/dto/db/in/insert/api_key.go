@@ -0,0 +1,15 @@
+package insert
+
+import (
+	"time"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/other"
+)
+
+type ApiKey struct {
+	Name       string
+	Hash       string
+	Scopes     other.ApiKeyScopes
+	ProviderId *int32
+	ExpiresAt  *time.Time
+}
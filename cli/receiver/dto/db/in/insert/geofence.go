@@ -0,0 +1,26 @@
+package insert
+
+import (
+	"time"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/other"
+)
+
+type Geofence struct {
+	Name            string             `json:"name"`
+	Type            other.GeofenceType `json:"type"`
+	Polygon         [][][2]float64     `json:"polygon"`
+	CenterLatitude  *float64           `json:"center_latitude"`
+	CenterLongitude *float64           `json:"center_longitude"`
+	RadiusMeters    *float64           `json:"radius_meters"`
+}
+
+type GeofenceEvent struct {
+	GeofenceId int32                   `json:"geofence_id"`
+	VehicleId  int32                   `json:"vehicle_id"`
+	Type       other.GeofenceEventType `json:"type"`
+	LocationId *int32                  `json:"location_id"`
+	Latitude   float64                 `json:"latitude"`
+	Longitude  float64                 `json:"longitude"`
+	OccurredAt time.Time               `json:"occurred_at"`
+}
@@ -0,0 +1,11 @@
+package insert
+
+import "github.com/daniil11ru/egts/cli/receiver/dto/other"
+
+type OidResolutionRule struct {
+	ProviderId int32                       `json:"provider_id"`
+	Priority   int32                       `json:"priority"`
+	Type       other.OidResolutionRuleType `json:"type"`
+	Position   *other.ImeiSegmentPosition  `json:"position"`
+	Length     *int16                      `json:"length"`
+}
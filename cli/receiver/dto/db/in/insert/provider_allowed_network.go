@@ -0,0 +1,7 @@
+package insert
+
+type ProviderAllowedNetwork struct {
+	ProviderId  int32   `json:"provider_id"`
+	Network     string  `json:"network"`
+	Description *string `json:"description"`
+}
@@ -0,0 +1,24 @@
+package insert
+
+import (
+	"time"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/other"
+)
+
+type RejectedLocation struct {
+	ProviderId int32               `json:"provider_id"`
+	OID        int64               `json:"oid"`
+	VehicleId  *int32              `json:"vehicle_id"`
+	Rule       other.GpsFilterRule `json:"rule"`
+	Detail     string              `json:"detail"`
+	Latitude   float64             `json:"latitude"`
+	Longitude  float64             `json:"longitude"`
+	Altitude   *int64              `json:"altitude"`
+	Speed      *int32              `json:"speed"`
+	Hdop       *float64            `json:"hdop"`
+	Valid      bool                `json:"valid"`
+	Fix3d      bool                `json:"fix_3d"`
+	SentAt     *time.Time          `json:"sent_at"`
+	ReceivedAt time.Time           `json:"received_at"`
+}
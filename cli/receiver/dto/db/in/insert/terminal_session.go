@@ -0,0 +1,18 @@
+package insert
+
+import "time"
+
+type TerminalSession struct {
+	ProviderId    int32     `json:"provider_id"`
+	RemoteAddress string    `json:"remote_address"`
+	ConnectedAt   time.Time `json:"connected_at"`
+}
+
+type TerminalSessionVehicle struct {
+	SessionId   int32     `json:"session_id"`
+	VehicleId   int32     `json:"vehicle_id"`
+	OID         int64     `json:"oid"`
+	FirstSeenAt time.Time `json:"first_seen_at"`
+	LastSeenAt  time.Time `json:"last_seen_at"`
+	RecordCount int32     `json:"record_count"`
+}
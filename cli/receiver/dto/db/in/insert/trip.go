@@ -0,0 +1,25 @@
+package insert
+
+import "time"
+
+type Trip struct {
+	VehicleId      int32     `json:"vehicle_id"`
+	StartedAt      time.Time `json:"started_at"`
+	EndedAt        time.Time `json:"ended_at"`
+	StartLatitude  float64   `json:"start_latitude"`
+	StartLongitude float64   `json:"start_longitude"`
+	EndLatitude    float64   `json:"end_latitude"`
+	EndLongitude   float64   `json:"end_longitude"`
+	DistanceMeters float64   `json:"distance_meters"`
+	MaxSpeed       *int32    `json:"max_speed"`
+	PointCount     int32     `json:"point_count"`
+}
+
+type Stop struct {
+	VehicleId  int32     `json:"vehicle_id"`
+	StartedAt  time.Time `json:"started_at"`
+	EndedAt    time.Time `json:"ended_at"`
+	Latitude   float64   `json:"latitude"`
+	Longitude  float64   `json:"longitude"`
+	PointCount int32     `json:"point_count"`
+}
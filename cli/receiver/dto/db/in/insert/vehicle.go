@@ -9,4 +9,5 @@ type Vehicle struct {
 	Name             *string                `json:"name"`
 	ProviderId       int32                  `json:"provider_id"`
 	ModerationStatus other.ModerationStatus `json:"moderation_status"`
+	VehicleGroupId   *int32                 `json:"vehicle_group_id"`
 }
@@ -0,0 +1,10 @@
+package insert
+
+import "github.com/daniil11ru/egts/cli/receiver/dto/other"
+
+// VehicleChangeAuthor — кто изменяет транспорт; записывается в журнал изменений. ApiKeyId
+// указывается для изменений через API.
+type VehicleChangeAuthor struct {
+	Source   other.VehicleChangeSource
+	ApiKeyId *int32
+}
@@ -0,0 +1,5 @@
+package insert
+
+type VehicleGroup struct {
+	Name string `json:"name"`
+}
@@ -0,0 +1,12 @@
+package update
+
+import "github.com/daniil11ru/egts/cli/receiver/dto/other"
+
+// OidResolutionRule — изменяемые поля правила определения транспорта. Пустой Position и Length,
+// равный 0, снимают значения.
+type OidResolutionRule struct {
+	Priority *int32                       `json:"priority"`
+	Type     *other.OidResolutionRuleType `json:"type"`
+	Position *other.ImeiSegmentPosition   `json:"position"`
+	Length   *int16                       `json:"length"`
+}
@@ -0,0 +1,16 @@
+package update
+
+import "github.com/daniil11ru/egts/cli/receiver/dto/other"
+
+// Provider — изменяемые поля провайдера. Port, равный 0, снимает порт, и сервер провайдера
+// останавливается. Ограничения, равные 0, снимаются.
+type Provider struct {
+	Name         *string                  `json:"name"`
+	Port         *int32                   `json:"port"`
+	Transport    *other.ProviderTransport `json:"transport"`
+	AuthRequired *bool                    `json:"auth_required"`
+	Enabled      *bool                    `json:"enabled"`
+
+	MaxPacketsPerMinute   *int32 `json:"max_packets_per_minute"`
+	MaxNewVehiclesPerHour *int32 `json:"max_new_vehicles_per_hour"`
+}
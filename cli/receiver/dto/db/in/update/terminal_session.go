@@ -0,0 +1,20 @@
+package update
+
+import (
+	"time"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/other"
+)
+
+// TerminalSession содержит накопленное состояние сессии; счетчики записываются целиком
+type TerminalSession struct {
+	TerminalIMEI     *string                   `json:"terminal_imei"`
+	OID              *int64                    `json:"oid"`
+	VehicleId        *int32                    `json:"vehicle_id"`
+	LastPacketAt     *time.Time                `json:"last_packet_at"`
+	PacketCount      int32                     `json:"packet_count"`
+	RecordCount      int32                     `json:"record_count"`
+	DecodeErrorCount int32                     `json:"decode_error_count"`
+	DisconnectedAt   *time.Time                `json:"disconnected_at"`
+	CloseReason      *other.SessionCloseReason `json:"close_reason"`
+}
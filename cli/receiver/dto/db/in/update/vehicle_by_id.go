@@ -9,4 +9,6 @@ type VehicleById struct {
 	OID              *int64                  `json:"oid"`
 	Name             *string                 `json:"name"`
 	ModerationStatus *other.ModerationStatus `json:"moderation_status"`
+	// Нулевое значение исключает транспорт из группы
+	VehicleGroupId *int32 `json:"vehicle_group_id"`
 }
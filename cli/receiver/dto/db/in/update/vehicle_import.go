@@ -0,0 +1,7 @@
+package update
+
+type VehicleImport struct {
+	ID   int32
+	OID  *int64
+	Name *string
+}
@@ -0,0 +1,23 @@
+package out
+
+import (
+	"time"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/other"
+)
+
+type AcceptanceSchedule struct {
+	ID              int32                     `json:"id" gorm:"column:id"`
+	ProviderId      *int32                    `json:"provider_id,omitempty"`
+	VehicleGroupId  *int32                    `json:"vehicle_group_id,omitempty"`
+	DateFrom        *string                   `json:"date_from,omitempty"`
+	DateTo          *string                   `json:"date_to,omitempty"`
+	RecurringYearly bool                      `json:"recurring_yearly"`
+	WeekdayMask     int16                     `json:"weekday_mask"`
+	HourMask        int32                     `json:"hour_mask"`
+	TimeZone        string                    `json:"time_zone"`
+	TimeBasis       other.AcceptanceTimeBasis `json:"time_basis"`
+
+	// Location — временная зона TimeZone, загруженная при проверке расписания
+	Location *time.Location `json:"-" gorm:"-"`
+}
@@ -1,7 +1,19 @@
 package out
 
+import (
+	"time"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/other"
+)
+
 type ApiKey struct {
-	ID   int32  `json:"id" gorm:"column:id"`
-	Name string `json:"name"`
-	Hash string `json:"hash"`
+	ID         int32              `json:"id" gorm:"column:id"`
+	Name       string             `json:"name"`
+	Hash       string             `json:"hash"`
+	Scopes     other.ApiKeyScopes `json:"scopes"`
+	ProviderId *int32             `json:"provider_id"`
+	ExpiresAt  *time.Time         `json:"expires_at"`
+	RevokedAt  *time.Time         `json:"revoked_at"`
+	CreatedAt  time.Time          `json:"created_at"`
+	LastUsedAt *time.Time         `json:"last_used_at"`
 }
@@ -0,0 +1,13 @@
+package out
+
+type DailyMileage struct {
+	VehicleId           int32   `json:"vehicle_id"`
+	ProviderId          int32   `json:"provider_id"`
+	Day                 string  `json:"day"`
+	DistanceMeters      float64 `json:"distance_meters"`
+	OdometerStartMeters *int64  `json:"odometer_start_meters"`
+	OdometerEndMeters   *int64  `json:"odometer_end_meters"`
+	MovingSeconds       int64   `json:"moving_seconds"`
+	IdleSeconds         int64   `json:"idle_seconds"`
+	PointCount          int32   `json:"point_count"`
+}
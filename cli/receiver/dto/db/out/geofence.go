@@ -0,0 +1,32 @@
+package out
+
+import (
+	"time"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/other"
+)
+
+// GeofencePolygon — кольца полигона в порядке координат GeoJSON [долгота, широта]. Первое кольцо
+// внешнее, остальные — вырезы.
+type GeofencePolygon [][][2]float64
+
+type Geofence struct {
+	ID              int32              `json:"id"`
+	Name            string             `json:"name"`
+	Type            other.GeofenceType `json:"type"`
+	Polygon         GeofencePolygon    `json:"polygon"`
+	CenterLatitude  *float64           `json:"center_latitude"`
+	CenterLongitude *float64           `json:"center_longitude"`
+	RadiusMeters    *float64           `json:"radius_meters"`
+}
+
+type GeofenceEvent struct {
+	ID         int32                   `json:"id"`
+	GeofenceId int32                   `json:"geofence_id"`
+	VehicleId  int32                   `json:"vehicle_id"`
+	Type       other.GeofenceEventType `json:"type"`
+	LocationId *int32                  `json:"location_id"`
+	Latitude   float64                 `json:"latitude"`
+	Longitude  float64                 `json:"longitude"`
+	OccurredAt time.Time               `json:"occurred_at"`
+}
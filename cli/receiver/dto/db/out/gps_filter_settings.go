@@ -0,0 +1,10 @@
+package out
+
+type GpsFilterSettings struct {
+	ProviderId       int32    `json:"provider_id"`
+	RequireValid     bool     `json:"require_valid"`
+	Require3dFix     bool     `json:"require_3d_fix" gorm:"column:require_3d_fix"`
+	MaxHdop          *float64 `json:"max_hdop,omitempty"`
+	MaxFutureSeconds *int32   `json:"max_future_seconds,omitempty"`
+	MaxSpeedKmh      *float64 `json:"max_speed_kmh,omitempty"`
+}
@@ -0,0 +1,14 @@
+package out
+
+import "time"
+
+type HistoryBacklog struct {
+	VehicleId         int32     `json:"vehicle_id"`
+	ProviderId        int32     `json:"provider_id"`
+	Count             int64     `json:"count"`
+	OldestSentAt      time.Time `json:"oldest_sent_at"`
+	NewestSentAt      time.Time `json:"newest_sent_at"`
+	FirstReceivedAt   time.Time `json:"first_received_at"`
+	LastReceivedAt    time.Time `json:"last_received_at"`
+	CurrentLagSeconds float64   `json:"current_lag_seconds"`
+}
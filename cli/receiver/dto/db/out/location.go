@@ -14,4 +14,5 @@ type Location struct {
 	SatelliteCount *int16     `json:"satellite_count"`
 	SentAt         *time.Time `json:"sent_at"`
 	ReceivedAt     time.Time  `json:"received_at"`
+	IsHistory      bool       `json:"is_history"`
 }
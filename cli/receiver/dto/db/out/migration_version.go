@@ -0,0 +1,6 @@
+package out
+
+type MigrationVersion struct {
+	Version uint `gorm:"column:version"`
+	Dirty   bool `gorm:"column:dirty"`
+}
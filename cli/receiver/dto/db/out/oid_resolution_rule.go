@@ -0,0 +1,12 @@
+package out
+
+import "github.com/daniil11ru/egts/cli/receiver/dto/other"
+
+type OidResolutionRule struct {
+	ID         int32                       `json:"id" gorm:"column:id"`
+	ProviderId int32                       `json:"provider_id"`
+	Priority   int32                       `json:"priority"`
+	Type       other.OidResolutionRuleType `json:"type"`
+	Position   *other.ImeiSegmentPosition  `json:"position,omitempty"`
+	Length     *int16                      `json:"length,omitempty"`
+}
@@ -3,13 +3,15 @@ package out
 import (
 	"fmt"
 	"math"
+	"time"
 )
 
 type Point struct {
-	LocationId int32   `json:"location_id"`
-	Latitude   float64 `json:"latitude"`
-	Longitude  float64 `json:"longitude"`
-	Altitude   *int64  `json:"altitude"`
+	LocationId int32      `json:"location_id"`
+	Latitude   float64    `json:"latitude"`
+	Longitude  float64    `json:"longitude"`
+	Altitude   *int64     `json:"altitude"`
+	SentAt     *time.Time `json:"sent_at,omitempty"`
 }
 
 func (p *Point) EqualsTo(position *Point, accuracy_meters float64) (bool, error) {
@@ -35,10 +37,8 @@ func (p *Point) EqualsTo(position *Point, accuracy_meters float64) (bool, error)
 	return dist <= accuracy_meters, nil
 }
 
-func (p *Point) EqualsHorizontallyTo(position *Point, accuracy_meters float64) (bool, error) {
-	if p == nil || position == nil {
-		return false, fmt.Errorf("точки не должны быть nil")
-	}
+// HorizontalDistanceTo возвращает расстояние по поверхности Земли в метрах
+func (p *Point) HorizontalDistanceTo(position *Point) float64 {
 	const R = 6371000.0
 	lat1 := p.Latitude * math.Pi / 180
 	lat2 := position.Latitude * math.Pi / 180
@@ -48,6 +48,12 @@ func (p *Point) EqualsHorizontallyTo(position *Point, accuracy_meters float64) (
 	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
 		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
 	c := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
-	dist := R * c
-	return dist <= accuracy_meters, nil
+	return R * c
+}
+
+func (p *Point) EqualsHorizontallyTo(position *Point, accuracy_meters float64) (bool, error) {
+	if p == nil || position == nil {
+		return false, fmt.Errorf("точки не должны быть nil")
+	}
+	return p.HorizontalDistanceTo(position) <= accuracy_meters, nil
 }
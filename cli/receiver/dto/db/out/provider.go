@@ -1,6 +1,15 @@
 package out
 
+import "github.com/daniil11ru/egts/cli/receiver/dto/other"
+
 type Provider struct {
-	ID   int32  `json:"id" gorm:"column:id"`
-	Name string `json:"name"`
+	ID           int32                   `json:"id" gorm:"column:id"`
+	Name         string                  `json:"name"`
+	Port         *int32                  `json:"port"`
+	Transport    other.ProviderTransport `json:"transport"`
+	AuthRequired bool                    `json:"auth_required"`
+	Enabled      bool                    `json:"enabled"`
+
+	MaxPacketsPerMinute   *int32 `json:"max_packets_per_minute"`
+	MaxNewVehiclesPerHour *int32 `json:"max_new_vehicles_per_hour"`
 }
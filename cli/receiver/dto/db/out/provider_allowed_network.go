@@ -0,0 +1,8 @@
+package out
+
+type ProviderAllowedNetwork struct {
+	ID          int32   `json:"id" gorm:"column:id"`
+	ProviderId  int32   `json:"provider_id"`
+	Network     string  `json:"network"`
+	Description *string `json:"description"`
+}
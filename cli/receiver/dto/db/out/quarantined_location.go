@@ -0,0 +1,41 @@
+package out
+
+import (
+	"time"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/other"
+)
+
+type QuarantinedLocation struct {
+	ID             int32                  `json:"id" gorm:"column:id"`
+	ProviderId     int32                  `json:"provider_id"`
+	OID            int64                  `json:"oid" gorm:"column:oid"`
+	TerminalIMEI   string                 `json:"terminal_imei" gorm:"column:terminal_imei"`
+	VehicleId      *int32                 `json:"vehicle_id"`
+	Reason         other.QuarantineReason `json:"reason"`
+	Latitude       float64                `json:"latitude"`
+	Longitude      float64                `json:"longitude"`
+	Altitude       *int64                 `json:"altitude"`
+	Direction      *int16                 `json:"direction"`
+	Speed          *int32                 `json:"speed"`
+	SatelliteCount *int16                 `json:"satellite_count"`
+	Hdop           *float64               `json:"hdop"`
+	Valid          bool                   `json:"valid"`
+	Fix3d          bool                   `json:"fix_3d" gorm:"column:fix_3d"`
+	IsHistory      bool                   `json:"is_history"`
+	OdometerMeters *int64                 `json:"odometer_meters"`
+	SentAt         *time.Time             `json:"sent_at"`
+	ReceivedAt     time.Time              `json:"received_at"`
+	QuarantinedAt  time.Time              `json:"quarantined_at"`
+}
+
+type QuarantineGroup struct {
+	ProviderId      int32                  `json:"provider_id"`
+	OID             int64                  `json:"oid" gorm:"column:oid"`
+	TerminalIMEI    string                 `json:"terminal_imei" gorm:"column:terminal_imei"`
+	VehicleId       *int32                 `json:"vehicle_id"`
+	Reason          other.QuarantineReason `json:"reason"`
+	Count           int64                  `json:"count"`
+	FirstReceivedAt time.Time              `json:"first_received_at"`
+	LastReceivedAt  time.Time              `json:"last_received_at"`
+}
@@ -0,0 +1,40 @@
+package out
+
+import (
+	"time"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/other"
+)
+
+type TerminalSession struct {
+	ID               int32                     `json:"id"`
+	ProviderId       int32                     `json:"provider_id"`
+	RemoteAddress    string                    `json:"remote_address"`
+	TerminalIMEI     *string                   `json:"terminal_imei"`
+	OID              *int64                    `json:"oid"`
+	VehicleId        *int32                    `json:"vehicle_id"`
+	ConnectedAt      time.Time                 `json:"connected_at"`
+	DisconnectedAt   *time.Time                `json:"disconnected_at"`
+	LastPacketAt     *time.Time                `json:"last_packet_at"`
+	PacketCount      int32                     `json:"packet_count"`
+	RecordCount      int32                     `json:"record_count"`
+	DecodeErrorCount int32                     `json:"decode_error_count"`
+	CloseReason      *other.SessionCloseReason `json:"close_reason"`
+}
+
+// TerminalSessionVehicle — транспорт, данные которого поступали в рамках сессии
+type TerminalSessionVehicle struct {
+	VehicleId   int32     `json:"vehicle_id"`
+	OID         int64     `json:"oid"`
+	FirstSeenAt time.Time `json:"first_seen_at"`
+	LastSeenAt  time.Time `json:"last_seen_at"`
+	RecordCount int32     `json:"record_count"`
+}
+
+// VehicleConnection — сведения о соединениях, через которые поступали данные транспорта
+type VehicleConnection struct {
+	VehicleId      int32      `json:"vehicle_id"`
+	ProviderId     int32      `json:"provider_id"`
+	HasOpenSession bool       `json:"has_open_session"`
+	LastSeenAt     *time.Time `json:"last_seen_at"`
+}
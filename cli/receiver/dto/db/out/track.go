@@ -1,6 +1,7 @@
 package out
 
 type Track struct {
-	VehicleId int32   `json:"vehicle_id"`
-	Points    []Point `json:"points"`
+	VehicleId  int32   `json:"vehicle_id"`
+	ProviderId int32   `json:"provider_id"`
+	Points     []Point `json:"points"`
 }
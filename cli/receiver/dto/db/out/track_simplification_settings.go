@@ -0,0 +1,10 @@
+package out
+
+type TrackSimplificationSettings struct {
+	ProviderId       int32   `json:"provider_id"`
+	Enabled          bool    `json:"enabled"`
+	ToleranceMeters  float64 `json:"tolerance_meters"`
+	MaxGapSeconds    int32   `json:"max_gap_seconds"`
+	StopRadiusMeters float64 `json:"stop_radius_meters"`
+	StopMinSeconds   int32   `json:"stop_min_seconds"`
+}
@@ -0,0 +1,44 @@
+package out
+
+import "time"
+
+type Trip struct {
+	ID             int32     `json:"id"`
+	VehicleId      int32     `json:"vehicle_id"`
+	StartedAt      time.Time `json:"started_at"`
+	EndedAt        time.Time `json:"ended_at"`
+	StartLatitude  float64   `json:"start_latitude"`
+	StartLongitude float64   `json:"start_longitude"`
+	EndLatitude    float64   `json:"end_latitude"`
+	EndLongitude   float64   `json:"end_longitude"`
+	DistanceMeters float64   `json:"distance_meters"`
+	MaxSpeed       *int32    `json:"max_speed"`
+	PointCount     int32     `json:"point_count"`
+}
+
+type Stop struct {
+	ID         int32     `json:"id"`
+	VehicleId  int32     `json:"vehicle_id"`
+	StartedAt  time.Time `json:"started_at"`
+	EndedAt    time.Time `json:"ended_at"`
+	Latitude   float64   `json:"latitude"`
+	Longitude  float64   `json:"longitude"`
+	PointCount int32     `json:"point_count"`
+}
+
+type TripPoint struct {
+	LocationId     int32     `json:"location_id"`
+	Latitude       float64   `json:"latitude"`
+	Longitude      float64   `json:"longitude"`
+	Speed          *int32    `json:"speed"`
+	OdometerMeters *int64    `json:"odometer_meters"`
+	SentAt         time.Time `json:"sent_at"`
+}
+
+// PendingTripVehicle — транспорт с новыми точками и наиболее раннее время навигации среди них.
+// Version меняется с каждой новой точкой транспорта.
+type PendingTripVehicle struct {
+	VehicleId   int32     `json:"vehicle_id"`
+	FirstSentAt time.Time `json:"first_sent_at"`
+	Version     int64     `json:"version"`
+}
@@ -13,6 +13,7 @@ type Vehicle struct {
 	Name             *string                `json:"name,omitempty"`
 	ProviderId       int32                  `json:"provider_id"`
 	ModerationStatus other.ModerationStatus `json:"moderation_status"`
+	VehicleGroupId   *int32                 `json:"vehicle_group_id,omitempty"`
 }
 
 func (v Vehicle) MarshalJSON() ([]byte, error) {
@@ -0,0 +1,20 @@
+package out
+
+import (
+	"time"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/other"
+)
+
+type VehicleChange struct {
+	ID         int64                     `json:"id"`
+	VehicleId  int32                     `json:"vehicle_id"`
+	Action     other.VehicleChangeAction `json:"action"`
+	Source     other.VehicleChangeSource `json:"source"`
+	ApiKeyId   *int32                    `json:"api_key_id"`
+	ApiKeyName *string                   `json:"api_key_name"`
+	// JSON-объекты с прежними и новыми значениями измененных полей
+	OldValues string    `json:"old_values"`
+	NewValues string    `json:"new_values"`
+	ChangedAt time.Time `json:"changed_at"`
+}
@@ -0,0 +1,6 @@
+package out
+
+type VehicleGroup struct {
+	ID   int32  `json:"id" gorm:"column:id"`
+	Name string `json:"name"`
+}
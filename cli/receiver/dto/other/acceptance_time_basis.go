@@ -0,0 +1,65 @@
+package other
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+type AcceptanceTimeBasis string
+
+const (
+	AcceptanceTimeBasisNavigation AcceptanceTimeBasis = "navigation"
+	AcceptanceTimeBasisReceived   AcceptanceTimeBasis = "received"
+	AcceptanceTimeBasisBoth       AcceptanceTimeBasis = "both"
+)
+
+func (b AcceptanceTimeBasis) IsValid() bool {
+	return b == AcceptanceTimeBasisNavigation || b == AcceptanceTimeBasisReceived || b == AcceptanceTimeBasisBoth
+}
+
+func (b *AcceptanceTimeBasis) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	v := AcceptanceTimeBasis(s)
+	if !v.IsValid() {
+		return fmt.Errorf("недопустимое время для проверки расписания: %q", s)
+	}
+	*b = v
+	return nil
+}
+
+func (b AcceptanceTimeBasis) MarshalJSON() ([]byte, error) {
+	if !b.IsValid() {
+		return nil, fmt.Errorf("недопустимое время для проверки расписания: %q", string(b))
+	}
+	return json.Marshal(string(b))
+}
+
+func (b *AcceptanceTimeBasis) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		*b = AcceptanceTimeBasis(string(v))
+	case string:
+		*b = AcceptanceTimeBasis(v)
+	default:
+		return fmt.Errorf("невозможно извлечь AcceptanceTimeBasis из %T", value)
+	}
+	if !b.IsValid() {
+		return fmt.Errorf("недопустимый AcceptanceTimeBasis: %q", string(*b))
+	}
+	return nil
+}
+
+func (b AcceptanceTimeBasis) Value() (driver.Value, error) {
+	if !b.IsValid() {
+		return nil, fmt.Errorf("недопустимый AcceptanceTimeBasis: %q", string(b))
+	}
+	return string(b), nil
+}
+
+func (b AcceptanceTimeBasis) String() string {
+	return string(b)
+}
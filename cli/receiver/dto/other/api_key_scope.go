@@ -0,0 +1,105 @@
+package other
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+type ApiKeyScope string
+
+const (
+	ApiKeyScopeReadVehicles  ApiKeyScope = "read_vehicles"
+	ApiKeyScopeReadLocations ApiKeyScope = "read_locations"
+	ApiKeyScopeModerate      ApiKeyScope = "moderate"
+	// ApiKeyScopeAdmin дает доступ ко всем маршрутам, включая управление ключами
+	ApiKeyScopeAdmin ApiKeyScope = "admin"
+)
+
+var apiKeyScopeSet = map[ApiKeyScope]struct{}{
+	ApiKeyScopeReadVehicles:  {},
+	ApiKeyScopeReadLocations: {},
+	ApiKeyScopeModerate:      {},
+	ApiKeyScopeAdmin:         {},
+}
+
+func (s ApiKeyScope) IsValid() bool {
+	_, ok := apiKeyScopeSet[s]
+	return ok
+}
+
+func (s *ApiKeyScope) UnmarshalJSON(b []byte) error {
+	var str string
+	if err := json.Unmarshal(b, &str); err != nil {
+		return err
+	}
+	v := ApiKeyScope(str)
+	if !v.IsValid() {
+		return fmt.Errorf("недопустимая область доступа: %q", str)
+	}
+	*s = v
+	return nil
+}
+
+func (s ApiKeyScope) MarshalJSON() ([]byte, error) {
+	if !s.IsValid() {
+		return nil, fmt.Errorf("недопустимая область доступа: %q", string(s))
+	}
+	return json.Marshal(string(s))
+}
+
+func (s ApiKeyScope) String() string {
+	return string(s)
+}
+
+// ApiKeyScopes хранится в базе данных массивом text[], который читается и записывается строкой
+// через запятую: array_to_string(scopes, ',') и string_to_array(?, ',')
+type ApiKeyScopes []ApiKeyScope
+
+func (s *ApiKeyScopes) Scan(value interface{}) error {
+	var str string
+	switch v := value.(type) {
+	case []byte:
+		str = string(v)
+	case string:
+		str = v
+	default:
+		return fmt.Errorf("невозможно извлечь ApiKeyScopes из %T", value)
+	}
+
+	scopes := ApiKeyScopes{}
+	for _, part := range strings.Split(str, ",") {
+		if part == "" {
+			continue
+		}
+		scope := ApiKeyScope(part)
+		if !scope.IsValid() {
+			return fmt.Errorf("недопустимая область доступа: %q", part)
+		}
+		scopes = append(scopes, scope)
+	}
+	*s = scopes
+	return nil
+}
+
+func (s ApiKeyScopes) Value() (driver.Value, error) {
+	parts := make([]string, 0, len(s))
+	for _, scope := range s {
+		if !scope.IsValid() {
+			return nil, fmt.Errorf("недопустимая область доступа: %q", string(scope))
+		}
+		parts = append(parts, string(scope))
+	}
+	return strings.Join(parts, ","), nil
+}
+
+// Has сообщает, входит ли область в список. Область admin включает все остальные.
+func (s ApiKeyScopes) Has(scope ApiKeyScope) bool {
+	for _, v := range s {
+		if v == scope || v == ApiKeyScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package other
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+type GeofenceEventType string
+
+const (
+	GeofenceEventTypeEnter GeofenceEventType = "enter"
+	GeofenceEventTypeExit  GeofenceEventType = "exit"
+)
+
+func (t GeofenceEventType) IsValid() bool {
+	return t == GeofenceEventTypeEnter || t == GeofenceEventTypeExit
+}
+
+func (t *GeofenceEventType) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	v := GeofenceEventType(s)
+	if !v.IsValid() {
+		return fmt.Errorf("недопустимый тип события геозоны: %q", s)
+	}
+	*t = v
+	return nil
+}
+
+func (t GeofenceEventType) MarshalJSON() ([]byte, error) {
+	if !t.IsValid() {
+		return nil, fmt.Errorf("недопустимый тип события геозоны: %q", string(t))
+	}
+	return json.Marshal(string(t))
+}
+
+func (t *GeofenceEventType) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		*t = GeofenceEventType(string(v))
+	case string:
+		*t = GeofenceEventType(v)
+	default:
+		return fmt.Errorf("невозможно извлечь GeofenceEventType из %T", value)
+	}
+	if !t.IsValid() {
+		return fmt.Errorf("недопустимый GeofenceEventType: %q", string(*t))
+	}
+	return nil
+}
+
+func (t GeofenceEventType) Value() (driver.Value, error) {
+	if !t.IsValid() {
+		return nil, fmt.Errorf("недопустимый GeofenceEventType: %q", string(t))
+	}
+	return string(t), nil
+}
+
+func (t GeofenceEventType) String() string {
+	return string(t)
+}
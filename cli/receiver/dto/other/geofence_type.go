@@ -0,0 +1,64 @@
+package other
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+type GeofenceType string
+
+const (
+	GeofenceTypePolygon GeofenceType = "polygon"
+	GeofenceTypeCircle  GeofenceType = "circle"
+)
+
+func (t GeofenceType) IsValid() bool {
+	return t == GeofenceTypePolygon || t == GeofenceTypeCircle
+}
+
+func (t *GeofenceType) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	v := GeofenceType(s)
+	if !v.IsValid() {
+		return fmt.Errorf("недопустимый тип геозоны: %q", s)
+	}
+	*t = v
+	return nil
+}
+
+func (t GeofenceType) MarshalJSON() ([]byte, error) {
+	if !t.IsValid() {
+		return nil, fmt.Errorf("недопустимый тип геозоны: %q", string(t))
+	}
+	return json.Marshal(string(t))
+}
+
+func (t *GeofenceType) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		*t = GeofenceType(string(v))
+	case string:
+		*t = GeofenceType(v)
+	default:
+		return fmt.Errorf("невозможно извлечь GeofenceType из %T", value)
+	}
+	if !t.IsValid() {
+		return fmt.Errorf("недопустимый GeofenceType: %q", string(*t))
+	}
+	return nil
+}
+
+func (t GeofenceType) Value() (driver.Value, error) {
+	if !t.IsValid() {
+		return nil, fmt.Errorf("недопустимый GeofenceType: %q", string(t))
+	}
+	return string(t), nil
+}
+
+func (t GeofenceType) String() string {
+	return string(t)
+}
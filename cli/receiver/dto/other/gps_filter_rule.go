@@ -0,0 +1,76 @@
+package other
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+type GpsFilterRule string
+
+const (
+	GpsFilterRuleInvalidPosition GpsFilterRule = "invalid_position"
+	GpsFilterRuleNo3dFix         GpsFilterRule = "no_3d_fix"
+	GpsFilterRuleHighHdop        GpsFilterRule = "high_hdop"
+	GpsFilterRuleFutureTime      GpsFilterRule = "future_time"
+	GpsFilterRuleImpossibleSpeed GpsFilterRule = "impossible_speed"
+)
+
+var gpsFilterRuleSet = map[GpsFilterRule]struct{}{
+	GpsFilterRuleInvalidPosition: {},
+	GpsFilterRuleNo3dFix:         {},
+	GpsFilterRuleHighHdop:        {},
+	GpsFilterRuleFutureTime:      {},
+	GpsFilterRuleImpossibleSpeed: {},
+}
+
+func (r GpsFilterRule) IsValid() bool {
+	_, ok := gpsFilterRuleSet[r]
+	return ok
+}
+
+func (r *GpsFilterRule) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	v := GpsFilterRule(s)
+	if !v.IsValid() {
+		return fmt.Errorf("недопустимое правило фильтрации: %q", s)
+	}
+	*r = v
+	return nil
+}
+
+func (r GpsFilterRule) MarshalJSON() ([]byte, error) {
+	if !r.IsValid() {
+		return nil, fmt.Errorf("недопустимое правило фильтрации: %q", string(r))
+	}
+	return json.Marshal(string(r))
+}
+
+func (r *GpsFilterRule) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		*r = GpsFilterRule(string(v))
+	case string:
+		*r = GpsFilterRule(v)
+	default:
+		return fmt.Errorf("невозможно извлечь GpsFilterRule из %T", value)
+	}
+	if !r.IsValid() {
+		return fmt.Errorf("недопустимый GpsFilterRule: %q", string(*r))
+	}
+	return nil
+}
+
+func (r GpsFilterRule) Value() (driver.Value, error) {
+	if !r.IsValid() {
+		return nil, fmt.Errorf("недопустимый GpsFilterRule: %q", string(r))
+	}
+	return string(r), nil
+}
+
+func (r GpsFilterRule) String() string {
+	return string(r)
+}
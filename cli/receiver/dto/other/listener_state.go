@@ -0,0 +1,29 @@
+package other
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+type ListenerState string
+
+const (
+	ListenerStateStarting  ListenerState = "starting"
+	ListenerStateListening ListenerState = "listening"
+	ListenerStateFailed    ListenerState = "failed"
+)
+
+func (r ListenerState) IsValid() bool {
+	return r == ListenerStateStarting || r == ListenerStateListening || r == ListenerStateFailed
+}
+
+func (r ListenerState) MarshalJSON() ([]byte, error) {
+	if !r.IsValid() {
+		return nil, fmt.Errorf("недопустимое состояние порта провайдера: %q", string(r))
+	}
+	return json.Marshal(string(r))
+}
+
+func (r ListenerState) String() string {
+	return string(r)
+}
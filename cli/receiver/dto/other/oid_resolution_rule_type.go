@@ -0,0 +1,133 @@
+package other
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+type OidResolutionRuleType string
+
+const (
+	OidResolutionRuleTypeExactOid         OidResolutionRuleType = "exact_oid"
+	OidResolutionRuleTypeImeiDigits       OidResolutionRuleType = "imei_digits"
+	OidResolutionRuleTypeImeiBytes        OidResolutionRuleType = "imei_bytes"
+	OidResolutionRuleTypeImeiMaxDigits    OidResolutionRuleType = "imei_max_digits"
+	OidResolutionRuleTypeTermIdentityImei OidResolutionRuleType = "term_identity_imei"
+)
+
+var oidResolutionRuleTypeSet = map[OidResolutionRuleType]struct{}{
+	OidResolutionRuleTypeExactOid:         {},
+	OidResolutionRuleTypeImeiDigits:       {},
+	OidResolutionRuleTypeImeiBytes:        {},
+	OidResolutionRuleTypeImeiMaxDigits:    {},
+	OidResolutionRuleTypeTermIdentityImei: {},
+}
+
+func (t OidResolutionRuleType) IsValid() bool {
+	_, ok := oidResolutionRuleTypeSet[t]
+	return ok
+}
+
+func (t *OidResolutionRuleType) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	v := OidResolutionRuleType(s)
+	if !v.IsValid() {
+		return fmt.Errorf("недопустимый тип правила: %q", s)
+	}
+	*t = v
+	return nil
+}
+
+func (t OidResolutionRuleType) MarshalJSON() ([]byte, error) {
+	if !t.IsValid() {
+		return nil, fmt.Errorf("недопустимый тип правила: %q", string(t))
+	}
+	return json.Marshal(string(t))
+}
+
+func (t *OidResolutionRuleType) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		*t = OidResolutionRuleType(string(v))
+	case string:
+		*t = OidResolutionRuleType(v)
+	default:
+		return fmt.Errorf("невозможно извлечь OidResolutionRuleType из %T", value)
+	}
+	if !t.IsValid() {
+		return fmt.Errorf("недопустимый OidResolutionRuleType: %q", string(*t))
+	}
+	return nil
+}
+
+func (t OidResolutionRuleType) Value() (driver.Value, error) {
+	if !t.IsValid() {
+		return nil, fmt.Errorf("недопустимый OidResolutionRuleType: %q", string(t))
+	}
+	return string(t), nil
+}
+
+func (t OidResolutionRuleType) String() string {
+	return string(t)
+}
+
+type ImeiSegmentPosition string
+
+const (
+	ImeiSegmentPositionStart ImeiSegmentPosition = "start"
+	ImeiSegmentPositionEnd   ImeiSegmentPosition = "end"
+)
+
+func (p ImeiSegmentPosition) IsValid() bool {
+	return p == ImeiSegmentPositionStart || p == ImeiSegmentPositionEnd
+}
+
+func (p *ImeiSegmentPosition) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	v := ImeiSegmentPosition(s)
+	if !v.IsValid() {
+		return fmt.Errorf("недопустимая позиция сегмента IMEI: %q", s)
+	}
+	*p = v
+	return nil
+}
+
+func (p ImeiSegmentPosition) MarshalJSON() ([]byte, error) {
+	if !p.IsValid() {
+		return nil, fmt.Errorf("недопустимая позиция сегмента IMEI: %q", string(p))
+	}
+	return json.Marshal(string(p))
+}
+
+func (p *ImeiSegmentPosition) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		*p = ImeiSegmentPosition(string(v))
+	case string:
+		*p = ImeiSegmentPosition(v)
+	default:
+		return fmt.Errorf("невозможно извлечь ImeiSegmentPosition из %T", value)
+	}
+	if !p.IsValid() {
+		return fmt.Errorf("недопустимый ImeiSegmentPosition: %q", string(*p))
+	}
+	return nil
+}
+
+func (p ImeiSegmentPosition) Value() (driver.Value, error) {
+	if !p.IsValid() {
+		return nil, fmt.Errorf("недопустимый ImeiSegmentPosition: %q", string(p))
+	}
+	return string(p), nil
+}
+
+func (p ImeiSegmentPosition) String() string {
+	return string(p)
+}
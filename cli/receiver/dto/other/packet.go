@@ -12,6 +12,16 @@ type PacketData struct {
 	Speed             uint16  `json:"speed"`
 	SatelliteCount    uint8   `json:"satellite_count"`
 	Direction         uint8   `json:"direction"`
+	TerminalIMEI      string  `json:"terminal_imei,omitempty"`
+	// Valid и Fix3d соответствуют флагам VLD и FIX подзаписи EGTS_SR_POS_DATA
+	Valid bool `json:"valid"`
+	Fix3d bool `json:"fix_3d"`
+	// History соответствует флагу BB: запись выгружена из черного ящика терминала
+	History bool `json:"history"`
+	// Hdop заполняется, если терминал передал подзапись EGTS_SR_EXT_POS_DATA с полем HDOP
+	Hdop *float64 `json:"hdop,omitempty"`
+	// Odometer — пробег в единицах по 0,1 км, заполняется, если терминал передает ненулевое значение
+	Odometer *uint32 `json:"odometer,omitempty"`
 }
 
 func (eep *PacketData) ToBytes() ([]byte, error) {
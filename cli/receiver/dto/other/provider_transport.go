@@ -0,0 +1,63 @@
+package other
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+type ProviderTransport string
+
+const (
+	ProviderTransportTcp ProviderTransport = "tcp"
+)
+
+func (r ProviderTransport) IsValid() bool {
+	return r == ProviderTransportTcp
+}
+
+func (r *ProviderTransport) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	v := ProviderTransport(s)
+	if !v.IsValid() {
+		return fmt.Errorf("недопустимый транспорт провайдера: %q", s)
+	}
+	*r = v
+	return nil
+}
+
+func (r ProviderTransport) MarshalJSON() ([]byte, error) {
+	if !r.IsValid() {
+		return nil, fmt.Errorf("недопустимый транспорт провайдера: %q", string(r))
+	}
+	return json.Marshal(string(r))
+}
+
+func (r *ProviderTransport) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		*r = ProviderTransport(string(v))
+	case string:
+		*r = ProviderTransport(v)
+	default:
+		return fmt.Errorf("невозможно извлечь ProviderTransport из %T", value)
+	}
+	if !r.IsValid() {
+		return fmt.Errorf("недопустимый ProviderTransport: %q", string(*r))
+	}
+	return nil
+}
+
+func (r ProviderTransport) Value() (driver.Value, error) {
+	if !r.IsValid() {
+		return nil, fmt.Errorf("недопустимый ProviderTransport: %q", string(r))
+	}
+	return string(r), nil
+}
+
+func (r ProviderTransport) String() string {
+	return string(r)
+}
@@ -0,0 +1,67 @@
+package other
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+type QuarantineReason string
+
+const (
+	QuarantineReasonAmbiguousVehicle QuarantineReason = "ambiguous_vehicle"
+	QuarantineReasonPendingVehicle   QuarantineReason = "pending_vehicle"
+	// QuarantineReasonVehicleCreationLimit — новый транспорт не добавлен, потому что провайдер
+	// превысил ограничение на количество нового транспорта в час
+	QuarantineReasonVehicleCreationLimit QuarantineReason = "vehicle_creation_limit"
+)
+
+func (r QuarantineReason) IsValid() bool {
+	return r == QuarantineReasonAmbiguousVehicle || r == QuarantineReasonPendingVehicle || r == QuarantineReasonVehicleCreationLimit
+}
+
+func (r *QuarantineReason) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	v := QuarantineReason(s)
+	if !v.IsValid() {
+		return fmt.Errorf("недопустимая причина карантина: %q", s)
+	}
+	*r = v
+	return nil
+}
+
+func (r QuarantineReason) MarshalJSON() ([]byte, error) {
+	if !r.IsValid() {
+		return nil, fmt.Errorf("недопустимая причина карантина: %q", string(r))
+	}
+	return json.Marshal(string(r))
+}
+
+func (r *QuarantineReason) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		*r = QuarantineReason(string(v))
+	case string:
+		*r = QuarantineReason(v)
+	default:
+		return fmt.Errorf("невозможно извлечь QuarantineReason из %T", value)
+	}
+	if !r.IsValid() {
+		return fmt.Errorf("недопустимый QuarantineReason: %q", string(*r))
+	}
+	return nil
+}
+
+func (r QuarantineReason) Value() (driver.Value, error) {
+	if !r.IsValid() {
+		return nil, fmt.Errorf("недопустимый QuarantineReason: %q", string(r))
+	}
+	return string(r), nil
+}
+
+func (r QuarantineReason) String() string {
+	return string(r)
+}
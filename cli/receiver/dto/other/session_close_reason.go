@@ -0,0 +1,72 @@
+package other
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+type SessionCloseReason string
+
+const (
+	SessionCloseReasonClientClosed  SessionCloseReason = "client_closed"
+	SessionCloseReasonTimeout       SessionCloseReason = "timeout"
+	SessionCloseReasonInvalidPacket SessionCloseReason = "invalid_packet"
+	SessionCloseReasonReadError     SessionCloseReason = "read_error"
+	SessionCloseReasonServerRestart SessionCloseReason = "server_restart"
+	// SessionCloseReasonIpNotAllowed — адрес не входит в разрешенные сети провайдера, соединение
+	// закрыто сразу после установки
+	SessionCloseReasonIpNotAllowed SessionCloseReason = "ip_not_allowed"
+	// SessionCloseReasonRateLimited — терминал превысил ограничение на количество пакетов в минуту
+	SessionCloseReasonRateLimited SessionCloseReason = "rate_limited"
+)
+
+func (r SessionCloseReason) IsValid() bool {
+	return r == SessionCloseReasonClientClosed || r == SessionCloseReasonTimeout || r == SessionCloseReasonInvalidPacket || r == SessionCloseReasonReadError || r == SessionCloseReasonServerRestart || r == SessionCloseReasonIpNotAllowed || r == SessionCloseReasonRateLimited
+}
+
+func (r *SessionCloseReason) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	v := SessionCloseReason(s)
+	if !v.IsValid() {
+		return fmt.Errorf("недопустимая причина закрытия соединения: %q", s)
+	}
+	*r = v
+	return nil
+}
+
+func (r SessionCloseReason) MarshalJSON() ([]byte, error) {
+	if !r.IsValid() {
+		return nil, fmt.Errorf("недопустимая причина закрытия соединения: %q", string(r))
+	}
+	return json.Marshal(string(r))
+}
+
+func (r *SessionCloseReason) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		*r = SessionCloseReason(string(v))
+	case string:
+		*r = SessionCloseReason(v)
+	default:
+		return fmt.Errorf("невозможно извлечь SessionCloseReason из %T", value)
+	}
+	if !r.IsValid() {
+		return fmt.Errorf("недопустимый SessionCloseReason: %q", string(*r))
+	}
+	return nil
+}
+
+func (r SessionCloseReason) Value() (driver.Value, error) {
+	if !r.IsValid() {
+		return nil, fmt.Errorf("недопустимый SessionCloseReason: %q", string(r))
+	}
+	return string(r), nil
+}
+
+func (r SessionCloseReason) String() string {
+	return string(r)
+}
@@ -0,0 +1,65 @@
+package other
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+type TerminalStatus string
+
+const (
+	TerminalStatusOnline  TerminalStatus = "online"
+	TerminalStatusStale   TerminalStatus = "stale"
+	TerminalStatusOffline TerminalStatus = "offline"
+)
+
+func (r TerminalStatus) IsValid() bool {
+	return r == TerminalStatusOnline || r == TerminalStatusStale || r == TerminalStatusOffline
+}
+
+func (r *TerminalStatus) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	v := TerminalStatus(s)
+	if !v.IsValid() {
+		return fmt.Errorf("недопустимый статус терминала: %q", s)
+	}
+	*r = v
+	return nil
+}
+
+func (r TerminalStatus) MarshalJSON() ([]byte, error) {
+	if !r.IsValid() {
+		return nil, fmt.Errorf("недопустимый статус терминала: %q", string(r))
+	}
+	return json.Marshal(string(r))
+}
+
+func (r *TerminalStatus) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		*r = TerminalStatus(string(v))
+	case string:
+		*r = TerminalStatus(v)
+	default:
+		return fmt.Errorf("невозможно извлечь TerminalStatus из %T", value)
+	}
+	if !r.IsValid() {
+		return fmt.Errorf("недопустимый TerminalStatus: %q", string(*r))
+	}
+	return nil
+}
+
+func (r TerminalStatus) Value() (driver.Value, error) {
+	if !r.IsValid() {
+		return nil, fmt.Errorf("недопустимый TerminalStatus: %q", string(r))
+	}
+	return string(r), nil
+}
+
+func (r TerminalStatus) String() string {
+	return string(r)
+}
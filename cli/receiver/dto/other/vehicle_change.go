@@ -0,0 +1,86 @@
+package other
+
+import (
+	"database/sql/driver"
+	"fmt"
+)
+
+type VehicleChangeAction string
+
+const (
+	VehicleChangeActionCreate VehicleChangeAction = "create"
+	VehicleChangeActionUpdate VehicleChangeAction = "update"
+	VehicleChangeActionDelete VehicleChangeAction = "delete"
+	// VehicleChangeActionMerge записывается и для транспорта, в который перенесены данные дубликата,
+	// и для самого дубликата, который при этом удаляется
+	VehicleChangeActionMerge VehicleChangeAction = "merge"
+)
+
+func (a VehicleChangeAction) IsValid() bool {
+	return a == VehicleChangeActionCreate || a == VehicleChangeActionUpdate || a == VehicleChangeActionDelete || a == VehicleChangeActionMerge
+}
+
+func (a *VehicleChangeAction) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		*a = VehicleChangeAction(string(v))
+	case string:
+		*a = VehicleChangeAction(v)
+	default:
+		return fmt.Errorf("невозможно извлечь VehicleChangeAction из %T", value)
+	}
+	if !a.IsValid() {
+		return fmt.Errorf("недопустимый VehicleChangeAction: %q", string(*a))
+	}
+	return nil
+}
+
+func (a VehicleChangeAction) Value() (driver.Value, error) {
+	if !a.IsValid() {
+		return nil, fmt.Errorf("недопустимый VehicleChangeAction: %q", string(a))
+	}
+	return string(a), nil
+}
+
+func (a VehicleChangeAction) String() string {
+	return string(a)
+}
+
+// VehicleChangeSource — откуда пришло изменение транспорта: через API или от приемника, который
+// сам добавляет транспорт и обновляет его OID по входящим пакетам
+type VehicleChangeSource string
+
+const (
+	VehicleChangeSourceApi      VehicleChangeSource = "api"
+	VehicleChangeSourceReceiver VehicleChangeSource = "receiver"
+)
+
+func (s VehicleChangeSource) IsValid() bool {
+	return s == VehicleChangeSourceApi || s == VehicleChangeSourceReceiver
+}
+
+func (s *VehicleChangeSource) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		*s = VehicleChangeSource(string(v))
+	case string:
+		*s = VehicleChangeSource(v)
+	default:
+		return fmt.Errorf("невозможно извлечь VehicleChangeSource из %T", value)
+	}
+	if !s.IsValid() {
+		return fmt.Errorf("недопустимый VehicleChangeSource: %q", string(*s))
+	}
+	return nil
+}
+
+func (s VehicleChangeSource) Value() (driver.Value, error) {
+	if !s.IsValid() {
+		return nil, fmt.Errorf("недопустимый VehicleChangeSource: %q", string(s))
+	}
+	return string(s), nil
+}
+
+func (s VehicleChangeSource) String() string {
+	return string(s)
+}
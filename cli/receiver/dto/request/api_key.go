@@ -0,0 +1,10 @@
+package request
+
+import "github.com/daniil11ru/egts/cli/receiver/dto/other"
+
+type AddApiKey struct {
+	Name       string              `json:"name" binding:"required"`
+	Scopes     []other.ApiKeyScope `json:"scopes" binding:"required"`
+	ProviderID *int32              `json:"provider_id"`
+	ExpiresAt  *string             `json:"expires_at"`
+}
@@ -0,0 +1,12 @@
+package request
+
+import "github.com/daniil11ru/egts/cli/receiver/dto/other"
+
+type Geofence struct {
+	Name            string              `json:"name" binding:"required"`
+	Type            *other.GeofenceType `json:"type" binding:"required"`
+	Polygon         [][][2]float64      `json:"polygon"`
+	CenterLatitude  *float64            `json:"center_latitude"`
+	CenterLongitude *float64            `json:"center_longitude"`
+	RadiusMeters    *float64            `json:"radius_meters"`
+}
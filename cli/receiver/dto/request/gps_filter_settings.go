@@ -0,0 +1,9 @@
+package request
+
+type GpsFilterSettings struct {
+	RequireValid     *bool    `json:"require_valid" binding:"required"`
+	Require3dFix     *bool    `json:"require_3d_fix" binding:"required"`
+	MaxHdop          *float64 `json:"max_hdop"`
+	MaxFutureSeconds *int32   `json:"max_future_seconds"`
+	MaxSpeedKmh      *float64 `json:"max_speed_kmh"`
+}
@@ -0,0 +1,23 @@
+package request
+
+import "github.com/daniil11ru/egts/cli/receiver/dto/other"
+
+type AddOidResolutionRule struct {
+	Priority int32                       `json:"priority"`
+	Type     other.OidResolutionRuleType `json:"type" binding:"required"`
+	Position *other.ImeiSegmentPosition  `json:"position"`
+	Length   *int16                      `json:"length"`
+}
+
+type UpdateOidResolutionRule struct {
+	Priority *int32                       `json:"priority"`
+	Type     *other.OidResolutionRuleType `json:"type"`
+	Position *other.ImeiSegmentPosition   `json:"position"`
+	Length   *int16                       `json:"length"`
+}
+
+type DryRunOidResolution struct {
+	OID   int64                  `json:"oid" binding:"required"`
+	IMEI  *string                `json:"imei"`
+	Rules []AddOidResolutionRule `json:"rules"`
+}
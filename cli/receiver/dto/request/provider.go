@@ -0,0 +1,31 @@
+package request
+
+import "github.com/daniil11ru/egts/cli/receiver/dto/other"
+
+type AddProvider struct {
+	Name            string                   `json:"name" binding:"required"`
+	Port            *int32                   `json:"port"`
+	Transport       *other.ProviderTransport `json:"transport"`
+	AuthRequired    bool                     `json:"auth_required"`
+	Enabled         *bool                    `json:"enabled"`
+	ResolutionRules []AddOidResolutionRule   `json:"resolution_rules"`
+
+	MaxPacketsPerMinute   *int32 `json:"max_packets_per_minute"`
+	MaxNewVehiclesPerHour *int32 `json:"max_new_vehicles_per_hour"`
+}
+
+type UpdateProvider struct {
+	Name         *string                  `json:"name"`
+	Port         *int32                   `json:"port"`
+	Transport    *other.ProviderTransport `json:"transport"`
+	AuthRequired *bool                    `json:"auth_required"`
+	Enabled      *bool                    `json:"enabled"`
+
+	MaxPacketsPerMinute   *int32 `json:"max_packets_per_minute"`
+	MaxNewVehiclesPerHour *int32 `json:"max_new_vehicles_per_hour"`
+}
+
+type AddProviderAllowedNetwork struct {
+	Network     string  `json:"network" binding:"required"`
+	Description *string `json:"description"`
+}
@@ -0,0 +1,6 @@
+package request
+
+type ReprocessQuarantine struct {
+	ProviderID *int32 `json:"provider_id"`
+	OID        *int64 `json:"oid"`
+}
@@ -0,0 +1,16 @@
+package request
+
+type TrackSimplificationSettings struct {
+	Enabled          *bool    `json:"enabled" binding:"required"`
+	ToleranceMeters  *float64 `json:"tolerance_meters" binding:"required"`
+	MaxGapSeconds    *int32   `json:"max_gap_seconds" binding:"required"`
+	StopRadiusMeters *float64 `json:"stop_radius_meters" binding:"required"`
+	StopMinSeconds   *int32   `json:"stop_min_seconds" binding:"required"`
+}
+
+type DryRunTrackSimplification struct {
+	VehicleID      *int32                       `json:"vehicle_id"`
+	ReceivedAfter  *string                      `json:"received_after"`
+	ReceivedBefore *string                      `json:"received_before"`
+	Settings       *TrackSimplificationSettings `json:"settings"`
+}
@@ -6,4 +6,5 @@ type UpdateVehicle struct {
 	IMEI             *string                 `json:"imei"`
 	Name             *string                 `json:"name"`
 	ModerationStatus *other.ModerationStatus `json:"moderation_status"`
+	VehicleGroupID   *int32                  `json:"vehicle_group_id"`
 }
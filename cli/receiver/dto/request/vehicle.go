@@ -0,0 +1,29 @@
+package request
+
+import "github.com/daniil11ru/egts/cli/receiver/dto/other"
+
+type AddVehicle struct {
+	IMEI             string                  `json:"imei" binding:"required"`
+	OID              *int64                  `json:"oid"`
+	Name             *string                 `json:"name"`
+	ProviderID       int32                   `json:"provider_id" binding:"required"`
+	ModerationStatus *other.ModerationStatus `json:"moderation_status"`
+	VehicleGroupID   *int32                  `json:"vehicle_group_id"`
+}
+
+type VehiclesFilter struct {
+	ProviderID       *int32                  `json:"provider_id"`
+	ModerationStatus *other.ModerationStatus `json:"moderation_status"`
+	VehicleGroupID   *int32                  `json:"vehicle_group_id"`
+}
+
+// ModerateVehicles выбирает транспорт либо списком IDs, либо фильтром Filter
+type ModerateVehicles struct {
+	ModerationStatus other.ModerationStatus `json:"moderation_status" binding:"required"`
+	IDs              []int32                `json:"ids"`
+	Filter           *VehiclesFilter        `json:"filter"`
+}
+
+type MergeVehicles struct {
+	DuplicateID int32 `json:"duplicate_id" binding:"required"`
+}
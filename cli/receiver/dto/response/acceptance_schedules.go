@@ -0,0 +1,23 @@
+package response
+
+type AcceptanceSchedule struct {
+	ID              int32   `json:"id"`
+	ProviderID      *int32  `json:"provider_id,omitempty"`
+	VehicleGroupID  *int32  `json:"vehicle_group_id,omitempty"`
+	DateFrom        *string `json:"date_from,omitempty"`
+	DateTo          *string `json:"date_to,omitempty"`
+	RecurringYearly bool    `json:"recurring_yearly"`
+	WeekdayMask     int16   `json:"weekday_mask"`
+	HourMask        int32   `json:"hour_mask"`
+	TimeZone        string  `json:"time_zone"`
+	TimeBasis       string  `json:"time_basis"`
+}
+
+type GetAcceptanceSchedules []AcceptanceSchedule
+
+type VehicleGroup struct {
+	ID   int32  `json:"id"`
+	Name string `json:"name"`
+}
+
+type GetVehicleGroups []VehicleGroup
@@ -0,0 +1,21 @@
+package response
+
+type ApiKey struct {
+	ID         int32    `json:"id"`
+	Name       string   `json:"name"`
+	Scopes     []string `json:"scopes"`
+	ProviderID *int32   `json:"provider_id"`
+	ExpiresAt  *string  `json:"expires_at"`
+	RevokedAt  *string  `json:"revoked_at"`
+	CreatedAt  string   `json:"created_at"`
+	LastUsedAt *string  `json:"last_used_at"`
+	Active     bool     `json:"active"`
+}
+
+type GetApiKeys []ApiKey
+
+// IssuedApiKey возвращается при создании и ротации. Ключ больше нигде не показывается.
+type IssuedApiKey struct {
+	ID  int32  `json:"id"`
+	Key string `json:"key"`
+}
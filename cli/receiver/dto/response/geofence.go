@@ -0,0 +1,26 @@
+package response
+
+type Geofence struct {
+	ID              int32          `json:"id"`
+	Name            string         `json:"name"`
+	Type            string         `json:"type"`
+	Polygon         [][][2]float64 `json:"polygon,omitempty"`
+	CenterLatitude  *float64       `json:"center_latitude,omitempty"`
+	CenterLongitude *float64       `json:"center_longitude,omitempty"`
+	RadiusMeters    *float64       `json:"radius_meters,omitempty"`
+}
+
+type GetGeofences []Geofence
+
+type GeofenceEvent struct {
+	ID         int32   `json:"id"`
+	GeofenceID int32   `json:"geofence_id"`
+	VehicleID  int32   `json:"vehicle_id"`
+	Type       string  `json:"type"`
+	LocationID *int32  `json:"location_id,omitempty"`
+	Latitude   float64 `json:"latitude"`
+	Longitude  float64 `json:"longitude"`
+	OccurredAt string  `json:"occurred_at"`
+}
+
+type GetGeofenceEvents []GeofenceEvent
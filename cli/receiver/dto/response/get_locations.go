@@ -12,6 +12,7 @@ type Location struct {
 	SatelliteCount *int16  `json:"satellite_count,omitempty"`
 	SentAt         *string `json:"sent_at,omitempty"`
 	ReceivedAt     string  `json:"received_at"`
+	IsHistory      bool    `json:"is_history"`
 }
 
 func (l Location) MarshalJSON() ([]byte, error) {
@@ -25,12 +26,14 @@ func (l Location) MarshalJSON() ([]byte, error) {
 		SatelliteCount interface{} `json:"satellite_count,omitempty"`
 		SentAt         interface{} `json:"sent_at,omitempty"`
 		ReceivedAt     string      `json:"received_at"`
+		IsHistory      bool        `json:"is_history"`
 	}
 	o := out{
 		OID:        l.OID,
 		Latitude:   l.Latitude,
 		Longitude:  l.Longitude,
 		ReceivedAt: l.ReceivedAt,
+		IsHistory:  l.IsHistory,
 	}
 	if l.Altitude != nil {
 		o.Altitude = *l.Altitude
@@ -56,3 +59,25 @@ type VehicleTrack struct {
 }
 
 type GetLocations []VehicleTrack
+
+// PagedLocation — местоположение в постраничном списке, где записи разных транспортных средств
+// идут одним списком
+type PagedLocation struct {
+	ID        int32 `json:"id"`
+	VehicleId int32 `json:"vehicle_id"`
+	Location
+}
+
+func (l PagedLocation) MarshalJSON() ([]byte, error) {
+	data, err := l.Location.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	fields["id"], _ = json.Marshal(l.ID)
+	fields["vehicle_id"], _ = json.Marshal(l.VehicleId)
+	return json.Marshal(fields)
+}
@@ -11,6 +11,7 @@ type GetVehicle struct {
 	Name             *string `json:"name,omitempty"`
 	ProviderID       int32   `json:"provider_id"`
 	ModerationStatus string  `json:"moderation_status"`
+	VehicleGroupID   *int32  `json:"vehicle_group_id,omitempty"`
 }
 
 func (v GetVehicle) MarshalJSON() ([]byte, error) {
@@ -21,6 +22,7 @@ func (v GetVehicle) MarshalJSON() ([]byte, error) {
 		Name             interface{} `json:"name,omitempty"`
 		ProviderID       int32       `json:"provider_id"`
 		ModerationStatus string      `json:"moderation_status"`
+		VehicleGroupID   interface{} `json:"vehicle_group_id,omitempty"`
 	}
 	o := out{
 		ID:               v.ID,
@@ -34,6 +36,9 @@ func (v GetVehicle) MarshalJSON() ([]byte, error) {
 	if v.Name != nil {
 		o.Name = *v.Name
 	}
+	if v.VehicleGroupID != nil {
+		o.VehicleGroupID = *v.VehicleGroupID
+	}
 	return json.Marshal(o)
 }
 
@@ -0,0 +1,32 @@
+package response
+
+type GpsFilterSettings struct {
+	ProviderID       int32    `json:"provider_id"`
+	Default          bool     `json:"default"`
+	RequireValid     bool     `json:"require_valid"`
+	Require3dFix     bool     `json:"require_3d_fix"`
+	MaxHdop          *float64 `json:"max_hdop"`
+	MaxFutureSeconds *int32   `json:"max_future_seconds"`
+	MaxSpeedKmh      *float64 `json:"max_speed_kmh"`
+}
+
+type RejectedLocation struct {
+	ID         int32    `json:"id"`
+	ProviderID int32    `json:"provider_id"`
+	OID        int64    `json:"oid"`
+	VehicleID  *int32   `json:"vehicle_id,omitempty"`
+	Rule       string   `json:"rule"`
+	Detail     string   `json:"detail"`
+	Latitude   float64  `json:"latitude"`
+	Longitude  float64  `json:"longitude"`
+	Altitude   *int64   `json:"altitude,omitempty"`
+	Speed      *int32   `json:"speed,omitempty"`
+	Hdop       *float64 `json:"hdop,omitempty"`
+	Valid      bool     `json:"valid"`
+	Fix3d      bool     `json:"fix_3d"`
+	SentAt     *string  `json:"sent_at,omitempty"`
+	ReceivedAt string   `json:"received_at"`
+	RejectedAt string   `json:"rejected_at"`
+}
+
+type GetRejectedLocations []RejectedLocation
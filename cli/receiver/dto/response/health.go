@@ -0,0 +1,32 @@
+package response
+
+type HealthListener struct {
+	ProviderID int32   `json:"provider_id"`
+	Address    string  `json:"address"`
+	State      string  `json:"state"`
+	Error      *string `json:"error"`
+	Since      string  `json:"since"`
+}
+
+type HealthDatabase struct {
+	Available        bool    `json:"available"`
+	Error            *string `json:"error"`
+	MigrationVersion *uint   `json:"migration_version"`
+	MigrationDirty   bool    `json:"migration_dirty"`
+}
+
+type HealthJob struct {
+	Job           string `json:"job"`
+	LastSuccessAt string `json:"last_success_at"`
+}
+
+type Health struct {
+	Status    string           `json:"status"`
+	Problems  []string         `json:"problems"`
+	StartedAt string           `json:"started_at"`
+	CheckedAt string           `json:"checked_at"`
+	Listeners []HealthListener `json:"listeners"`
+	Database  *HealthDatabase  `json:"database,omitempty"`
+	SaveQueue int              `json:"save_queue"`
+	Jobs      []HealthJob      `json:"jobs"`
+}
@@ -0,0 +1,14 @@
+package response
+
+type HistoryBacklog struct {
+	VehicleID         int32   `json:"vehicle_id"`
+	ProviderID        int32   `json:"provider_id"`
+	Count             int64   `json:"count"`
+	OldestSentAt      string  `json:"oldest_sent_at"`
+	NewestSentAt      string  `json:"newest_sent_at"`
+	FirstReceivedAt   string  `json:"first_received_at"`
+	LastReceivedAt    string  `json:"last_received_at"`
+	CurrentLagSeconds float64 `json:"current_lag_seconds"`
+}
+
+type GetHistoryBacklog []HistoryBacklog
@@ -0,0 +1,19 @@
+package response
+
+type LiveLocation struct {
+	LocationID int32   `json:"location_id"`
+	VehicleID  int32   `json:"vehicle_id"`
+	ProviderID int32   `json:"provider_id"`
+	OID        int64   `json:"oid"`
+	Latitude   float64 `json:"latitude"`
+	Longitude  float64 `json:"longitude"`
+	Altitude   int64   `json:"altitude"`
+	Direction  int16   `json:"direction"`
+	Speed      int32   `json:"speed"`
+	SentAt     string  `json:"sent_at"`
+	ReceivedAt string  `json:"received_at"`
+}
+
+type LiveLocationsDropped struct {
+	Count uint64 `json:"count"`
+}
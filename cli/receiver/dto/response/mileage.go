@@ -0,0 +1,26 @@
+package response
+
+type DailyMileage struct {
+	VehicleID                int32    `json:"vehicle_id"`
+	ProviderID               int32    `json:"provider_id"`
+	Date                     string   `json:"date"`
+	DistanceKm               float64  `json:"distance_km"`
+	OdometerDistanceKm       *float64 `json:"odometer_distance_km,omitempty"`
+	OdometerDeviationPercent *float64 `json:"odometer_deviation_percent,omitempty"`
+	MovingSeconds            int64    `json:"moving_seconds"`
+	IdleSeconds              int64    `json:"idle_seconds"`
+	PointCount               int32    `json:"point_count"`
+}
+
+type GetDailyMileage []DailyMileage
+
+type ProviderDailyMileage struct {
+	ProviderID    int32   `json:"provider_id"`
+	Date          string  `json:"date"`
+	VehicleCount  int32   `json:"vehicle_count"`
+	DistanceKm    float64 `json:"distance_km"`
+	MovingSeconds int64   `json:"moving_seconds"`
+	IdleSeconds   int64   `json:"idle_seconds"`
+}
+
+type GetProviderDailyMileage []ProviderDailyMileage
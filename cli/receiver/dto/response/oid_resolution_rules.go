@@ -0,0 +1,25 @@
+package response
+
+type OidResolutionRule struct {
+	ID         int32   `json:"id"`
+	ProviderID int32   `json:"provider_id"`
+	Priority   int32   `json:"priority"`
+	Type       string  `json:"type"`
+	Position   *string `json:"position,omitempty"`
+	Length     *int16  `json:"length,omitempty"`
+}
+
+type GetOidResolutionRules []OidResolutionRule
+
+type OidResolutionStep struct {
+	Rule       OidResolutionRule `json:"rule"`
+	VehicleIDs []int32           `json:"vehicle_ids"`
+	Error      *string           `json:"error,omitempty"`
+}
+
+type DryRunOidResolution struct {
+	Result  string              `json:"result"`
+	Vehicle *GetVehicle         `json:"vehicle,omitempty"`
+	RuleID  *int32              `json:"rule_id,omitempty"`
+	Steps   []OidResolutionStep `json:"steps"`
+}
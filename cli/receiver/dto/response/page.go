@@ -0,0 +1,9 @@
+package response
+
+// Page — страница списка при пагинации по курсору. На последней странице next_cursor и next
+// равны null.
+type Page struct {
+	Items      interface{} `json:"items"`
+	NextCursor *string     `json:"next_cursor"`
+	Next       *string     `json:"next"`
+}
@@ -0,0 +1,25 @@
+package response
+
+type Provider struct {
+	ID              int32               `json:"id"`
+	Name            string              `json:"name"`
+	Port            *int32              `json:"port"`
+	Transport       string              `json:"transport"`
+	AuthRequired    bool                `json:"auth_required"`
+	Enabled         bool                `json:"enabled"`
+	ResolutionRules []OidResolutionRule `json:"resolution_rules,omitempty"`
+
+	MaxPacketsPerMinute   *int32 `json:"max_packets_per_minute"`
+	MaxNewVehiclesPerHour *int32 `json:"max_new_vehicles_per_hour"`
+}
+
+type GetProviders []Provider
+
+type ProviderAllowedNetwork struct {
+	ID          int32   `json:"id"`
+	ProviderID  int32   `json:"provider_id"`
+	Network     string  `json:"network"`
+	Description *string `json:"description"`
+}
+
+type GetProviderAllowedNetworks []ProviderAllowedNetwork
@@ -0,0 +1,46 @@
+package response
+
+type QuarantinedLocation struct {
+	ID             int32    `json:"id"`
+	ProviderID     int32    `json:"provider_id"`
+	OID            int64    `json:"oid"`
+	TerminalIMEI   *string  `json:"terminal_imei,omitempty"`
+	VehicleID      *int32   `json:"vehicle_id,omitempty"`
+	Reason         string   `json:"reason"`
+	Latitude       float64  `json:"latitude"`
+	Longitude      float64  `json:"longitude"`
+	Altitude       *int64   `json:"altitude,omitempty"`
+	Direction      *int16   `json:"direction,omitempty"`
+	Speed          *int32   `json:"speed,omitempty"`
+	SatelliteCount *int16   `json:"satellite_count,omitempty"`
+	Hdop           *float64 `json:"hdop,omitempty"`
+	Valid          bool     `json:"valid"`
+	Fix3d          bool     `json:"fix_3d"`
+	IsHistory      bool     `json:"is_history"`
+	OdometerMeters *int64   `json:"odometer_meters,omitempty"`
+	SentAt         *string  `json:"sent_at,omitempty"`
+	ReceivedAt     string   `json:"received_at"`
+	QuarantinedAt  string   `json:"quarantined_at"`
+}
+
+type GetQuarantinedLocations []QuarantinedLocation
+
+type QuarantineGroup struct {
+	ProviderID      int32   `json:"provider_id"`
+	OID             int64   `json:"oid"`
+	TerminalIMEI    *string `json:"terminal_imei,omitempty"`
+	VehicleID       *int32  `json:"vehicle_id,omitempty"`
+	Reason          string  `json:"reason"`
+	Count           int64   `json:"count"`
+	FirstReceivedAt string  `json:"first_received_at"`
+	LastReceivedAt  string  `json:"last_received_at"`
+}
+
+type GetQuarantineGroups []QuarantineGroup
+
+type ReprocessQuarantine struct {
+	Moved     int64 `json:"moved"`
+	Skipped   int64 `json:"skipped"`
+	Discarded int64 `json:"discarded"`
+	Remaining int64 `json:"remaining"`
+}
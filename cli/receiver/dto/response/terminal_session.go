@@ -0,0 +1,28 @@
+package response
+
+type TerminalSession struct {
+	ID               int32   `json:"id"`
+	ProviderID       int32   `json:"provider_id"`
+	RemoteAddress    string  `json:"remote_address"`
+	TerminalIMEI     *string `json:"terminal_imei"`
+	OID              *int64  `json:"oid"`
+	VehicleID        *int32  `json:"vehicle_id"`
+	ConnectedAt      string  `json:"connected_at"`
+	DisconnectedAt   *string `json:"disconnected_at"`
+	LastPacketAt     *string `json:"last_packet_at"`
+	PacketCount      int32   `json:"packet_count"`
+	RecordCount      int32   `json:"record_count"`
+	DecodeErrorCount int32   `json:"decode_error_count"`
+	CloseReason      *string `json:"close_reason"`
+}
+
+type GetTerminalSessions []TerminalSession
+
+type VehicleStatus struct {
+	VehicleID  int32   `json:"vehicle_id"`
+	ProviderID int32   `json:"provider_id"`
+	Status     string  `json:"status"`
+	LastSeenAt *string `json:"last_seen_at"`
+}
+
+type GetVehicleStatuses []VehicleStatus
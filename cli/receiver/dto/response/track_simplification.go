@@ -0,0 +1,27 @@
+package response
+
+type TrackSimplificationSettings struct {
+	ProviderID       int32   `json:"provider_id"`
+	Default          bool    `json:"default"`
+	Enabled          bool    `json:"enabled"`
+	ToleranceMeters  float64 `json:"tolerance_meters"`
+	MaxGapSeconds    int32   `json:"max_gap_seconds"`
+	StopRadiusMeters float64 `json:"stop_radius_meters"`
+	StopMinSeconds   int32   `json:"stop_min_seconds"`
+}
+
+type VehicleTrackSimplification struct {
+	VehicleID int32 `json:"vehicle_id"`
+	Total     int   `json:"total"`
+	Kept      int   `json:"kept"`
+	Archived  int   `json:"archived"`
+	Segments  int   `json:"segments"`
+	Stops     int   `json:"stops"`
+}
+
+type DryRunTrackSimplification struct {
+	Total    int                          `json:"total"`
+	Kept     int                          `json:"kept"`
+	Archived int                          `json:"archived"`
+	Vehicles []VehicleTrackSimplification `json:"vehicles"`
+}
@@ -0,0 +1,31 @@
+package response
+
+type Trip struct {
+	ID              int32   `json:"id"`
+	VehicleID       int32   `json:"vehicle_id"`
+	StartedAt       string  `json:"started_at"`
+	EndedAt         string  `json:"ended_at"`
+	DurationSeconds int64   `json:"duration_seconds"`
+	StartLatitude   float64 `json:"start_latitude"`
+	StartLongitude  float64 `json:"start_longitude"`
+	EndLatitude     float64 `json:"end_latitude"`
+	EndLongitude    float64 `json:"end_longitude"`
+	DistanceMeters  float64 `json:"distance_meters"`
+	MaxSpeed        *int32  `json:"max_speed,omitempty"`
+	PointCount      int32   `json:"point_count"`
+}
+
+type GetTrips []Trip
+
+type Stop struct {
+	ID              int32   `json:"id"`
+	VehicleID       int32   `json:"vehicle_id"`
+	StartedAt       string  `json:"started_at"`
+	EndedAt         string  `json:"ended_at"`
+	DurationSeconds int64   `json:"duration_seconds"`
+	Latitude        float64 `json:"latitude"`
+	Longitude       float64 `json:"longitude"`
+	PointCount      int32   `json:"point_count"`
+}
+
+type GetStops []Stop
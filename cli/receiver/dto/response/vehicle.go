@@ -0,0 +1,14 @@
+package response
+
+type ModerateVehicles struct {
+	// Количество выбранного транспорта
+	Matched int `json:"matched"`
+	// ID транспорта, статус модерации которого изменился
+	Updated []int32 `json:"updated"`
+}
+
+type MergeVehicles struct {
+	MovedLocations int64 `json:"moved_locations"`
+	// Точки дубликата с временем отправки, которое у транспорта уже было; удалены вместе с дубликатом
+	SkippedLocations int64 `json:"skipped_locations"`
+}
@@ -0,0 +1,16 @@
+package response
+
+import "encoding/json"
+
+type VehicleChange struct {
+	ID         int64           `json:"id"`
+	Action     string          `json:"action"`
+	Source     string          `json:"source"`
+	ApiKeyID   *int32          `json:"api_key_id,omitempty"`
+	ApiKeyName *string         `json:"api_key_name,omitempty"`
+	OldValues  json.RawMessage `json:"old_values"`
+	NewValues  json.RawMessage `json:"new_values"`
+	ChangedAt  string          `json:"changed_at"`
+}
+
+type GetVehicleHistory []VehicleChange
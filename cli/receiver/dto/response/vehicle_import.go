@@ -0,0 +1,25 @@
+package response
+
+type VehicleImportRow struct {
+	Row       int     `json:"row"`
+	Action    string  `json:"action"`
+	VehicleID *int32  `json:"vehicle_id,omitempty"`
+	IMEI      string  `json:"imei"`
+	OID       *int64  `json:"oid,omitempty"`
+	Name      *string `json:"name,omitempty"`
+	Reason    *string `json:"reason,omitempty"`
+}
+
+type VehicleImportSummary struct {
+	Insert    int `json:"insert"`
+	Update    int `json:"update"`
+	Unchanged int `json:"unchanged"`
+	Conflict  int `json:"conflict"`
+}
+
+type VehicleImport struct {
+	DryRun  bool                 `json:"dry_run"`
+	Applied bool                 `json:"applied"`
+	Summary VehicleImportSummary `json:"summary"`
+	Rows    []VehicleImportRow   `json:"rows"`
+}
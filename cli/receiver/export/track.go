@@ -0,0 +1,337 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	"github.com/xuri/excelize/v2"
+)
+
+// Format — формат выгрузки трека
+type Format string
+
+const (
+	FormatGeoJSON Format = "geojson"
+	FormatGPX     Format = "gpx"
+	FormatKML     Format = "kml"
+	FormatCSV     Format = "csv"
+	FormatXLSX    Format = "xlsx"
+)
+
+func (f Format) IsValid() bool {
+	return f == FormatGeoJSON || f == FormatGPX || f == FormatKML || f == FormatCSV || f == FormatXLSX
+}
+
+func (f Format) ContentType() string {
+	switch f {
+	case FormatGeoJSON:
+		return "application/geo+json"
+	case FormatGPX:
+		return "application/gpx+xml"
+	case FormatKML:
+		return "application/vnd.google-earth.kml+xml"
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "application/octet-stream"
+}
+
+func (f Format) String() string {
+	return string(f)
+}
+
+// TrackWriter записывает точки трека по мере их чтения из базы данных, не накапливая трек в
+// памяти. Close дописывает окончание документа; после ошибки записи документ неполон.
+type TrackWriter interface {
+	WritePoint(point out.Location) error
+	Close() error
+}
+
+// NewTrackWriter создает запись трека с названием name в формате format. Начало документа
+// записывается сразу, поэтому трек без точек тоже является корректным документом. GeoJSON, GPX и
+// KML содержат время в UTC в формате RFC 3339, CSV и XLSX — в формате formatTime.
+func NewTrackWriter(format Format, w io.Writer, name string, formatTime func(time.Time) string) (TrackWriter, error) {
+	switch format {
+	case FormatGeoJSON:
+		return newGeoJSONWriter(w, name)
+	case FormatGPX:
+		return newGPXWriter(w, name)
+	case FormatKML:
+		return newKMLWriter(w, name)
+	case FormatCSV:
+		return newCSVWriter(w, formatTime)
+	case FormatXLSX:
+		return newXLSXWriter(w, formatTime)
+	}
+	return nil, fmt.Errorf("неизвестный формат выгрузки трека %q", format)
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func formatUTC(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+func escapeXML(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+type geoJSONWriter struct {
+	w     io.Writer
+	count int
+}
+
+type geoJSONFeature struct {
+	Type       string            `json:"type"`
+	Geometry   geoJSONPoint      `json:"geometry"`
+	Properties geoJSONProperties `json:"properties"`
+}
+
+type geoJSONPoint struct {
+	Type        string    `json:"type"`
+	Coordinates []float64 `json:"coordinates"`
+}
+
+type geoJSONProperties struct {
+	ID             int32   `json:"id"`
+	SentAt         *string `json:"sent_at"`
+	ReceivedAt     string  `json:"received_at"`
+	Speed          *int32  `json:"speed"`
+	Direction      *int16  `json:"direction"`
+	SatelliteCount *int16  `json:"satellite_count"`
+	IsHistory      bool    `json:"is_history"`
+}
+
+func newGeoJSONWriter(w io.Writer, name string) (*geoJSONWriter, error) {
+	nameJSON, err := json.Marshal(name)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := fmt.Fprintf(w, `{"type":"FeatureCollection","name":%s,"features":[`, nameJSON); err != nil {
+		return nil, err
+	}
+	return &geoJSONWriter{w: w}, nil
+}
+
+func (g *geoJSONWriter) WritePoint(point out.Location) error {
+	coordinates := []float64{point.Longitude, point.Latitude}
+	if point.Altitude != nil {
+		coordinates = append(coordinates, float64(*point.Altitude))
+	}
+	feature := geoJSONFeature{
+		Type:     "Feature",
+		Geometry: geoJSONPoint{Type: "Point", Coordinates: coordinates},
+		Properties: geoJSONProperties{
+			ID:             point.ID,
+			ReceivedAt:     formatUTC(point.ReceivedAt),
+			Speed:          point.Speed,
+			Direction:      point.Direction,
+			SatelliteCount: point.SatelliteCount,
+			IsHistory:      point.IsHistory,
+		},
+	}
+	if point.SentAt != nil {
+		sentAt := formatUTC(*point.SentAt)
+		feature.Properties.SentAt = &sentAt
+	}
+
+	data, err := json.Marshal(feature)
+	if err != nil {
+		return err
+	}
+	if g.count > 0 {
+		if _, err := io.WriteString(g.w, ","); err != nil {
+			return err
+		}
+	}
+	g.count++
+	_, err = g.w.Write(data)
+	return err
+}
+
+func (g *geoJSONWriter) Close() error {
+	_, err := io.WriteString(g.w, "]}")
+	return err
+}
+
+type gpxWriter struct {
+	w io.Writer
+}
+
+func newGPXWriter(w io.Writer, name string) (*gpxWriter, error) {
+	_, err := fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?>`+"\n"+
+		`<gpx version="1.1" creator="egts" xmlns="http://www.topografix.com/GPX/1/1">`+"\n"+
+		"<trk><name>%s</name><trkseg>\n", escapeXML(name))
+	if err != nil {
+		return nil, err
+	}
+	return &gpxWriter{w: w}, nil
+}
+
+func (g *gpxWriter) WritePoint(point out.Location) error {
+	trkpt := fmt.Sprintf(`<trkpt lat="%s" lon="%s">`, formatFloat(point.Latitude), formatFloat(point.Longitude))
+	if point.Altitude != nil {
+		trkpt += "<ele>" + strconv.FormatInt(*point.Altitude, 10) + "</ele>"
+	}
+	if point.SentAt != nil {
+		trkpt += "<time>" + formatUTC(*point.SentAt) + "</time>"
+	}
+	if point.SatelliteCount != nil {
+		trkpt += "<sat>" + strconv.Itoa(int(*point.SatelliteCount)) + "</sat>"
+	}
+	trkpt += "</trkpt>\n"
+	_, err := io.WriteString(g.w, trkpt)
+	return err
+}
+
+func (g *gpxWriter) Close() error {
+	_, err := io.WriteString(g.w, "</trkseg></trk>\n</gpx>\n")
+	return err
+}
+
+type kmlWriter struct {
+	w io.Writer
+}
+
+func newKMLWriter(w io.Writer, name string) (*kmlWriter, error) {
+	escaped := escapeXML(name)
+	_, err := fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?>`+"\n"+
+		`<kml xmlns="http://www.opengis.net/kml/2.2">`+"\n"+
+		"<Document><name>%s</name><Placemark><name>%s</name><LineString><coordinates>\n", escaped, escaped)
+	if err != nil {
+		return nil, err
+	}
+	return &kmlWriter{w: w}, nil
+}
+
+func (k *kmlWriter) WritePoint(point out.Location) error {
+	coordinates := formatFloat(point.Longitude) + "," + formatFloat(point.Latitude)
+	if point.Altitude != nil {
+		coordinates += "," + strconv.FormatInt(*point.Altitude, 10)
+	}
+	_, err := io.WriteString(k.w, coordinates+"\n")
+	return err
+}
+
+func (k *kmlWriter) Close() error {
+	_, err := io.WriteString(k.w, "</coordinates></LineString></Placemark></Document>\n</kml>\n")
+	return err
+}
+
+var csvHeader = []string{"id", "sent_at", "received_at", "latitude", "longitude", "altitude", "direction", "speed", "satellite_count", "is_history"}
+
+func optionalInt[T int16 | int32 | int64](v *T) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatInt(int64(*v), 10)
+}
+
+type csvWriter struct {
+	w          *csv.Writer
+	formatTime func(time.Time) string
+}
+
+func newCSVWriter(w io.Writer, formatTime func(time.Time) string) (*csvWriter, error) {
+	c := &csvWriter{w: csv.NewWriter(w), formatTime: formatTime}
+	if err := c.w.Write(csvHeader); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *csvWriter) WritePoint(point out.Location) error {
+	sentAt := ""
+	if point.SentAt != nil {
+		sentAt = c.formatTime(*point.SentAt)
+	}
+	return c.w.Write([]string{
+		strconv.Itoa(int(point.ID)),
+		sentAt,
+		c.formatTime(point.ReceivedAt),
+		formatFloat(point.Latitude),
+		formatFloat(point.Longitude),
+		optionalInt(point.Altitude),
+		optionalInt(point.Direction),
+		optionalInt(point.Speed),
+		optionalInt(point.SatelliteCount),
+		strconv.FormatBool(point.IsHistory),
+	})
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+var xlsxHeader = []interface{}{"ID", "Время отправки", "Время получения", "Широта", "Долгота", "Высота", "Направление", "Скорость", "Количество спутников", "Из истории"}
+
+// xlsxWriter записывает строки потоком excelize, который при большом количестве строк хранит их во
+// временном файле. Документ отправляется целиком при закрытии, так как XLSX — это ZIP-архив.
+type xlsxWriter struct {
+	w          io.Writer
+	file       *excelize.File
+	stream     *excelize.StreamWriter
+	row        int
+	formatTime func(time.Time) string
+}
+
+func newXLSXWriter(w io.Writer, formatTime func(time.Time) string) (*xlsxWriter, error) {
+	file := excelize.NewFile()
+	stream, err := file.NewStreamWriter("Sheet1")
+	if err != nil {
+		return nil, err
+	}
+	if err := stream.SetRow("A1", xlsxHeader); err != nil {
+		return nil, err
+	}
+	return &xlsxWriter{w: w, file: file, stream: stream, row: 1, formatTime: formatTime}, nil
+}
+
+func (x *xlsxWriter) WritePoint(point out.Location) error {
+	row := []interface{}{point.ID, nil, x.formatTime(point.ReceivedAt), point.Latitude, point.Longitude, nil, nil, nil, nil, point.IsHistory}
+	if point.SentAt != nil {
+		row[1] = x.formatTime(*point.SentAt)
+	}
+	if point.Altitude != nil {
+		row[5] = *point.Altitude
+	}
+	if point.Direction != nil {
+		row[6] = *point.Direction
+	}
+	if point.Speed != nil {
+		row[7] = *point.Speed
+	}
+	if point.SatelliteCount != nil {
+		row[8] = *point.SatelliteCount
+	}
+
+	x.row++
+	cell, err := excelize.CoordinatesToCellName(1, x.row)
+	if err != nil {
+		return err
+	}
+	return x.stream.SetRow(cell, row)
+}
+
+func (x *xlsxWriter) Close() error {
+	defer x.file.Close()
+	if err := x.stream.Flush(); err != nil {
+		return err
+	}
+	_, err := x.file.WriteTo(x.w)
+	return err
+}
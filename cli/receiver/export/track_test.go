@@ -0,0 +1,111 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	"github.com/stretchr/testify/assert"
+	"github.com/xuri/excelize/v2"
+)
+
+func trackPoints() []out.Location {
+	sentAt := time.Date(2025, 7, 1, 9, 50, 43, 0, time.FixedZone("MSK", 3*60*60))
+	altitude := int64(120)
+	speed := int32(60)
+	return []out.Location{
+		{ID: 1, VehicleId: 7, Latitude: 63.5, Longitude: 48.75, Altitude: &altitude, Speed: &speed, SentAt: &sentAt, ReceivedAt: sentAt.Add(time.Minute)},
+		{ID: 2, VehicleId: 7, Latitude: 63.25, Longitude: 48.5, SentAt: &sentAt, ReceivedAt: sentAt.Add(2 * time.Minute), IsHistory: true},
+	}
+}
+
+func writeTrack(t *testing.T, format Format, name string, points []out.Location) []byte {
+	var buf bytes.Buffer
+	w, err := NewTrackWriter(format, &buf, name, func(t time.Time) string { return t.UTC().Format("02.01.2006 15:04:05") })
+	if !assert.NoError(t, err) {
+		return nil
+	}
+	for _, point := range points {
+		assert.NoError(t, w.WritePoint(point))
+	}
+	assert.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestGeoJSONTrack(t *testing.T) {
+	data := writeTrack(t, FormatGeoJSON, "О810СМ11", trackPoints())
+
+	var collection struct {
+		Type     string `json:"type"`
+		Name     string `json:"name"`
+		Features []struct {
+			Geometry struct {
+				Coordinates []float64 `json:"coordinates"`
+			} `json:"geometry"`
+			Properties map[string]interface{} `json:"properties"`
+		} `json:"features"`
+	}
+	if !assert.NoError(t, json.Unmarshal(data, &collection)) {
+		return
+	}
+	assert.Equal(t, "FeatureCollection", collection.Type)
+	assert.Equal(t, "О810СМ11", collection.Name)
+	if !assert.Len(t, collection.Features, 2) {
+		return
+	}
+	assert.Equal(t, []float64{48.75, 63.5, 120}, collection.Features[0].Geometry.Coordinates)
+	assert.Equal(t, []float64{48.5, 63.25}, collection.Features[1].Geometry.Coordinates)
+	assert.Equal(t, "2025-07-01T06:50:43Z", collection.Features[0].Properties["sent_at"])
+	assert.Equal(t, true, collection.Features[1].Properties["is_history"])
+
+	empty := writeTrack(t, FormatGeoJSON, "", nil)
+	assert.JSONEq(t, `{"type":"FeatureCollection","name":"","features":[]}`, string(empty))
+}
+
+func TestGPXTrack(t *testing.T) {
+	data := string(writeTrack(t, FormatGPX, "A&B", trackPoints()))
+
+	assert.Contains(t, data, "<trk><name>A&amp;B</name><trkseg>")
+	assert.Contains(t, data, `<trkpt lat="63.5" lon="48.75"><ele>120</ele><time>2025-07-01T06:50:43Z</time></trkpt>`)
+	assert.Contains(t, data, `<trkpt lat="63.25" lon="48.5"><time>2025-07-01T06:50:43Z</time></trkpt>`)
+	assert.Contains(t, data, "</trkseg></trk>\n</gpx>\n")
+}
+
+func TestKMLTrack(t *testing.T) {
+	data := string(writeTrack(t, FormatKML, "О810СМ11", trackPoints()))
+
+	assert.Contains(t, data, "<Placemark><name>О810СМ11</name><LineString><coordinates>\n48.75,63.5,120\n48.5,63.25\n</coordinates>")
+	assert.Contains(t, data, "</kml>\n")
+}
+
+func TestCSVTrack(t *testing.T) {
+	data := string(writeTrack(t, FormatCSV, "", trackPoints()))
+
+	assert.Equal(t, "id,sent_at,received_at,latitude,longitude,altitude,direction,speed,satellite_count,is_history\n"+
+		"1,01.07.2025 06:50:43,01.07.2025 06:51:43,63.5,48.75,120,,60,,false\n"+
+		"2,01.07.2025 06:50:43,01.07.2025 06:52:43,63.25,48.5,,,,,true\n", data)
+}
+
+func TestXLSXTrack(t *testing.T) {
+	data := writeTrack(t, FormatXLSX, "", trackPoints())
+
+	f, err := excelize.OpenReader(bytes.NewReader(data))
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer f.Close()
+	rows, err := f.GetRows("Sheet1")
+	if !assert.NoError(t, err) || !assert.Len(t, rows, 3) {
+		return
+	}
+	assert.Equal(t, "Время отправки", rows[0][1])
+	assert.Equal(t, []string{"1", "01.07.2025 06:50:43", "01.07.2025 06:51:43", "63.5", "48.75", "120", "", "60", "", "FALSE"}, rows[1])
+}
+
+func TestUnknownFormat(t *testing.T) {
+	assert.False(t, Format("shp").IsValid())
+	_, err := NewTrackWriter(Format("shp"), &bytes.Buffer{}, "", nil)
+	assert.Error(t, err)
+}
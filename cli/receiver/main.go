@@ -6,12 +6,15 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"strconv"
 	"time"
 
 	"github.com/daniil11ru/egts/cli/receiver/api"
 	arepo "github.com/daniil11ru/egts/cli/receiver/api/repository"
+	"github.com/daniil11ru/egts/cli/receiver/archive"
+	"github.com/daniil11ru/egts/cli/receiver/broker"
+	"github.com/daniil11ru/egts/cli/receiver/cache"
 	"github.com/daniil11ru/egts/cli/receiver/config"
+	"github.com/daniil11ru/egts/cli/receiver/metrics"
 	"github.com/daniil11ru/egts/cli/receiver/server"
 	"github.com/daniil11ru/egts/cli/receiver/server/domain"
 	srepo "github.com/daniil11ru/egts/cli/receiver/server/repository"
@@ -28,6 +31,13 @@ import (
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 )
 
+// listenerRetryInterval — пауза перед повторной попыткой открыть порт провайдера
+const listenerRetryInterval = 10 * time.Second
+
+// providerSyncInterval — период сверки серверов с провайдерами в базе данных на случай изменений,
+// внесенных в обход API
+const providerSyncInterval = 30 * time.Second
+
 type ServerSettings struct {
 	Host                           string
 	ProviderIdToPort               map[int32]int
@@ -35,21 +45,33 @@ type ServerSettings struct {
 	SaveTelematicsDataMonthStart   int
 	SaveTelematicsDataMonthEnd     int
 	OptimizeGeometryCronExpression string
+	TripDetectionCronExpression    string
+	TripDetection                  domain.TripDetectionSettings
+	LastPositionCache              *cache.LastPosition
+	IngestCache                    *domain.IngestCache
+	LocationBroker                 *broker.Locations
+	TerminalSessionFlushInterval   time.Duration
+	PacketArchivePath              string
+	PacketArchiveRetentionDays     int
+	Health                         *domain.Health
+	Reloads                        <-chan config.Config
+	ProviderChanges                domain.ProviderChanges
+	TimeZone                       *time.Location
 }
 
 func (s *ServerSettings) GetEmptyConnectionTtl() time.Duration {
-	return time.Duration(s.ConnectionTtl) * time.Second
-}
-func (s *ServerSettings) GetListenAddresses() map[int32]string {
-	addresses := make(map[int32]string)
-	for providerID, port := range s.ProviderIdToPort {
-		addresses[providerID] = s.Host + ":" + strconv.Itoa(int(port))
-	}
-	return addresses
+	return connectionTtl(s.ConnectionTtl)
 }
 
 type ApiSettings struct {
-	Port int
+	Port              int
+	LastPositionCache *cache.LastPosition
+	IngestCache       *domain.IngestCache
+	LocationBroker    *broker.Locations
+	TerminalStatus    domain.TerminalStatusPolicy
+	Health            *domain.Health
+	ProviderChanges   domain.ProviderChanges
+	TimeZone          *time.Location
 }
 
 type LoggingSettings struct {
@@ -80,45 +102,107 @@ func main() {
 	configFilePath := ""
 	flag.StringVar(&configFilePath, "c", "", "")
 	flag.Parse()
-	config, err := getConfig(configFilePath)
+	cfg, err := getConfig(configFilePath)
 	if err != nil {
 		log.Fatalf("Не удалось получить конфиг: %v", err)
 		return
 	}
 
 	configureLogging(LoggingSettings{
-		LogLevel:      config.LogLevel,
-		LogFilePath:   config.LogFilePath,
-		LogMaxAgeDays: config.LogMaxAgeDays,
+		LogLevel:      cfg.LogLevel,
+		LogFilePath:   cfg.LogFilePath,
+		LogMaxAgeDays: cfg.LogMaxAgeDays,
 	})
 
-	err = applyMigrations(fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=%s",
-		config.Store["user"], config.Store["password"], config.Store["host"], config.Store["port"], config.Store["database"], config.Store["sslmode"]), config.MigrationsPath)
+	err = applyMigrations(cfg.Storage.URL(), cfg.MigrationsPath)
 	if err != nil {
 		log.Fatalf("Не удалось применить миграции: %v", err)
 		return
 	}
 
-	primarySource, err := source.NewDefaultPrimary(fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s", config.Store["host"], config.Store["user"], config.Store["password"], config.Store["database"], config.Store["port"], config.Store["sslmode"]))
+	timeZone, err := time.LoadLocation(cfg.TimeZone)
+	if err != nil {
+		log.Fatalf("Не удалось загрузить временную зону %s: %v", cfg.TimeZone, err)
+		return
+	}
+
+	primarySource, err := source.NewDefaultPrimary(cfg.Storage.DSN())
 	if err != nil {
 		log.Fatalf("Не удалось инициализировать источник данных: %v", err)
 		return
 	}
 
+	if flag.Arg(0) == "replay" {
+		runReplay(primarySource, cfg, timeZone, flag.Args()[1:])
+		return
+	}
+	if flag.Arg(0) == "create-api-key" {
+		runCreateApiKey(primarySource, timeZone, flag.Args()[1:])
+		return
+	}
+
+	cacheRepository := srepo.Primary{Source: primarySource}
+	lastPositionCache := cache.NewLastPosition(
+		cacheRepository.GetLastVehiclePoint,
+		time.Duration(cfg.LastPositionCacheTtl)*time.Second,
+		cfg.LastPositionCacheCapacity,
+	)
+	ingestCache := domain.NewIngestCache(cacheRepository)
+	locationBroker := broker.NewLocations(cfg.LocationStreamBufferSize)
+	reloads := make(chan config.Config)
+	providerChanges := domain.NewProviderChanges()
+	health := domain.NewHealth(cacheRepository, domain.HealthPolicy{MaxSaveQueue: cfg.ReadinessMaxSaveQueue}, server.SaveQueueDepth)
+	metrics.RegisterSaveQueue(server.SaveQueueDepth)
+	metrics.RegisterLastPositionCache(lastPositionCache)
+
 	go runServer(primarySource, ServerSettings{
-		Host:                           config.Host,
-		ProviderIdToPort:               config.ProviderIdToPort,
-		ConnectionTtl:                  config.ConnectionTtl,
-		SaveTelematicsDataMonthStart:   config.SaveTelematicsDataMonthStart,
-		SaveTelematicsDataMonthEnd:     config.SaveTelematicsDataMonthEnd,
-		OptimizeGeometryCronExpression: config.OptimizeGeometryCronExpression,
+		Host:                           cfg.Host,
+		ProviderIdToPort:               cfg.ProviderIdToPort,
+		ConnectionTtl:                  cfg.ConnectionTtl,
+		SaveTelematicsDataMonthStart:   cfg.SaveTelematicsDataMonthStart,
+		SaveTelematicsDataMonthEnd:     cfg.SaveTelematicsDataMonthEnd,
+		OptimizeGeometryCronExpression: cfg.OptimizeGeometryCronExpression,
+		TripDetectionCronExpression:    cfg.TripDetectionCronExpression,
+		TripDetection:                  tripDetectionSettings(cfg),
+		LastPositionCache:              lastPositionCache,
+		IngestCache:                    ingestCache,
+		LocationBroker:                 locationBroker,
+		TerminalSessionFlushInterval:   time.Duration(cfg.TerminalSessionFlushSeconds) * time.Second,
+		PacketArchivePath:              cfg.PacketArchivePath,
+		PacketArchiveRetentionDays:     cfg.PacketArchiveRetentionDays,
+		Health:                         health,
+		Reloads:                        reloads,
+		ProviderChanges:                providerChanges,
+		TimeZone:                       timeZone,
 	})
 
 	go runApi(primarySource, ApiSettings{
-		Port: config.ApiPort,
+		Port:              cfg.ApiPort,
+		LastPositionCache: lastPositionCache,
+		IngestCache:       ingestCache,
+		LocationBroker:    locationBroker,
+		TerminalStatus:    domain.TerminalStatusPolicy{StaleAfter: time.Duration(cfg.TerminalStaleSeconds) * time.Second},
+		Health:            health,
+		ProviderChanges:   providerChanges,
+		TimeZone:          timeZone,
 	})
 
-	select {}
+	watchReload(configFilePath, cfg, reloads)
+}
+
+func tripDetectionSettings(c config.Config) domain.TripDetectionSettings {
+	return domain.TripDetectionSettings{
+		StopSpeedKmh:          c.TripStopSpeedKmh,
+		StopRadiusMeters:      c.TripStopRadiusMeters,
+		StopMinSeconds:        c.TripStopMinSeconds,
+		MaxGapSeconds:         c.TripMaxGapSeconds,
+		MinTripDistanceMeters: c.TripMinDistanceMeters,
+	}
+}
+
+// connectionTtl переводит время жизни пустого соединения из секунд конфига в time.Duration
+func connectionTtl(seconds int) time.Duration {
+	return time.Duration(seconds) * time.Second
 }
 
 func getConfig(configFilePath string) (config.Config, error) {
@@ -180,37 +264,124 @@ func runServer(source source.Primary, settings ServerSettings) {
 
 	savePacket, err := domain.NewSavePacket(
 		primaryRepository,
+		settings.LastPositionCache,
+		settings.IngestCache,
+		settings.LocationBroker,
 		settings.SaveTelematicsDataMonthStart,
 		settings.SaveTelematicsDataMonthEnd,
+		settings.TimeZone,
 	)
 	if err != nil {
-		log.Fatalf("Не удалось инициализировать кэш: %v", err)
+		log.Fatalf("Не удалось инициализировать сохранение телематических данных: %v", err)
 		return
 	}
 
 	defer savePacket.Shutdown()
 
-	optimizeGeometry := domain.OptimizeGeometry{PrimaryRepository: primaryRepository}
-	c := cron.New()
-	c.AddFunc(settings.OptimizeGeometryCronExpression, func() { optimizeGeometry.Run() })
+	optimizeGeometry := domain.OptimizeGeometry{PrimaryRepository: primaryRepository, LastPositionCache: settings.LastPositionCache}
+	c := cron.NewWithLocation(settings.TimeZone)
+	c.AddFunc(settings.OptimizeGeometryCronExpression, func() {
+		startedAt := time.Now()
+		err := optimizeGeometry.Run()
+		metrics.ObserveJob("optimize_geometry", startedAt, err)
+	})
+	detectTrips := &domain.DetectTrips{PrimaryRepository: primaryRepository, Settings: settings.TripDetection, TimeZone: settings.TimeZone}
+	c.AddFunc(settings.TripDetectionCronExpression, func() {
+		startedAt := time.Now()
+		err := detectTrips.Run()
+		metrics.ObserveJob("trip_detection", startedAt, err)
+		if err != nil {
+			log.Errorf("Ошибка построения поездок и стоянок: %v", err)
+		}
+	})
+
+	var frameArchive *archive.Writer
+	if settings.PacketArchivePath != "" {
+		frameArchive, err = archive.NewWriter(settings.PacketArchivePath, 5*time.Second)
+		if err != nil {
+			log.Fatalf("Не удалось открыть архив кадров: %v", err)
+			return
+		}
+		defer frameArchive.Close()
+		log.Infof("Принятые кадры сохраняются в архив %s", settings.PacketArchivePath)
+
+		if settings.PacketArchiveRetentionDays > 0 {
+			c.AddFunc("0 30 4 * * *", func() {
+				startedAt := time.Now()
+				removed, err := archive.Prune(settings.PacketArchivePath, time.Now().AddDate(0, 0, -settings.PacketArchiveRetentionDays))
+				metrics.ObserveJob("packet_archive_prune", startedAt, err)
+				if err != nil {
+					log.Errorf("Ошибка очистки архива кадров: %v", err)
+					return
+				}
+				log.Infof("Из архива кадров удалено дней: %d", removed)
+			})
+		}
+	}
+
 	c.Start()
 	log.Info("Запланирована ежедневная оптимизация геометрии треков")
+	log.Info("Запланировано построение поездок и стоянок транспорта")
 
-	for providerID, addr := range settings.GetListenAddresses() {
-		srv := server.NewServer(addr, settings.GetEmptyConnectionTtl(), providerID, savePacket)
-		go func(a string, s *server.Server) {
-			if err := s.Run(); err != nil {
-				log.Fatalf("Не удалось запустить сервер на %s: %v", a, err)
-			}
-		}(addr, srv)
+	terminalSessions := &domain.TerminalSessions{PrimaryRepository: primaryRepository, FlushInterval: settings.TerminalSessionFlushInterval}
+
+	listeners := &server.Listeners{
+		SavePacket:    savePacket,
+		Sessions:      terminalSessions,
+		Archive:       frameArchive,
+		Health:        settings.Health,
+		RetryInterval: listenerRetryInterval,
+	}
+
+	if err := domain.ImportProviderPorts(primaryRepository, settings.ProviderIdToPort); err != nil {
+		log.Errorf("Не удалось перенести порты провайдеров из конфига: %v", err)
 	}
 
-	select {}
+	host, ttl := settings.Host, settings.GetEmptyConnectionTtl()
+	syncListeners := func() {
+		providers, err := primaryRepository.GetAllProviders()
+		if err != nil {
+			log.Errorf("Не удалось получить провайдеров: %v", err)
+			return
+		}
+		networks, err := primaryRepository.GetAllProviderAllowedNetworks()
+		if err != nil {
+			log.Errorf("Не удалось получить разрешенные сети провайдеров: %v", err)
+			return
+		}
+		listeners.Apply(server.ProviderListenerSettings(providers, networks, host), ttl)
+	}
+	syncListeners()
+
+	ticker := time.NewTicker(providerSyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case c := <-settings.Reloads:
+			host, ttl = c.Host, connectionTtl(c.ConnectionTtl)
+			savePacket.SetAcceptanceMonths(c.SaveTelematicsDataMonthStart, c.SaveTelematicsDataMonthEnd)
+			detectTrips.SetSettings(tripDetectionSettings(c))
+			syncListeners()
+		case <-settings.ProviderChanges:
+			syncListeners()
+		case <-ticker.C:
+			syncListeners()
+		}
+	}
 }
 
 func runApi(source source.Primary, apiSettings ApiSettings) {
 	businessDataRepository := arepo.NewBusinessDataDefault(source)
-	handler := api.NewHandler(businessDataRepository)
+	// Точки из карантина сохраняются тем же путем, что и принятые приемником
+	quarantineSavePacket := &domain.SavePacket{
+		PrimaryRepository: srepo.Primary{Source: source},
+		LastPositionCache: apiSettings.LastPositionCache,
+		IngestCache:       apiSettings.IngestCache,
+		LocationBroker:    apiSettings.LocationBroker,
+	}
+	reprocessQuarantine := &domain.ReprocessQuarantine{PrimaryRepository: srepo.Primary{Source: source}, SavePacket: quarantineSavePacket}
+	trackSimplifier := &domain.OptimizeGeometry{PrimaryRepository: srepo.Primary{Source: source}, LastPositionCache: apiSettings.LastPositionCache}
+	handler := api.NewHandler(businessDataRepository, reprocessQuarantine, apiSettings.LastPositionCache, apiSettings.IngestCache, trackSimplifier, apiSettings.LocationBroker, apiSettings.TerminalStatus, apiSettings.Health, apiSettings.ProviderChanges, apiSettings.TimeZone)
 	additionalDataRepository := arepo.NewAdditionalDataDefault(source)
 	controller, err := api.NewController(handler, additionalDataRepository)
 	if err != nil {
@@ -0,0 +1,147 @@
+package metrics
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/daniil11ru/egts/cli/receiver/cache"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Default — реестр метрик приемника, доступный по /metrics
+var Default = prometheus.NewRegistry()
+
+var factory = promauto.With(Default)
+
+var (
+	Connections = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "egts_connections_total",
+		Help: "Принятые соединения по провайдеру и порту",
+	}, []string{"provider_id", "port"})
+	ActiveConnections = factory.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "egts_active_connections",
+		Help: "Открытые соединения по провайдеру и порту",
+	}, []string{"provider_id", "port"})
+	RejectedConnections = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "egts_rejected_connections_total",
+		Help: "Соединения, закрытые контролем доступа, по причине",
+	}, []string{"provider_id", "reason"})
+	Packets = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "egts_packets_total",
+		Help: "Принятые пакеты по типу и коду результата разбора",
+	}, []string{"provider_id", "packet_type", "result_code"})
+	Records = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "egts_records_total",
+		Help: "Записи пакетов EGTS_PT_APPDATA по типу сервиса и статусу подтверждения",
+	}, []string{"provider_id", "service_type", "status"})
+	DecodeErrors = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "egts_decode_errors_total",
+		Help: "Пакеты, которые не удалось разобрать, по причине",
+	}, []string{"provider_id", "reason"})
+	UnsupportedSubrecords = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "egts_unsupported_subrecords_total",
+		Help: "Подзаписи неподдерживаемых типов по SRT",
+	}, []string{"provider_id", "srt"})
+	SaveDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "egts_save_duration_seconds",
+		Help:    "Время обработки и сохранения одного местоположения",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"result"})
+	DroppedPoints = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "egts_dropped_points_total",
+		Help: "Местоположения, не попавшие в трек, по причине",
+	}, []string{"reason"})
+	JobDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "egts_job_duration_seconds",
+		Help:    "Длительность фоновых задач",
+		Buckets: []float64{0.1, 0.5, 1, 5, 10, 30, 60, 300, 900, 1800},
+	}, []string{"job", "result"})
+	JobLastSuccess = factory.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "egts_job_last_success_timestamp_seconds",
+		Help: "Время последнего успешного выполнения фоновой задачи",
+	}, []string{"job"})
+	ApiRequestDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "egts_api_request_duration_seconds",
+		Help:    "Время обработки запросов API по маршруту",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+)
+
+// jobSuccesses хранит время последнего успешного выполнения фоновых задач для проверки состояния
+var jobSuccesses = struct {
+	mu sync.Mutex
+	at map[string]time.Time
+}{at: make(map[string]time.Time)}
+
+// Handler отдает метрики реестра Default в формате Prometheus
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Default, promhttp.HandlerOpts{})
+}
+
+// ObserveJob записывает длительность фоновой задачи, начатой в startedAt, и время ее последнего
+// успешного выполнения
+func ObserveJob(job string, startedAt time.Time, err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	JobDuration.WithLabelValues(job, result).Observe(time.Since(startedAt).Seconds())
+	if err == nil {
+		now := time.Now()
+		JobLastSuccess.WithLabelValues(job).Set(float64(now.Unix()))
+		jobSuccesses.mu.Lock()
+		jobSuccesses.at[job] = now
+		jobSuccesses.mu.Unlock()
+	}
+}
+
+// RegisterSaveQueue добавляет метрику количества местоположений, ожидающих сохранения, по
+// значению, которое ведет очередь сохранения
+func RegisterSaveQueue(depth func() int) {
+	factory.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "egts_save_queue_depth",
+		Help: "Местоположения, принятые от терминалов и ожидающие сохранения",
+	}, func() float64 { return float64(depth()) })
+}
+
+// RegisterLastPositionCache добавляет метрики кэша последних местоположений по его статистике
+func RegisterLastPositionCache(c *cache.LastPosition) {
+	counters := []struct {
+		name, help string
+		value      func(stats cache.LastPositionStats) uint64
+	}{
+		{"egts_last_position_cache_hits_total", "Обращения к кэшу последних местоположений, обслуженные из кэша",
+			func(stats cache.LastPositionStats) uint64 { return stats.Hits }},
+		{"egts_last_position_cache_misses_total", "Обращения к кэшу последних местоположений, потребовавшие загрузки из базы данных",
+			func(stats cache.LastPositionStats) uint64 { return stats.Misses }},
+		{"egts_last_position_cache_load_errors_total", "Ошибки загрузки последних местоположений из базы данных",
+			func(stats cache.LastPositionStats) uint64 { return stats.LoadErrors }},
+		{"egts_last_position_cache_evictions_total", "Записи, вытесненные из кэша последних местоположений или устаревшие",
+			func(stats cache.LastPositionStats) uint64 { return stats.Evictions }},
+	}
+	for _, counter := range counters {
+		value := counter.value
+		factory.NewCounterFunc(prometheus.CounterOpts{Name: counter.name, Help: counter.help}, func() float64 {
+			return float64(value(c.Stats()))
+		})
+	}
+	factory.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "egts_last_position_cache_size",
+		Help: "Записи в кэше последних местоположений",
+	}, func() float64 { return float64(c.Stats().Size) })
+}
+
+// LastJobSuccesses возвращает время последнего успешного выполнения каждой фоновой задачи, которая
+// хотя бы раз завершилась успешно
+func LastJobSuccesses() map[string]time.Time {
+	jobSuccesses.mu.Lock()
+	defer jobSuccesses.mu.Unlock()
+	successes := make(map[string]time.Time, len(jobSuccesses.at))
+	for job, at := range jobSuccesses.at {
+		successes[job] = at
+	}
+	return successes
+}
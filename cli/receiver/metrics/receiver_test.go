@@ -0,0 +1,58 @@
+package metrics
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/daniil11ru/egts/cli/receiver/cache"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObserveJob(t *testing.T) {
+	ObserveJob("test_job_error", time.Now(), errors.New("нет соединения"))
+	ObserveJob("test_job", time.Now(), nil)
+
+	successes := LastJobSuccesses()
+	assert.Contains(t, successes, "test_job")
+	assert.NotContains(t, successes, "test_job_error")
+	assert.WithinDuration(t, time.Now(), successes["test_job"], 2*time.Second)
+}
+
+func TestHandler(t *testing.T) {
+	DroppedPoints.WithLabelValues("duplicate").Inc()
+	RegisterSaveQueue(func() int { return 3 })
+
+	recorder := httptest.NewRecorder()
+	Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+
+	assert.Equal(t, 200, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `egts_dropped_points_total{reason="duplicate"} 1`)
+	assert.Contains(t, recorder.Body.String(), "egts_save_queue_depth 3")
+}
+
+func TestRegisterLastPositionCache(t *testing.T) {
+	c := cache.NewLastPosition(func(vehicleId int32) (out.Point, error) {
+		if vehicleId == 2 {
+			return out.Point{}, errors.New("нет соединения")
+		}
+		return out.Point{LocationId: vehicleId}, nil
+	}, time.Hour, 1000)
+	RegisterLastPositionCache(c)
+
+	_, _, _ = c.Get(1)
+	_, _, _ = c.Get(1)
+	_, _, _ = c.Get(2)
+
+	recorder := httptest.NewRecorder()
+	Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+
+	body := recorder.Body.String()
+	assert.Contains(t, body, "egts_last_position_cache_hits_total 1")
+	assert.Contains(t, body, "egts_last_position_cache_misses_total 2")
+	assert.Contains(t, body, "egts_last_position_cache_load_errors_total 1")
+	assert.Contains(t, body, "egts_last_position_cache_evictions_total 0")
+	assert.Contains(t, body, "egts_last_position_cache_size 1")
+}
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS oid_resolution_rule;
+
+DROP TYPE IF EXISTS imei_segment_position;
+DROP TYPE IF EXISTS oid_resolution_rule_type;
@@ -0,0 +1,24 @@
+CREATE TYPE oid_resolution_rule_type AS ENUM (
+  'exact_oid',
+  'imei_digits',
+  'imei_bytes',
+  'imei_max_digits',
+  'term_identity_imei'
+);
+
+CREATE TYPE imei_segment_position AS ENUM (
+  'start',
+  'end'
+);
+
+CREATE TABLE oid_resolution_rule (
+    id SERIAL PRIMARY KEY,
+    provider_id int4 NOT NULL,
+    priority int4 NOT NULL DEFAULT 0,
+    type oid_resolution_rule_type NOT NULL,
+    position imei_segment_position,
+    length int2,
+    CONSTRAINT oid_resolution_rule_provider_id_fkey FOREIGN KEY (provider_id) REFERENCES provider(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX oid_resolution_rule_provider_id_idx ON oid_resolution_rule (provider_id, priority);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS quarantined_location;
+
+DROP TYPE IF EXISTS quarantine_reason;
@@ -0,0 +1,26 @@
+CREATE TYPE quarantine_reason AS ENUM (
+  'ambiguous_vehicle',
+  'pending_vehicle'
+);
+
+CREATE TABLE quarantined_location (
+    id SERIAL PRIMARY KEY,
+    provider_id int4 NOT NULL,
+    "oid" BIGINT NOT NULL,
+    terminal_imei VARCHAR(15) NOT NULL DEFAULT '',
+    vehicle_id int4,
+    reason quarantine_reason NOT NULL,
+    latitude FLOAT8 NOT NULL,
+    longitude FLOAT8 NOT NULL,
+    altitude BIGINT,
+    direction SMALLINT,
+    speed INTEGER,
+    satellite_count SMALLINT,
+    sent_at TIMESTAMP,
+    received_at TIMESTAMP NOT NULL,
+    quarantined_at TIMESTAMP NOT NULL DEFAULT now(),
+    CONSTRAINT quarantined_location_provider_id_fkey FOREIGN KEY (provider_id) REFERENCES provider(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT quarantined_location_vehicle_id_fkey FOREIGN KEY (vehicle_id) REFERENCES vehicle(id) ON DELETE SET NULL ON UPDATE CASCADE
+);
+
+CREATE INDEX quarantined_location_provider_id_oid_idx ON quarantined_location (provider_id, "oid");
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS acceptance_schedule;
+
+DROP TYPE IF EXISTS acceptance_time_basis;
+
+ALTER TABLE vehicle
+  DROP CONSTRAINT IF EXISTS vehicle_vehicle_group_id_fkey,
+  DROP COLUMN IF EXISTS vehicle_group_id;
+
+DROP TABLE IF EXISTS vehicle_group;
@@ -0,0 +1,30 @@
+CREATE TABLE vehicle_group (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL UNIQUE
+);
+
+ALTER TABLE vehicle
+  ADD COLUMN vehicle_group_id int4,
+  ADD CONSTRAINT vehicle_vehicle_group_id_fkey FOREIGN KEY (vehicle_group_id) REFERENCES vehicle_group(id) ON DELETE SET NULL ON UPDATE CASCADE;
+
+CREATE TYPE acceptance_time_basis AS ENUM (
+  'navigation',
+  'received',
+  'both'
+);
+
+CREATE TABLE acceptance_schedule (
+    id SERIAL PRIMARY KEY,
+    provider_id int4,
+    vehicle_group_id int4,
+    date_from DATE,
+    date_to DATE,
+    recurring_yearly BOOLEAN NOT NULL DEFAULT FALSE,
+    weekday_mask int2 NOT NULL DEFAULT 127,
+    hour_mask int4 NOT NULL DEFAULT 16777215,
+    time_zone VARCHAR(64) NOT NULL DEFAULT 'Europe/Moscow',
+    time_basis acceptance_time_basis NOT NULL DEFAULT 'both',
+    CONSTRAINT acceptance_schedule_provider_id_fkey FOREIGN KEY (provider_id) REFERENCES provider(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT acceptance_schedule_vehicle_group_id_fkey FOREIGN KEY (vehicle_group_id) REFERENCES vehicle_group(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT acceptance_schedule_scope_check CHECK (provider_id IS NULL OR vehicle_group_id IS NULL)
+);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS rejected_location;
+
+DROP TYPE IF EXISTS gps_filter_rule;
+
+DROP TABLE IF EXISTS gps_filter_settings;
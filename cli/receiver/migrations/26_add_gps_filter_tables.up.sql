@@ -0,0 +1,41 @@
+CREATE TABLE gps_filter_settings (
+    provider_id int4 PRIMARY KEY,
+    require_valid BOOLEAN NOT NULL DEFAULT TRUE,
+    require_3d_fix BOOLEAN NOT NULL DEFAULT FALSE,
+    max_hdop REAL,
+    max_future_seconds int4,
+    max_speed_kmh REAL,
+    CONSTRAINT gps_filter_settings_provider_id_fkey FOREIGN KEY (provider_id) REFERENCES provider(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE TYPE gps_filter_rule AS ENUM (
+  'invalid_position',
+  'no_3d_fix',
+  'high_hdop',
+  'future_time',
+  'impossible_speed'
+);
+
+CREATE TABLE rejected_location (
+    id SERIAL PRIMARY KEY,
+    provider_id int4 NOT NULL,
+    "oid" BIGINT NOT NULL,
+    vehicle_id int4,
+    rule gps_filter_rule NOT NULL,
+    detail TEXT NOT NULL DEFAULT '',
+    latitude FLOAT8 NOT NULL,
+    longitude FLOAT8 NOT NULL,
+    altitude BIGINT,
+    speed INTEGER,
+    hdop REAL,
+    valid BOOLEAN NOT NULL,
+    fix_3d BOOLEAN NOT NULL,
+    sent_at TIMESTAMP,
+    received_at TIMESTAMP NOT NULL,
+    rejected_at TIMESTAMP NOT NULL DEFAULT now(),
+    CONSTRAINT rejected_location_provider_id_fkey FOREIGN KEY (provider_id) REFERENCES provider(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT rejected_location_vehicle_id_fkey FOREIGN KEY (vehicle_id) REFERENCES vehicle(id) ON DELETE SET NULL ON UPDATE CASCADE
+);
+
+CREATE INDEX rejected_location_provider_id_rule_idx ON rejected_location (provider_id, rule);
+CREATE INDEX rejected_location_vehicle_id_idx ON rejected_location (vehicle_id);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS location_vehicle_id_received_at_history_idx;
+
+ALTER TABLE location
+  DROP COLUMN IF EXISTS is_history;
@@ -0,0 +1,4 @@
+ALTER TABLE location
+  ADD COLUMN is_history BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX location_vehicle_id_received_at_history_idx ON location (vehicle_id, received_at) WHERE is_history;
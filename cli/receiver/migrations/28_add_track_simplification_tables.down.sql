@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS archived_location;
+
+DROP TABLE IF EXISTS track_simplification_settings;
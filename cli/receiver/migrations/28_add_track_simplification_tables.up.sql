@@ -0,0 +1,28 @@
+CREATE TABLE track_simplification_settings (
+    provider_id int4 PRIMARY KEY,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    tolerance_meters REAL NOT NULL DEFAULT 10,
+    max_gap_seconds int4 NOT NULL DEFAULT 300,
+    stop_radius_meters REAL NOT NULL DEFAULT 30,
+    stop_min_seconds int4 NOT NULL DEFAULT 180,
+    CONSTRAINT track_simplification_settings_provider_id_fkey FOREIGN KEY (provider_id) REFERENCES provider(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE TABLE archived_location (
+    id int4 PRIMARY KEY,
+    vehicle_id int4 NOT NULL,
+    "oid" BIGINT NOT NULL,
+    latitude FLOAT8,
+    longitude FLOAT8,
+    altitude BIGINT,
+    direction SMALLINT,
+    speed INTEGER,
+    satellite_count SMALLINT,
+    sent_at TIMESTAMP,
+    received_at TIMESTAMP NOT NULL,
+    is_history BOOLEAN NOT NULL DEFAULT FALSE,
+    archived_at TIMESTAMP NOT NULL DEFAULT now(),
+    CONSTRAINT archived_location_vehicle_id_fkey FOREIGN KEY (vehicle_id) REFERENCES vehicle(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX archived_location_vehicle_id_sent_at_idx ON archived_location (vehicle_id, sent_at);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS trip_detection_state;
+
+DROP TABLE IF EXISTS vehicle_stop;
+
+DROP TABLE IF EXISTS vehicle_trip;
@@ -0,0 +1,37 @@
+CREATE TABLE vehicle_trip (
+    id SERIAL PRIMARY KEY,
+    vehicle_id int4 NOT NULL,
+    started_at TIMESTAMP NOT NULL,
+    ended_at TIMESTAMP NOT NULL,
+    start_latitude FLOAT8 NOT NULL,
+    start_longitude FLOAT8 NOT NULL,
+    end_latitude FLOAT8 NOT NULL,
+    end_longitude FLOAT8 NOT NULL,
+    distance_meters FLOAT8 NOT NULL,
+    max_speed INTEGER,
+    point_count INTEGER NOT NULL,
+    CONSTRAINT vehicle_trip_vehicle_id_fkey FOREIGN KEY (vehicle_id) REFERENCES vehicle(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX vehicle_trip_vehicle_id_started_at_idx ON vehicle_trip (vehicle_id, started_at);
+
+CREATE TABLE vehicle_stop (
+    id SERIAL PRIMARY KEY,
+    vehicle_id int4 NOT NULL,
+    started_at TIMESTAMP NOT NULL,
+    ended_at TIMESTAMP NOT NULL,
+    latitude FLOAT8 NOT NULL,
+    longitude FLOAT8 NOT NULL,
+    point_count INTEGER NOT NULL,
+    CONSTRAINT vehicle_stop_vehicle_id_fkey FOREIGN KEY (vehicle_id) REFERENCES vehicle(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX vehicle_stop_vehicle_id_started_at_idx ON vehicle_stop (vehicle_id, started_at);
+
+-- Идентификатор последней точки, учтенной при построении поездок и стоянок
+CREATE TABLE trip_detection_state (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    last_location_id int4 NOT NULL DEFAULT 0
+);
+
+INSERT INTO trip_detection_state (id, last_location_id) VALUES (TRUE, 0);
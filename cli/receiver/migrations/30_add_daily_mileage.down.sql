@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS daily_mileage;
+
+ALTER TABLE archived_location
+  DROP COLUMN IF EXISTS odometer_meters;
+
+ALTER TABLE location
+  DROP COLUMN IF EXISTS odometer_meters;
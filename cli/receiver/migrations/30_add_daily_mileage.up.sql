@@ -0,0 +1,24 @@
+-- Показание одометра терминала в метрах, если терминал его передает
+ALTER TABLE location
+  ADD COLUMN odometer_meters BIGINT;
+
+ALTER TABLE archived_location
+  ADD COLUMN odometer_meters BIGINT;
+
+CREATE TABLE daily_mileage (
+    vehicle_id int4 NOT NULL,
+    "day" DATE NOT NULL,
+    distance_meters FLOAT8 NOT NULL,
+    odometer_start_meters BIGINT,
+    odometer_end_meters BIGINT,
+    moving_seconds BIGINT NOT NULL,
+    idle_seconds BIGINT NOT NULL,
+    point_count INTEGER NOT NULL,
+    PRIMARY KEY (vehicle_id, "day"),
+    CONSTRAINT daily_mileage_vehicle_id_fkey FOREIGN KEY (vehicle_id) REFERENCES vehicle(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX daily_mileage_day_idx ON daily_mileage ("day");
+
+-- Пробег строится вместе с поездками, поэтому все точки обрабатываются заново
+UPDATE trip_detection_state SET last_location_id = 0;
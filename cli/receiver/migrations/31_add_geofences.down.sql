@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS geofence_event;
+DROP TABLE IF EXISTS geofence;
@@ -0,0 +1,30 @@
+CREATE TABLE geofence (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    type VARCHAR(16) NOT NULL CHECK (type IN ('polygon', 'circle')),
+    -- Кольца полигона в формате координат GeoJSON: первое кольцо внешнее, остальные — вырезы
+    polygon JSONB,
+    center_latitude FLOAT8,
+    center_longitude FLOAT8,
+    radius_meters FLOAT8,
+    CONSTRAINT geofence_shape_check CHECK (
+        (type = 'polygon' AND polygon IS NOT NULL) OR
+        (type = 'circle' AND center_latitude IS NOT NULL AND center_longitude IS NOT NULL AND radius_meters > 0)
+    )
+);
+
+CREATE TABLE geofence_event (
+    id SERIAL PRIMARY KEY,
+    geofence_id int4 NOT NULL,
+    vehicle_id int4 NOT NULL,
+    type VARCHAR(8) NOT NULL CHECK (type IN ('enter', 'exit')),
+    location_id int4,
+    latitude FLOAT8 NOT NULL,
+    longitude FLOAT8 NOT NULL,
+    occurred_at TIMESTAMP NOT NULL,
+    CONSTRAINT geofence_event_geofence_id_fkey FOREIGN KEY (geofence_id) REFERENCES geofence(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT geofence_event_vehicle_id_fkey FOREIGN KEY (vehicle_id) REFERENCES vehicle(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX geofence_event_geofence_id_occurred_at_idx ON geofence_event (geofence_id, occurred_at);
+CREATE INDEX geofence_event_vehicle_id_occurred_at_idx ON geofence_event (vehicle_id, occurred_at);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS terminal_session_vehicle;
+DROP TABLE IF EXISTS terminal_session;
@@ -0,0 +1,37 @@
+CREATE TABLE terminal_session (
+    id SERIAL PRIMARY KEY,
+    provider_id int4 NOT NULL,
+    remote_address VARCHAR(64) NOT NULL,
+    terminal_imei VARCHAR(32),
+    -- OID и транспорт из последней записи сессии, по которой удалось определить транспорт
+    oid int8,
+    vehicle_id int4,
+    connected_at TIMESTAMP NOT NULL,
+    disconnected_at TIMESTAMP,
+    last_packet_at TIMESTAMP,
+    packet_count int4 NOT NULL DEFAULT 0,
+    record_count int4 NOT NULL DEFAULT 0,
+    decode_error_count int4 NOT NULL DEFAULT 0,
+    close_reason VARCHAR(32),
+    CONSTRAINT terminal_session_provider_id_fkey FOREIGN KEY (provider_id) REFERENCES provider(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT terminal_session_vehicle_id_fkey FOREIGN KEY (vehicle_id) REFERENCES vehicle(id) ON DELETE SET NULL ON UPDATE CASCADE
+);
+
+CREATE INDEX terminal_session_provider_id_connected_at_idx ON terminal_session (provider_id, connected_at);
+CREATE INDEX terminal_session_open_idx ON terminal_session (provider_id) WHERE disconnected_at IS NULL;
+
+-- Транспорт, данные которого поступали в рамках сессии: через одно соединение ретранслятора
+-- может передаваться информация о многих единицах транспорта
+CREATE TABLE terminal_session_vehicle (
+    session_id int4 NOT NULL,
+    vehicle_id int4 NOT NULL,
+    oid int8 NOT NULL,
+    first_seen_at TIMESTAMP NOT NULL,
+    last_seen_at TIMESTAMP NOT NULL,
+    record_count int4 NOT NULL DEFAULT 0,
+    PRIMARY KEY (session_id, vehicle_id),
+    CONSTRAINT terminal_session_vehicle_session_id_fkey FOREIGN KEY (session_id) REFERENCES terminal_session(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT terminal_session_vehicle_vehicle_id_fkey FOREIGN KEY (vehicle_id) REFERENCES vehicle(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX terminal_session_vehicle_vehicle_id_last_seen_at_idx ON terminal_session_vehicle (vehicle_id, last_seen_at);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS location_vehicle_id_sent_at_idx;
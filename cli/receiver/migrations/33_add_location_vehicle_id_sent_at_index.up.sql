@@ -0,0 +1,3 @@
+-- Используется для поиска последней точки транспорта и для пропуска уже сохраненных точек при
+-- повторной обработке архива кадров
+CREATE INDEX IF NOT EXISTS location_vehicle_id_sent_at_idx ON location (vehicle_id, sent_at);
@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS provider_port_idx;
+
+ALTER TABLE provider
+    DROP CONSTRAINT IF EXISTS provider_port_check,
+    DROP COLUMN IF EXISTS enabled,
+    DROP COLUMN IF EXISTS auth_required,
+    DROP COLUMN IF EXISTS transport,
+    DROP COLUMN IF EXISTS port;
+
+DROP TYPE IF EXISTS provider_transport;
@@ -0,0 +1,12 @@
+CREATE TYPE provider_transport AS ENUM (
+  'tcp'
+);
+
+ALTER TABLE provider
+    ADD COLUMN port int4,
+    ADD COLUMN transport provider_transport NOT NULL DEFAULT 'tcp',
+    ADD COLUMN auth_required boolean NOT NULL DEFAULT false,
+    ADD COLUMN enabled boolean NOT NULL DEFAULT true,
+    ADD CONSTRAINT provider_port_check CHECK (port BETWEEN 1 AND 65535);
+
+CREATE UNIQUE INDEX provider_port_idx ON provider (port);
@@ -0,0 +1,17 @@
+DROP TABLE IF EXISTS provider_allowed_network;
+
+ALTER TABLE provider
+    DROP CONSTRAINT IF EXISTS provider_max_new_vehicles_per_hour_check,
+    DROP CONSTRAINT IF EXISTS provider_max_packets_per_minute_check,
+    DROP COLUMN IF EXISTS max_new_vehicles_per_hour,
+    DROP COLUMN IF EXISTS max_packets_per_minute;
+
+DELETE FROM quarantined_location WHERE reason = 'vehicle_creation_limit';
+
+ALTER TYPE quarantine_reason RENAME TO quarantine_reason_old;
+CREATE TYPE quarantine_reason AS ENUM (
+  'ambiguous_vehicle',
+  'pending_vehicle'
+);
+ALTER TABLE quarantined_location ALTER COLUMN reason TYPE quarantine_reason USING reason::text::quarantine_reason;
+DROP TYPE quarantine_reason_old;
@@ -0,0 +1,18 @@
+ALTER TYPE quarantine_reason ADD VALUE IF NOT EXISTS 'vehicle_creation_limit';
+
+ALTER TABLE provider
+    ADD COLUMN max_packets_per_minute int4,
+    ADD COLUMN max_new_vehicles_per_hour int4,
+    ADD CONSTRAINT provider_max_packets_per_minute_check CHECK (max_packets_per_minute > 0),
+    ADD CONSTRAINT provider_max_new_vehicles_per_hour_check CHECK (max_new_vehicles_per_hour > 0);
+
+-- Сети, с адресов которых разрешено подключаться к порту провайдера. Если для провайдера не задано
+-- ни одной сети, подключение разрешено с любого адреса.
+CREATE TABLE provider_allowed_network (
+    id SERIAL PRIMARY KEY,
+    provider_id int4 NOT NULL,
+    network cidr NOT NULL,
+    description VARCHAR(255),
+    CONSTRAINT provider_allowed_network_provider_id_fkey FOREIGN KEY (provider_id) REFERENCES provider(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT provider_allowed_network_provider_id_network_key UNIQUE (provider_id, network)
+);
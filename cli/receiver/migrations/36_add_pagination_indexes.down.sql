@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS vehicle_imei_idx;
+DROP INDEX IF EXISTS location_received_at_id_idx;
+DROP INDEX IF EXISTS location_sent_at_id_idx;
+DROP INDEX IF EXISTS location_vehicle_id_received_at_idx;
@@ -0,0 +1,10 @@
+-- Используются при постраничной выдаче местоположений транспорта, упорядоченных по времени
+-- получения, и при выборке всех местоположений без фильтра по транспорту.
+-- Миграции применяются в транзакции, поэтому индексы на location строятся без CONCURRENTLY и на всё
+-- время построения блокируют запись точек. На большой таблице их следует создать заранее вне
+-- миграции (см. раздел «Обновление базы данных» в README) — тогда здесь они будут пропущены.
+CREATE INDEX IF NOT EXISTS location_vehicle_id_received_at_idx ON location (vehicle_id, received_at, id);
+CREATE INDEX IF NOT EXISTS location_sent_at_id_idx ON location (sent_at, id);
+CREATE INDEX IF NOT EXISTS location_received_at_id_idx ON location (received_at, id);
+
+CREATE INDEX IF NOT EXISTS vehicle_imei_idx ON vehicle (imei);
@@ -0,0 +1,39 @@
+-- Возвращает хранение временных меток без временной зоны по московскому времени
+ALTER TABLE location
+    ALTER COLUMN sent_at TYPE TIMESTAMP USING sent_at AT TIME ZONE 'Europe/Moscow',
+    ALTER COLUMN received_at TYPE TIMESTAMP USING received_at AT TIME ZONE 'Europe/Moscow';
+
+ALTER TABLE quarantined_location
+    ALTER COLUMN sent_at TYPE TIMESTAMP USING sent_at AT TIME ZONE 'Europe/Moscow',
+    ALTER COLUMN received_at TYPE TIMESTAMP USING received_at AT TIME ZONE 'Europe/Moscow',
+    ALTER COLUMN quarantined_at TYPE TIMESTAMP USING quarantined_at AT TIME ZONE 'Europe/Moscow';
+
+ALTER TABLE rejected_location
+    ALTER COLUMN sent_at TYPE TIMESTAMP USING sent_at AT TIME ZONE 'Europe/Moscow',
+    ALTER COLUMN received_at TYPE TIMESTAMP USING received_at AT TIME ZONE 'Europe/Moscow',
+    ALTER COLUMN rejected_at TYPE TIMESTAMP USING rejected_at AT TIME ZONE 'Europe/Moscow';
+
+ALTER TABLE archived_location
+    ALTER COLUMN sent_at TYPE TIMESTAMP USING sent_at AT TIME ZONE 'Europe/Moscow',
+    ALTER COLUMN received_at TYPE TIMESTAMP USING received_at AT TIME ZONE 'Europe/Moscow',
+    ALTER COLUMN archived_at TYPE TIMESTAMP USING archived_at AT TIME ZONE 'Europe/Moscow';
+
+ALTER TABLE vehicle_trip
+    ALTER COLUMN started_at TYPE TIMESTAMP USING started_at AT TIME ZONE 'Europe/Moscow',
+    ALTER COLUMN ended_at TYPE TIMESTAMP USING ended_at AT TIME ZONE 'Europe/Moscow';
+
+ALTER TABLE vehicle_stop
+    ALTER COLUMN started_at TYPE TIMESTAMP USING started_at AT TIME ZONE 'Europe/Moscow',
+    ALTER COLUMN ended_at TYPE TIMESTAMP USING ended_at AT TIME ZONE 'Europe/Moscow';
+
+ALTER TABLE geofence_event
+    ALTER COLUMN occurred_at TYPE TIMESTAMP USING occurred_at AT TIME ZONE 'Europe/Moscow';
+
+ALTER TABLE terminal_session
+    ALTER COLUMN connected_at TYPE TIMESTAMP USING connected_at AT TIME ZONE 'Europe/Moscow',
+    ALTER COLUMN disconnected_at TYPE TIMESTAMP USING disconnected_at AT TIME ZONE 'Europe/Moscow',
+    ALTER COLUMN last_packet_at TYPE TIMESTAMP USING last_packet_at AT TIME ZONE 'Europe/Moscow';
+
+ALTER TABLE terminal_session_vehicle
+    ALTER COLUMN first_seen_at TYPE TIMESTAMP USING first_seen_at AT TIME ZONE 'Europe/Moscow',
+    ALTER COLUMN last_seen_at TYPE TIMESTAMP USING last_seen_at AT TIME ZONE 'Europe/Moscow';
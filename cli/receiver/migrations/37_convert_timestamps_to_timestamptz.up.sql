@@ -0,0 +1,41 @@
+-- До этой миграции временные метки хранились без временной зоны по московскому времени. Они
+-- переводятся в timestamptz как московское время и далее хранятся как моменты времени, которые
+-- выводятся в UTC или в зоне, запрошенной клиентом.
+ALTER TABLE location
+    ALTER COLUMN sent_at TYPE TIMESTAMPTZ USING sent_at AT TIME ZONE 'Europe/Moscow',
+    ALTER COLUMN received_at TYPE TIMESTAMPTZ USING received_at AT TIME ZONE 'Europe/Moscow';
+
+ALTER TABLE quarantined_location
+    ALTER COLUMN sent_at TYPE TIMESTAMPTZ USING sent_at AT TIME ZONE 'Europe/Moscow',
+    ALTER COLUMN received_at TYPE TIMESTAMPTZ USING received_at AT TIME ZONE 'Europe/Moscow',
+    ALTER COLUMN quarantined_at TYPE TIMESTAMPTZ USING quarantined_at AT TIME ZONE 'Europe/Moscow';
+
+ALTER TABLE rejected_location
+    ALTER COLUMN sent_at TYPE TIMESTAMPTZ USING sent_at AT TIME ZONE 'Europe/Moscow',
+    ALTER COLUMN received_at TYPE TIMESTAMPTZ USING received_at AT TIME ZONE 'Europe/Moscow',
+    ALTER COLUMN rejected_at TYPE TIMESTAMPTZ USING rejected_at AT TIME ZONE 'Europe/Moscow';
+
+ALTER TABLE archived_location
+    ALTER COLUMN sent_at TYPE TIMESTAMPTZ USING sent_at AT TIME ZONE 'Europe/Moscow',
+    ALTER COLUMN received_at TYPE TIMESTAMPTZ USING received_at AT TIME ZONE 'Europe/Moscow',
+    ALTER COLUMN archived_at TYPE TIMESTAMPTZ USING archived_at AT TIME ZONE 'Europe/Moscow';
+
+ALTER TABLE vehicle_trip
+    ALTER COLUMN started_at TYPE TIMESTAMPTZ USING started_at AT TIME ZONE 'Europe/Moscow',
+    ALTER COLUMN ended_at TYPE TIMESTAMPTZ USING ended_at AT TIME ZONE 'Europe/Moscow';
+
+ALTER TABLE vehicle_stop
+    ALTER COLUMN started_at TYPE TIMESTAMPTZ USING started_at AT TIME ZONE 'Europe/Moscow',
+    ALTER COLUMN ended_at TYPE TIMESTAMPTZ USING ended_at AT TIME ZONE 'Europe/Moscow';
+
+ALTER TABLE geofence_event
+    ALTER COLUMN occurred_at TYPE TIMESTAMPTZ USING occurred_at AT TIME ZONE 'Europe/Moscow';
+
+ALTER TABLE terminal_session
+    ALTER COLUMN connected_at TYPE TIMESTAMPTZ USING connected_at AT TIME ZONE 'Europe/Moscow',
+    ALTER COLUMN disconnected_at TYPE TIMESTAMPTZ USING disconnected_at AT TIME ZONE 'Europe/Moscow',
+    ALTER COLUMN last_packet_at TYPE TIMESTAMPTZ USING last_packet_at AT TIME ZONE 'Europe/Moscow';
+
+ALTER TABLE terminal_session_vehicle
+    ALTER COLUMN first_seen_at TYPE TIMESTAMPTZ USING first_seen_at AT TIME ZONE 'Europe/Moscow',
+    ALTER COLUMN last_seen_at TYPE TIMESTAMPTZ USING last_seen_at AT TIME ZONE 'Europe/Moscow';
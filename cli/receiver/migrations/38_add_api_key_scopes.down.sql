@@ -0,0 +1,10 @@
+ALTER TABLE api_key
+    DROP CONSTRAINT IF EXISTS api_key_admin_provider_check,
+    DROP CONSTRAINT IF EXISTS api_key_scopes_check,
+    DROP CONSTRAINT IF EXISTS api_key_provider_id_fkey,
+    DROP COLUMN IF EXISTS last_used_at,
+    DROP COLUMN IF EXISTS created_at,
+    DROP COLUMN IF EXISTS revoked_at,
+    DROP COLUMN IF EXISTS expires_at,
+    DROP COLUMN IF EXISTS provider_id,
+    DROP COLUMN IF EXISTS scopes;
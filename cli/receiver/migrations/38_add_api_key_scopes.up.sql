@@ -0,0 +1,16 @@
+-- Существующие ключи сохраняют полный доступ
+ALTER TABLE api_key
+    ADD COLUMN scopes TEXT[] NOT NULL DEFAULT '{admin}',
+    ADD COLUMN provider_id int4,
+    ADD COLUMN expires_at TIMESTAMPTZ,
+    ADD COLUMN revoked_at TIMESTAMPTZ,
+    ADD COLUMN created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    ADD COLUMN last_used_at TIMESTAMPTZ,
+    ADD CONSTRAINT api_key_provider_id_fkey FOREIGN KEY (provider_id) REFERENCES provider(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    ADD CONSTRAINT api_key_scopes_check CHECK (
+        cardinality(scopes) > 0
+        AND scopes <@ ARRAY['read_vehicles', 'read_locations', 'moderate', 'admin']::TEXT[]
+    ),
+    ADD CONSTRAINT api_key_admin_provider_check CHECK (provider_id IS NULL OR NOT 'admin' = ANY(scopes));
+
+ALTER TABLE api_key ALTER COLUMN scopes DROP DEFAULT;
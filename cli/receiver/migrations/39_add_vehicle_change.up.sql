@@ -0,0 +1,18 @@
+-- Журнал изменений реестра транспорта. Внешнего ключа на vehicle нет, чтобы журнал сохранялся
+-- после удаления транспорта.
+CREATE TABLE vehicle_change (
+    id BIGSERIAL PRIMARY KEY,
+    vehicle_id int4 NOT NULL,
+    action VARCHAR(16) NOT NULL,
+    source VARCHAR(16) NOT NULL,
+    api_key_id int4,
+    -- Прежние и новые значения только измененных полей
+    old_values JSONB NOT NULL,
+    new_values JSONB NOT NULL,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT vehicle_change_action_check CHECK (action IN ('create', 'update')),
+    CONSTRAINT vehicle_change_source_check CHECK (source IN ('api', 'receiver')),
+    CONSTRAINT vehicle_change_api_key_id_fkey FOREIGN KEY (api_key_id) REFERENCES api_key(id) ON DELETE SET NULL ON UPDATE CASCADE
+);
+
+CREATE INDEX vehicle_change_vehicle_id_changed_at_idx ON vehicle_change (vehicle_id, changed_at);
@@ -0,0 +1,5 @@
+DELETE FROM vehicle_change WHERE action IN ('delete', 'merge');
+
+ALTER TABLE vehicle_change
+    DROP CONSTRAINT vehicle_change_action_check,
+    ADD CONSTRAINT vehicle_change_action_check CHECK (action IN ('create', 'update'));
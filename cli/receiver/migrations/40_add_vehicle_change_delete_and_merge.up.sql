@@ -0,0 +1,3 @@
+ALTER TABLE vehicle_change
+    DROP CONSTRAINT vehicle_change_action_check,
+    ADD CONSTRAINT vehicle_change_action_check CHECK (action IN ('create', 'update', 'delete', 'merge'));
@@ -0,0 +1,6 @@
+ALTER TABLE quarantined_location
+  DROP COLUMN odometer_meters,
+  DROP COLUMN is_history,
+  DROP COLUMN fix_3d,
+  DROP COLUMN valid,
+  DROP COLUMN hdop;
@@ -0,0 +1,12 @@
+-- Точки, помещенные в карантин до миграции, считаются валидными 3D-решениями без HDOP, чтобы
+-- фильтры провайдера не отклонили их при переносе из-за отсутствующих признаков
+ALTER TABLE quarantined_location
+  ADD COLUMN hdop REAL,
+  ADD COLUMN valid BOOLEAN NOT NULL DEFAULT TRUE,
+  ADD COLUMN fix_3d BOOLEAN NOT NULL DEFAULT TRUE,
+  ADD COLUMN is_history BOOLEAN NOT NULL DEFAULT FALSE,
+  ADD COLUMN odometer_meters BIGINT;
+
+ALTER TABLE quarantined_location
+  ALTER COLUMN valid DROP DEFAULT,
+  ALTER COLUMN fix_3d DROP DEFAULT;
@@ -0,0 +1,9 @@
+CREATE TABLE trip_detection_state (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    last_location_id int4 NOT NULL DEFAULT 0
+);
+
+-- Очередь нельзя выразить идентификатором точки, поэтому все точки обрабатываются заново
+INSERT INTO trip_detection_state (id, last_location_id) VALUES (TRUE, 0);
+
+DROP TABLE IF EXISTS trip_detection_queue;
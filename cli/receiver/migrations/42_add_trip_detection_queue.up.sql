@@ -0,0 +1,18 @@
+-- Транспорт с точками, еще не учтенными в поездках, стоянках и пробеге. Строка добавляется тем же
+-- запросом, что и точка, поэтому точка, зафиксированная после построения, не теряется, даже если ее
+-- ID меньше уже обработанных. version растет с каждой новой точкой: построение удаляет строку, только
+-- если за время построения точек не добавилось.
+CREATE TABLE trip_detection_queue (
+    vehicle_id int4 PRIMARY KEY,
+    first_sent_at TIMESTAMPTZ NOT NULL,
+    version int8 NOT NULL DEFAULT 1,
+    CONSTRAINT trip_detection_queue_vehicle_id_fkey FOREIGN KEY (vehicle_id) REFERENCES vehicle(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+INSERT INTO trip_detection_queue (vehicle_id, first_sent_at)
+SELECT vehicle_id, MIN(sent_at)
+FROM location
+WHERE id > (SELECT last_location_id FROM trip_detection_state) AND sent_at IS NOT NULL
+GROUP BY vehicle_id;
+
+DROP TABLE trip_detection_state;
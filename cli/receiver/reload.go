@@ -0,0 +1,65 @@
+package main
+
+import (
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/daniil11ru/egts/cli/receiver/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// reloadableKeys — параметры конфига, которые применяются по SIGHUP без перезапуска процесса.
+// Остальные параметры применяются только после перезапуска.
+var reloadableKeys = map[string]bool{
+	"log_level":                        true,
+	"host":                             true,
+	"connection_ttl":                   true,
+	"save_telematics_data_month_start": true,
+	"save_telematics_data_month_end":   true,
+	"trip_stop_speed_kmh":              true,
+	"trip_stop_radius_meters":          true,
+	"trip_stop_min_seconds":            true,
+	"trip_max_gap_seconds":             true,
+	"trip_min_distance_meters":         true,
+}
+
+// watchReload перечитывает конфиг по SIGHUP и передает его в reloads. Конфиг с ошибками не
+// применяется, об изменениях параметров, требующих перезапуска, сообщается в журнале.
+func watchReload(configFilePath string, startup config.Config, reloads chan<- config.Config) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+
+	applied := startup
+	for range signals {
+		log.Info("Получен сигнал SIGHUP, конфиг перечитывается")
+		next, err := getConfig(configFilePath)
+		if err != nil {
+			log.Errorf("Конфиг не перезагружен: %v", err)
+			continue
+		}
+
+		for _, key := range config.Changed(startup, next) {
+			if !reloadableKeys[key] {
+				log.Warnf("Параметр %s изменен, но будет применен только после перезапуска", key)
+			}
+		}
+
+		var changed []string
+		for _, key := range config.Changed(applied, next) {
+			if reloadableKeys[key] {
+				changed = append(changed, key)
+			}
+		}
+		if len(changed) == 0 {
+			log.Info("Параметры, применяемые без перезапуска, не изменились")
+			continue
+		}
+
+		log.SetLevel((&LoggingSettings{LogLevel: next.LogLevel}).GetLogLevel())
+		reloads <- next
+		applied = next
+		log.Infof("Конфиг перезагружен, применены параметры: %s", strings.Join(changed, ", "))
+	}
+}
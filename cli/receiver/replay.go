@@ -0,0 +1,100 @@
+package main
+
+import (
+	"flag"
+	"time"
+
+	"github.com/daniil11ru/egts/cli/receiver/cache"
+	"github.com/daniil11ru/egts/cli/receiver/config"
+	"github.com/daniil11ru/egts/cli/receiver/server"
+	"github.com/daniil11ru/egts/cli/receiver/server/domain"
+	srepo "github.com/daniil11ru/egts/cli/receiver/server/repository"
+	"github.com/daniil11ru/egts/cli/receiver/source"
+	log "github.com/sirupsen/logrus"
+)
+
+const replayTimeLayout = "02.01.2006 15:04:05"
+
+// runReplay повторно обрабатывает кадры из архива за указанный промежуток времени:
+//
+//	receiver -c config.yaml replay -from "01.07.2025 00:00:00" -to "02.07.2025 00:00:00" [-provider 1] [-oid 1014463084]
+func runReplay(source source.Primary, cfg config.Config, timeZone *time.Location, args []string) {
+	flags := flag.NewFlagSet("replay", flag.ExitOnError)
+	fromStr := flags.String("from", "", "начало промежутка в RFC 3339 или DD.MM.YYYY HH:MM:SS по времени зоны time_zone")
+	toStr := flags.String("to", "", "конец промежутка в RFC 3339 или DD.MM.YYYY HH:MM:SS по времени зоны time_zone")
+	providerId := flags.Int("provider", 0, "ID провайдера")
+	oid := flags.Uint("oid", 0, "OID")
+	_ = flags.Parse(args)
+
+	if cfg.PacketArchivePath == "" {
+		log.Fatal("Не задан путь до архива кадров packet_archive_path")
+		return
+	}
+
+	from, err := parseReplayTime(*fromStr, timeZone)
+	if err != nil {
+		log.Fatal("Начало промежутка -from должно быть в формате RFC 3339 или DD.MM.YYYY HH:MM:SS")
+		return
+	}
+	to, err := parseReplayTime(*toStr, timeZone)
+	if err != nil {
+		log.Fatal("Конец промежутка -to должен быть в формате RFC 3339 или DD.MM.YYYY HH:MM:SS")
+		return
+	}
+	if !from.Before(to) {
+		log.Fatal("Начало промежутка должно быть раньше конца")
+		return
+	}
+
+	filter := server.ReplayFilter{From: from, To: to}
+	if *providerId > 0 {
+		providerId32 := int32(*providerId)
+		filter.ProviderId = &providerId32
+	}
+	if *oid > 0 {
+		oid32 := uint32(*oid)
+		filter.OID = &oid32
+	}
+
+	primaryRepository := srepo.Primary{Source: source}
+	lastPositionCache := cache.NewLastPosition(
+		primaryRepository.GetLastVehiclePoint,
+		time.Duration(cfg.LastPositionCacheTtl)*time.Second,
+		cfg.LastPositionCacheCapacity,
+	)
+	savePacket, err := domain.NewSavePacket(
+		primaryRepository,
+		lastPositionCache,
+		domain.NewIngestCache(primaryRepository),
+		nil,
+		cfg.SaveTelematicsDataMonthStart,
+		cfg.SaveTelematicsDataMonthEnd,
+		timeZone,
+	)
+	if err != nil {
+		log.Fatalf("Не удалось инициализировать сохранение телематических данных: %v", err)
+		return
+	}
+	defer savePacket.Shutdown()
+	savePacket.SkipExistingLocations = true
+
+	log.Infof("Повторная обработка кадров из архива %s с %s по %s", cfg.PacketArchivePath, *fromStr, *toStr)
+	replay := server.Replay{ArchivePath: cfg.PacketArchivePath, SavePacket: savePacket}
+	result, err := replay.Run(filter)
+	if err != nil {
+		log.Errorf("Повторная обработка прервана: %v", err)
+	}
+	log.Infof(
+		"Обработано кадров: %d, не удалось разобрать: %d, местоположений: %d, не сохранено: %d",
+		result.Frames, result.DecodeErrors, result.Locations, result.Failed,
+	)
+}
+
+// parseReplayTime разбирает границу промежутка в RFC 3339 или в формате DD.MM.YYYY HH:MM:SS по
+// времени зоны loc
+func parseReplayTime(s string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation(replayTimeLayout, s, loc)
+}
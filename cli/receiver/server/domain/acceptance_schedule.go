@@ -0,0 +1,167 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	"github.com/daniil11ru/egts/cli/receiver/dto/other"
+)
+
+const (
+	dateLayout      = "2006-01-02"
+	allWeekdaysMask = 1<<7 - 1
+	allHoursMask    = 1<<24 - 1
+)
+
+// ValidateAcceptanceSchedule проверяет расписание и заполняет Location, чтобы при проверке каждой
+// точки не загружать временную зону заново
+func ValidateAcceptanceSchedule(schedule *out.AcceptanceSchedule) error {
+	if schedule.ProviderId != nil && schedule.VehicleGroupId != nil {
+		return fmt.Errorf("расписание не может одновременно относиться к провайдеру и к группе транспорта")
+	}
+	if !schedule.TimeBasis.IsValid() {
+		return fmt.Errorf("недопустимое время для проверки расписания: %q", schedule.TimeBasis)
+	}
+	loc, err := time.LoadLocation(schedule.TimeZone)
+	if err != nil {
+		return fmt.Errorf("неизвестная временная зона %q", schedule.TimeZone)
+	}
+	schedule.Location = loc
+	if schedule.WeekdayMask < 1 || schedule.WeekdayMask > allWeekdaysMask {
+		return fmt.Errorf("weekday_mask должен быть в пределах от 1 до %d", allWeekdaysMask)
+	}
+	if schedule.HourMask < 1 || schedule.HourMask > allHoursMask {
+		return fmt.Errorf("hour_mask должен быть в пределах от 1 до %d", allHoursMask)
+	}
+
+	for _, date := range []*string{schedule.DateFrom, schedule.DateTo} {
+		if date == nil {
+			continue
+		}
+		if _, err := time.Parse(dateLayout, *date); err != nil {
+			return fmt.Errorf("некорректная дата %q, ожидается формат ГГГГ-ММ-ДД", *date)
+		}
+	}
+	if !schedule.RecurringYearly && schedule.DateFrom != nil && schedule.DateTo != nil && *schedule.DateFrom > *schedule.DateTo {
+		return fmt.Errorf("date_from не может быть позже date_to")
+	}
+
+	return nil
+}
+
+func matchesDateRange(schedule out.AcceptanceSchedule, t time.Time) bool {
+	if !schedule.RecurringYearly {
+		date := t.Format(dateLayout)
+		if schedule.DateFrom != nil && date < *schedule.DateFrom {
+			return false
+		}
+		if schedule.DateTo != nil && date > *schedule.DateTo {
+			return false
+		}
+		return true
+	}
+
+	// Для ежегодных расписаний сравниваются только месяц и день, диапазон может переходить через Новый год
+	monthDay := t.Format("01-02")
+	from, to := "01-01", "12-31"
+	if schedule.DateFrom != nil {
+		from = (*schedule.DateFrom)[5:]
+	}
+	if schedule.DateTo != nil {
+		to = (*schedule.DateTo)[5:]
+	}
+	if from <= to {
+		return monthDay >= from && monthDay <= to
+	}
+	return monthDay >= from || monthDay <= to
+}
+
+// MatchesAcceptanceSchedule проверяет, попадает ли момент времени в расписание. Момент переводится во
+// временную зону расписания, после чего проверяются диапазон дат, день недели и час.
+func MatchesAcceptanceSchedule(schedule out.AcceptanceSchedule, t time.Time) (bool, error) {
+	loc := schedule.Location
+	if loc == nil {
+		var err error
+		if loc, err = time.LoadLocation(schedule.TimeZone); err != nil {
+			return false, fmt.Errorf("неизвестная временная зона %q", schedule.TimeZone)
+		}
+	}
+	t = t.In(loc)
+
+	if !matchesDateRange(schedule, t) {
+		return false, nil
+	}
+
+	weekdayBit := (int(t.Weekday()) + 6) % 7 // понедельник — младший бит
+	if schedule.WeekdayMask&(1<<weekdayBit) == 0 {
+		return false, nil
+	}
+	if schedule.HourMask&(1<<t.Hour()) == 0 {
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// SelectAcceptanceSchedules выбирает наиболее специфичный уровень расписаний: группы транспорта,
+// затем провайдера, затем общие расписания без привязки.
+func SelectAcceptanceSchedules(schedules []out.AcceptanceSchedule, providerId int32, vehicleGroupId *int32) []out.AcceptanceSchedule {
+	var byGroup, byProvider, global []out.AcceptanceSchedule
+
+	for _, schedule := range schedules {
+		switch {
+		case schedule.VehicleGroupId != nil:
+			if vehicleGroupId != nil && *schedule.VehicleGroupId == *vehicleGroupId {
+				byGroup = append(byGroup, schedule)
+			}
+		case schedule.ProviderId != nil:
+			if *schedule.ProviderId == providerId {
+				byProvider = append(byProvider, schedule)
+			}
+		default:
+			global = append(global, schedule)
+		}
+	}
+
+	if len(byGroup) > 0 {
+		return byGroup
+	}
+	if len(byProvider) > 0 {
+		return byProvider
+	}
+	return global
+}
+
+// IsAcceptedBySchedules возвращает true, если данные попадают хотя бы в одно из расписаний. В
+// зависимости от time_basis проверяется время навигации, время получения или оба.
+func IsAcceptedBySchedules(schedules []out.AcceptanceSchedule, sentAt, receivedAt time.Time) (bool, error) {
+	for _, schedule := range schedules {
+		var moments []time.Time
+		switch schedule.TimeBasis {
+		case other.AcceptanceTimeBasisNavigation:
+			moments = []time.Time{sentAt}
+		case other.AcceptanceTimeBasisReceived:
+			moments = []time.Time{receivedAt}
+		default:
+			moments = []time.Time{sentAt, receivedAt}
+		}
+
+		matched := true
+		for _, moment := range moments {
+			ok, err := MatchesAcceptanceSchedule(schedule, moment)
+			if err != nil {
+				return false, fmt.Errorf("не удалось применить расписание с ID %d: %w", schedule.ID, err)
+			}
+			if !ok {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
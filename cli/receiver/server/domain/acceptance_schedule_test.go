@@ -0,0 +1,121 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	"github.com/daniil11ru/egts/cli/receiver/dto/other"
+	"github.com/stretchr/testify/assert"
+)
+
+func stringPtr(v string) *string { return &v }
+
+func int32Ptr(v int32) *int32 { return &v }
+
+func TestMatchesAcceptanceSchedule(t *testing.T) {
+	// Рабочие дни с 08:00 до 19:59 по Москве, с мая по сентябрь каждого года
+	schedule := out.AcceptanceSchedule{
+		DateFrom:        stringPtr("2000-05-01"),
+		DateTo:          stringPtr("2000-09-30"),
+		RecurringYearly: true,
+		WeekdayMask:     0b0011111,
+		HourMask:        0b000011111111111100000000,
+		TimeZone:        "Europe/Moscow",
+		TimeBasis:       other.AcceptanceTimeBasisBoth,
+	}
+
+	// Понедельник, 10:00 по Москве
+	ok, err := MatchesAcceptanceSchedule(schedule, time.Date(2025, 6, 2, 7, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	// Понедельник, 22:00 по Москве
+	ok, _ = MatchesAcceptanceSchedule(schedule, time.Date(2025, 6, 2, 19, 0, 0, 0, time.UTC))
+	assert.False(t, ok)
+
+	// Суббота
+	ok, _ = MatchesAcceptanceSchedule(schedule, time.Date(2025, 6, 7, 7, 0, 0, 0, time.UTC))
+	assert.False(t, ok)
+
+	// Октябрь
+	ok, _ = MatchesAcceptanceSchedule(schedule, time.Date(2025, 10, 6, 7, 0, 0, 0, time.UTC))
+	assert.False(t, ok)
+
+	// Зимний диапазон с переходом через Новый год
+	winter := out.AcceptanceSchedule{
+		DateFrom:        stringPtr("2000-12-01"),
+		DateTo:          stringPtr("2000-02-28"),
+		RecurringYearly: true,
+		WeekdayMask:     allWeekdaysMask,
+		HourMask:        allHoursMask,
+		TimeZone:        "UTC",
+	}
+	ok, _ = MatchesAcceptanceSchedule(winter, time.Date(2026, 1, 15, 12, 0, 0, 0, time.UTC))
+	assert.True(t, ok)
+	ok, _ = MatchesAcceptanceSchedule(winter, time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC))
+	assert.False(t, ok)
+}
+
+func TestIsAcceptedBySchedules(t *testing.T) {
+	schedule := out.AcceptanceSchedule{
+		DateFrom:    stringPtr("2025-06-01"),
+		DateTo:      stringPtr("2025-06-30"),
+		WeekdayMask: allWeekdaysMask,
+		HourMask:    allHoursMask,
+		TimeZone:    "UTC",
+		TimeBasis:   other.AcceptanceTimeBasisBoth,
+	}
+	sentAt := time.Date(2025, 5, 31, 23, 0, 0, 0, time.UTC)
+	receivedAt := time.Date(2025, 6, 1, 1, 0, 0, 0, time.UTC)
+
+	ok, err := IsAcceptedBySchedules([]out.AcceptanceSchedule{schedule}, sentAt, receivedAt)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	schedule.TimeBasis = other.AcceptanceTimeBasisReceived
+	ok, _ = IsAcceptedBySchedules([]out.AcceptanceSchedule{schedule}, sentAt, receivedAt)
+	assert.True(t, ok)
+
+	schedule.TimeBasis = other.AcceptanceTimeBasisNavigation
+	ok, _ = IsAcceptedBySchedules([]out.AcceptanceSchedule{schedule}, sentAt, receivedAt)
+	assert.False(t, ok)
+}
+
+func TestSelectAcceptanceSchedules(t *testing.T) {
+	schedules := []out.AcceptanceSchedule{
+		{ID: 1},
+		{ID: 2, ProviderId: int32Ptr(1)},
+		{ID: 3, VehicleGroupId: int32Ptr(5)},
+	}
+
+	assert.Equal(t, int32(3), SelectAcceptanceSchedules(schedules, 1, int32Ptr(5))[0].ID)
+	assert.Equal(t, int32(2), SelectAcceptanceSchedules(schedules, 1, int32Ptr(6))[0].ID)
+	assert.Equal(t, int32(1), SelectAcceptanceSchedules(schedules, 2, nil)[0].ID)
+	assert.Empty(t, SelectAcceptanceSchedules(schedules[1:], 2, nil))
+}
+
+func TestValidateAcceptanceSchedule(t *testing.T) {
+	schedule := out.AcceptanceSchedule{
+		WeekdayMask: allWeekdaysMask,
+		HourMask:    allHoursMask,
+		TimeZone:    "Europe/Moscow",
+		TimeBasis:   other.AcceptanceTimeBasisBoth,
+	}
+	assert.NoError(t, ValidateAcceptanceSchedule(&schedule))
+	if assert.NotNil(t, schedule.Location) {
+		assert.Equal(t, "Europe/Moscow", schedule.Location.String())
+	}
+
+	invalid := schedule
+	invalid.TimeZone = "Mars/Olympus"
+	assert.Error(t, ValidateAcceptanceSchedule(&invalid))
+
+	invalid = schedule
+	invalid.DateFrom, invalid.DateTo = stringPtr("2025-09-01"), stringPtr("2025-05-01")
+	assert.Error(t, ValidateAcceptanceSchedule(&invalid))
+
+	invalid = schedule
+	invalid.ProviderId, invalid.VehicleGroupId = int32Ptr(1), int32Ptr(1)
+	assert.Error(t, ValidateAcceptanceSchedule(&invalid))
+}
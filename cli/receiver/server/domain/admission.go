@@ -0,0 +1,117 @@
+package domain
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ParseAllowedNetwork разбирает разрешенную сеть провайдера в нотации CIDR. Отдельный адрес
+// считается сетью из одного адреса.
+func ParseAllowedNetwork(value string) (*net.IPNet, error) {
+	value = strings.TrimSpace(value)
+	if !strings.Contains(value, "/") {
+		ip := net.ParseIP(value)
+		if ip == nil {
+			return nil, fmt.Errorf("некорректный адрес %q", value)
+		}
+		if ip4 := ip.To4(); ip4 != nil {
+			return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}, nil
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+	}
+
+	ip, network, err := net.ParseCIDR(value)
+	if err != nil {
+		return nil, fmt.Errorf("некорректная сеть %q", value)
+	}
+	if !ip.Equal(network.IP) {
+		return nil, fmt.Errorf("в сети %q заданы биты адреса узла, ожидается %s", value, network)
+	}
+	return network, nil
+}
+
+// NetworkAllowed проверяет, что адрес входит в одну из разрешенных сетей. Пустой список разрешает
+// любой адрес.
+func NetworkAllowed(networks []*net.IPNet, ip net.IP) bool {
+	if len(networks) == 0 {
+		return true
+	}
+	if ip == nil {
+		return false
+	}
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// PacketRateLimiter ограничивает количество пакетов одного соединения в минуту. Пакеты
+// расходуют запас, который пополняется равномерно и не превышает лимита, поэтому кратковременный
+// всплеск до лимита допускается. Нулевой ограничитель пропускает все пакеты.
+type PacketRateLimiter struct {
+	perMinute float64
+	tokens    float64
+	updatedAt time.Time
+}
+
+func NewPacketRateLimiter(perMinute int32, now time.Time) *PacketRateLimiter {
+	if perMinute <= 0 {
+		return nil
+	}
+	return &PacketRateLimiter{perMinute: float64(perMinute), tokens: float64(perMinute), updatedAt: now}
+}
+
+func (l *PacketRateLimiter) Allow(now time.Time) bool {
+	if l == nil {
+		return true
+	}
+	if elapsed := now.Sub(l.updatedAt); elapsed > 0 {
+		l.tokens += elapsed.Minutes() * l.perMinute
+		if l.tokens > l.perMinute {
+			l.tokens = l.perMinute
+		}
+		l.updatedAt = now
+	}
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
+
+// VehicleCreationLimiter считает транспорт, автоматически добавленный для каждого провайдера за
+// последний час. Счетчики хранятся в памяти и сбрасываются при перезапуске приемника.
+type VehicleCreationLimiter struct {
+	mu        sync.Mutex
+	creations map[int32][]time.Time
+}
+
+func NewVehicleCreationLimiter() *VehicleCreationLimiter {
+	return &VehicleCreationLimiter{creations: make(map[int32][]time.Time)}
+}
+
+// Allow резервирует добавление транспорта провайдером, если за последний час он добавил меньше
+// perHour единиц транспорта
+func (l *VehicleCreationLimiter) Allow(providerId int32, perHour int32, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	since := now.Add(-time.Hour)
+	recent := l.creations[providerId][:0]
+	for _, at := range l.creations[providerId] {
+		if at.After(since) {
+			recent = append(recent, at)
+		}
+	}
+	if int32(len(recent)) >= perHour {
+		l.creations[providerId] = recent
+		return false
+	}
+	l.creations[providerId] = append(recent, now)
+	return true
+}
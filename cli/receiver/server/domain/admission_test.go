@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAllowedNetwork(t *testing.T) {
+	network, err := ParseAllowedNetwork("10.1.0.0/16")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "10.1.0.0/16", network.String())
+	}
+
+	network, err = ParseAllowedNetwork(" 192.168.1.5 ")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "192.168.1.5/32", network.String())
+	}
+
+	network, err = ParseAllowedNetwork("2001:db8::/32")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "2001:db8::/32", network.String())
+	}
+
+	_, err = ParseAllowedNetwork("10.1.2.3/16")
+	assert.Error(t, err)
+	_, err = ParseAllowedNetwork("10.1.2")
+	assert.Error(t, err)
+}
+
+func TestNetworkAllowed(t *testing.T) {
+	office, _ := ParseAllowedNetwork("10.1.0.0/16")
+	relay, _ := ParseAllowedNetwork("192.168.1.5")
+
+	assert.True(t, NetworkAllowed(nil, net.ParseIP("8.8.8.8")))
+	assert.True(t, NetworkAllowed([]*net.IPNet{office, relay}, net.ParseIP("10.1.200.7")))
+	assert.True(t, NetworkAllowed([]*net.IPNet{office, relay}, net.ParseIP("192.168.1.5")))
+	assert.True(t, NetworkAllowed([]*net.IPNet{office}, net.ParseIP("::ffff:10.1.0.1")))
+	assert.False(t, NetworkAllowed([]*net.IPNet{office, relay}, net.ParseIP("192.168.1.6")))
+	assert.False(t, NetworkAllowed([]*net.IPNet{office}, nil))
+}
+
+func TestPacketRateLimiter(t *testing.T) {
+	start := time.Date(2025, 6, 2, 10, 0, 0, 0, time.UTC)
+	limiter := NewPacketRateLimiter(3, start)
+
+	assert.True(t, limiter.Allow(start))
+	assert.True(t, limiter.Allow(start))
+	assert.True(t, limiter.Allow(start))
+	assert.False(t, limiter.Allow(start.Add(time.Second)))
+
+	// За 20 секунд восстанавливается один пакет из трех в минуту
+	assert.True(t, limiter.Allow(start.Add(21*time.Second)))
+	assert.False(t, limiter.Allow(start.Add(22*time.Second)))
+
+	// Запас не превышает лимита даже после долгой паузы
+	later := start.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		assert.True(t, limiter.Allow(later))
+	}
+	assert.False(t, limiter.Allow(later))
+
+	unlimited := NewPacketRateLimiter(0, start)
+	assert.Nil(t, unlimited)
+	assert.True(t, unlimited.Allow(start))
+}
+
+func TestVehicleCreationLimiter(t *testing.T) {
+	start := time.Date(2025, 6, 2, 10, 0, 0, 0, time.UTC)
+	limiter := NewVehicleCreationLimiter()
+
+	assert.True(t, limiter.Allow(1, 2, start))
+	assert.True(t, limiter.Allow(1, 2, start.Add(10*time.Minute)))
+	assert.False(t, limiter.Allow(1, 2, start.Add(20*time.Minute)))
+	assert.True(t, limiter.Allow(2, 2, start.Add(20*time.Minute)))
+
+	// Через час после первого добавления освобождается одно место
+	assert.True(t, limiter.Allow(1, 2, start.Add(61*time.Minute)))
+	assert.False(t, limiter.Allow(1, 2, start.Add(62*time.Minute)))
+}
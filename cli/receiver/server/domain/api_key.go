@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	"github.com/daniil11ru/egts/cli/receiver/dto/other"
+)
+
+// HashApiKey возвращает хеш ключа в том виде, в котором он хранится в таблице api_key
+func HashApiKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewApiKey создает случайный ключ из 32 байт в шестнадцатеричной записи и его хеш. Сам ключ не
+// сохраняется и показывается только при создании.
+func NewApiKey() (key string, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", fmt.Errorf("не удалось сгенерировать API-ключ: %w", err)
+	}
+	key = hex.EncodeToString(b)
+	return key, HashApiKey(key), nil
+}
+
+func ValidateApiKey(name string, scopes []other.ApiKeyScope, providerId *int32) error {
+	if name == "" {
+		return fmt.Errorf("название ключа не может быть пустым")
+	}
+	if len(scopes) == 0 {
+		return fmt.Errorf("не задано ни одной области доступа")
+	}
+	for _, scope := range scopes {
+		if !scope.IsValid() {
+			return fmt.Errorf("недопустимая область доступа: %q", scope)
+		}
+		if scope == other.ApiKeyScopeAdmin && providerId != nil {
+			return fmt.Errorf("ключ с областью admin не может быть ограничен провайдером")
+		}
+	}
+	if providerId != nil && *providerId <= 0 {
+		return fmt.Errorf("ID провайдера должен быть положительным")
+	}
+	return nil
+}
+
+// ApiKeyActive сообщает, что ключ не отозван и срок его действия не истек
+func ApiKeyActive(key out.ApiKey, now time.Time) bool {
+	if key.RevokedAt != nil {
+		return false
+	}
+	return key.ExpiresAt == nil || now.Before(*key.ExpiresAt)
+}
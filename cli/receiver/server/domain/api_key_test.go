@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	"github.com/daniil11ru/egts/cli/receiver/dto/other"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewApiKey(t *testing.T) {
+	key, hash, err := NewApiKey()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Len(t, key, 64)
+	assert.Equal(t, HashApiKey(key), hash)
+	// echo -n "test" | openssl dgst -sha256
+	assert.Equal(t, "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08", HashApiKey("test"))
+}
+
+func TestValidateApiKey(t *testing.T) {
+	providerId := int32(2)
+	assert.NoError(t, ValidateApiKey("диспетчер", []other.ApiKeyScope{other.ApiKeyScopeReadVehicles, other.ApiKeyScopeReadLocations}, &providerId))
+	assert.NoError(t, ValidateApiKey("администратор", []other.ApiKeyScope{other.ApiKeyScopeAdmin}, nil))
+	assert.Error(t, ValidateApiKey("", []other.ApiKeyScope{other.ApiKeyScopeModerate}, nil))
+	assert.Error(t, ValidateApiKey("пустой", nil, nil))
+	assert.Error(t, ValidateApiKey("чтение", []other.ApiKeyScope{"read_all"}, nil))
+	assert.Error(t, ValidateApiKey("администратор", []other.ApiKeyScope{other.ApiKeyScopeAdmin}, &providerId))
+}
+
+func TestApiKeyActive(t *testing.T) {
+	now := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+
+	assert.True(t, ApiKeyActive(out.ApiKey{}, now))
+	assert.True(t, ApiKeyActive(out.ApiKey{ExpiresAt: &future}, now))
+	assert.False(t, ApiKeyActive(out.ApiKey{ExpiresAt: &past}, now))
+	assert.False(t, ApiKeyActive(out.ApiKey{RevokedAt: &past}, now))
+
+	scopes := other.ApiKeyScopes{other.ApiKeyScopeReadVehicles}
+	assert.True(t, scopes.Has(other.ApiKeyScopeReadVehicles))
+	assert.False(t, scopes.Has(other.ApiKeyScopeModerate))
+	assert.True(t, other.ApiKeyScopes{other.ApiKeyScopeAdmin}.Has(other.ApiKeyScopeModerate))
+}
@@ -0,0 +1,134 @@
+package domain
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+)
+
+func isMovingSegment(from, to out.TripPoint, distance float64, settings TripDetectionSettings) bool {
+	if distance > settings.StopRadiusMeters {
+		return true
+	}
+	for _, point := range []out.TripPoint{from, to} {
+		if point.Speed != nil && float64(*point.Speed) > settings.StopSpeedKmh {
+			return true
+		}
+	}
+	return false
+}
+
+// ComputeDailyMileage считает пробег, время движения и время простоя по дням начиная с fromDay.
+// Отрезок между соседними точками относится к дню второй точки, поэтому для первого дня нужна
+// предшествующая ему точка. Расстояние учитывается только для отрезков движения, чтобы дрожание
+// координат на стоянке не увеличивало пробег. Отрезки через разрыв в данных не учитываются совсем:
+// пробег за время разрыва виден при сверке с одометром.
+func ComputeDailyMileage(points []out.TripPoint, settings TripDetectionSettings, loc *time.Location, fromDay string) []out.DailyMileage {
+	var result []out.DailyMileage
+	maxGap := time.Duration(settings.MaxGapSeconds) * time.Second
+
+	for i, point := range points {
+		day := point.SentAt.In(loc).Format(dateLayout)
+		if day < fromDay {
+			continue
+		}
+		if len(result) == 0 || result[len(result)-1].Day != day {
+			result = append(result, out.DailyMileage{Day: day})
+		}
+		current := &result[len(result)-1]
+
+		current.PointCount++
+		if point.OdometerMeters != nil {
+			odometer := *point.OdometerMeters
+			if current.OdometerStartMeters == nil || odometer < *current.OdometerStartMeters {
+				current.OdometerStartMeters = &odometer
+			}
+			if current.OdometerEndMeters == nil || odometer > *current.OdometerEndMeters {
+				current.OdometerEndMeters = &odometer
+			}
+		}
+
+		if i == 0 {
+			continue
+		}
+		previous := points[i-1]
+		elapsed := point.SentAt.Sub(previous.SentAt)
+		if elapsed > maxGap {
+			continue
+		}
+
+		distance := horizontalDistance(previous, point)
+		if isMovingSegment(previous, point, distance, settings) {
+			current.DistanceMeters += distance
+			current.MovingSeconds += int64(elapsed.Seconds())
+		} else {
+			current.IdleSeconds += int64(elapsed.Seconds())
+		}
+	}
+
+	return result
+}
+
+// OdometerDistance возвращает пробег по одометру терминала за день, если терминал передавал одометр
+func OdometerDistance(mileage out.DailyMileage) *float64 {
+	if mileage.OdometerStartMeters == nil || mileage.OdometerEndMeters == nil {
+		return nil
+	}
+	distance := float64(*mileage.OdometerEndMeters - *mileage.OdometerStartMeters)
+	return &distance
+}
+
+// OdometerDeviationPercent возвращает расхождение пробега по треку с пробегом по одометру в процентах
+func OdometerDeviationPercent(mileage out.DailyMileage) *float64 {
+	odometer := OdometerDistance(mileage)
+	if odometer == nil || *odometer <= 0 {
+		return nil
+	}
+	deviation := math.Round((mileage.DistanceMeters-*odometer) / *odometer * 1000) / 10
+	return &deviation
+}
+
+type ProviderDailyMileage struct {
+	ProviderId     int32
+	Day            string
+	VehicleCount   int32
+	DistanceMeters float64
+	MovingSeconds  int64
+	IdleSeconds    int64
+}
+
+// SummarizeMileageByProvider суммирует пробег транспорта по провайдерам и дням
+func SummarizeMileageByProvider(mileage []out.DailyMileage) []ProviderDailyMileage {
+	type key struct {
+		providerId int32
+		day        string
+	}
+	summaries := map[key]*ProviderDailyMileage{}
+
+	for _, item := range mileage {
+		k := key{item.ProviderId, item.Day}
+		summary, ok := summaries[k]
+		if !ok {
+			summary = &ProviderDailyMileage{ProviderId: item.ProviderId, Day: item.Day}
+			summaries[k] = summary
+		}
+		summary.VehicleCount++
+		summary.DistanceMeters += item.DistanceMeters
+		summary.MovingSeconds += item.MovingSeconds
+		summary.IdleSeconds += item.IdleSeconds
+	}
+
+	result := make([]ProviderDailyMileage, 0, len(summaries))
+	for _, summary := range summaries {
+		result = append(result, *summary)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].ProviderId != result[j].ProviderId {
+			return result[i].ProviderId < result[j].ProviderId
+		}
+		return result[i].Day < result[j].Day
+	})
+	return result
+}
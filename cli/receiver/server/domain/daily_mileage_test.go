@@ -0,0 +1,68 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	"github.com/stretchr/testify/assert"
+)
+
+func int64Ptr(v int64) *int64 { return &v }
+
+func TestComputeDailyMileage(t *testing.T) {
+	loc := time.FixedZone("MSK", 3*60*60)
+
+	// Движение до полуночи, стоянка с дрожанием координат, разрыв и движение на следующий день
+	points := buildTripPoints([]tripStep{
+		{0, 60, 0}, {1000, 60, time.Minute}, {2000, 60, time.Minute},
+		{2010, 0, time.Minute}, {2000, 0, time.Minute},
+		{3000, 60, time.Hour}, {4000, 60, time.Minute},
+	})
+	for i := range points {
+		// Первая точка — 23:50 по Москве
+		points[i].SentAt = points[i].SentAt.Add(11*time.Hour + 50*time.Minute - 3*time.Hour)
+	}
+	points[0].OdometerMeters = int64Ptr(100000)
+	points[4].OdometerMeters = int64Ptr(102100)
+
+	mileage := ComputeDailyMileage(points, tripSettings, loc, "2025-06-01")
+
+	if assert.Len(t, mileage, 2) {
+		assert.Equal(t, "2025-06-01", mileage[0].Day)
+		// Отрезок торможения считается движением, дрожание на стоянке — нет
+		assert.InDelta(t, 2010, mileage[0].DistanceMeters, 1)
+		assert.Equal(t, int64(180), mileage[0].MovingSeconds)
+		assert.Equal(t, int64(60), mileage[0].IdleSeconds)
+		assert.Equal(t, int32(5), mileage[0].PointCount)
+		assert.InDelta(t, 2100, *OdometerDistance(mileage[0]), 0.1)
+		assert.InDelta(t, -4.3, *OdometerDeviationPercent(mileage[0]), 0.1)
+
+		// Отрезок через часовой разрыв не учитывается
+		assert.Equal(t, "2025-06-02", mileage[1].Day)
+		assert.InDelta(t, 1000, mileage[1].DistanceMeters, 1)
+		assert.Equal(t, int64(60), mileage[1].MovingSeconds)
+		assert.Nil(t, OdometerDistance(mileage[1]))
+	}
+
+	// Перестроение со второго дня использует последнюю точку предыдущего дня только как начало отрезка
+	rebuilt := ComputeDailyMileage(points, tripSettings, loc, "2025-06-02")
+	if assert.Len(t, rebuilt, 1) {
+		assert.Equal(t, mileage[1], rebuilt[0])
+	}
+}
+
+func TestSummarizeMileageByProvider(t *testing.T) {
+	summary := SummarizeMileageByProvider([]out.DailyMileage{
+		{VehicleId: 1, ProviderId: 2, Day: "2025-06-01", DistanceMeters: 1000, MovingSeconds: 60},
+		{VehicleId: 2, ProviderId: 1, Day: "2025-06-01", DistanceMeters: 500},
+		{VehicleId: 3, ProviderId: 2, Day: "2025-06-01", DistanceMeters: 2000, IdleSeconds: 30},
+	})
+
+	if assert.Len(t, summary, 2) {
+		assert.Equal(t, int32(1), summary[0].ProviderId)
+		assert.Equal(t, ProviderDailyMileage{
+			ProviderId: 2, Day: "2025-06-01", VehicleCount: 2, DistanceMeters: 3000, MovingSeconds: 60, IdleSeconds: 30,
+		}, summary[1])
+	}
+}
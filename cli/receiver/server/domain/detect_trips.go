@@ -0,0 +1,220 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	repository "github.com/daniil11ru/egts/cli/receiver/server/repository"
+	"github.com/sirupsen/logrus"
+)
+
+type TripDetectionSettings struct {
+	StopSpeedKmh          float64
+	StopRadiusMeters      float64
+	StopMinSeconds        int32
+	MaxGapSeconds         int32
+	MinTripDistanceMeters float64
+}
+
+func isStationary(anchor, point out.TripPoint, settings TripDetectionSettings) bool {
+	if point.Speed != nil && float64(*point.Speed) > settings.StopSpeedKmh {
+		return false
+	}
+	return horizontalDistance(anchor, point) <= settings.StopRadiusMeters
+}
+
+// isTripGap возвращает true, если между точками нет данных дольше допустимого и транспорт за это
+// время сместился, то есть движение между ними восстановить нельзя
+func isTripGap(from, to out.TripPoint, settings TripDetectionSettings) bool {
+	return to.SentAt.Sub(from.SentAt) > time.Duration(settings.MaxGapSeconds)*time.Second &&
+		horizontalDistance(from, to) > settings.StopRadiusMeters
+}
+
+func horizontalDistance(from, to out.TripPoint) float64 {
+	a := out.Point{Latitude: from.Latitude, Longitude: from.Longitude}
+	b := out.Point{Latitude: to.Latitude, Longitude: to.Longitude}
+	return a.HorizontalDistanceTo(&b)
+}
+
+func buildTrip(points []out.TripPoint, settings TripDetectionSettings) (out.Trip, bool) {
+	if len(points) < 2 {
+		return out.Trip{}, false
+	}
+
+	first, last := points[0], points[len(points)-1]
+	trip := out.Trip{
+		StartedAt:      first.SentAt,
+		EndedAt:        last.SentAt,
+		StartLatitude:  first.Latitude,
+		StartLongitude: first.Longitude,
+		EndLatitude:    last.Latitude,
+		EndLongitude:   last.Longitude,
+		PointCount:     int32(len(points)),
+	}
+	for i, point := range points {
+		if i > 0 {
+			trip.DistanceMeters += horizontalDistance(points[i-1], point)
+		}
+		if point.Speed != nil && (trip.MaxSpeed == nil || *point.Speed > *trip.MaxSpeed) {
+			speed := *point.Speed
+			trip.MaxSpeed = &speed
+		}
+	}
+
+	// Короткие перемещения считаются дрейфом координат, а не поездкой
+	if trip.DistanceMeters < settings.MinTripDistanceMeters {
+		return out.Trip{}, false
+	}
+	return trip, true
+}
+
+func buildStop(points []out.TripPoint) out.Stop {
+	stop := out.Stop{
+		StartedAt:  points[0].SentAt,
+		EndedAt:    points[len(points)-1].SentAt,
+		PointCount: int32(len(points)),
+	}
+	for _, point := range points {
+		stop.Latitude += point.Latitude
+		stop.Longitude += point.Longitude
+	}
+	stop.Latitude /= float64(len(points))
+	stop.Longitude /= float64(len(points))
+	return stop
+}
+
+// DetectActivities делит упорядоченные по времени навигации точки на поездки и стоянки. Стоянка —
+// последовательность точек со скоростью не выше порога в пределах радиуса от первой точки,
+// длящаяся не меньше заданного времени. Поездки — участки между стоянками и разрывами в данных;
+// граничная точка стоянки одновременно завершает предыдущую поездку и начинает следующую.
+func DetectActivities(points []out.TripPoint, settings TripDetectionSettings) ([]out.Trip, []out.Stop) {
+	var trips []out.Trip
+	var stops []out.Stop
+
+	n := len(points)
+	if n == 0 {
+		return trips, stops
+	}
+
+	addTrip := func(first, last int) {
+		if trip, ok := buildTrip(points[first:last+1], settings); ok {
+			trips = append(trips, trip)
+		}
+	}
+
+	tripStart, stopEnd := 0, -1
+	for i := 0; i < n; i++ {
+		if i != stopEnd {
+			j := i
+			for j+1 < n && isStationary(points[i], points[j+1], settings) {
+				j++
+			}
+			if j > i && points[j].SentAt.Sub(points[i].SentAt) >= time.Duration(settings.StopMinSeconds)*time.Second {
+				addTrip(tripStart, i)
+				stops = append(stops, buildStop(points[i:j+1]))
+				tripStart, stopEnd = j, j
+				i = j - 1
+				continue
+			}
+		}
+
+		if i+1 < n && isTripGap(points[i], points[i+1], settings) {
+			addTrip(tripStart, i)
+			tripStart = i + 1
+		}
+	}
+	addTrip(tripStart, n-1)
+
+	return trips, stops
+}
+
+type DetectTrips struct {
+	PrimaryRepository repository.Primary
+	Settings          TripDetectionSettings
+	// TimeZone — временная зона, по которой суточный пробег делится на дни
+	TimeZone *time.Location
+
+	mu sync.Mutex
+}
+
+// SetSettings меняет параметры построения поездок при перезагрузке конфига. Если построение уже идет,
+// вызов дожидается его завершения, и новые параметры применяются со следующего запуска.
+func (s *DetectTrips) SetSettings(settings TripDetectionSettings) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Settings = settings
+}
+
+// Run перестраивает поездки, стоянки и суточный пробег транспорта из очереди построения, в которую
+// транспорт попадает вместе с каждой новой точкой. Поездки строятся с последней поездки или стоянки,
+// начавшейся до самой ранней новой точки, а пробег — с дня этой точки, поэтому поздно пришедшие
+// данные встраиваются в уже построенную историю.
+func (s *DetectTrips) Run() error {
+	if !s.mu.TryLock() {
+		logrus.Warn("Предыдущее построение поездок и стоянок еще не завершено, запуск пропущен")
+		return nil
+	}
+	defer s.mu.Unlock()
+
+	vehicles, err := s.PrimaryRepository.GetPendingTripVehicles()
+	if err != nil {
+		return fmt.Errorf("не удалось получить транспорт с новыми точками: %w", err)
+	}
+	if len(vehicles) == 0 {
+		return nil
+	}
+
+	failed := 0
+	for _, vehicle := range vehicles {
+		if err := s.rebuild(vehicle, s.TimeZone); err != nil {
+			logrus.Errorf("Не удалось построить поездки транспорта с ID %d: %v", vehicle.VehicleId, err)
+			failed++
+			continue
+		}
+		// Если за время построения пришли новые точки, транспорт остается в очереди до следующего запуска
+		if err := s.PrimaryRepository.DeletePendingTripVehicle(vehicle.VehicleId, vehicle.Version); err != nil {
+			logrus.Errorf("Не удалось убрать транспорт с ID %d из очереди построения поездок: %v", vehicle.VehicleId, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		// Транспорт с ошибкой остается в очереди, чтобы повторить построение при следующем запуске
+		return fmt.Errorf("не удалось построить поездки для %d единиц транспорта", failed)
+	}
+
+	logrus.Debugf("Поездки и стоянки перестроены для %d единиц транспорта", len(vehicles))
+	return nil
+}
+
+func (s *DetectTrips) rebuild(vehicle out.PendingTripVehicle, loc *time.Location) error {
+	from, err := s.PrimaryRepository.GetTripRebuildStart(vehicle.VehicleId, vehicle.FirstSentAt)
+	if err != nil {
+		return err
+	}
+
+	// Для пробега нужны точки с начала дня, а также предшествующая ему точка
+	firstSentAt := vehicle.FirstSentAt.In(loc)
+	fromDay := firstSentAt.Format(dateLayout)
+	dayStart := time.Date(firstSentAt.Year(), firstSentAt.Month(), firstSentAt.Day(), 0, 0, 0, 0, loc)
+	pointsFrom := dayStart.Add(-time.Duration(s.Settings.MaxGapSeconds) * time.Second)
+	if from.Before(pointsFrom) {
+		pointsFrom = from
+	}
+
+	points, err := s.PrimaryRepository.GetTripPoints(vehicle.VehicleId, pointsFrom)
+	if err != nil {
+		return err
+	}
+
+	tripPoints := points[sort.Search(len(points), func(i int) bool { return !points[i].SentAt.Before(from) }):]
+	trips, stops := DetectActivities(tripPoints, s.Settings)
+	if err := s.PrimaryRepository.ReplaceVehicleActivities(vehicle.VehicleId, from, trips, stops); err != nil {
+		return err
+	}
+
+	mileage := ComputeDailyMileage(points, s.Settings, loc, fromDay)
+	return s.PrimaryRepository.ReplaceDailyMileage(vehicle.VehicleId, fromDay, mileage)
+}
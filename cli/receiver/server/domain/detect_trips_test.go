@@ -0,0 +1,113 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	"github.com/stretchr/testify/assert"
+)
+
+var tripSettings = TripDetectionSettings{
+	StopSpeedKmh:          5,
+	StopRadiusMeters:      50,
+	StopMinSeconds:        300,
+	MaxGapSeconds:         600,
+	MinTripDistanceMeters: 200,
+}
+
+type tripStep struct {
+	northMeters float64
+	speed       int32
+	after       time.Duration
+}
+
+// buildTripPoints строит точки, смещающиеся к северу, каждая следующая — через after после предыдущей
+func buildTripPoints(steps []tripStep) []out.TripPoint {
+	const metersPerDegree = 111195.0
+	sentAt := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	points := make([]out.TripPoint, 0, len(steps))
+	for i, step := range steps {
+		sentAt = sentAt.Add(step.after)
+		speed := step.speed
+		points = append(points, out.TripPoint{
+			LocationId: int32(i + 1),
+			Latitude:   55.75 + step.northMeters/metersPerDegree,
+			Longitude:  37.62,
+			Speed:      &speed,
+			SentAt:     sentAt,
+		})
+	}
+	return points
+}
+
+func TestDetectActivities(t *testing.T) {
+	points := buildTripPoints([]tripStep{
+		{0, 60, 0}, {1000, 60, time.Minute}, {2000, 60, time.Minute},
+		// Стоянка на 10 минут с дрожанием координат
+		{2005, 0, time.Minute}, {2010, 0, 5 * time.Minute}, {2000, 2, 5 * time.Minute},
+		{3000, 70, time.Minute}, {4000, 80, time.Minute},
+	})
+
+	trips, stops := DetectActivities(points, tripSettings)
+
+	if assert.Len(t, stops, 1) {
+		// Стоянка начинается с точки прибытия в радиус стоянки
+		assert.Equal(t, points[2].SentAt, stops[0].StartedAt)
+		assert.Equal(t, points[5].SentAt, stops[0].EndedAt)
+		assert.Equal(t, int32(4), stops[0].PointCount)
+	}
+	if assert.Len(t, trips, 2) {
+		assert.Equal(t, points[0].SentAt, trips[0].StartedAt)
+		assert.Equal(t, points[2].SentAt, trips[0].EndedAt)
+		assert.InDelta(t, 2000, trips[0].DistanceMeters, 1)
+		assert.Equal(t, int32(60), *trips[0].MaxSpeed)
+
+		assert.Equal(t, points[5].SentAt, trips[1].StartedAt)
+		assert.Equal(t, points[7].SentAt, trips[1].EndedAt)
+		assert.Equal(t, int32(80), *trips[1].MaxSpeed)
+	}
+}
+
+func TestDetectActivitiesSplitsOnGap(t *testing.T) {
+	points := buildTripPoints([]tripStep{
+		{0, 60, 0}, {1000, 60, time.Minute},
+		// Терминал не передавал данные час, за это время транспорт уехал
+		{50000, 60, time.Hour}, {51000, 60, time.Minute},
+	})
+
+	trips, stops := DetectActivities(points, tripSettings)
+
+	assert.Empty(t, stops)
+	if assert.Len(t, trips, 2) {
+		assert.Equal(t, points[1].SentAt, trips[0].EndedAt)
+		assert.Equal(t, points[2].SentAt, trips[1].StartedAt)
+	}
+}
+
+func TestDetectActivitiesIgnoresDrift(t *testing.T) {
+	points := buildTripPoints([]tripStep{
+		{0, 0, 0}, {60, 8, time.Minute}, {0, 0, time.Minute}, {70, 9, time.Minute},
+	})
+
+	trips, stops := DetectActivities(points, tripSettings)
+
+	assert.Empty(t, trips)
+	assert.Empty(t, stops)
+}
+
+func TestDetectActivitiesParkedWithoutData(t *testing.T) {
+	// Терминал выключен на стоянке: разрыв без смещения считается стоянкой
+	points := buildTripPoints([]tripStep{
+		{0, 60, 0}, {1000, 60, time.Minute}, {1010, 0, 8 * time.Hour}, {2000, 60, time.Minute},
+	})
+
+	trips, stops := DetectActivities(points, tripSettings)
+
+	if assert.Len(t, stops, 1) {
+		assert.Equal(t, points[1].SentAt, stops[0].StartedAt)
+		assert.Equal(t, points[2].SentAt, stops[0].EndedAt)
+	}
+	assert.Len(t, trips, 2)
+}
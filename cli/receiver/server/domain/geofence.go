@@ -0,0 +1,229 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	"github.com/daniil11ru/egts/cli/receiver/dto/other"
+)
+
+func isValidCoordinate(latitude, longitude float64) bool {
+	return latitude >= -90 && latitude <= 90 && longitude >= -180 && longitude <= 180
+}
+
+func ValidateGeofence(geofence out.Geofence) error {
+	if geofence.Name == "" {
+		return fmt.Errorf("название геозоны не может быть пустым")
+	}
+
+	switch geofence.Type {
+	case other.GeofenceTypePolygon:
+		if len(geofence.Polygon) == 0 {
+			return fmt.Errorf("для полигона необходимо указать polygon")
+		}
+		for i, ring := range geofence.Polygon {
+			vertices := len(ring)
+			if vertices > 0 && ring[0] == ring[vertices-1] {
+				vertices--
+			}
+			if vertices < 3 {
+				return fmt.Errorf("кольцо %d полигона должно содержать не менее трех вершин", i)
+			}
+			for _, position := range ring {
+				if !isValidCoordinate(position[1], position[0]) {
+					return fmt.Errorf("кольцо %d полигона содержит недопустимые координаты [%g, %g]", i, position[0], position[1])
+				}
+			}
+		}
+	case other.GeofenceTypeCircle:
+		if geofence.CenterLatitude == nil || geofence.CenterLongitude == nil || geofence.RadiusMeters == nil {
+			return fmt.Errorf("для круга необходимо указать center_latitude, center_longitude и radius_meters")
+		}
+		if !isValidCoordinate(*geofence.CenterLatitude, *geofence.CenterLongitude) {
+			return fmt.Errorf("недопустимые координаты центра круга")
+		}
+		if *geofence.RadiusMeters <= 0 {
+			return fmt.Errorf("radius_meters должен быть положительным")
+		}
+	default:
+		return fmt.Errorf("недопустимый тип геозоны: %q", geofence.Type)
+	}
+	return nil
+}
+
+// ringContains проверяет вхождение точки в кольцо методом трассировки луча. Кольцо может быть как
+// замкнутым, так и незамкнутым.
+func ringContains(ring [][2]float64, latitude, longitude float64) bool {
+	inside := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		xi, yi := ring[i][0], ring[i][1]
+		xj, yj := ring[j][0], ring[j][1]
+		if (yi > latitude) != (yj > latitude) && longitude < (xj-xi)*(latitude-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// GeofenceContains проверяет, находится ли точка внутри геозоны. Точка внутри полигона, если она
+// лежит во внешнем кольце и не лежит ни в одном из вырезов.
+func GeofenceContains(geofence out.Geofence, latitude, longitude float64) bool {
+	switch geofence.Type {
+	case other.GeofenceTypeCircle:
+		if geofence.CenterLatitude == nil || geofence.CenterLongitude == nil || geofence.RadiusMeters == nil {
+			return false
+		}
+		center := out.Point{Latitude: *geofence.CenterLatitude, Longitude: *geofence.CenterLongitude}
+		return center.HorizontalDistanceTo(&out.Point{Latitude: latitude, Longitude: longitude}) <= *geofence.RadiusMeters
+	case other.GeofenceTypePolygon:
+		if len(geofence.Polygon) == 0 || !ringContains(geofence.Polygon[0], latitude, longitude) {
+			return false
+		}
+		for _, hole := range geofence.Polygon[1:] {
+			if ringContains(hole, latitude, longitude) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// DetectGeofenceEvents сравнивает предыдущее и текущее местоположение транспорта и возвращает
+// события входа и выхода. Если предыдущего местоположения нет, считается, что транспорт был вне
+// всех геозон.
+func DetectGeofenceEvents(geofences []out.Geofence, previous *out.Point, current out.Point) []out.GeofenceEvent {
+	var events []out.GeofenceEvent
+	if current.SentAt == nil {
+		return events
+	}
+
+	for _, geofence := range geofences {
+		wasInside := previous != nil && GeofenceContains(geofence, previous.Latitude, previous.Longitude)
+		isInside := GeofenceContains(geofence, current.Latitude, current.Longitude)
+		if wasInside == isInside {
+			continue
+		}
+
+		event := out.GeofenceEvent{
+			GeofenceId: geofence.ID,
+			Type:       other.GeofenceEventTypeEnter,
+			Latitude:   current.Latitude,
+			Longitude:  current.Longitude,
+			OccurredAt: *current.SentAt,
+		}
+		if wasInside {
+			event.Type = other.GeofenceEventTypeExit
+		}
+		if current.LocationId != 0 {
+			locationId := current.LocationId
+			event.LocationId = &locationId
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+type geoJSONObject struct {
+	Type        string          `json:"type"`
+	Features    []geoJSONObject `json:"features"`
+	Geometry    *geoJSONObject  `json:"geometry"`
+	Properties  map[string]any  `json:"properties"`
+	Coordinates json.RawMessage `json:"coordinates"`
+}
+
+func geoJSONNumber(properties map[string]any, keys ...string) (float64, bool) {
+	for _, key := range keys {
+		if value, ok := properties[key].(float64); ok {
+			return value, true
+		}
+	}
+	return 0, false
+}
+
+// ParseGeoJSONGeofences читает геозоны из GeoJSON: FeatureCollection, Feature или отдельной
+// геометрии. Polygon становится полигоном, каждый полигон MultiPolygon — отдельной геозоной, а Point
+// со свойством radius_meters — кругом. Название берется из свойства name.
+func ParseGeoJSONGeofences(data []byte) ([]out.Geofence, error) {
+	var root geoJSONObject
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("некорректный GeoJSON: %w", err)
+	}
+
+	var features []geoJSONObject
+	switch root.Type {
+	case "FeatureCollection":
+		features = root.Features
+	case "Feature":
+		features = []geoJSONObject{root}
+	default:
+		features = []geoJSONObject{{Type: "Feature", Geometry: &root}}
+	}
+	if len(features) == 0 {
+		return nil, fmt.Errorf("GeoJSON не содержит объектов")
+	}
+
+	var geofences []out.Geofence
+	for i, feature := range features {
+		if feature.Geometry == nil {
+			return nil, fmt.Errorf("объект %d не содержит геометрии", i)
+		}
+
+		name, _ := feature.Properties["name"].(string)
+		if name == "" {
+			name = fmt.Sprintf("Геозона %d", i+1)
+		}
+
+		var parsed []out.Geofence
+		geometry := feature.Geometry
+		switch geometry.Type {
+		case "Polygon":
+			var polygon out.GeofencePolygon
+			if err := json.Unmarshal(geometry.Coordinates, &polygon); err != nil {
+				return nil, fmt.Errorf("объект %d: некорректные координаты полигона: %w", i, err)
+			}
+			parsed = append(parsed, out.Geofence{Name: name, Type: other.GeofenceTypePolygon, Polygon: polygon})
+		case "MultiPolygon":
+			var polygons []out.GeofencePolygon
+			if err := json.Unmarshal(geometry.Coordinates, &polygons); err != nil {
+				return nil, fmt.Errorf("объект %d: некорректные координаты мультиполигона: %w", i, err)
+			}
+			for j, polygon := range polygons {
+				partName := name
+				if len(polygons) > 1 {
+					partName = fmt.Sprintf("%s (%d)", name, j+1)
+				}
+				parsed = append(parsed, out.Geofence{Name: partName, Type: other.GeofenceTypePolygon, Polygon: polygon})
+			}
+		case "Point":
+			var position [2]float64
+			if err := json.Unmarshal(geometry.Coordinates, &position); err != nil {
+				return nil, fmt.Errorf("объект %d: некорректные координаты точки: %w", i, err)
+			}
+			radius, ok := geoJSONNumber(feature.Properties, "radius_meters", "radius")
+			if !ok {
+				return nil, fmt.Errorf("объект %d: для точки необходимо указать свойство radius_meters", i)
+			}
+			longitude, latitude := position[0], position[1]
+			parsed = append(parsed, out.Geofence{
+				Name:            name,
+				Type:            other.GeofenceTypeCircle,
+				CenterLatitude:  &latitude,
+				CenterLongitude: &longitude,
+				RadiusMeters:    &radius,
+			})
+		default:
+			return nil, fmt.Errorf("объект %d: неподдерживаемый тип геометрии %q", i, geometry.Type)
+		}
+
+		for _, geofence := range parsed {
+			if err := ValidateGeofence(geofence); err != nil {
+				return nil, fmt.Errorf("объект %d: %w", i, err)
+			}
+		}
+		geofences = append(geofences, parsed...)
+	}
+
+	return geofences, nil
+}
@@ -0,0 +1,156 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	"github.com/daniil11ru/egts/cli/receiver/dto/other"
+	"github.com/stretchr/testify/assert"
+)
+
+// squareGeofence — квадрат 37.0–38.0 в.д., 55.0–56.0 с.ш. с вырезом 37.4–37.6, 55.4–55.6
+var squareGeofence = out.Geofence{
+	ID:   1,
+	Name: "Квадрат",
+	Type: other.GeofenceTypePolygon,
+	Polygon: out.GeofencePolygon{
+		{{37.0, 55.0}, {38.0, 55.0}, {38.0, 56.0}, {37.0, 56.0}, {37.0, 55.0}},
+		{{37.4, 55.4}, {37.6, 55.4}, {37.6, 55.6}, {37.4, 55.6}},
+	},
+}
+
+func circleGeofence(id int32, latitude, longitude, radius float64) out.Geofence {
+	return out.Geofence{
+		ID:              id,
+		Name:            "Круг",
+		Type:            other.GeofenceTypeCircle,
+		CenterLatitude:  &latitude,
+		CenterLongitude: &longitude,
+		RadiusMeters:    &radius,
+	}
+}
+
+func TestGeofenceContains(t *testing.T) {
+	assert.True(t, GeofenceContains(squareGeofence, 55.2, 37.2))
+	assert.False(t, GeofenceContains(squareGeofence, 55.5, 37.5), "точка в вырезе")
+	assert.False(t, GeofenceContains(squareGeofence, 56.5, 37.5))
+	assert.False(t, GeofenceContains(squareGeofence, 55.5, 36.9))
+
+	// 0.001 градуса широты — около 111 метров
+	circle := circleGeofence(2, 55.75, 37.62, 200)
+	assert.True(t, GeofenceContains(circle, 55.751, 37.62))
+	assert.False(t, GeofenceContains(circle, 55.752, 37.62))
+}
+
+func TestDetectGeofenceEvents(t *testing.T) {
+	sentAt := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	geofences := []out.Geofence{squareGeofence, circleGeofence(2, 55.2, 37.2, 1000)}
+
+	outside := out.Point{Latitude: 54.9, Longitude: 37.2}
+	inside := out.Point{LocationId: 10, Latitude: 55.2, Longitude: 37.2, SentAt: &sentAt}
+
+	events := DetectGeofenceEvents(geofences, &outside, inside)
+	if assert.Len(t, events, 2) {
+		assert.Equal(t, int32(1), events[0].GeofenceId)
+		assert.Equal(t, other.GeofenceEventTypeEnter, events[0].Type)
+		assert.Equal(t, sentAt, events[0].OccurredAt)
+		if assert.NotNil(t, events[0].LocationId) {
+			assert.Equal(t, int32(10), *events[0].LocationId)
+		}
+		assert.Equal(t, int32(2), events[1].GeofenceId)
+	}
+
+	// Переход из круга в вырез квадрата: выход из обеих геозон
+	hole := out.Point{Latitude: 55.5, Longitude: 37.5, SentAt: &sentAt}
+	events = DetectGeofenceEvents(geofences, &inside, hole)
+	if assert.Len(t, events, 2) {
+		assert.Equal(t, other.GeofenceEventTypeExit, events[0].Type)
+		assert.Equal(t, other.GeofenceEventTypeExit, events[1].Type)
+		assert.Nil(t, events[0].LocationId)
+	}
+
+	assert.Empty(t, DetectGeofenceEvents(geofences, &inside, inside))
+	assert.Len(t, DetectGeofenceEvents(geofences, nil, inside), 2, "без предыдущей точки транспорт считается вне геозон")
+	assert.Empty(t, DetectGeofenceEvents(geofences, nil, hole))
+}
+
+func TestValidateGeofence(t *testing.T) {
+	assert.NoError(t, ValidateGeofence(squareGeofence))
+	assert.NoError(t, ValidateGeofence(circleGeofence(1, 55.75, 37.62, 100)))
+
+	assert.Error(t, ValidateGeofence(out.Geofence{Name: "Пусто", Type: other.GeofenceTypePolygon}))
+	assert.Error(t, ValidateGeofence(out.Geofence{
+		Name:    "Отрезок",
+		Type:    other.GeofenceTypePolygon,
+		Polygon: out.GeofencePolygon{{{37.0, 55.0}, {38.0, 55.0}, {37.0, 55.0}}},
+	}))
+	assert.Error(t, ValidateGeofence(out.Geofence{
+		Name:    "Вне диапазона",
+		Type:    other.GeofenceTypePolygon,
+		Polygon: out.GeofencePolygon{{{37.0, 55.0}, {38.0, 95.0}, {37.0, 56.0}}},
+	}))
+	assert.Error(t, ValidateGeofence(circleGeofence(1, 55.75, 37.62, 0)))
+
+	unnamed := squareGeofence
+	unnamed.Name = ""
+	assert.Error(t, ValidateGeofence(unnamed))
+}
+
+func TestParseGeoJSONGeofences(t *testing.T) {
+	data := []byte(`{
+		"type": "FeatureCollection",
+		"features": [
+			{
+				"type": "Feature",
+				"properties": {"name": "Склад"},
+				"geometry": {"type": "Polygon", "coordinates": [[[37.0, 55.0], [38.0, 55.0], [38.0, 56.0, 150], [37.0, 55.0]]]}
+			},
+			{
+				"type": "Feature",
+				"properties": {"name": "База", "radius_meters": 250},
+				"geometry": {"type": "Point", "coordinates": [37.62, 55.75]}
+			},
+			{
+				"type": "Feature",
+				"properties": {},
+				"geometry": {"type": "MultiPolygon", "coordinates": [
+					[[[37.0, 55.0], [38.0, 55.0], [38.0, 56.0]]],
+					[[[39.0, 55.0], [40.0, 55.0], [40.0, 56.0]]]
+				]}
+			}
+		]
+	}`)
+
+	geofences, err := ParseGeoJSONGeofences(data)
+	if assert.NoError(t, err) && assert.Len(t, geofences, 4) {
+		assert.Equal(t, "Склад", geofences[0].Name)
+		assert.Equal(t, other.GeofenceTypePolygon, geofences[0].Type)
+		assert.Equal(t, [2]float64{38.0, 56.0}, geofences[0].Polygon[0][2], "высота отбрасывается")
+
+		assert.Equal(t, other.GeofenceTypeCircle, geofences[1].Type)
+		assert.Equal(t, 55.75, *geofences[1].CenterLatitude)
+		assert.Equal(t, 37.62, *geofences[1].CenterLongitude)
+		assert.Equal(t, 250.0, *geofences[1].RadiusMeters)
+
+		assert.Equal(t, "Геозона 3 (1)", geofences[2].Name)
+		assert.Equal(t, "Геозона 3 (2)", geofences[3].Name)
+	}
+
+	geofences, err = ParseGeoJSONGeofences([]byte(`{"type": "Polygon", "coordinates": [[[37.0, 55.0], [38.0, 55.0], [38.0, 56.0]]]}`))
+	if assert.NoError(t, err) && assert.Len(t, geofences, 1) {
+		assert.Equal(t, "Геозона 1", geofences[0].Name)
+	}
+
+	_, err = ParseGeoJSONGeofences([]byte(`{"type": "Point", "coordinates": [37.62, 55.75]}`))
+	assert.Error(t, err, "точка без радиуса")
+
+	_, err = ParseGeoJSONGeofences([]byte(`{"type": "LineString", "coordinates": [[37.0, 55.0], [38.0, 55.0]]}`))
+	assert.Error(t, err)
+
+	_, err = ParseGeoJSONGeofences([]byte(`{"type": "FeatureCollection", "features": []}`))
+	assert.Error(t, err)
+
+	_, err = ParseGeoJSONGeofences([]byte(`not json`))
+	assert.Error(t, err)
+}
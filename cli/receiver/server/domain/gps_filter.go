@@ -0,0 +1,118 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	"github.com/daniil11ru/egts/cli/receiver/dto/other"
+)
+
+type GpsFilterRejection struct {
+	Rule   other.GpsFilterRule
+	Detail string
+}
+
+func int32Value(v int32) *int32 { return &v }
+
+func float64Value(v float64) *float64 { return &v }
+
+// DefaultGpsFilterSettings применяются к провайдерам, для которых настройки не сохранены. Все
+// проверки отключены: фильтрация начинается только после сохранения настроек провайдера.
+func DefaultGpsFilterSettings(providerId int32) out.GpsFilterSettings {
+	return out.GpsFilterSettings{ProviderId: providerId}
+}
+
+func ValidateGpsFilterSettings(settings out.GpsFilterSettings) error {
+	if settings.MaxHdop != nil && *settings.MaxHdop <= 0 {
+		return fmt.Errorf("max_hdop должен быть положительным")
+	}
+	if settings.MaxFutureSeconds != nil && *settings.MaxFutureSeconds < 0 {
+		return fmt.Errorf("max_future_seconds не может быть отрицательным")
+	}
+	if settings.MaxSpeedKmh != nil && *settings.MaxSpeedKmh <= 0 {
+		return fmt.Errorf("max_speed_kmh должен быть положительным")
+	}
+	return nil
+}
+
+type packetFilter func(settings out.GpsFilterSettings, data *other.PacketData) *GpsFilterRejection
+
+func filterInvalidPosition(settings out.GpsFilterSettings, data *other.PacketData) *GpsFilterRejection {
+	if settings.RequireValid && !data.Valid {
+		return &GpsFilterRejection{Rule: other.GpsFilterRuleInvalidPosition, Detail: "флаг VLD не установлен"}
+	}
+	return nil
+}
+
+func filterNo3dFix(settings out.GpsFilterSettings, data *other.PacketData) *GpsFilterRejection {
+	if settings.Require3dFix && !data.Fix3d {
+		return &GpsFilterRejection{Rule: other.GpsFilterRuleNo3dFix, Detail: "флаг FIX указывает на 2D-решение"}
+	}
+	return nil
+}
+
+func filterHighHdop(settings out.GpsFilterSettings, data *other.PacketData) *GpsFilterRejection {
+	if settings.MaxHdop != nil && data.Hdop != nil && *data.Hdop > *settings.MaxHdop {
+		return &GpsFilterRejection{
+			Rule:   other.GpsFilterRuleHighHdop,
+			Detail: fmt.Sprintf("HDOP %.2f превышает %.2f", *data.Hdop, *settings.MaxHdop),
+		}
+	}
+	return nil
+}
+
+func filterFutureTime(settings out.GpsFilterSettings, data *other.PacketData) *GpsFilterRejection {
+	if settings.MaxFutureSeconds == nil {
+		return nil
+	}
+	ahead := data.SentTimestamp - data.ReceivedTimestamp
+	if ahead > int64(*settings.MaxFutureSeconds) {
+		return &GpsFilterRejection{
+			Rule:   other.GpsFilterRuleFutureTime,
+			Detail: fmt.Sprintf("время навигации опережает время получения на %d с", ahead),
+		}
+	}
+	return nil
+}
+
+var packetFilters = []packetFilter{
+	filterInvalidPosition,
+	filterNo3dFix,
+	filterHighHdop,
+	filterFutureTime,
+}
+
+// FilterPacket последовательно применяет фильтры, не зависящие от истории транспорта, и возвращает
+// первое сработавшее правило
+func FilterPacket(settings out.GpsFilterSettings, data *other.PacketData) *GpsFilterRejection {
+	for _, filter := range packetFilters {
+		if rejection := filter(settings, data); rejection != nil {
+			return rejection
+		}
+	}
+	return nil
+}
+
+// FilterMovement отклоняет точку, если переход от предыдущей точки требует скорости выше допустимой.
+// Точки без времени навигации и точки, пришедшие не по порядку, не проверяются.
+func FilterMovement(settings out.GpsFilterSettings, last, current out.Point) *GpsFilterRejection {
+	if settings.MaxSpeedKmh == nil || last.SentAt == nil || current.SentAt == nil {
+		return nil
+	}
+	elapsed := current.SentAt.Sub(*last.SentAt)
+	if elapsed <= 0 {
+		return nil
+	}
+
+	distance := last.HorizontalDistanceTo(&current)
+	speedKmh := distance / elapsed.Seconds() * 3.6
+	if speedKmh > *settings.MaxSpeedKmh {
+		return &GpsFilterRejection{
+			Rule: other.GpsFilterRuleImpossibleSpeed,
+			Detail: fmt.Sprintf("%.0f м за %s, скорость %.0f км/ч превышает %.0f км/ч",
+				distance, elapsed.Round(time.Second), speedKmh, *settings.MaxSpeedKmh),
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	"github.com/daniil11ru/egts/cli/receiver/dto/other"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultGpsFilterSettingsAcceptEverything(t *testing.T) {
+	settings := DefaultGpsFilterSettings(1)
+	start := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	minuteLater := start.Add(time.Minute)
+
+	data := other.PacketData{Valid: false, SentTimestamp: 10000, ReceivedTimestamp: 1000, Hdop: float64Value(50)}
+	assert.Nil(t, FilterPacket(settings, &data))
+	assert.Nil(t, FilterMovement(settings,
+		out.Point{Latitude: 55.75, Longitude: 37.62, SentAt: &start},
+		out.Point{Latitude: 56.65, Longitude: 37.62, SentAt: &minuteLater},
+	))
+}
+
+func TestFilterPacket(t *testing.T) {
+	settings := DefaultGpsFilterSettings(1)
+	settings.RequireValid = true
+	settings.MaxHdop = float64Value(5)
+	settings.MaxFutureSeconds = int32Value(300)
+
+	data := other.PacketData{Valid: true, SentTimestamp: 1000, ReceivedTimestamp: 1000}
+	assert.Nil(t, FilterPacket(settings, &data))
+
+	invalid := data
+	invalid.Valid = false
+	if rejection := FilterPacket(settings, &invalid); assert.NotNil(t, rejection) {
+		assert.Equal(t, other.GpsFilterRuleInvalidPosition, rejection.Rule)
+	}
+
+	settings.Require3dFix = true
+	if rejection := FilterPacket(settings, &data); assert.NotNil(t, rejection) {
+		assert.Equal(t, other.GpsFilterRuleNo3dFix, rejection.Rule)
+	}
+	settings.Require3dFix = false
+
+	noisy := data
+	noisy.Hdop = float64Value(12.5)
+	if rejection := FilterPacket(settings, &noisy); assert.NotNil(t, rejection) {
+		assert.Equal(t, other.GpsFilterRuleHighHdop, rejection.Rule)
+	}
+
+	future := data
+	future.SentTimestamp = data.ReceivedTimestamp + 3600
+	if rejection := FilterPacket(settings, &future); assert.NotNil(t, rejection) {
+		assert.Equal(t, other.GpsFilterRuleFutureTime, rejection.Rule)
+	}
+}
+
+func TestFilterMovement(t *testing.T) {
+	settings := DefaultGpsFilterSettings(1)
+	settings.MaxSpeedKmh = float64Value(300)
+	start := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	minuteLater := start.Add(time.Minute)
+
+	last := out.Point{Latitude: 55.75, Longitude: 37.62, SentAt: &start}
+	// Около 1 км за минуту — 60 км/ч
+	near := out.Point{Latitude: 55.759, Longitude: 37.62, SentAt: &minuteLater}
+	// Около 100 км за минуту
+	far := out.Point{Latitude: 56.65, Longitude: 37.62, SentAt: &minuteLater}
+
+	assert.Nil(t, FilterMovement(settings, last, near))
+	if rejection := FilterMovement(settings, last, far); assert.NotNil(t, rejection) {
+		assert.Equal(t, other.GpsFilterRuleImpossibleSpeed, rejection.Rule)
+	}
+
+	// Точки, пришедшие не по порядку, не проверяются
+	assert.Nil(t, FilterMovement(settings, far, last))
+
+	settings.MaxSpeedKmh = nil
+	assert.Nil(t, FilterMovement(settings, last, far))
+}
@@ -0,0 +1,194 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/other"
+	"github.com/daniil11ru/egts/cli/receiver/metrics"
+	repository "github.com/daniil11ru/egts/cli/receiver/server/repository"
+)
+
+// HealthPolicy задает условия, при которых приемник считается готовым принимать данные
+type HealthPolicy struct {
+	// MaxSaveQueue — количество местоположений в очереди на сохранение, при превышении которого
+	// приемник считается неготовым. 0 отключает проверку.
+	MaxSaveQueue int
+}
+
+type ListenerHealth struct {
+	ProviderId int32
+	Address    string
+	State      other.ListenerState
+	Error      string
+	Since      time.Time
+}
+
+type DatabaseHealth struct {
+	Checked          bool
+	Available        bool
+	Error            string
+	MigrationVersion *uint
+	MigrationDirty   bool
+}
+
+type JobHealth struct {
+	Job           string
+	LastSuccessAt time.Time
+}
+
+type HealthReport struct {
+	Healthy   bool
+	Problems  []string
+	StartedAt time.Time
+	CheckedAt time.Time
+	Listeners []ListenerHealth
+	Database  DatabaseHealth
+	SaveQueue int
+	Jobs      []JobHealth
+}
+
+// Health собирает состояние портов провайдеров, базы данных, очереди сохранения и фоновых задач.
+// Состояние портов сообщают серверы, остальное считывается в момент проверки.
+type Health struct {
+	PrimaryRepository repository.Primary
+	Policy            HealthPolicy
+	// SaveQueueDepth возвращает количество местоположений, ожидающих сохранения
+	SaveQueueDepth func() int
+
+	startedAt time.Time
+
+	mu        sync.Mutex
+	listeners map[int32]ListenerHealth
+}
+
+func NewHealth(primaryRepository repository.Primary, policy HealthPolicy, saveQueueDepth func() int) *Health {
+	return &Health{
+		PrimaryRepository: primaryRepository,
+		Policy:            policy,
+		SaveQueueDepth:    saveQueueDepth,
+		startedAt:         time.Now(),
+		listeners:         make(map[int32]ListenerHealth),
+	}
+}
+
+// SetListener запоминает состояние порта провайдера. Для нулевого Health ничего не делает.
+func (h *Health) SetListener(providerId int32, address string, state other.ListenerState, err error) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	listener := ListenerHealth{ProviderId: providerId, Address: address, State: state, Since: time.Now()}
+	if previous, ok := h.listeners[providerId]; ok && previous.State == state {
+		listener.Since = previous.Since
+	}
+	if err != nil {
+		listener.Error = err.Error()
+	}
+	h.listeners[providerId] = listener
+}
+
+// RemoveListener забывает порт провайдера, удаленного из конфига
+func (h *Health) RemoveListener(providerId int32) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.listeners, providerId)
+}
+
+// Liveness сообщает, что процесс работает, не обращаясь к базе данных
+func (h *Health) Liveness(now time.Time) HealthReport {
+	report := h.snapshot(now)
+	report.Healthy = true
+	return report
+}
+
+// Readiness проверяет, что все порты провайдеров открыты, база данных доступна, миграции применены
+// без ошибок и очередь сохранения не переполнена
+func (h *Health) Readiness(now time.Time) HealthReport {
+	report := h.snapshot(now)
+	report.Database = h.checkDatabase()
+	evaluateReadiness(&report, h.Policy)
+	return report
+}
+
+func (h *Health) snapshot(now time.Time) HealthReport {
+	report := HealthReport{
+		StartedAt: h.startedAt,
+		CheckedAt: now,
+		SaveQueue: h.SaveQueueDepth(),
+	}
+
+	h.mu.Lock()
+	for _, listener := range h.listeners {
+		report.Listeners = append(report.Listeners, listener)
+	}
+	h.mu.Unlock()
+	sort.Slice(report.Listeners, func(i, j int) bool {
+		return report.Listeners[i].ProviderId < report.Listeners[j].ProviderId
+	})
+
+	for job, lastSuccessAt := range metrics.LastJobSuccesses() {
+		report.Jobs = append(report.Jobs, JobHealth{Job: job, LastSuccessAt: lastSuccessAt})
+	}
+	sort.Slice(report.Jobs, func(i, j int) bool { return report.Jobs[i].Job < report.Jobs[j].Job })
+
+	return report
+}
+
+func (h *Health) checkDatabase() DatabaseHealth {
+	database := DatabaseHealth{Checked: true}
+	if err := h.PrimaryRepository.Ping(); err != nil {
+		database.Error = err.Error()
+		return database
+	}
+	database.Available = true
+
+	version, err := h.PrimaryRepository.GetMigrationVersion()
+	if err != nil {
+		database.Error = err.Error()
+		return database
+	}
+	database.MigrationVersion = &version.Version
+	database.MigrationDirty = version.Dirty
+	return database
+}
+
+func evaluateReadiness(report *HealthReport, policy HealthPolicy) {
+	report.Problems = nil
+
+	if len(report.Listeners) == 0 {
+		report.Problems = append(report.Problems, "Ни один порт провайдера не открыт")
+	}
+	for _, listener := range report.Listeners {
+		switch listener.State {
+		case other.ListenerStateStarting:
+			report.Problems = append(report.Problems, fmt.Sprintf("Порт %s провайдера с ID %d еще не открыт", listener.Address, listener.ProviderId))
+		case other.ListenerStateFailed:
+			report.Problems = append(report.Problems, fmt.Sprintf("Не удалось открыть порт %s провайдера с ID %d: %s", listener.Address, listener.ProviderId, listener.Error))
+		}
+	}
+
+	if report.Database.Checked {
+		switch {
+		case !report.Database.Available:
+			report.Problems = append(report.Problems, "База данных недоступна: "+report.Database.Error)
+		case report.Database.MigrationVersion == nil:
+			report.Problems = append(report.Problems, "Не удалось определить версию миграций: "+report.Database.Error)
+		case report.Database.MigrationDirty:
+			report.Problems = append(report.Problems, fmt.Sprintf("Миграции остановились на версии %d (dirty)", *report.Database.MigrationVersion))
+		}
+	}
+
+	if policy.MaxSaveQueue > 0 && report.SaveQueue > policy.MaxSaveQueue {
+		report.Problems = append(report.Problems, fmt.Sprintf("В очереди на сохранение %d местоположений, допустимо не более %d", report.SaveQueue, policy.MaxSaveQueue))
+	}
+
+	report.Healthy = len(report.Problems) == 0
+}
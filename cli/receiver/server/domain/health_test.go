@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/other"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealthSetListenerKeepsStateSince(t *testing.T) {
+	health := &Health{SaveQueueDepth: func() int { return 0 }, listeners: make(map[int32]ListenerHealth)}
+
+	health.SetListener(2, "127.0.0.1:7001", other.ListenerStateFailed, errors.New("address already in use"))
+	health.SetListener(1, "127.0.0.1:7000", other.ListenerStateListening, nil)
+	failedSince := health.listeners[2].Since
+	time.Sleep(time.Millisecond)
+	health.SetListener(2, "127.0.0.1:7001", other.ListenerStateFailed, errors.New("address already in use"))
+
+	report := health.Liveness(time.Now())
+	assert.True(t, report.Healthy)
+	if assert.Len(t, report.Listeners, 2) {
+		assert.Equal(t, int32(1), report.Listeners[0].ProviderId)
+		assert.Equal(t, failedSince, report.Listeners[1].Since)
+		assert.Equal(t, "address already in use", report.Listeners[1].Error)
+	}
+
+	var nilHealth *Health
+	nilHealth.SetListener(1, "127.0.0.1:7000", other.ListenerStateListening, nil)
+}
+
+func TestEvaluateReadiness(t *testing.T) {
+	version := uint(34)
+	listening := ListenerHealth{ProviderId: 1, Address: "127.0.0.1:7000", State: other.ListenerStateListening}
+	database := DatabaseHealth{Checked: true, Available: true, MigrationVersion: &version}
+
+	report := HealthReport{Listeners: []ListenerHealth{listening}, Database: database, SaveQueue: 10}
+	evaluateReadiness(&report, HealthPolicy{MaxSaveQueue: 100})
+	assert.True(t, report.Healthy)
+	assert.Empty(t, report.Problems)
+
+	failed := ListenerHealth{ProviderId: 2, Address: "127.0.0.1:7001", State: other.ListenerStateFailed, Error: "address already in use"}
+	report = HealthReport{Listeners: []ListenerHealth{listening, failed}, Database: database}
+	evaluateReadiness(&report, HealthPolicy{})
+	assert.False(t, report.Healthy)
+	assert.Len(t, report.Problems, 1)
+
+	report = HealthReport{Listeners: []ListenerHealth{listening}, Database: DatabaseHealth{Checked: true, Error: "timeout"}}
+	evaluateReadiness(&report, HealthPolicy{})
+	assert.False(t, report.Healthy)
+
+	report = HealthReport{Listeners: []ListenerHealth{listening}, Database: DatabaseHealth{Checked: true, Available: true, MigrationVersion: &version, MigrationDirty: true}}
+	evaluateReadiness(&report, HealthPolicy{})
+	assert.False(t, report.Healthy)
+
+	report = HealthReport{Listeners: []ListenerHealth{listening}, Database: database, SaveQueue: 101}
+	evaluateReadiness(&report, HealthPolicy{MaxSaveQueue: 100})
+	assert.False(t, report.Healthy)
+
+	report = HealthReport{Database: database}
+	evaluateReadiness(&report, HealthPolicy{})
+	assert.False(t, report.Healthy, "порты провайдеров не открыты")
+}
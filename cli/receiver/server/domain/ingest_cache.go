@@ -0,0 +1,106 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/daniil11ru/egts/cli/receiver/cache"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	repository "github.com/daniil11ru/egts/cli/receiver/server/repository"
+	"gorm.io/gorm"
+)
+
+// ingestCacheTtl — срок хранения настроек приема данных, измененных в обход API
+const ingestCacheTtl = 30 * time.Second
+
+// IngestCache хранит настройки, которые нужны при сохранении каждой точки. Приемник и API работают
+// в одном процессе: обработчики API сбрасывают кэш после изменения настроек.
+type IngestCache struct {
+	gpsFilterSettings *cache.Loading[int32, out.GpsFilterSettings]
+	geofences         *cache.Loading[struct{}, []out.Geofence]
+	schedules         *cache.Loading[struct{}, []out.AcceptanceSchedule]
+	vehicles          *cache.Loading[int32, *VehicleIndex]
+}
+
+func NewIngestCache(primaryRepository repository.Primary) *IngestCache {
+	return &IngestCache{
+		gpsFilterSettings: cache.NewLoading(func(providerId int32) (out.GpsFilterSettings, error) {
+			settings, err := primaryRepository.GetGpsFilterSettings(providerId)
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return DefaultGpsFilterSettings(providerId), nil
+			}
+			return settings, err
+		}, ingestCacheTtl),
+		geofences: cache.NewLoading(func(struct{}) ([]out.Geofence, error) {
+			return primaryRepository.GetAllGeofences()
+		}, ingestCacheTtl),
+		schedules: cache.NewLoading(func(struct{}) ([]out.AcceptanceSchedule, error) {
+			schedules, err := primaryRepository.GetAllAcceptanceSchedules()
+			if err != nil {
+				return nil, err
+			}
+			for i := range schedules {
+				if err := ValidateAcceptanceSchedule(&schedules[i]); err != nil {
+					return nil, fmt.Errorf("расписание с ID %d некорректно: %w", schedules[i].ID, err)
+				}
+			}
+			return schedules, nil
+		}, ingestCacheTtl),
+		vehicles: cache.NewLoading(func(providerId int32) (*VehicleIndex, error) {
+			rules, err := primaryRepository.GetOidResolutionRulesByProviderId(providerId)
+			if err != nil {
+				return nil, fmt.Errorf("не удалось получить правила определения транспорта: %w", err)
+			}
+			vehicles, err := primaryRepository.GetVehiclesByProviderId(providerId)
+			if err != nil {
+				return nil, fmt.Errorf("не удалось получить транспорт провайдера: %w", err)
+			}
+			return NewVehicleIndex(rules, vehicles), nil
+		}, ingestCacheTtl),
+	}
+}
+
+func (c *IngestCache) GpsFilterSettings(providerId int32) (out.GpsFilterSettings, error) {
+	return c.gpsFilterSettings.Get(providerId)
+}
+
+func (c *IngestCache) InvalidateGpsFilterSettings(providerId int32) {
+	c.gpsFilterSettings.Invalidate(providerId)
+}
+
+// Geofences возвращает все геозоны с разобранной геометрией
+func (c *IngestCache) Geofences() ([]out.Geofence, error) {
+	return c.geofences.Get(struct{}{})
+}
+
+func (c *IngestCache) InvalidateGeofences() {
+	c.geofences.InvalidateAll()
+}
+
+// AcceptanceSchedules возвращает все расписания приема с загруженными временными зонами
+func (c *IngestCache) AcceptanceSchedules() ([]out.AcceptanceSchedule, error) {
+	return c.schedules.Get(struct{}{})
+}
+
+func (c *IngestCache) InvalidateAcceptanceSchedules() {
+	c.schedules.InvalidateAll()
+}
+
+// VehicleIndex возвращает транспорт провайдера, разложенный по OID согласно правилам определения
+// транспорта
+func (c *IngestCache) VehicleIndex(providerId int32) (*VehicleIndex, error) {
+	return c.vehicles.Get(providerId)
+}
+
+// InvalidateVehicles сбрасывает индекс транспорта провайдера после изменения транспорта или правил
+// определения транспорта
+func (c *IngestCache) InvalidateVehicles(providerId int32) {
+	c.vehicles.Invalidate(providerId)
+}
+
+// InvalidateAllVehicles сбрасывает индексы всех провайдеров, например когда транспорт мог перейти к
+// другому провайдеру
+func (c *IngestCache) InvalidateAllVehicles() {
+	c.vehicles.InvalidateAll()
+}
@@ -1,98 +1,286 @@
 package domain
 
 import (
+	"errors"
 	"fmt"
 	"math"
 	"time"
 
+	"github.com/daniil11ru/egts/cli/receiver/cache"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/filter"
 	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
 	repository "github.com/daniil11ru/egts/cli/receiver/server/repository"
-	"github.com/daniil11ru/egts/cli/receiver/util"
 	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
 )
 
-type Line struct {
-	First  out.Point
-	Second out.Point
+const (
+	earthRadiusMeters = 6371000.0
+	archiveBatchSize  = 1000
+)
+
+// DefaultTrackSimplificationSettings применяются к провайдерам, для которых настройки не сохранены
+func DefaultTrackSimplificationSettings(providerId int32) out.TrackSimplificationSettings {
+	return out.TrackSimplificationSettings{
+		ProviderId:       providerId,
+		Enabled:          true,
+		ToleranceMeters:  10,
+		MaxGapSeconds:    300,
+		StopRadiusMeters: 30,
+		StopMinSeconds:   180,
+	}
 }
 
-func (lineMovement Line) DistanceToPosition(position out.Point) float64 {
-	a, b, c := lineMovement.Coefficients()
-	return math.Abs(a*position.Longitude+b*position.Latitude+c) / math.Sqrt(a*a+b*b)
+func ValidateTrackSimplificationSettings(settings out.TrackSimplificationSettings) error {
+	if settings.ToleranceMeters <= 0 {
+		return fmt.Errorf("tolerance_meters должен быть положительным")
+	}
+	if settings.MaxGapSeconds <= 0 {
+		return fmt.Errorf("max_gap_seconds должен быть положительным")
+	}
+	if settings.StopRadiusMeters < 0 {
+		return fmt.Errorf("stop_radius_meters не может быть отрицательным")
+	}
+	if settings.StopMinSeconds <= 0 {
+		return fmt.Errorf("stop_min_seconds должен быть положительным")
+	}
+	return nil
 }
 
-func (lineMovement Line) Coefficients() (a, b, c float64) {
-	a = lineMovement.First.Latitude - lineMovement.Second.Latitude
-	b = lineMovement.First.Longitude - lineMovement.Second.Longitude
-	c = lineMovement.First.Longitude*lineMovement.Second.Latitude - lineMovement.First.Longitude*lineMovement.Second.Latitude
+// toLocalMeters проецирует точку на плоскость, касательную к Земле в точке origin. Для отрезков
+// трека длиной до десятков километров погрешность проекции пренебрежимо мала.
+func toLocalMeters(origin, p out.Point) (x, y float64) {
+	const rad = math.Pi / 180
+
+	dLon := p.Longitude - origin.Longitude
+	if dLon > 180 {
+		dLon -= 360
+	} else if dLon < -180 {
+		dLon += 360
+	}
 
-	return a, b, c
+	x = dLon * rad * earthRadiusMeters * math.Cos(origin.Latitude*rad)
+	y = (p.Latitude - origin.Latitude) * rad * earthRadiusMeters
+	return x, y
 }
 
-type OptimizeGeometry struct {
-	PrimaryRepository repository.Primary
+// distanceToSegment возвращает расстояние в метрах от точки p до отрезка ab
+func distanceToSegment(p, a, b out.Point) float64 {
+	px, py := toLocalMeters(a, p)
+	bx, by := toLocalMeters(a, b)
+
+	lengthSq := bx*bx + by*by
+	if lengthSq == 0 {
+		return a.HorizontalDistanceTo(&p)
+	}
+
+	t := (px*bx + py*by) / lengthSq
+	t = math.Max(0, math.Min(1, t))
+	return math.Hypot(px-t*bx, py-t*by)
 }
 
-func (s *OptimizeGeometry) Run() error {
-	logrus.Info("Запуск запланированной задачи оптимизации транспортных треков")
+func elapsedSeconds(from, to out.Point) (float64, bool) {
+	if from.SentAt == nil || to.SentAt == nil {
+		return 0, false
+	}
+	return to.SentAt.Sub(*from.SentAt).Seconds(), true
+}
 
-	tracks, err := s.PrimaryRepository.GetTracks(time.Now().Add(-24*time.Hour), time.Now())
-	if err != nil {
-		return fmt.Errorf("не удалось получить треки: %w", err)
+type TrackSimplification struct {
+	Kept     []int32
+	Archived []int32
+	Segments int
+	Stops    int
+}
+
+// SimplifyTrack упрощает трек, упорядоченный по времени навигации. Трек разбивается на участки по
+// разрывам во времени и стоянкам: концы участков сохраняются всегда, промежуточные точки стоянок
+// отбрасываются, а движение между ними упрощается алгоритмом Дугласа — Пекера с допуском в метрах.
+func SimplifyTrack(points []out.Point, settings out.TrackSimplificationSettings) TrackSimplification {
+	n := len(points)
+	result := TrackSimplification{}
+	if n == 0 {
+		return result
 	}
+	result.Segments = 1
 
-	count := 0
-	for _, track := range tracks {
-		simplifiedTrack := GetSimplifiedTrack(track.Points, 0.0001)
+	keep := make([]bool, n)
+	keep[0], keep[n-1] = true, true
+
+	isGap := func(i int) bool {
+		elapsed, ok := elapsedSeconds(points[i], points[i+1])
+		return ok && elapsed > float64(settings.MaxGapSeconds)
+	}
 
-		for _, movement := range track.Points {
-			exists := false
+	// Границы участков: для стоянки запоминается её последняя точка, чтобы не упрощать её изнутри
+	anchors := []int{0}
+	stopEnds := map[int]int{}
 
-			for _, movementID := range simplifiedTrack {
-				if movement.LocationId == movementID {
-					exists = true
-					break
-				}
-			}
+	for i := 0; i < n-1; {
+		if isGap(i) {
+			result.Segments++
+			anchors = append(anchors, i, i+1)
+			i++
+			continue
+		}
+
+		j := i
+		for j+1 < n && !isGap(j) && points[i].HorizontalDistanceTo(&points[j+1]) <= settings.StopRadiusMeters {
+			j++
+		}
+		if duration, ok := elapsedSeconds(points[i], points[j]); ok && j > i && duration >= float64(settings.StopMinSeconds) {
+			result.Stops++
+			anchors = append(anchors, i, j)
+			stopEnds[i] = j
+			i = j
+			continue
+		}
+
+		i++
+	}
+	anchors = append(anchors, n-1)
+
+	for _, anchor := range anchors {
+		keep[anchor] = true
+	}
 
-			if !exists {
-				count++
-				s.PrimaryRepository.DeleteLocation(movement.LocationId)
+	for k := 0; k+1 < len(anchors); k++ {
+		first, last := anchors[k], anchors[k+1]
+		if last-first < 2 || stopEnds[first] == last {
+			continue
+		}
+		douglasPeucker(points, first, last, settings.ToleranceMeters, keep)
+	}
+
+	for i, point := range points {
+		if keep[i] {
+			result.Kept = append(result.Kept, point.LocationId)
+		} else {
+			result.Archived = append(result.Archived, point.LocationId)
+		}
+	}
+	return result
+}
+
+func douglasPeucker(points []out.Point, first, last int, tolerance float64, keep []bool) {
+	type span struct{ first, last int }
+	stack := []span{{first, last}}
+
+	for len(stack) > 0 {
+		s := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		idx, maxDist := -1, 0.0
+		for i := s.first + 1; i < s.last; i++ {
+			if d := distanceToSegment(points[i], points[s.first], points[s.last]); d > maxDist {
+				idx, maxDist = i, d
 			}
 		}
+
+		if idx != -1 && maxDist > tolerance {
+			keep[idx] = true
+			stack = append(stack, span{s.first, idx}, span{idx, s.last})
+		}
 	}
+}
 
-	logrus.Info("Оптимизация транспортных треков завершена, удалено точек: ", count)
+type VehicleTrackSimplification struct {
+	VehicleId  int32
+	ProviderId int32
+	Total      int
+	TrackSimplification
+}
 
-	return nil
+type OptimizeGeometry struct {
+	PrimaryRepository repository.Primary
+	LastPositionCache *cache.LastPosition
 }
 
-func GetSimplifiedTrack(movements []out.Point, ep float64) []int32 {
-	if len(movements) <= 2 {
-		result := util.Map(movements, func(item out.Point) int32 { return item.LocationId })
-		return result
+func (s *OptimizeGeometry) getSettings(providerId int32, known map[int32]out.TrackSimplificationSettings) (out.TrackSimplificationSettings, error) {
+	if settings, ok := known[providerId]; ok {
+		return settings, nil
+	}
+
+	settings, err := s.PrimaryRepository.GetTrackSimplificationSettings(providerId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		settings, err = DefaultTrackSimplificationSettings(providerId), nil
+	}
+	if err != nil {
+		return out.TrackSimplificationSettings{}, err
 	}
 
-	line := Line{First: movements[0], Second: movements[len(movements)-1]}
+	known[providerId] = settings
+	return settings, nil
+}
 
-	idx, maxDist := seekMostDistantPoint(line, movements)
-	if maxDist >= ep {
-		left := GetSimplifiedTrack(movements[:idx+1], ep)
-		right := GetSimplifiedTrack(movements[idx:], ep)
-		return append(left[:len(left)-1], right...)
+// DryRun вычисляет, какие точки будут перенесены в архив, ничего не изменяя. Если settings не nil,
+// они применяются ко всем трекам вместо сохранённых настроек провайдеров.
+func (s *OptimizeGeometry) DryRun(tracksFilter filter.Tracks, settings *out.TrackSimplificationSettings) ([]VehicleTrackSimplification, error) {
+	tracks, err := s.PrimaryRepository.GetTracks(tracksFilter)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить треки: %w", err)
 	}
 
-	return []int32{movements[0].LocationId, movements[len(movements)-1].LocationId}
+	known := map[int32]out.TrackSimplificationSettings{}
+	var result []VehicleTrackSimplification
+
+	for _, track := range tracks {
+		var trackSettings out.TrackSimplificationSettings
+		if settings != nil {
+			trackSettings = *settings
+		} else {
+			trackSettings, err = s.getSettings(track.ProviderId, known)
+			if err != nil {
+				return nil, fmt.Errorf("не удалось получить настройки упрощения треков провайдера с ID %d: %w", track.ProviderId, err)
+			}
+		}
+		if !trackSettings.Enabled {
+			continue
+		}
+
+		result = append(result, VehicleTrackSimplification{
+			VehicleId:           track.VehicleId,
+			ProviderId:          track.ProviderId,
+			Total:               len(track.Points),
+			TrackSimplification: SimplifyTrack(track.Points, trackSettings),
+		})
+	}
+
+	return result, nil
 }
 
-func seekMostDistantPoint(lineMovement Line, movements []out.Point) (idx int, maxDist float64) {
-	for i := 0; i < len(movements); i++ {
-		d := lineMovement.DistanceToPosition(out.Point{Latitude: movements[i].Latitude, Longitude: movements[i].Longitude})
-		if d > maxDist {
-			maxDist = d
-			idx = i
+func (s *OptimizeGeometry) Run() error {
+	logrus.Info("Запуск запланированной задачи оптимизации транспортных треков")
+
+	now := time.Now()
+	plan, err := s.DryRun(filter.Tracks{ReceivedAfter: now.Add(-24 * time.Hour), ReceivedBefore: now}, nil)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+
+	var count int64
+	for _, vehicle := range plan {
+		if len(vehicle.Archived) == 0 {
+			continue
+		}
+
+		for start := 0; start < len(vehicle.Archived); start += archiveBatchSize {
+			end := min(start+archiveBatchSize, len(vehicle.Archived))
+			archived, err := s.PrimaryRepository.ArchiveLocations(vehicle.Archived[start:end])
+			if err != nil {
+				logrus.Errorf("Не удалось перенести точки транспорта с ID %d в архив: %v", vehicle.VehicleId, err)
+				break
+			}
+			count += archived
+		}
+
+		if s.LastPositionCache != nil {
+			s.LastPositionCache.Invalidate(vehicle.VehicleId)
 		}
 	}
 
-	return idx, maxDist
+	logrus.Info("Оптимизация транспортных треков завершена, перенесено в архив точек: ", count)
+
+	return nil
 }
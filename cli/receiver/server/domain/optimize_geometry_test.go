@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	"github.com/stretchr/testify/assert"
+)
+
+// buildTrack строит трек из смещений в метрах к северу и востоку от начальной точки, по одной
+// точке в интервал
+func buildTrack(latitude float64, interval time.Duration, offsets [][2]float64) []out.Point {
+	start := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	origin := out.Point{Latitude: latitude, Longitude: 37.62}
+
+	metersPerDegreeLon, _ := toLocalMeters(origin, out.Point{Latitude: latitude, Longitude: 38.62})
+	_, metersPerDegreeLat := toLocalMeters(origin, out.Point{Latitude: latitude + 1, Longitude: 37.62})
+
+	points := make([]out.Point, 0, len(offsets))
+	for i, offset := range offsets {
+		sentAt := start.Add(time.Duration(i) * interval)
+		points = append(points, out.Point{
+			LocationId: int32(i + 1),
+			Latitude:   latitude + offset[0]/metersPerDegreeLat,
+			Longitude:  37.62 + offset[1]/metersPerDegreeLon,
+			SentAt:     &sentAt,
+		})
+	}
+	return points
+}
+
+func TestSimplifyTrackToleranceInMeters(t *testing.T) {
+	settings := DefaultTrackSimplificationSettings(1)
+
+	// Движение на восток с отклонениями в 2–3 м и объездом на 60 м
+	offsets := [][2]float64{{0, 0}, {2, 100}, {-2, 200}, {0, 300}, {60, 350}, {0, 400}, {3, 500}, {0, 600}}
+
+	for _, latitude := range []float64{0, 55.75, 70} {
+		result := SimplifyTrack(buildTrack(latitude, 10*time.Second, offsets), settings)
+		assert.Equal(t, []int32{1, 4, 5, 6, 8}, result.Kept, "широта %v", latitude)
+		assert.Equal(t, []int32{2, 3, 7}, result.Archived, "широта %v", latitude)
+	}
+}
+
+func TestSimplifyTrackKeepsTimeGaps(t *testing.T) {
+	settings := DefaultTrackSimplificationSettings(1)
+	points := buildTrack(55.75, 10*time.Second, [][2]float64{{0, 0}, {0, 100}, {0, 200}, {0, 300}, {0, 400}})
+
+	// Разрыв в час между третьей и четвёртой точками
+	for i := 3; i < len(points); i++ {
+		sentAt := points[i].SentAt.Add(time.Hour)
+		points[i].SentAt = &sentAt
+	}
+
+	result := SimplifyTrack(points, settings)
+	assert.Equal(t, []int32{1, 3, 4, 5}, result.Kept)
+	assert.Equal(t, 2, result.Segments)
+}
+
+func TestSimplifyTrackKeepsStops(t *testing.T) {
+	settings := DefaultTrackSimplificationSettings(1)
+
+	// Движение, стоянка на 4 минуты с дрожанием координат в пределах 20 м, движение
+	offsets := [][2]float64{
+		{0, 0}, {0, 500},
+		{0, 1000}, {15, 1010}, {-15, 990}, {10, 1000}, {0, 1005},
+		{0, 1500}, {0, 2000},
+	}
+	result := SimplifyTrack(buildTrack(55.75, time.Minute, offsets), settings)
+
+	assert.Equal(t, 1, result.Stops)
+	assert.Equal(t, []int32{1, 3, 7, 9}, result.Kept)
+}
+
+func TestValidateTrackSimplificationSettings(t *testing.T) {
+	settings := DefaultTrackSimplificationSettings(1)
+	assert.NoError(t, ValidateTrackSimplificationSettings(settings))
+
+	invalid := settings
+	invalid.ToleranceMeters = 0
+	assert.Error(t, ValidateTrackSimplificationSettings(invalid))
+
+	invalid = settings
+	invalid.MaxGapSeconds = -1
+	assert.Error(t, ValidateTrackSimplificationSettings(invalid))
+}
@@ -0,0 +1,94 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	repository "github.com/daniil11ru/egts/cli/receiver/server/repository"
+	"github.com/sirupsen/logrus"
+)
+
+func ValidateProvider(provider out.Provider) error {
+	if strings.TrimSpace(provider.Name) == "" {
+		return fmt.Errorf("название провайдера не может быть пустым")
+	}
+	if provider.Port != nil && (*provider.Port < 1 || *provider.Port > 65535) {
+		return fmt.Errorf("порт должен быть в пределах от 1 до 65535")
+	}
+	if !provider.Transport.IsValid() {
+		return fmt.Errorf("недопустимый транспорт: %q", provider.Transport)
+	}
+	if provider.MaxPacketsPerMinute != nil && *provider.MaxPacketsPerMinute <= 0 {
+		return fmt.Errorf("ограничение на количество пакетов в минуту должно быть положительным")
+	}
+	if provider.MaxNewVehiclesPerHour != nil && *provider.MaxNewVehiclesPerHour <= 0 {
+		return fmt.Errorf("ограничение на количество нового транспорта в час должно быть положительным")
+	}
+	return nil
+}
+
+// FindProviderByPort возвращает провайдера, кроме провайдера с ID exceptId, которому назначен порт
+func FindProviderByPort(providers []out.Provider, port int32, exceptId int32) (out.Provider, bool) {
+	for _, provider := range providers {
+		if provider.ID != exceptId && provider.Port != nil && *provider.Port == port {
+			return provider, true
+		}
+	}
+	return out.Provider{}, false
+}
+
+// ProviderChanges сообщает приемнику, что провайдеры в базе данных изменились и серверы нужно
+// привести в соответствие. Уведомления, поступившие до обработки предыдущего, объединяются.
+type ProviderChanges chan struct{}
+
+func NewProviderChanges() ProviderChanges {
+	return make(ProviderChanges, 1)
+}
+
+func (c ProviderChanges) Notify() {
+	select {
+	case c <- struct{}{}:
+	default:
+	}
+}
+
+// ImportProviderPorts переносит порты из устаревшего параметра конфига provider_id_to_port в
+// таблицу provider для провайдеров, у которых порт еще не задан
+func ImportProviderPorts(primaryRepository repository.Primary, ports map[int32]int) error {
+	if len(ports) == 0 {
+		return nil
+	}
+
+	providers, err := primaryRepository.GetAllProviders()
+	if err != nil {
+		return fmt.Errorf("не удалось получить провайдеров: %w", err)
+	}
+	byId := make(map[int32]out.Provider, len(providers))
+	for _, provider := range providers {
+		byId[provider.ID] = provider
+	}
+
+	for providerId, port := range ports {
+		provider, ok := byId[providerId]
+		if !ok {
+			logrus.Warnf("Провайдер с ID %d из provider_id_to_port не найден в базе данных", providerId)
+			continue
+		}
+		if provider.Port != nil {
+			if int(*provider.Port) != port {
+				logrus.Warnf("Для провайдера с ID %d в базе данных задан порт %d, порт %d из provider_id_to_port не используется", providerId, *provider.Port, port)
+			}
+			continue
+		}
+		if owner, ok := FindProviderByPort(providers, int32(port), providerId); ok {
+			logrus.Warnf("Порт %d из provider_id_to_port уже назначен провайдеру с ID %d", port, owner.ID)
+			continue
+		}
+		if err := primaryRepository.SetProviderPort(providerId, int32(port)); err != nil {
+			return fmt.Errorf("не удалось назначить порт провайдеру с ID %d: %w", providerId, err)
+		}
+		logrus.Infof("Провайдеру с ID %d назначен порт %d из provider_id_to_port", providerId, port)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	"github.com/daniil11ru/egts/cli/receiver/dto/other"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateProvider(t *testing.T) {
+	provider := out.Provider{Name: "Провайдер", Port: int32Ptr(7000), Transport: other.ProviderTransportTcp}
+	assert.NoError(t, ValidateProvider(provider))
+
+	provider.Port = nil
+	assert.NoError(t, ValidateProvider(provider))
+
+	provider.Port = int32Ptr(70000)
+	assert.Error(t, ValidateProvider(provider))
+
+	provider.Port = int32Ptr(7000)
+	provider.Name = "  "
+	assert.Error(t, ValidateProvider(provider))
+
+	provider.Name = "Провайдер"
+	provider.Transport = "udp"
+	assert.Error(t, ValidateProvider(provider))
+
+	provider.Transport = other.ProviderTransportTcp
+	provider.MaxPacketsPerMinute = int32Ptr(600)
+	provider.MaxNewVehiclesPerHour = int32Ptr(10)
+	assert.NoError(t, ValidateProvider(provider))
+
+	provider.MaxNewVehiclesPerHour = int32Ptr(-1)
+	assert.Error(t, ValidateProvider(provider))
+}
+
+func TestFindProviderByPort(t *testing.T) {
+	providers := []out.Provider{
+		{ID: 1, Port: int32Ptr(7000)},
+		{ID: 2},
+		{ID: 3, Port: int32Ptr(7001)},
+	}
+
+	owner, ok := FindProviderByPort(providers, 7001, 0)
+	assert.True(t, ok)
+	assert.Equal(t, int32(3), owner.ID)
+
+	_, ok = FindProviderByPort(providers, 7001, 3)
+	assert.False(t, ok)
+
+	_, ok = FindProviderByPort(providers, 7002, 0)
+	assert.False(t, ok)
+}
+
+func TestProviderChangesNotifyCoalesces(t *testing.T) {
+	changes := NewProviderChanges()
+	changes.Notify()
+	changes.Notify()
+
+	assert.Len(t, changes, 1)
+	<-changes
+	assert.Len(t, changes, 0)
+}
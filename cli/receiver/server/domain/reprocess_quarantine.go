@@ -0,0 +1,149 @@
+package domain
+
+import (
+	"fmt"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	"github.com/daniil11ru/egts/cli/receiver/dto/other"
+	repository "github.com/daniil11ru/egts/cli/receiver/server/repository"
+	"github.com/sirupsen/logrus"
+)
+
+// reprocessQuarantineBatchSize — количество точек карантина, переносимых за один проход
+const reprocessQuarantineBatchSize = 1000
+
+type ReprocessQuarantineResult struct {
+	Moved     int64
+	Skipped   int64
+	Discarded int64
+	Remaining int64
+}
+
+type ReprocessQuarantine struct {
+	PrimaryRepository repository.Primary
+	// SavePacket сохраняет перенесенные точки тем же путем, что и принятые: с фильтрами, определением
+	// исторических точек и проверкой на повтор
+	SavePacket *SavePacket
+}
+
+// quarantinedPacketData восстанавливает данные пакета по точке из карантина
+func quarantinedPacketData(location out.QuarantinedLocation) *other.PacketData {
+	data := &other.PacketData{
+		OID:               uint32(location.OID),
+		ReceivedTimestamp: location.ReceivedAt.Unix(),
+		Latitude:          location.Latitude,
+		Longitude:         location.Longitude,
+		TerminalIMEI:      location.TerminalIMEI,
+		Valid:             location.Valid,
+		Fix3d:             location.Fix3d,
+		History:           location.IsHistory,
+		Hdop:              location.Hdop,
+	}
+	sentAt := location.ReceivedAt
+	if location.SentAt != nil {
+		sentAt = *location.SentAt
+	}
+	data.SentTimestamp = sentAt.Unix()
+	if location.Altitude != nil {
+		data.Altitude = uint32(*location.Altitude)
+	}
+	if location.Direction != nil {
+		data.Direction = uint8(*location.Direction)
+	}
+	if location.Speed != nil {
+		data.Speed = uint16(*location.Speed)
+	}
+	if location.SatelliteCount != nil {
+		data.SatelliteCount = uint8(*location.SatelliteCount)
+	}
+	if location.OdometerMeters != nil {
+		odometer := uint32(*location.OdometerMeters / 100)
+		data.Odometer = &odometer
+	}
+	return data
+}
+
+// moveGroup переносит точки группы карантина в местоположения транспорта. Обработанные точки
+// удаляются из карантина после каждого прохода; если перенос прервется, уже сохраненные точки при
+// повторном запуске будут пропущены проверкой на повтор.
+func (s *ReprocessQuarantine) moveGroup(group out.QuarantineGroup, vehicleId int32) (int64, int64, error) {
+	var moved, skipped int64
+	for {
+		locations, err := s.PrimaryRepository.GetQuarantinedLocations(group.ProviderId, group.OID, group.TerminalIMEI, reprocessQuarantineBatchSize)
+		if err != nil {
+			return moved, skipped, fmt.Errorf("не удалось получить данные из карантина: %w", err)
+		}
+		if len(locations) == 0 {
+			return moved, skipped, nil
+		}
+
+		ids := make([]int32, 0, len(locations))
+		for _, location := range locations {
+			saved, err := s.SavePacket.SaveVehicleLocation(quarantinedPacketData(location), group.ProviderId, vehicleId)
+			if err != nil {
+				if _, deleteErr := s.PrimaryRepository.DeleteQuarantinedLocationsByIds(ids); deleteErr != nil {
+					logrus.Warnf("Не удалось удалить перенесенные точки из карантина: %v", deleteErr)
+				}
+				return moved, skipped, err
+			}
+			if saved {
+				moved++
+			} else {
+				skipped++
+			}
+			ids = append(ids, location.ID)
+		}
+
+		if _, err := s.PrimaryRepository.DeleteQuarantinedLocationsByIds(ids); err != nil {
+			return moved, skipped, fmt.Errorf("не удалось удалить перенесенные точки из карантина: %w", err)
+		}
+	}
+}
+
+func (s *ReprocessQuarantine) Run(providerId *int32, oid *int64) (ReprocessQuarantineResult, error) {
+	var result ReprocessQuarantineResult
+
+	groups, err := s.PrimaryRepository.GetQuarantineGroups(providerId, oid)
+	if err != nil {
+		return result, fmt.Errorf("не удалось получить данные из карантина: %w", err)
+	}
+
+	for _, group := range groups {
+		vehicles, err := findVehicles(s.SavePacket.IngestCache, group.OID, group.ProviderId, group.TerminalIMEI)
+		if err != nil {
+			return result, fmt.Errorf("не удалось найти транспорт по OID %d: %w", group.OID, err)
+		}
+		if len(vehicles) != 1 {
+			result.Remaining += group.Count
+			continue
+		}
+
+		vehicle := vehicles[0]
+		switch vehicle.ModerationStatus {
+		case other.ModerationStatusApproved:
+			moved, skipped, err := s.moveGroup(group, vehicle.ID)
+			result.Moved += moved
+			result.Skipped += skipped
+			if err != nil {
+				return result, err
+			}
+			if vehicle.OID == nil || *vehicle.OID != group.OID {
+				if err := s.PrimaryRepository.UpdateVehicleOid(vehicle.ID, group.OID); err != nil {
+					logrus.Warnf("Не удалось обновить OID транспорта с ID %d: %v", vehicle.ID, err)
+				}
+				s.SavePacket.IngestCache.InvalidateVehicles(group.ProviderId)
+			}
+			logrus.Infof("Из карантина перенесено %d точек для транспорта с ID %d, пропущено %d", moved, vehicle.ID, skipped)
+		case other.ModerationStatusRejected:
+			discarded, err := s.PrimaryRepository.DeleteQuarantinedLocations(group.ProviderId, group.OID, group.TerminalIMEI)
+			if err != nil {
+				return result, err
+			}
+			result.Discarded += discarded
+		default:
+			result.Remaining += group.Count
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuarantinedPacketData(t *testing.T) {
+	sentAt := time.Date(2025, 7, 1, 9, 50, 43, 0, time.UTC)
+	altitude, speed, odometer := int64(10), int32(60), int64(1523400)
+	location := out.QuarantinedLocation{
+		OID:            1014463084,
+		TerminalIMEI:   "356307042441013",
+		Latitude:       63.47,
+		Longitude:      48.84,
+		Altitude:       &altitude,
+		Speed:          &speed,
+		Hdop:           float64Value(0.9),
+		Valid:          true,
+		IsHistory:      true,
+		OdometerMeters: &odometer,
+		SentAt:         &sentAt,
+		ReceivedAt:     sentAt.Add(2 * time.Second),
+	}
+
+	data := quarantinedPacketData(location)
+	assert.Equal(t, uint32(1014463084), data.OID)
+	assert.Equal(t, sentAt.Unix(), data.SentTimestamp)
+	assert.Equal(t, sentAt.Unix()+2, data.ReceivedTimestamp)
+	assert.Equal(t, uint32(10), data.Altitude)
+	assert.Equal(t, uint16(60), data.Speed)
+	assert.True(t, data.Valid)
+	assert.False(t, data.Fix3d)
+	assert.True(t, data.History)
+	if assert.NotNil(t, data.Odometer) {
+		assert.Equal(t, uint32(15234), *data.Odometer)
+	}
+
+	location.SentAt = nil
+	assert.Equal(t, location.ReceivedAt.Unix(), quarantinedPacketData(location).SentTimestamp)
+}
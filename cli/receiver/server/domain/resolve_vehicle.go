@@ -0,0 +1,141 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	"github.com/daniil11ru/egts/cli/receiver/dto/other"
+)
+
+type VehicleResolutionResult string
+
+const (
+	VehicleResolutionResolved      VehicleResolutionResult = "resolved"
+	VehicleResolutionAmbiguous     VehicleResolutionResult = "ambiguous"
+	VehicleResolutionNotFound      VehicleResolutionResult = "not_found"
+	VehicleResolutionNotConfigured VehicleResolutionResult = "not_configured"
+)
+
+type VehicleResolutionStep struct {
+	Rule     out.OidResolutionRule
+	Vehicles []out.Vehicle
+	Err      error
+}
+
+type VehicleResolution struct {
+	Result   VehicleResolutionResult
+	Vehicles []out.Vehicle
+	Rule     *out.OidResolutionRule
+	Steps    []VehicleResolutionStep
+}
+
+func onlyDigits(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// OidResolutionRuleUsesPosition возвращает true, если правило берет сегмент IMEI с начала или конца
+func OidResolutionRuleUsesPosition(ruleType other.OidResolutionRuleType) bool {
+	switch ruleType {
+	case other.OidResolutionRuleTypeImeiDigits, other.OidResolutionRuleTypeImeiBytes, other.OidResolutionRuleTypeImeiMaxDigits:
+		return true
+	}
+	return false
+}
+
+// OidResolutionRuleUsesLength возвращает true, если длина сегмента IMEI задается в правиле
+func OidResolutionRuleUsesLength(ruleType other.OidResolutionRuleType) bool {
+	return ruleType == other.OidResolutionRuleTypeImeiDigits || ruleType == other.OidResolutionRuleTypeImeiBytes
+}
+
+func ValidateOidResolutionRule(ruleType other.OidResolutionRuleType, position *other.ImeiSegmentPosition, length *int16) error {
+	if !ruleType.IsValid() {
+		return fmt.Errorf("недопустимый тип правила: %q", ruleType)
+	}
+	if position != nil && !position.IsValid() {
+		return fmt.Errorf("недопустимая позиция сегмента IMEI: %q", *position)
+	}
+
+	switch ruleType {
+	case other.OidResolutionRuleTypeImeiDigits, other.OidResolutionRuleTypeImeiBytes:
+		if position == nil || length == nil {
+			return fmt.Errorf("для правила %s обязательны position и length", ruleType)
+		}
+		if *length < 1 {
+			return fmt.Errorf("length должен быть положительным")
+		}
+		// OID в EGTS занимает 4 байта
+		if ruleType == other.OidResolutionRuleTypeImeiBytes && *length > 4 {
+			return fmt.Errorf("для правила %s length не должен превышать 4", ruleType)
+		}
+		if ruleType == other.OidResolutionRuleTypeImeiDigits && *length > 18 {
+			return fmt.Errorf("для правила %s length не должен превышать 18", ruleType)
+		}
+	case other.OidResolutionRuleTypeImeiMaxDigits:
+		if position == nil {
+			return fmt.Errorf("для правила %s обязателен position", ruleType)
+		}
+	}
+
+	return nil
+}
+
+// DeriveOid вычисляет OID из IMEI так же, как это делает tools/fill_database_with_vehicles_from_xlsx.py
+func DeriveOid(imei string, ruleType other.OidResolutionRuleType, position other.ImeiSegmentPosition, length int) (int64, error) {
+	s := onlyDigits(imei)
+	if s == "" {
+		return 0, fmt.Errorf("IMEI не содержит цифр")
+	}
+
+	switch ruleType {
+	case other.OidResolutionRuleTypeImeiDigits:
+		if length > len(s) {
+			length = len(s)
+		}
+		sub := s[:length]
+		if position == other.ImeiSegmentPositionEnd {
+			sub = s[len(s)-length:]
+		}
+		return strconv.ParseInt(sub, 10, 64)
+	case other.OidResolutionRuleTypeImeiBytes:
+		n, ok := new(big.Int).SetString(s, 10)
+		if !ok {
+			return 0, fmt.Errorf("не удалось преобразовать IMEI %q в число", imei)
+		}
+		b := n.Bytes()
+		if len(b) == 0 {
+			b = []byte{0}
+		}
+		if length > len(b) {
+			length = len(b)
+		}
+		slice := b[:length]
+		if position == other.ImeiSegmentPositionEnd {
+			slice = b[len(b)-length:]
+		}
+		return new(big.Int).SetBytes(slice).Int64(), nil
+	case other.OidResolutionRuleTypeImeiMaxDigits:
+		for n := len(s); n > 0; n-- {
+			sub := s[:n]
+			if position == other.ImeiSegmentPositionEnd {
+				sub = s[len(s)-n:]
+			}
+			v, err := strconv.ParseUint(sub, 10, 64)
+			if err == nil && v <= math.MaxUint32 {
+				return int64(v), nil
+			}
+		}
+		return 0, fmt.Errorf("невозможно вместить IMEI %q в 4 байта", imei)
+	}
+
+	return 0, fmt.Errorf("правило %s не вычисляет OID из IMEI", ruleType)
+}
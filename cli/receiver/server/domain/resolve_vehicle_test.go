@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/other"
+	"github.com/stretchr/testify/assert"
+)
+
+func int16Ptr(v int16) *int16 { return &v }
+
+func positionPtr(p other.ImeiSegmentPosition) *other.ImeiSegmentPosition { return &p }
+
+func TestDeriveOid(t *testing.T) {
+	const imei = "863071014463084"
+
+	oid, err := DeriveOid(imei, other.OidResolutionRuleTypeImeiDigits, other.ImeiSegmentPositionEnd, 10)
+	if assert.NoError(t, err) {
+		assert.Equal(t, int64(1014463084), oid)
+	}
+
+	oid, err = DeriveOid(imei, other.OidResolutionRuleTypeImeiDigits, other.ImeiSegmentPositionStart, 6)
+	if assert.NoError(t, err) {
+		assert.Equal(t, int64(863071), oid)
+	}
+
+	oid, err = DeriveOid(imei, other.OidResolutionRuleTypeImeiMaxDigits, other.ImeiSegmentPositionEnd, 0)
+	if assert.NoError(t, err) {
+		assert.Equal(t, int64(1014463084), oid)
+	}
+
+	// 863071014463084 = 0x03 10 F5 61 3B A6 6C
+	oid, err = DeriveOid(imei, other.OidResolutionRuleTypeImeiBytes, other.ImeiSegmentPositionEnd, 4)
+	if assert.NoError(t, err) {
+		assert.Equal(t, int64(0x613BA66C), oid)
+	}
+
+	_, err = DeriveOid("нет цифр", other.OidResolutionRuleTypeImeiDigits, other.ImeiSegmentPositionEnd, 4)
+	assert.Error(t, err)
+}
+
+func TestValidateOidResolutionRuleBytesLength(t *testing.T) {
+	end := positionPtr(other.ImeiSegmentPositionEnd)
+	assert.NoError(t, ValidateOidResolutionRule(other.OidResolutionRuleTypeImeiBytes, end, int16Ptr(4)))
+	// OID в EGTS занимает 4 байта, а 8 байтов могут не поместиться в int64
+	assert.Error(t, ValidateOidResolutionRule(other.OidResolutionRuleTypeImeiBytes, end, int16Ptr(5)))
+	assert.Error(t, ValidateOidResolutionRule(other.OidResolutionRuleTypeImeiBytes, end, int16Ptr(8)))
+}
+
+func TestOidResolutionRuleFields(t *testing.T) {
+	assert.True(t, OidResolutionRuleUsesPosition(other.OidResolutionRuleTypeImeiMaxDigits))
+	assert.False(t, OidResolutionRuleUsesLength(other.OidResolutionRuleTypeImeiMaxDigits))
+	assert.True(t, OidResolutionRuleUsesLength(other.OidResolutionRuleTypeImeiBytes))
+	assert.False(t, OidResolutionRuleUsesPosition(other.OidResolutionRuleTypeExactOid))
+	assert.False(t, OidResolutionRuleUsesPosition(other.OidResolutionRuleTypeTermIdentityImei))
+	assert.False(t, OidResolutionRuleUsesLength(other.OidResolutionRuleTypeTermIdentityImei))
+}
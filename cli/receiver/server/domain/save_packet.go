@@ -2,12 +2,14 @@ package domain
 
 import (
 	"fmt"
-	"math/bits"
-	"strconv"
+	"sync"
 	"time"
 
+	"github.com/daniil11ru/egts/cli/receiver/broker"
+	"github.com/daniil11ru/egts/cli/receiver/cache"
 	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
 	util "github.com/daniil11ru/egts/cli/receiver/dto/other"
+	"github.com/daniil11ru/egts/cli/receiver/metrics"
 	repository "github.com/daniil11ru/egts/cli/receiver/server/repository"
 	cron "github.com/robfig/cron/v3"
 	"github.com/sirupsen/logrus"
@@ -15,57 +17,46 @@ import (
 
 type SavePacket struct {
 	PrimaryRepository repository.Primary
+	LastPositionCache *cache.LastPosition
+	IngestCache       *IngestCache
+	LocationBroker    *broker.Locations
+
+	// SkipExistingLocations включается при повторной обработке архива кадров: местоположения, уже
+	// сохраненные для транспорта с тем же временем отправки, пропускаются
+	SkipExistingLocations bool
 
 	AddVehicleMovementMonthStart int
 	AddVehicleMovementMonthEnd   int
 
-	vehicleIdToLastPosition map[int32]out.Point
-
-	cronScheduler *cron.Cron
-}
-
-func (domain *SavePacket) fillVehicleIdToLastPosition() error {
-	domain.vehicleIdToLastPosition = make(map[int32]out.Point)
-
-	vehicles, getAllVehiclesErr := domain.PrimaryRepository.GetAllVehicles()
-	if getAllVehiclesErr != nil {
-		return fmt.Errorf("не удалось получить список транспорта: %w", getAllVehiclesErr)
-	}
-
-	for i := 0; i < len(vehicles); i++ {
-		lastPosition, getLastPositionErr := domain.PrimaryRepository.GetLastVehiclePoint(vehicles[i].ID)
-		if getLastPositionErr == nil {
-			domain.vehicleIdToLastPosition[vehicles[i].ID] = lastPosition
-		}
-	}
-
-	return nil
+	cronScheduler    *cron.Cron
+	monthsMu         sync.RWMutex
+	vehicleCreations *VehicleCreationLimiter
+	timeZone         *time.Location
 }
 
-func NewSavePacket(primaryRepository repository.Primary, addVehicleMovementStart int, addVehicleMovementEnd int) (*SavePacket, error) {
+func NewSavePacket(primaryRepository repository.Primary, lastPositionCache *cache.LastPosition, ingestCache *IngestCache, locationBroker *broker.Locations, addVehicleMovementStart int, addVehicleMovementEnd int, timeZone *time.Location) (*SavePacket, error) {
 	domain := SavePacket{
 		PrimaryRepository:            primaryRepository,
+		LastPositionCache:            lastPositionCache,
+		IngestCache:                  ingestCache,
+		LocationBroker:               locationBroker,
 		AddVehicleMovementMonthStart: addVehicleMovementStart,
 		AddVehicleMovementMonthEnd:   addVehicleMovementEnd,
+		vehicleCreations:             NewVehicleCreationLimiter(),
+		timeZone:                     timeZone,
 	}
 
-	if err := domain.fillVehicleIdToLastPosition(); err != nil {
-		return nil, fmt.Errorf("не удалось инициализировать кэш транспорта: %w", err)
-	}
-
-	loc, loadLocationErr := time.LoadLocation("Europe/Moscow")
-	if loadLocationErr != nil {
-		return nil, fmt.Errorf("не удалось загрузить временную зону Europe/Moscow: %w", loadLocationErr)
-	}
-	domain.cronScheduler = cron.New(cron.WithLocation(loc))
+	domain.cronScheduler = cron.New(cron.WithLocation(timeZone))
 
 	_, err := domain.cronScheduler.AddFunc("0 3 * * *", func() {
-		logrus.Info("Запуск запланированного обновления кэша транспорта")
-		if err := domain.fillVehicleIdToLastPosition(); err != nil {
-			logrus.Errorf("Ошибка обновления кэша транспорта: %v", err)
-		} else {
-			logrus.Info("Кэш транспорта успешно обновлен")
-		}
+		startedAt := time.Now()
+		evicted := domain.LastPositionCache.EvictExpired()
+		metrics.ObserveJob("last_position_cache_eviction", startedAt, nil)
+		stats := domain.LastPositionCache.Stats()
+		logrus.Infof(
+			"Из кэша последних местоположений удалено устаревших записей: %d, записей в кэше: %d, попаданий: %d, промахов: %d, ошибок загрузки: %d",
+			evicted, stats.Size, stats.Hits, stats.Misses, stats.LoadErrors,
+		)
 	})
 
 	if err != nil {
@@ -73,11 +64,19 @@ func NewSavePacket(primaryRepository repository.Primary, addVehicleMovementStart
 	}
 
 	domain.cronScheduler.Start()
-	logrus.Info("Запланировано ежедневное обновление кэша провайдеров в 03:00")
+	logrus.Info("Запланирована ежедневная очистка кэша последних местоположений в 03:00")
 
 	return &domain, nil
 }
 
+// SetAcceptanceMonths меняет глобальный диапазон месяцев приема данных при перезагрузке конфига
+func (domain *SavePacket) SetAcceptanceMonths(start, end int) {
+	domain.monthsMu.Lock()
+	defer domain.monthsMu.Unlock()
+	domain.AddVehicleMovementMonthStart = start
+	domain.AddVehicleMovementMonthEnd = end
+}
+
 func (domain *SavePacket) Shutdown() {
 	if domain.cronScheduler != nil {
 		domain.cronScheduler.Stop()
@@ -85,163 +84,237 @@ func (domain *SavePacket) Shutdown() {
 	}
 }
 
-func isPrefixBytes(a, b uint64, n int) bool {
-	width := (bits.Len64(b) + 7) / 8
-	if n >= width {
-		return a == b
+// findVehicles определяет транспорт провайдера по OID и IMEI терминала с помощью правил определения
+// транспорта, а если правил нет — по точному совпадению OID
+func findVehicles(ingestCache *IngestCache, OID int64, providerID int32, terminalIMEI string) ([]out.Vehicle, error) {
+	index, err := ingestCache.VehicleIndex(providerID)
+	if err != nil {
+		return []out.Vehicle{}, err
 	}
-	shift := uint((width - n) * 8)
-	mask := uint64(1)<<(uint(n)*8) - 1
-	return a == (b>>shift)&mask
-}
-
-func isSuffixBytes(a, b uint64, n int) bool {
-	width := (bits.Len64(b) + 7) / 8
-	if n >= width {
-		return a == b
+	resolution := index.Resolve(OID, terminalIMEI)
+	for _, step := range resolution.Steps {
+		if step.Err != nil {
+			logrus.Warnf("Правило определения транспорта с ID %d не применено: %v", step.Rule.ID, step.Err)
+		}
 	}
-	return a == b&((1<<(uint(n)*8))-1)
+	return resolution.Vehicles, nil
 }
 
-func isPrefixDigits(a, b uint64, n int) bool {
-	digits := 1
-	for tmp := b; tmp >= 10; tmp /= 10 {
-		digits++
-	}
-	div := uint64(1)
-	for i := 0; i < digits-n; i++ {
-		div *= 10
-	}
-	return a == b/div
+func (s *SavePacket) resolveModerationStatus(id int32) (util.ModerationStatus, error) {
+	moderationStatus, err := s.PrimaryRepository.GetVehicleModerationStatus(id)
+	return moderationStatus, err
 }
 
-func isSuffixDigits(a, b uint64, n int) bool {
-	mod := uint64(1)
-	for i := 0; i < n; i++ {
-		mod *= 10
+// isAccepted проверяет данные по расписаниям приема. Если ни одно расписание не применимо,
+// используется глобальный диапазон месяцев из конфига.
+func (s *SavePacket) isAccepted(data *util.PacketData, providerID int32, vehicleGroupId *int32) (bool, error) {
+	schedules, err := s.IngestCache.AcceptanceSchedules()
+	if err != nil {
+		return false, err
 	}
-	return a == b%mod
-}
 
-func digitCount(n uint64) int {
-	if n == 0 {
-		return 1
-	}
-	count := 0
-	for n > 0 {
-		count++
-		n /= 10
+	applicable := SelectAcceptanceSchedules(schedules, providerID, vehicleGroupId)
+	if len(applicable) == 0 {
+		month := int(time.Now().In(s.timeZone).Month())
+		s.monthsMu.RLock()
+		defer s.monthsMu.RUnlock()
+		return month >= s.AddVehicleMovementMonthStart && month <= s.AddVehicleMovementMonthEnd, nil
 	}
-	return count
-}
 
-func byteCount(n uint64) int {
-	if n == 0 {
-		return 1
-	}
-	return (bits.Len64(n) + 7) / 8
+	return IsAcceptedBySchedules(applicable, time.Unix(data.SentTimestamp, 0), time.Unix(data.ReceivedTimestamp, 0))
 }
 
-func isPartOf(a, b uint64) bool {
-	byteCount := byteCount(a)
-	digitCount := digitCount(a)
-
-	if isSuffixBytes(a, b, byteCount) || isPrefixBytes(a, b, byteCount) ||
-		isSuffixDigits(a, b, digitCount) || isPrefixDigits(a, b, digitCount) {
+// IsHistoryPoint определяет, относится ли точка к истории: терминал пометил ее флагом BB или время
+// навигации раньше, чем у последней живой точки транспорта
+func IsHistoryPoint(data *util.PacketData, lastPosition out.Point, hasLastPosition bool) bool {
+	if data.History {
 		return true
 	}
-
-	return false
+	if !hasLastPosition || lastPosition.SentAt == nil {
+		return false
+	}
+	return time.Unix(data.SentTimestamp, 0).Before(*lastPosition.SentAt)
 }
 
-func (domain *SavePacket) filterVehiclesByOID(OID int64, vehicles []out.Vehicle) ([]out.Vehicle, error) {
-	var result []out.Vehicle
-
-	for _, v := range vehicles {
-		imeiStr := v.IMEI
-		imei, err := strconv.ParseUint(imeiStr, 10, 64)
-		if err != nil {
-			logrus.Warnf("Не удалось преобразовать IMEI '%s' в число: %v", imeiStr, err)
-			continue
-		}
-		if isPartOf(uint64(OID), imei) {
-			result = append(result, v)
-		}
+func (s *SavePacket) reject(data *util.PacketData, providerID int32, vehicleID *int32, rejection *GpsFilterRejection) error {
+	if _, err := s.PrimaryRepository.AddRejectedLocation(data, providerID, vehicleID, rejection.Rule, rejection.Detail); err != nil {
+		return fmt.Errorf("не удалось сохранить отклоненное местоположение по OID %d: %w", data.OID, err)
 	}
-
-	return result, nil
+	logrus.Debugf("Местоположение по OID %d отклонено правилом %s: %s", data.OID, rejection.Rule, rejection.Detail)
+	metrics.DroppedPoints.WithLabelValues("filter_" + string(rejection.Rule)).Inc()
+	return nil
 }
 
-func (s *SavePacket) findVehicles(OID int64, providerID int32) ([]out.Vehicle, error) {
-	vehicles, err := s.PrimaryRepository.GetVehiclesByOIDAndProviderId(OID, providerID)
-	if err == nil {
-		return vehicles, nil
+// allowVehicleCreation проверяет ограничение провайдера на количество транспорта, автоматически
+// добавляемого за час
+func (s *SavePacket) allowVehicleCreation(providerID int32) (bool, error) {
+	if s.vehicleCreations == nil {
+		return true, nil
 	}
-
-	vehicles, err = s.PrimaryRepository.GetVehiclesByProviderId(providerID)
+	provider, err := s.PrimaryRepository.GetProvider(providerID)
 	if err != nil {
-		return []out.Vehicle{}, err
+		return false, err
 	}
-	vehicles, err = s.filterVehiclesByOID(OID, vehicles)
-	if err != nil {
-		return []out.Vehicle{}, err
+	if provider.MaxNewVehiclesPerHour == nil {
+		return true, nil
 	}
-
-	return vehicles, nil
+	return s.vehicleCreations.Allow(providerID, *provider.MaxNewVehiclesPerHour, time.Now()), nil
 }
 
-func (s *SavePacket) resolveModerationStatus(id int32) (util.ModerationStatus, error) {
-	moderationStatus, err := s.PrimaryRepository.GetVehicleModerationStatus(id)
-	return moderationStatus, err
-}
-
-func (s *SavePacket) Run(data *util.PacketData, providerID int32) error {
+// Run сохраняет местоположение и возвращает ID транспорта, к которому оно отнесено, или 0, если
+// транспорт определить не удалось
+func (s *SavePacket) Run(data *util.PacketData, providerID int32) (int32, error) {
 	if data.Latitude == 0 || data.Longitude == 0 || data.OID == 0 {
 		logrus.Debugf("OID: %d, широта: %f, долгота: %f", data.OID, data.Latitude, data.Longitude)
-		return fmt.Errorf("широта, долгота и OID не должны быть пустыми или иметь нулевое значение")
+		metrics.DroppedPoints.WithLabelValues("empty_coordinates").Inc()
+		return 0, fmt.Errorf("широта, долгота и OID не должны быть пустыми или иметь нулевое значение")
 	}
 
 	oid := data.OID
 
-	month := int(time.Now().Local().Month())
-	if month < s.AddVehicleMovementMonthStart || month > s.AddVehicleMovementMonthEnd {
-		logrus.Debug("Запись телематических данных в текущий месяц запрещена")
-		return nil
+	gpsFilterSettings, err := s.IngestCache.GpsFilterSettings(providerID)
+	if err != nil {
+		return 0, fmt.Errorf("не удалось получить настройки фильтрации для провайдера с ID %d: %w", providerID, err)
+	}
+	if rejection := FilterPacket(gpsFilterSettings, data); rejection != nil {
+		return 0, s.reject(data, providerID, nil, rejection)
 	}
 
 	var vehicleID int32
-	vehicles, err := s.findVehicles(int64(oid), providerID)
+	vehicles, err := findVehicles(s.IngestCache, int64(oid), providerID, data.TerminalIMEI)
 	if err != nil {
-		return fmt.Errorf("не удалось найти транспорт по OID %d: %w", oid, err)
-	} else if len(vehicles) == 0 {
+		return 0, fmt.Errorf("не удалось найти транспорт по OID %d: %w", oid, err)
+	}
+
+	var vehicleGroupId *int32
+	if len(vehicles) == 1 {
+		vehicleGroupId = vehicles[0].VehicleGroupId
+	}
+	accepted, err := s.isAccepted(data, providerID, vehicleGroupId)
+	if err != nil {
+		return 0, fmt.Errorf("не удалось проверить расписание приема данных: %w", err)
+	}
+	if !accepted {
+		logrus.Debugf("Запись телематических данных по OID %d не разрешена расписанием приема", oid)
+		metrics.DroppedPoints.WithLabelValues("schedule").Inc()
+		if len(vehicles) == 1 {
+			return vehicles[0].ID, nil
+		}
+		return 0, nil
+	}
+
+	if len(vehicles) == 0 {
+		allowed, err := s.allowVehicleCreation(providerID)
+		if err != nil {
+			return 0, fmt.Errorf("не удалось проверить ограничение на добавление транспорта провайдером с ID %d: %w", providerID, err)
+		}
+		if !allowed {
+			if _, err := s.PrimaryRepository.AddQuarantinedLocation(data, providerID, nil, util.QuarantineReasonVehicleCreationLimit); err != nil {
+				return 0, fmt.Errorf("не удалось поместить в карантин данные по OID %d: %w", oid, err)
+			}
+			logrus.Warnf("Провайдер с ID %d превысил ограничение на добавление нового транспорта в час, данные по OID %d помещены в карантин", providerID, oid)
+			metrics.DroppedPoints.WithLabelValues("quarantine_" + string(util.QuarantineReasonVehicleCreationLimit)).Inc()
+			return 0, nil
+		}
+
 		var addIndefiniteVehicleErr error
 		vehicleID, addIndefiniteVehicleErr = s.PrimaryRepository.AddIndefiniteVehicle(int64(oid), providerID)
 		if addIndefiniteVehicleErr != nil {
-			return fmt.Errorf("не удалось добавить новый транспорт: %w", addIndefiniteVehicleErr)
+			return 0, fmt.Errorf("не удалось добавить новый транспорт: %w", addIndefiniteVehicleErr)
 		}
+		s.IngestCache.InvalidateVehicles(providerID)
 		logrus.Warnf("Не удалось найти транспорт по OID %d, был добавлен новый транспорт с ID %d", oid, vehicleID)
 	} else if len(vehicles) > 1 {
-		return fmt.Errorf("не удалось однозначно определить транспорт по OID %d", oid)
+		if _, err := s.PrimaryRepository.AddQuarantinedLocation(data, providerID, nil, util.QuarantineReasonAmbiguousVehicle); err != nil {
+			return 0, fmt.Errorf("не удалось однозначно определить транспорт по OID %d и поместить данные в карантин: %w", oid, err)
+		}
+		logrus.Warnf("Не удалось однозначно определить транспорт по OID %d, данные помещены в карантин", oid)
+		metrics.DroppedPoints.WithLabelValues("quarantine_" + string(util.QuarantineReasonAmbiguousVehicle)).Inc()
+		return 0, nil
 	} else if len(vehicles) == 1 {
 		vehicleID = vehicles[0].ID
 
-		// FIXME: нужно обновлять только тогда, когда OID действительно отсутствует
-		s.PrimaryRepository.UpdateVehicleOid(vehicleID, int64(oid))
+		// Транспорт мог быть найден по IMEI или правилам определения OID; OID переписывается только
+		// при изменении, чтобы не обновлять запись и не засорять журнал изменений на каждом пакете
+		if current := vehicles[0].OID; current == nil || *current != int64(oid) {
+			if err := s.PrimaryRepository.UpdateVehicleOid(vehicleID, int64(oid)); err != nil {
+				logrus.Warnf("Не удалось обновить OID транспорта с ID %d: %v", vehicleID, err)
+			}
+			s.IngestCache.InvalidateVehicles(providerID)
+		}
 	}
 
 	moderationStatus, err := s.resolveModerationStatus(vehicleID)
 	if err != nil {
-		return fmt.Errorf("не удалось определить статус модерации транспорта с ID %d: %w", vehicleID, err)
+		return vehicleID, fmt.Errorf("не удалось определить статус модерации транспорта с ID %d: %w", vehicleID, err)
 	}
 	if moderationStatus == util.ModerationStatusRejected {
 		logrus.Debugf("Запись телематических данных для транспорта с ID %d запрещена", vehicleID)
-		return nil
+		metrics.DroppedPoints.WithLabelValues("vehicle_rejected").Inc()
+		return vehicleID, nil
+	}
+	if moderationStatus == util.ModerationStatusPending {
+		if _, err := s.PrimaryRepository.AddQuarantinedLocation(data, providerID, &vehicleID, util.QuarantineReasonPendingVehicle); err != nil {
+			return vehicleID, fmt.Errorf("не удалось поместить в карантин данные транспорта с ID %d: %w", vehicleID, err)
+		}
+		logrus.Debugf("Транспорт с ID %d ожидает модерации, данные помещены в карантин", vehicleID)
+		metrics.DroppedPoints.WithLabelValues("quarantine_" + string(util.QuarantineReasonPendingVehicle)).Inc()
+		return vehicleID, nil
 	}
 
+	_, err = s.storeLocation(data, providerID, vehicleID, gpsFilterSettings, s.SkipExistingLocations)
+	return vehicleID, err
+}
+
+// SaveVehicleLocation сохраняет местоположение транспорта, который уже определен и одобрен, например
+// при переносе данных из карантина. Точка проходит те же фильтры и проверки, что и при приеме, а
+// точки, уже сохраненные для транспорта с тем же временем отправки, пропускаются. Возвращает false,
+// если точка отклонена или не сохранена как повторная.
+func (s *SavePacket) SaveVehicleLocation(data *util.PacketData, providerID int32, vehicleID int32) (bool, error) {
+	gpsFilterSettings, err := s.IngestCache.GpsFilterSettings(providerID)
+	if err != nil {
+		return false, fmt.Errorf("не удалось получить настройки фильтрации для провайдера с ID %d: %w", providerID, err)
+	}
+	if rejection := FilterPacket(gpsFilterSettings, data); rejection != nil {
+		return false, s.reject(data, providerID, &vehicleID, rejection)
+	}
+	return s.storeLocation(data, providerID, vehicleID, gpsFilterSettings, true)
+}
+
+// storeLocation проверяет точку относительно последнего местоположения транспорта и сохраняет ее.
+// При checkExisting любая точка, а не только историческая, сверяется с сохраненными по времени
+// отправки. Возвращает false, если точка отклонена или совпадает с уже сохраненной.
+func (s *SavePacket) storeLocation(data *util.PacketData, providerID int32, vehicleID int32, gpsFilterSettings out.GpsFilterSettings, checkExisting bool) (bool, error) {
 	altitude := int64(data.Altitude)
-	currentPosition := out.Point{Latitude: data.Latitude, Longitude: data.Longitude, Altitude: &altitude}
-	lastPosition, OK := s.vehicleIdToLastPosition[vehicleID]
-	if OK {
+	sentAt := time.Unix(data.SentTimestamp, 0)
+	currentPosition := out.Point{Latitude: data.Latitude, Longitude: data.Longitude, Altitude: &altitude, SentAt: &sentAt}
+
+	lastPosition, OK, err := s.LastPositionCache.Get(vehicleID)
+	if err != nil {
+		logrus.Warnf("Не удалось получить последнее местоположение транспорта с ID %d: %v", vehicleID, err)
+	}
+
+	// Точки из черного ящика и точки старше последней живой точки не сравниваются с ней и не попадают в кэш
+	isHistory := IsHistoryPoint(data, lastPosition, OK)
+
+	// Терминал передает записи черного ящика повторно, если не получил подтверждение, поэтому
+	// историческая точка сохраняется, только если у транспорта нет точки с тем же временем отправки
+	if checkExisting || isHistory {
+		exists, err := s.PrimaryRepository.LocationExists(vehicleID, sentAt)
+		if err != nil {
+			return false, fmt.Errorf("не удалось проверить наличие местоположения транспорта с ID %d: %w", vehicleID, err)
+		}
+		if exists {
+			logrus.Debugf("Местоположение транспорта с ID %d уже сохранено", vehicleID)
+			metrics.DroppedPoints.WithLabelValues("already_saved").Inc()
+			return false, nil
+		}
+	}
+	if OK && !isHistory {
+		if rejection := FilterMovement(gpsFilterSettings, lastPosition, currentPosition); rejection != nil {
+			return false, s.reject(data, providerID, &vehicleID, rejection)
+		}
+
 		accuracyMeters := 10.0
 
 		equals := false
@@ -251,19 +324,72 @@ func (s *SavePacket) Run(data *util.PacketData, providerID int32) error {
 			equals, err = lastPosition.EqualsTo(&currentPosition, accuracyMeters)
 		}
 		if err != nil {
-			return fmt.Errorf("не удалось оценить расстояние между новым и предыдущим местоположением для транспорта с ID %d: %w", vehicleID, err)
+			return false, fmt.Errorf("не удалось оценить расстояние между новым и предыдущим местоположением для транспорта с ID %d: %w", vehicleID, err)
 		}
 
 		if equals {
 			logrus.Debugf("Новое местоположение транспорта с ID %d не отличается от предыдущего", vehicleID)
-			return nil
+			metrics.DroppedPoints.WithLabelValues("duplicate").Inc()
+			return false, nil
 		}
 	}
-	s.vehicleIdToLastPosition[vehicleID] = currentPosition
 
-	if _, err := s.PrimaryRepository.AddLocation(data, vehicleID); err != nil {
-		return fmt.Errorf("не удалось сохранить телематические данные для транспорта с ID %d: %w", vehicleID, err)
+	locationId, err := s.PrimaryRepository.AddLocation(data, vehicleID, isHistory)
+	if err != nil {
+		return false, fmt.Errorf("не удалось сохранить телематические данные для транспорта с ID %d: %w", vehicleID, err)
 	}
+	if isHistory {
+		logrus.Debugf("Сохранена историческая точка транспорта с ID %d", vehicleID)
+		return true, nil
+	}
+	currentPosition.LocationId = locationId
+	s.LastPositionCache.Set(vehicleID, currentPosition)
+	s.publishLocation(data, providerID, vehicleID, locationId)
 
-	return nil
+	var previousPosition *out.Point
+	if OK {
+		previousPosition = &lastPosition
+	}
+	s.detectGeofenceEvents(vehicleID, previousPosition, currentPosition)
+
+	return true, nil
+}
+
+func (s *SavePacket) publishLocation(data *util.PacketData, providerID int32, vehicleID int32, locationId int32) {
+	if s.LocationBroker == nil {
+		return
+	}
+	s.LocationBroker.Publish(broker.Location{
+		LocationId: locationId,
+		VehicleId:  vehicleID,
+		ProviderId: providerID,
+		OID:        int64(data.OID),
+		Latitude:   data.Latitude,
+		Longitude:  data.Longitude,
+		Altitude:   int64(data.Altitude),
+		Direction:  int16(data.Direction),
+		Speed:      int32(data.Speed),
+		SentAt:     time.Unix(data.SentTimestamp, 0),
+		ReceivedAt: time.Unix(data.ReceivedTimestamp, 0),
+	})
+}
+
+// detectGeofenceEvents сохраняет события входа в геозоны и выхода из них. Местоположение к этому
+// моменту уже сохранено, поэтому ошибки только записываются в журнал.
+func (s *SavePacket) detectGeofenceEvents(vehicleID int32, previousPosition *out.Point, currentPosition out.Point) {
+	geofences, err := s.IngestCache.Geofences()
+	if err != nil {
+		logrus.Warnf("Не удалось получить геозоны для транспорта с ID %d: %v", vehicleID, err)
+		return
+	}
+
+	events := DetectGeofenceEvents(geofences, previousPosition, currentPosition)
+	if len(events) == 0 {
+		return
+	}
+	if err := s.PrimaryRepository.AddGeofenceEvents(vehicleID, events); err != nil {
+		logrus.Warnf("Не удалось сохранить события геозон для транспорта с ID %d: %v", vehicleID, err)
+		return
+	}
+	logrus.Debugf("Сохранено событий геозон для транспорта с ID %d: %d", vehicleID, len(events))
 }
@@ -0,0 +1,20 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	"github.com/daniil11ru/egts/cli/receiver/dto/other"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsHistoryPoint(t *testing.T) {
+	lastSentAt := time.Unix(2000, 0)
+	last := out.Point{SentAt: &lastSentAt}
+
+	assert.True(t, IsHistoryPoint(&other.PacketData{History: true, SentTimestamp: 3000}, last, true))
+	assert.True(t, IsHistoryPoint(&other.PacketData{SentTimestamp: 1000}, last, true))
+	assert.False(t, IsHistoryPoint(&other.PacketData{SentTimestamp: 3000}, last, true))
+	assert.False(t, IsHistoryPoint(&other.PacketData{SentTimestamp: 1000}, out.Point{}, false))
+}
@@ -0,0 +1,228 @@
+package domain
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	"github.com/daniil11ru/egts/cli/receiver/dto/other"
+	repository "github.com/daniil11ru/egts/cli/receiver/server/repository"
+	"github.com/sirupsen/logrus"
+)
+
+// TerminalSession накапливает статистику одного соединения с терминалом или ретранслятором.
+// Транспорт определяется асинхронно при сохранении данных, поэтому методы безопасны для вызова из
+// нескольких горутин. Методы нулевой сессии ничего не делают: прием данных не зависит от того,
+// удалось ли сохранить сессию.
+type TerminalSession struct {
+	mu        sync.Mutex
+	state     out.TerminalSession
+	vehicles  map[int32]*out.TerminalSessionVehicle
+	closed    bool
+	flushedAt time.Time
+}
+
+func newTerminalSession(state out.TerminalSession) *TerminalSession {
+	return &TerminalSession{
+		state:     state,
+		vehicles:  make(map[int32]*out.TerminalSessionVehicle),
+		flushedAt: state.ConnectedAt,
+	}
+}
+
+// ID возвращает ID сессии в базе данных или 0 для нулевой сессии
+func (s *TerminalSession) ID() int32 {
+	if s == nil {
+		return 0
+	}
+	return s.state.ID
+}
+
+func (s *TerminalSession) RecordPacket(records int, at time.Time) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.state.PacketCount++
+	s.state.RecordCount += int32(records)
+	s.state.LastPacketAt = &at
+}
+
+func (s *TerminalSession) RecordDecodeError(at time.Time) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.state.PacketCount++
+	s.state.DecodeErrorCount++
+	s.state.LastPacketAt = &at
+}
+
+func (s *TerminalSession) SetTerminalIMEI(imei string) {
+	if s == nil || imei == "" {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.state.TerminalIMEI = &imei
+}
+
+// RecordVehicle отмечает, что в рамках сессии поступили данные транспорта. Данные, определенные
+// после закрытия сессии, не учитываются.
+func (s *TerminalSession) RecordVehicle(vehicleId int32, oid int64, at time.Time) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return
+	}
+	s.state.VehicleId = &vehicleId
+	s.state.OID = &oid
+	s.mergeVehicle(out.TerminalSessionVehicle{VehicleId: vehicleId, OID: oid, FirstSeenAt: at, LastSeenAt: at, RecordCount: 1})
+}
+
+func (s *TerminalSession) mergeVehicle(vehicle out.TerminalSessionVehicle) {
+	existing, ok := s.vehicles[vehicle.VehicleId]
+	if !ok {
+		s.vehicles[vehicle.VehicleId] = &vehicle
+		return
+	}
+	existing.OID = vehicle.OID
+	existing.RecordCount += vehicle.RecordCount
+	if vehicle.FirstSeenAt.Before(existing.FirstSeenAt) {
+		existing.FirstSeenAt = vehicle.FirstSeenAt
+	}
+	if vehicle.LastSeenAt.After(existing.LastSeenAt) {
+		existing.LastSeenAt = vehicle.LastSeenAt
+	}
+}
+
+// takeChanges возвращает состояние сессии и транспорт, накопленный с предыдущей записи
+func (s *TerminalSession) takeChanges(now time.Time) (out.TerminalSession, []out.TerminalSessionVehicle) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	vehicles := make([]out.TerminalSessionVehicle, 0, len(s.vehicles))
+	for _, vehicle := range s.vehicles {
+		vehicles = append(vehicles, *vehicle)
+	}
+	sort.Slice(vehicles, func(i, j int) bool { return vehicles[i].VehicleId < vehicles[j].VehicleId })
+
+	s.vehicles = make(map[int32]*out.TerminalSessionVehicle)
+	s.flushedAt = now
+	return s.state, vehicles
+}
+
+// restoreVehicles возвращает транспорт, который не удалось записать, чтобы повторить запись позже
+func (s *TerminalSession) restoreVehicles(vehicles []out.TerminalSessionVehicle) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, vehicle := range vehicles {
+		s.mergeVehicle(vehicle)
+	}
+}
+
+type TerminalSessions struct {
+	PrimaryRepository repository.Primary
+	FlushInterval     time.Duration
+}
+
+func (t *TerminalSessions) Open(providerId int32, remoteAddress string) (*TerminalSession, error) {
+	connectedAt := time.Now()
+	id, err := t.PrimaryRepository.OpenTerminalSession(providerId, remoteAddress, connectedAt)
+	if err != nil {
+		return nil, err
+	}
+	return newTerminalSession(out.TerminalSession{
+		ID:            id,
+		ProviderId:    providerId,
+		RemoteAddress: remoteAddress,
+		ConnectedAt:   connectedAt,
+	}), nil
+}
+
+func (t *TerminalSessions) flush(session *TerminalSession, now time.Time) error {
+	state, vehicles := session.takeChanges(now)
+	if len(vehicles) > 0 {
+		if err := t.PrimaryRepository.SaveTerminalSessionVehicles(state.ID, vehicles); err != nil {
+			session.restoreVehicles(vehicles)
+			return err
+		}
+	}
+	return t.PrimaryRepository.SaveTerminalSession(state)
+}
+
+// Touch записывает накопленную статистику, если с предыдущей записи прошло больше FlushInterval
+func (t *TerminalSessions) Touch(session *TerminalSession) {
+	if session == nil {
+		return
+	}
+
+	now := time.Now()
+	session.mu.Lock()
+	due := now.Sub(session.flushedAt) >= t.FlushInterval
+	session.mu.Unlock()
+	if !due {
+		return
+	}
+
+	if err := t.flush(session, now); err != nil {
+		logrus.Warnf("Не удалось сохранить состояние сессии с ID %d: %v", session.state.ID, err)
+	}
+}
+
+func (t *TerminalSessions) Close(session *TerminalSession, reason other.SessionCloseReason) {
+	if session == nil {
+		return
+	}
+
+	now := time.Now()
+	session.mu.Lock()
+	session.closed = true
+	session.state.DisconnectedAt = &now
+	session.state.CloseReason = &reason
+	session.mu.Unlock()
+
+	if err := t.flush(session, now); err != nil {
+		logrus.Warnf("Не удалось сохранить закрытие сессии с ID %d: %v", session.state.ID, err)
+	}
+}
+
+// CloseOpen закрывает сессии провайдера, оставшиеся открытыми после предыдущего запуска сервера
+func (t *TerminalSessions) CloseOpen(providerId int32) {
+	closed, err := t.PrimaryRepository.CloseOpenTerminalSessions(providerId, other.SessionCloseReasonServerRestart)
+	if err != nil {
+		logrus.Warnf("Не удалось закрыть незавершенные сессии провайдера с ID %d: %v", providerId, err)
+		return
+	}
+	if closed > 0 {
+		logrus.Infof("Закрыто незавершенных сессий провайдера с ID %d: %d", providerId, closed)
+	}
+}
+
+// TerminalStatusPolicy определяет статус связи с транспортом: online — данные поступали не позже
+// StaleAfter назад через открытое соединение, stale — соединение открыто, но данных дольше
+// StaleAfter нет, offline — открытых соединений нет.
+type TerminalStatusPolicy struct {
+	StaleAfter time.Duration
+}
+
+func (p TerminalStatusPolicy) Resolve(connection out.VehicleConnection, now time.Time) other.TerminalStatus {
+	if !connection.HasOpenSession {
+		return other.TerminalStatusOffline
+	}
+	if connection.LastSeenAt == nil || now.Sub(*connection.LastSeenAt) > p.StaleAfter {
+		return other.TerminalStatusStale
+	}
+	return other.TerminalStatusOnline
+}
@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	"github.com/daniil11ru/egts/cli/receiver/dto/other"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTerminalSessionAccumulatesChanges(t *testing.T) {
+	connectedAt := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	session := newTerminalSession(out.TerminalSession{ID: 7, ProviderId: 1, ConnectedAt: connectedAt})
+
+	session.RecordPacket(3, connectedAt.Add(time.Second))
+	session.RecordDecodeError(connectedAt.Add(2 * time.Second))
+	session.SetTerminalIMEI("")
+	session.SetTerminalIMEI("356307042441013")
+	session.RecordVehicle(20, 200, connectedAt.Add(3*time.Second))
+	session.RecordVehicle(10, 100, connectedAt.Add(4*time.Second))
+	session.RecordVehicle(20, 200, connectedAt.Add(5*time.Second))
+
+	state, vehicles := session.takeChanges(connectedAt.Add(6 * time.Second))
+	assert.Equal(t, int32(2), state.PacketCount)
+	assert.Equal(t, int32(3), state.RecordCount)
+	assert.Equal(t, int32(1), state.DecodeErrorCount)
+	assert.Equal(t, connectedAt.Add(2*time.Second), *state.LastPacketAt)
+	assert.Equal(t, "356307042441013", *state.TerminalIMEI)
+	assert.Equal(t, int32(20), *state.VehicleId, "последний определенный транспорт")
+
+	if assert.Len(t, vehicles, 2) {
+		assert.Equal(t, int32(10), vehicles[0].VehicleId)
+		assert.Equal(t, int32(20), vehicles[1].VehicleId)
+		assert.Equal(t, int32(2), vehicles[1].RecordCount)
+		assert.Equal(t, connectedAt.Add(3*time.Second), vehicles[1].FirstSeenAt)
+		assert.Equal(t, connectedAt.Add(5*time.Second), vehicles[1].LastSeenAt)
+	}
+
+	_, vehicles = session.takeChanges(connectedAt.Add(7 * time.Second))
+	assert.Empty(t, vehicles, "транспорт передается только один раз")
+}
+
+func TestTerminalSessionRestoresVehicles(t *testing.T) {
+	connectedAt := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	session := newTerminalSession(out.TerminalSession{ID: 7, ConnectedAt: connectedAt})
+
+	session.RecordVehicle(10, 100, connectedAt.Add(time.Second))
+	_, failed := session.takeChanges(connectedAt.Add(2 * time.Second))
+	session.RecordVehicle(10, 100, connectedAt.Add(3*time.Second))
+	session.restoreVehicles(failed)
+
+	_, vehicles := session.takeChanges(connectedAt.Add(4 * time.Second))
+	if assert.Len(t, vehicles, 1) {
+		assert.Equal(t, int32(2), vehicles[0].RecordCount)
+		assert.Equal(t, connectedAt.Add(time.Second), vehicles[0].FirstSeenAt)
+		assert.Equal(t, connectedAt.Add(3*time.Second), vehicles[0].LastSeenAt)
+	}
+
+	session.closed = true
+	session.RecordVehicle(10, 100, connectedAt.Add(5*time.Second))
+	_, vehicles = session.takeChanges(connectedAt.Add(6 * time.Second))
+	assert.Empty(t, vehicles, "данные после закрытия сессии не учитываются")
+}
+
+func TestNilTerminalSession(t *testing.T) {
+	var session *TerminalSession
+	assert.NotPanics(t, func() {
+		session.RecordPacket(1, time.Now())
+		session.RecordDecodeError(time.Now())
+		session.SetTerminalIMEI("356307042441013")
+		session.RecordVehicle(1, 1, time.Now())
+	})
+}
+
+func TestTerminalStatusPolicy(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	policy := TerminalStatusPolicy{StaleAfter: 5 * time.Minute}
+	recent := now.Add(-time.Minute)
+	old := now.Add(-10 * time.Minute)
+
+	assert.Equal(t, other.TerminalStatusOnline, policy.Resolve(out.VehicleConnection{HasOpenSession: true, LastSeenAt: &recent}, now))
+	assert.Equal(t, other.TerminalStatusStale, policy.Resolve(out.VehicleConnection{HasOpenSession: true, LastSeenAt: &old}, now))
+	assert.Equal(t, other.TerminalStatusStale, policy.Resolve(out.VehicleConnection{HasOpenSession: true}, now))
+	assert.Equal(t, other.TerminalStatusOffline, policy.Resolve(out.VehicleConnection{LastSeenAt: &recent}, now))
+	assert.Equal(t, other.TerminalStatusOffline, policy.Resolve(out.VehicleConnection{}, now))
+}
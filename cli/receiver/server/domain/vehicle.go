@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/insert"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+)
+
+// maxImeiLength — длина колонки vehicle.imei
+const maxImeiLength = 15
+
+func ValidateVehicle(vehicle insert.Vehicle) error {
+	if onlyDigits(vehicle.IMEI) == "" {
+		return fmt.Errorf("IMEI должен содержать цифры")
+	}
+	if len(vehicle.IMEI) > maxImeiLength {
+		return fmt.Errorf("IMEI не может быть длиннее %d символов", maxImeiLength)
+	}
+	if vehicle.OID != nil && (*vehicle.OID < 0 || *vehicle.OID > math.MaxUint32) {
+		return fmt.Errorf("OID %d не помещается в 4 байта", *vehicle.OID)
+	}
+	if vehicle.Name != nil && strings.TrimSpace(*vehicle.Name) == "" {
+		return fmt.Errorf("название транспорта не может быть пустым")
+	}
+	if !vehicle.ModerationStatus.IsValid() {
+		return fmt.Errorf("недопустимый статус модерации: %q", vehicle.ModerationStatus)
+	}
+	return nil
+}
+
+// ValidateVehicleMerge проверяет, что данные транспорта duplicate можно перенести в target.
+// Объединять можно только транспорт одного провайдера: иначе точки одного провайдера окажутся у
+// транспорта другого.
+func ValidateVehicleMerge(target, duplicate out.Vehicle) error {
+	if target.ID == duplicate.ID {
+		return fmt.Errorf("нельзя объединить транспорт с самим собой")
+	}
+	if target.ProviderId != duplicate.ProviderId {
+		return fmt.Errorf("транспорт принадлежит разным провайдерам: %d и %d", target.ProviderId, duplicate.ProviderId)
+	}
+	return nil
+}
@@ -0,0 +1,254 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	"github.com/daniil11ru/egts/cli/receiver/dto/other"
+)
+
+type VehicleImportAction string
+
+const (
+	VehicleImportActionInsert    VehicleImportAction = "insert"
+	VehicleImportActionUpdate    VehicleImportAction = "update"
+	VehicleImportActionUnchanged VehicleImportAction = "unchanged"
+	VehicleImportActionConflict  VehicleImportAction = "conflict"
+)
+
+// VehicleImportOid описывает вычисление OID из IMEI для строк, в которых OID не указан явно
+type VehicleImportOid struct {
+	Type     other.OidResolutionRuleType
+	Position other.ImeiSegmentPosition
+	Length   int
+}
+
+type VehicleImportOptions struct {
+	ProviderId int32
+	IMEIColumn string
+	NameColumn string
+	OIDColumn  string
+	Oid        *VehicleImportOid
+}
+
+type VehicleImportItem struct {
+	// Номер строки в файле, начиная с 1 для заголовка
+	Row       int
+	Action    VehicleImportAction
+	VehicleId int32
+	IMEI      string
+	OID       *int64
+	Name      *string
+	Reason    string
+}
+
+type VehicleImportPlan struct {
+	Items []VehicleImportItem
+}
+
+// Count возвращает количество строк плана с указанным действием
+func (p VehicleImportPlan) Count(action VehicleImportAction) int {
+	n := 0
+	for _, item := range p.Items {
+		if item.Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+func findColumn(header []string, name string) int {
+	for i, cell := range header {
+		if strings.EqualFold(strings.TrimSpace(cell), strings.TrimSpace(name)) {
+			return i
+		}
+	}
+	return -1
+}
+
+func cell(row []string, index int) string {
+	if index < 0 || index >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[index])
+}
+
+func isBlankRow(row []string) bool {
+	for _, value := range row {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func importOid(row []string, oidIndex int, imei string, options *VehicleImportOid) (*int64, error) {
+	var oid int64
+	if raw := cell(row, oidIndex); raw != "" {
+		v, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("некорректный OID %q", raw)
+		}
+		oid = v
+	} else if options != nil {
+		v, err := DeriveOid(imei, options.Type, options.Position, options.Length)
+		if err != nil {
+			return nil, err
+		}
+		oid = v
+	} else {
+		return nil, nil
+	}
+
+	if oid < 0 || oid > math.MaxUint32 {
+		return nil, fmt.Errorf("OID %d не помещается в 4 байта", oid)
+	}
+	return &oid, nil
+}
+
+func sameOid(a, b *int64) bool {
+	return a == nil && b == nil || a != nil && b != nil && *a == *b
+}
+
+// PlanVehicleImport сопоставляет строки реестра с уже известными транспортными средствами. Первая
+// строка таблицы считается заголовком. Транспорт ищется по цифрам IMEI среди всех провайдеров:
+// новый IMEI добавляется, а у найденного транспорта того же провайдера обновляются имя и OID,
+// если они указаны в файле. Конфликтом считаются некорректные строки, повторы IMEI в файле,
+// IMEI другого провайдера и OID, который после импорта оказался бы у нескольких транспортных
+// средств провайдера: по такому OID приемник не сможет однозначно определить транспорт.
+func PlanVehicleImport(table [][]string, options VehicleImportOptions, existing []out.Vehicle) (VehicleImportPlan, error) {
+	plan := VehicleImportPlan{}
+	if len(table) == 0 {
+		return plan, fmt.Errorf("файл не содержит строк")
+	}
+
+	header := table[0]
+	imeiIndex := findColumn(header, options.IMEIColumn)
+	if imeiIndex < 0 {
+		return plan, fmt.Errorf("в заголовке нет колонки IMEI %q", options.IMEIColumn)
+	}
+	nameIndex := -1
+	if options.NameColumn != "" {
+		if nameIndex = findColumn(header, options.NameColumn); nameIndex < 0 {
+			return plan, fmt.Errorf("в заголовке нет колонки названия %q", options.NameColumn)
+		}
+	}
+	oidIndex := -1
+	if options.OIDColumn != "" {
+		if oidIndex = findColumn(header, options.OIDColumn); oidIndex < 0 {
+			return plan, fmt.Errorf("в заголовке нет колонки OID %q", options.OIDColumn)
+		}
+	}
+
+	byImei := make(map[string][]out.Vehicle)
+	for _, v := range existing {
+		key := onlyDigits(v.IMEI)
+		byImei[key] = append(byImei[key], v)
+	}
+
+	seen := make(map[string]int)
+	for i, row := range table[1:] {
+		if isBlankRow(row) {
+			continue
+		}
+		item := VehicleImportItem{Row: i + 2, IMEI: cell(row, imeiIndex)}
+		if name := cell(row, nameIndex); name != "" {
+			item.Name = &name
+		}
+
+		key := onlyDigits(item.IMEI)
+		if key == "" {
+			item.Action, item.Reason = VehicleImportActionConflict, "IMEI не содержит цифр"
+			plan.Items = append(plan.Items, item)
+			continue
+		}
+		if previous, ok := seen[key]; ok {
+			item.Action, item.Reason = VehicleImportActionConflict, fmt.Sprintf("IMEI повторяет строку %d", previous)
+			plan.Items = append(plan.Items, item)
+			continue
+		}
+		seen[key] = item.Row
+
+		matched := byImei[key]
+		if len(matched) > 1 {
+			item.Action, item.Reason = VehicleImportActionConflict, "по IMEI найдено более одного транспортного средства"
+			plan.Items = append(plan.Items, item)
+			continue
+		}
+		if len(matched) == 1 && matched[0].ProviderId != options.ProviderId {
+			item.Action, item.VehicleId = VehicleImportActionConflict, matched[0].ID
+			item.Reason = fmt.Sprintf("IMEI принадлежит транспорту провайдера с ID %d", matched[0].ProviderId)
+			plan.Items = append(plan.Items, item)
+			continue
+		}
+
+		oid, err := importOid(row, oidIndex, item.IMEI, options.Oid)
+		if err != nil {
+			item.Action, item.Reason = VehicleImportActionConflict, err.Error()
+			plan.Items = append(plan.Items, item)
+			continue
+		}
+		item.OID = oid
+
+		if len(matched) == 0 {
+			item.Action = VehicleImportActionInsert
+		} else {
+			vehicle := matched[0]
+			item.VehicleId = vehicle.ID
+			item.Action = VehicleImportActionUnchanged
+			if item.Name != nil && (vehicle.Name == nil || *vehicle.Name != *item.Name) ||
+				item.OID != nil && !sameOid(vehicle.OID, item.OID) {
+				item.Action = VehicleImportActionUpdate
+			}
+			if item.Name == nil {
+				item.Name = vehicle.Name
+			}
+			if item.OID == nil {
+				item.OID = vehicle.OID
+			}
+		}
+		plan.Items = append(plan.Items, item)
+	}
+
+	markOidConflicts(&plan, options.ProviderId, existing)
+	return plan, nil
+}
+
+// markOidConflicts помечает конфликтами добавляемые и обновляемые строки, OID которых после
+// импорта совпал бы с OID другого транспортного средства провайдера
+func markOidConflicts(plan *VehicleImportPlan, providerId int32, existing []out.Vehicle) {
+	finalOid := make(map[int32]*int64)
+	for _, v := range existing {
+		if v.ProviderId == providerId {
+			finalOid[v.ID] = v.OID
+		}
+	}
+	owners := make(map[int64]int)
+	for _, item := range plan.Items {
+		if item.Action == VehicleImportActionUpdate {
+			finalOid[item.VehicleId] = item.OID
+		}
+		if item.Action == VehicleImportActionInsert && item.OID != nil {
+			owners[*item.OID]++
+		}
+	}
+	for _, oid := range finalOid {
+		if oid != nil {
+			owners[*oid]++
+		}
+	}
+
+	for i := range plan.Items {
+		item := &plan.Items[i]
+		if item.Action != VehicleImportActionInsert && item.Action != VehicleImportActionUpdate {
+			continue
+		}
+		if item.OID != nil && owners[*item.OID] > 1 {
+			item.Action = VehicleImportActionConflict
+			item.Reason = fmt.Sprintf("OID %d уже используется другим транспортом провайдера", *item.OID)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	"github.com/daniil11ru/egts/cli/receiver/dto/other"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanVehicleImport(t *testing.T) {
+	existing := []out.Vehicle{
+		{ID: 1, IMEI: "863071014463084", OID: int64Ptr(1014463084), Name: stringPtr("А001АА"), ProviderId: 1},
+		{ID: 2, IMEI: "863071014460000", OID: int64Ptr(1014460000), Name: stringPtr("В002ВВ"), ProviderId: 1},
+		{ID: 3, IMEI: "351234567890123", OID: int64Ptr(4567890123), ProviderId: 2},
+		{ID: 4, IMEI: "863071014461111", OID: int64Ptr(1014461111), Name: stringPtr("Е004ЕЕ"), ProviderId: 1},
+	}
+	table := [][]string{
+		{"Гос. номер", " IMEI "},
+		{"А001АА", "863071014463084"},
+		{"В002ВВ-1", "863071014460000"},
+		{"С003СС", "863 071 014 469 999"},
+		{"", ""},
+		{"Дубль", "863071014469999"},
+		{"Чужой", "351234567890123"},
+		{"Без цифр", "нет"},
+		{"Е004ЕЕ", "863071014461111"},
+		{"Коллизия", "999991014461111"},
+	}
+
+	plan, err := PlanVehicleImport(table, VehicleImportOptions{
+		ProviderId: 1,
+		IMEIColumn: "imei",
+		NameColumn: "Гос. номер",
+		Oid:        &VehicleImportOid{Type: other.OidResolutionRuleTypeImeiDigits, Position: other.ImeiSegmentPositionEnd, Length: 10},
+	}, existing)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	actions := make(map[int]VehicleImportAction)
+	for _, item := range plan.Items {
+		actions[item.Row] = item.Action
+	}
+	assert.Equal(t, map[int]VehicleImportAction{
+		2:  VehicleImportActionUnchanged,
+		3:  VehicleImportActionUpdate,
+		4:  VehicleImportActionInsert,
+		6:  VehicleImportActionConflict,
+		7:  VehicleImportActionConflict,
+		8:  VehicleImportActionConflict,
+		9:  VehicleImportActionUnchanged,
+		10: VehicleImportActionConflict,
+	}, actions)
+
+	assert.Equal(t, int32(2), plan.Items[1].VehicleId)
+	assert.Equal(t, "В002ВВ-1", *plan.Items[1].Name)
+	assert.Equal(t, int64(1014469999), *plan.Items[2].OID)
+	assert.Contains(t, plan.Items[3].Reason, "строку 4")
+	assert.Contains(t, plan.Items[4].Reason, "провайдера с ID 2")
+	assert.Contains(t, plan.Items[7].Reason, "OID 1014461111")
+	assert.Equal(t, 1, plan.Count(VehicleImportActionInsert))
+	assert.Equal(t, 4, plan.Count(VehicleImportActionConflict))
+}
+
+func TestPlanVehicleImportOidColumn(t *testing.T) {
+	table := [][]string{
+		{"imei", "oid"},
+		{"863071014463084", "42"},
+		{"863071014463085", ""},
+		{"863071014463086", "4294967296"},
+		{"863071014463087", "сорок"},
+	}
+
+	plan, err := PlanVehicleImport(table, VehicleImportOptions{ProviderId: 1, IMEIColumn: "imei", OIDColumn: "oid"}, nil)
+	if !assert.NoError(t, err) || !assert.Len(t, plan.Items, 4) {
+		return
+	}
+	assert.Equal(t, int64(42), *plan.Items[0].OID)
+	assert.Nil(t, plan.Items[1].OID)
+	assert.Equal(t, VehicleImportActionInsert, plan.Items[1].Action)
+	assert.Equal(t, VehicleImportActionConflict, plan.Items[2].Action)
+	assert.Equal(t, VehicleImportActionConflict, plan.Items[3].Action)
+
+	_, err = PlanVehicleImport(table, VehicleImportOptions{ProviderId: 1, IMEIColumn: "IMEI терминала"}, nil)
+	assert.Error(t, err)
+	_, err = PlanVehicleImport(nil, VehicleImportOptions{ProviderId: 1, IMEIColumn: "imei"}, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,128 @@
+package domain
+
+import (
+	"fmt"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	"github.com/daniil11ru/egts/cli/receiver/dto/other"
+)
+
+// vehicleIndexStep — транспорт провайдера, разложенный по ключу одного правила: по OID, в том числе
+// вычисленному из IMEI, или по IMEI для правила term_identity_imei
+type vehicleIndexStep struct {
+	rule   out.OidResolutionRule
+	byOid  map[int64][]out.Vehicle
+	byImei map[string][]out.Vehicle
+	err    error
+}
+
+// VehicleIndex позволяет определить транспорт провайдера по OID без перебора всего транспорта.
+// Правила применяются в порядке приоритета, а если правил нет — транспорт ищется по точному
+// совпадению OID.
+type VehicleIndex struct {
+	steps []vehicleIndexStep
+	byOid map[int64][]out.Vehicle
+}
+
+func indexVehiclesByOid(vehicles []out.Vehicle) map[int64][]out.Vehicle {
+	index := make(map[int64][]out.Vehicle)
+	for _, v := range vehicles {
+		if v.OID != nil {
+			index[*v.OID] = append(index[*v.OID], v)
+		}
+	}
+	return index
+}
+
+func NewVehicleIndex(rules []out.OidResolutionRule, vehicles []out.Vehicle) *VehicleIndex {
+	if len(rules) == 0 {
+		return &VehicleIndex{byOid: indexVehiclesByOid(vehicles)}
+	}
+
+	index := &VehicleIndex{steps: make([]vehicleIndexStep, 0, len(rules))}
+	for _, rule := range rules {
+		step := vehicleIndexStep{rule: rule}
+		switch rule.Type {
+		case other.OidResolutionRuleTypeExactOid:
+			step.byOid = indexVehiclesByOid(vehicles)
+		case other.OidResolutionRuleTypeTermIdentityImei:
+			step.byImei = make(map[string][]out.Vehicle)
+			for _, v := range vehicles {
+				imei := onlyDigits(v.IMEI)
+				step.byImei[imei] = append(step.byImei[imei], v)
+			}
+		case other.OidResolutionRuleTypeImeiDigits, other.OidResolutionRuleTypeImeiBytes, other.OidResolutionRuleTypeImeiMaxDigits:
+			if err := ValidateOidResolutionRule(rule.Type, rule.Position, rule.Length); err != nil {
+				step.err = err
+				break
+			}
+			length := 0
+			if rule.Length != nil {
+				length = int(*rule.Length)
+			}
+			step.byOid = make(map[int64][]out.Vehicle)
+			for _, v := range vehicles {
+				derived, err := DeriveOid(v.IMEI, rule.Type, *rule.Position, length)
+				if err != nil {
+					continue
+				}
+				step.byOid[derived] = append(step.byOid[derived], v)
+			}
+		default:
+			step.err = fmt.Errorf("недопустимый тип правила: %q", rule.Type)
+		}
+		index.steps = append(index.steps, step)
+	}
+	return index
+}
+
+func (s vehicleIndexStep) match(oid int64, terminalIMEI string) []out.Vehicle {
+	if s.byImei != nil {
+		imei := onlyDigits(terminalIMEI)
+		if imei == "" {
+			return nil
+		}
+		return s.byImei[imei]
+	}
+	return s.byOid[oid]
+}
+
+// Resolve определяет транспорт по OID и IMEI терминала. Побеждает первое правило, давшее ровно одно
+// совпадение; если ни одно правило не дало однозначного результата, но хотя бы одно дало несколько
+// совпадений, результат считается неоднозначным. Если правил нет, возвращается результат
+// VehicleResolutionNotConfigured с транспортом, OID которого совпадает точно.
+func (i *VehicleIndex) Resolve(oid int64, terminalIMEI string) VehicleResolution {
+	resolution := VehicleResolution{Result: VehicleResolutionNotFound}
+	if len(i.steps) == 0 {
+		resolution.Result = VehicleResolutionNotConfigured
+		resolution.Vehicles = i.byOid[oid]
+		return resolution
+	}
+
+	var ambiguous []out.Vehicle
+	for _, step := range i.steps {
+		var matched []out.Vehicle
+		if step.err == nil {
+			matched = step.match(oid, terminalIMEI)
+		}
+		resolution.Steps = append(resolution.Steps, VehicleResolutionStep{Rule: step.rule, Vehicles: matched, Err: step.err})
+
+		if len(matched) == 1 {
+			rule := step.rule
+			resolution.Result = VehicleResolutionResolved
+			resolution.Vehicles = matched
+			resolution.Rule = &rule
+			return resolution
+		}
+		if len(matched) > 1 && ambiguous == nil {
+			ambiguous = matched
+		}
+	}
+
+	if ambiguous != nil {
+		resolution.Result = VehicleResolutionAmbiguous
+		resolution.Vehicles = ambiguous
+	}
+
+	return resolution
+}
@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	"github.com/daniil11ru/egts/cli/receiver/dto/other"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVehicleIndexResolve(t *testing.T) {
+	oid := int64(1014463084)
+	vehicles := []out.Vehicle{
+		{ID: 1, IMEI: "863071014463084", OID: &oid},
+		{ID: 2, IMEI: "863081014463084"},
+		{ID: 3, IMEI: "863071014474792"},
+	}
+
+	digitsRule := out.OidResolutionRule{ID: 10, Type: other.OidResolutionRuleTypeImeiDigits, Position: positionPtr(other.ImeiSegmentPositionEnd), Length: int16Ptr(10)}
+	exactRule := out.OidResolutionRule{ID: 11, Type: other.OidResolutionRuleTypeExactOid}
+	imeiRule := out.OidResolutionRule{ID: 12, Type: other.OidResolutionRuleTypeTermIdentityImei}
+
+	resolution := NewVehicleIndex([]out.OidResolutionRule{digitsRule}, vehicles).Resolve(oid, "")
+	assert.Equal(t, VehicleResolutionAmbiguous, resolution.Result)
+	assert.Len(t, resolution.Vehicles, 2)
+
+	resolution = NewVehicleIndex([]out.OidResolutionRule{digitsRule, exactRule}, vehicles).Resolve(oid, "")
+	if assert.Equal(t, VehicleResolutionResolved, resolution.Result) {
+		assert.Equal(t, int32(1), resolution.Vehicles[0].ID)
+		assert.Equal(t, int32(11), resolution.Rule.ID)
+	}
+
+	resolution = NewVehicleIndex([]out.OidResolutionRule{imeiRule}, vehicles).Resolve(42, "863071014474792\x00")
+	if assert.Equal(t, VehicleResolutionResolved, resolution.Result) {
+		assert.Equal(t, int32(3), resolution.Vehicles[0].ID)
+	}
+
+	resolution = NewVehicleIndex([]out.OidResolutionRule{exactRule}, vehicles).Resolve(42, "")
+	assert.Equal(t, VehicleResolutionNotFound, resolution.Result)
+
+	invalidRule := out.OidResolutionRule{ID: 13, Type: other.OidResolutionRuleTypeImeiDigits}
+	resolution = NewVehicleIndex([]out.OidResolutionRule{invalidRule, exactRule}, vehicles).Resolve(oid, "")
+	if assert.Equal(t, VehicleResolutionResolved, resolution.Result) && assert.Len(t, resolution.Steps, 2) {
+		assert.Error(t, resolution.Steps[0].Err)
+		assert.Equal(t, int32(13), resolution.Steps[0].Rule.ID)
+	}
+
+	resolution = NewVehicleIndex(nil, vehicles).Resolve(oid, "")
+	assert.Equal(t, VehicleResolutionNotConfigured, resolution.Result)
+}
+
+func TestVehicleIndexWithoutRules(t *testing.T) {
+	oid := int64(1014463084)
+	index := NewVehicleIndex(nil, []out.Vehicle{
+		{ID: 1, IMEI: "863071014463084", OID: &oid},
+		{ID: 2, IMEI: "863081014463084"},
+	})
+
+	resolution := index.Resolve(oid, "")
+	assert.Equal(t, VehicleResolutionNotConfigured, resolution.Result)
+	if assert.Len(t, resolution.Vehicles, 1) {
+		assert.Equal(t, int32(1), resolution.Vehicles[0].ID)
+	}
+	assert.Empty(t, index.Resolve(42, "").Vehicles)
+}
@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/insert"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	"github.com/daniil11ru/egts/cli/receiver/dto/other"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateVehicle(t *testing.T) {
+	valid := insert.Vehicle{IMEI: "863071014463084", OID: int64Ptr(1014463084), Name: stringPtr("О810СМ11"), ProviderId: 1, ModerationStatus: other.ModerationStatusApproved}
+	assert.NoError(t, ValidateVehicle(valid))
+
+	noDigits := valid
+	noDigits.IMEI = "нет"
+	assert.Error(t, ValidateVehicle(noDigits))
+
+	tooLong := valid
+	tooLong.IMEI = "8630710144630841"
+	assert.Error(t, ValidateVehicle(tooLong))
+
+	bigOid := valid
+	bigOid.OID = int64Ptr(4294967296)
+	assert.Error(t, ValidateVehicle(bigOid))
+
+	blankName := valid
+	blankName.Name = stringPtr(" ")
+	assert.Error(t, ValidateVehicle(blankName))
+
+	badStatus := valid
+	badStatus.ModerationStatus = "unknown"
+	assert.Error(t, ValidateVehicle(badStatus))
+}
+
+func TestValidateVehicleMerge(t *testing.T) {
+	target := out.Vehicle{ID: 1, ProviderId: 1}
+	assert.NoError(t, ValidateVehicleMerge(target, out.Vehicle{ID: 2, ProviderId: 1}))
+	assert.Error(t, ValidateVehicleMerge(target, target))
+	assert.Error(t, ValidateVehicleMerge(target, out.Vehicle{ID: 3, ProviderId: 2}))
+}
@@ -0,0 +1,127 @@
+package server
+
+import (
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/daniil11ru/egts/cli/receiver/archive"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	"github.com/daniil11ru/egts/cli/receiver/dto/other"
+	"github.com/daniil11ru/egts/cli/receiver/server/domain"
+	log "github.com/sirupsen/logrus"
+)
+
+// ListenerSettings — настройки сервера одного провайдера
+type ListenerSettings struct {
+	ProviderID          int32
+	Address             string
+	AuthRequired        bool
+	AllowedNetworks     []*net.IPNet
+	MaxPacketsPerMinute int32
+}
+
+// ProviderListenerSettings возвращает настройки серверов включенных провайдеров, для которых задан порт
+func ProviderListenerSettings(providers []out.Provider, networks []out.ProviderAllowedNetwork, host string) []ListenerSettings {
+	allowed := make(map[int32][]*net.IPNet)
+	for _, item := range networks {
+		network, err := domain.ParseAllowedNetwork(item.Network)
+		if err != nil {
+			log.Warnf("Разрешенная сеть с ID %d провайдера с ID %d пропущена: %v", item.ID, item.ProviderId, err)
+			continue
+		}
+		allowed[item.ProviderId] = append(allowed[item.ProviderId], network)
+	}
+
+	var settings []ListenerSettings
+	for _, provider := range providers {
+		if !provider.Enabled || provider.Port == nil {
+			continue
+		}
+		item := ListenerSettings{
+			ProviderID:      provider.ID,
+			Address:         net.JoinHostPort(host, strconv.Itoa(int(*provider.Port))),
+			AuthRequired:    provider.AuthRequired,
+			AllowedNetworks: allowed[provider.ID],
+		}
+		if provider.MaxPacketsPerMinute != nil {
+			item.MaxPacketsPerMinute = *provider.MaxPacketsPerMinute
+		}
+		settings = append(settings, item)
+	}
+	return settings
+}
+
+// Listeners запускает серверы провайдеров и перезапускает их при изменении настроек. Если порт не
+// удалось открыть, попытка повторяется каждые RetryInterval.
+type Listeners struct {
+	SavePacket    *domain.SavePacket
+	Sessions      *domain.TerminalSessions
+	Archive       *archive.Writer
+	Health        *domain.Health
+	RetryInterval time.Duration
+
+	mu      sync.Mutex
+	servers map[int32]*Server
+	started map[int32]bool
+}
+
+// Apply приводит набор запущенных серверов к settings: серверы отключенных провайдеров
+// останавливаются, серверы с измененным адресом, авторизацией или ttl перезапускаются, новые
+// запускаются. Разрешенные сети и ограничение на количество пакетов меняются без перезапуска.
+func (l *Listeners) Apply(settings []ListenerSettings, ttl time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.servers == nil {
+		l.servers = make(map[int32]*Server)
+		l.started = make(map[int32]bool)
+	}
+
+	wanted := make(map[int32]ListenerSettings, len(settings))
+	for _, item := range settings {
+		wanted[item.ProviderID] = item
+	}
+
+	for providerID, srv := range l.servers {
+		item, ok := wanted[providerID]
+		if ok && item.Address == srv.Address && item.AuthRequired == srv.AuthRequired && srv.TTL == ttl {
+			srv.SetAdmission(item.AllowedNetworks, item.MaxPacketsPerMinute)
+			continue
+		}
+		srv.Stop()
+		delete(l.servers, providerID)
+		if !ok {
+			l.Health.RemoveListener(providerID)
+			log.Infof("Порт %s провайдера с ID %d закрыт", srv.Address, providerID)
+		}
+	}
+
+	for providerID, item := range wanted {
+		if _, ok := l.servers[providerID]; ok {
+			continue
+		}
+		// Незакрытые сессии остаются от предыдущего запуска процесса, а не от перезапуска порта
+		if !l.started[providerID] && l.Sessions != nil {
+			l.Sessions.CloseOpen(providerID)
+		}
+		l.started[providerID] = true
+
+		srv := NewServer(item.Address, ttl, providerID, l.SavePacket, l.Sessions, l.Archive, l.Health)
+		srv.AuthRequired = item.AuthRequired
+		srv.SetAdmission(item.AllowedNetworks, item.MaxPacketsPerMinute)
+		l.Health.SetListener(providerID, item.Address, other.ListenerStateStarting, nil)
+		l.servers[providerID] = srv
+		go l.run(srv)
+	}
+}
+
+func (l *Listeners) run(srv *Server) {
+	for !srv.Stopped() {
+		if err := srv.Run(); err != nil {
+			log.Errorf("Не удалось запустить сервер на %s, повторная попытка через %s: %v", srv.Address, l.RetryInterval, err)
+			time.Sleep(l.RetryInterval)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/daniil11ru/egts/cli/receiver/archive"
+	"github.com/daniil11ru/egts/cli/receiver/server/domain"
+	"github.com/daniil11ru/egts/libs/egts"
+	log "github.com/sirupsen/logrus"
+)
+
+type ReplayFilter struct {
+	From       time.Time
+	To         time.Time
+	ProviderId *int32
+	OID        *uint32
+}
+
+type ReplayResult struct {
+	Frames       int
+	DecodeErrors int
+	Locations    int
+	Failed       int
+}
+
+// Replay повторно обрабатывает кадры из архива текущим декодером и передает местоположения в
+// SavePacket так же, как при приеме данных. IMEI терминала восстанавливается в пределах соединения,
+// в котором был принят кадр. Время приема местоположений берется из архива.
+type Replay struct {
+	ArchivePath string
+	SavePacket  *domain.SavePacket
+}
+
+func replayConnectionKey(frame archive.Frame) string {
+	return fmt.Sprintf("%d/%d/%s", frame.ProviderId, frame.SessionId, frame.RemoteAddress)
+}
+
+func (r *Replay) Run(filter ReplayFilter) (ReplayResult, error) {
+	var result ReplayResult
+	states := make(map[string]*connectionState)
+
+	query := archive.Query{From: filter.From, To: filter.To, ProviderId: filter.ProviderId}
+	err := archive.Read(r.ArchivePath, query, func(frame archive.Frame) error {
+		result.Frames++
+
+		pkg := egts.Package{}
+		if _, err := pkg.Decode(frame.Data); err != nil {
+			result.DecodeErrors++
+			log.Debugf("Не удалось разобрать кадр, принятый %s: %v", frame.ReceivedAt.Format(time.RFC3339), err)
+			return nil
+		}
+		if pkg.PacketType != egts.PtAppdataPacket {
+			return nil
+		}
+
+		key := replayConnectionKey(frame)
+		state, ok := states[key]
+		if !ok {
+			state = &connectionState{}
+			states[key] = state
+		}
+
+		for _, packet := range parseAppData(state, &pkg, frame.ReceivedAt.Unix()).packets {
+			if filter.OID != nil && packet.OID != *filter.OID {
+				continue
+			}
+			result.Locations++
+			if _, err := r.SavePacket.Run(&packet, frame.ProviderId); err != nil {
+				result.Failed++
+				log.Warnf("Телематические данные не были сохранены: %s", err)
+			}
+		}
+		return nil
+	})
+	return result, err
+}
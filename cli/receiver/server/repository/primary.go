@@ -12,6 +12,9 @@ import (
 	"github.com/daniil11ru/egts/cli/receiver/source"
 )
 
+// receiverAuthor отмечает в журнале изменения транспорта, которые приемник делает сам
+var receiverAuthor = insert.VehicleChangeAuthor{Source: other.VehicleChangeSourceReceiver}
+
 type Primary struct {
 	Source source.Primary
 }
@@ -29,33 +32,42 @@ func (p *Primary) GetVehiclesByProviderId(providerId int32) ([]out.Vehicle, erro
 	return p.Source.GetVehicles(filter.Vehicles{ProviderId: &providerId})
 }
 
-func (p *Primary) GetVehiclesByOIDAndProviderId(oid int64, providerId int32) ([]out.Vehicle, error) {
-	return p.Source.GetVehicles(filter.Vehicles{
-		OID:        &oid,
-		ProviderId: &providerId,
-	})
-}
-
 func (p *Primary) AddIndefiniteVehicle(oid int64, providerId int32) (int32, error) {
 	return p.Source.AddVehicle(insert.Vehicle{
 		IMEI:             strconv.FormatInt(oid, 10),
 		OID:              &oid,
 		ProviderId:       providerId,
 		ModerationStatus: other.ModerationStatusPending,
-	})
+	}, receiverAuthor)
 }
 
 func (p *Primary) UpdateVehicleOid(id int32, oid int64) error {
 	return p.Source.UpdateVehicleById(id, update.VehicleById{
 		OID: &oid,
-	})
+	}, receiverAuthor)
+}
+
+func (p *Primary) GetOidResolutionRulesByProviderId(providerId int32) ([]out.OidResolutionRule, error) {
+	return p.Source.GetOidResolutionRules(filter.OidResolutionRules{ProviderId: &providerId})
+}
+
+func (p *Primary) GetAllAcceptanceSchedules() ([]out.AcceptanceSchedule, error) {
+	return p.Source.GetAcceptanceSchedules(filter.AcceptanceSchedules{})
 }
 
 func (p *Primary) GetAllProviders() ([]out.Provider, error) {
 	return p.Source.GetProviders()
 }
 
-func (p *Primary) AddLocation(data *other.PacketData, vehicleId int32) (int32, error) {
+func (p *Primary) GetProvider(id int32) (out.Provider, error) {
+	return p.Source.GetProvider(id)
+}
+
+func (p *Primary) GetAllProviderAllowedNetworks() ([]out.ProviderAllowedNetwork, error) {
+	return p.Source.GetProviderAllowedNetworks(filter.ProviderAllowedNetworks{})
+}
+
+func (p *Primary) AddLocation(data *other.PacketData, vehicleId int32, isHistory bool) (int32, error) {
 	speed := int32(data.Speed)
 	altitude := int64(data.Altitude)
 	oid := int64(data.OID)
@@ -64,6 +76,12 @@ func (p *Primary) AddLocation(data *other.PacketData, vehicleId int32) (int32, e
 	sentTimestamp := time.Unix(data.SentTimestamp, 0)
 	receivedTimestamp := time.Unix(data.ReceivedTimestamp, 0)
 
+	var odometerMeters *int64
+	if data.Odometer != nil {
+		meters := int64(*data.Odometer) * 100
+		odometerMeters = &meters
+	}
+
 	return p.Source.AddLocation(insert.Location{
 		VehicleId:      vehicleId,
 		OID:            oid,
@@ -75,6 +93,8 @@ func (p *Primary) AddLocation(data *other.PacketData, vehicleId int32) (int32, e
 		SatelliteCount: &satelliteCount,
 		SentAt:         &sentTimestamp,
 		ReceivedAt:     receivedTimestamp,
+		IsHistory:      isHistory,
+		OdometerMeters: odometerMeters,
 	})
 }
 
@@ -82,10 +102,249 @@ func (p *Primary) GetLastVehiclePoint(vehicleId int32) (out.Point, error) {
 	return p.Source.GetLastVehiclePoint(vehicleId)
 }
 
-func (p *Primary) GetTracks(after, before time.Time) ([]out.Track, error) {
-	return p.Source.GetTracks(after, before)
+func (p *Primary) LocationExists(vehicleId int32, sentAt time.Time) (bool, error) {
+	return p.Source.LocationExists(vehicleId, sentAt)
+}
+
+func (p *Primary) GetTracks(filter filter.Tracks) ([]out.Track, error) {
+	return p.Source.GetTracks(filter)
 }
 
 func (p *Primary) DeleteLocation(locationId int32) error {
 	return p.Source.DeleteLocation(locationId)
 }
+
+func (p *Primary) ArchiveLocations(locationIds []int32) (int64, error) {
+	return p.Source.ArchiveLocations(locationIds)
+}
+
+func (p *Primary) GetTrackSimplificationSettings(providerId int32) (out.TrackSimplificationSettings, error) {
+	return p.Source.GetTrackSimplificationSettings(providerId)
+}
+
+func (p *Primary) AddQuarantinedLocation(data *other.PacketData, providerId int32, vehicleId *int32, reason other.QuarantineReason) (int32, error) {
+	speed := int32(data.Speed)
+	altitude := int64(data.Altitude)
+	direction := int16(data.Direction)
+	satelliteCount := int16(data.SatelliteCount)
+	sentTimestamp := time.Unix(data.SentTimestamp, 0)
+	receivedTimestamp := time.Unix(data.ReceivedTimestamp, 0)
+
+	var odometerMeters *int64
+	if data.Odometer != nil {
+		meters := int64(*data.Odometer) * 100
+		odometerMeters = &meters
+	}
+
+	return p.Source.AddQuarantinedLocation(insert.QuarantinedLocation{
+		ProviderId:     providerId,
+		OID:            int64(data.OID),
+		TerminalIMEI:   data.TerminalIMEI,
+		VehicleId:      vehicleId,
+		Reason:         reason,
+		Latitude:       data.Latitude,
+		Longitude:      data.Longitude,
+		Altitude:       &altitude,
+		Direction:      &direction,
+		Speed:          &speed,
+		SatelliteCount: &satelliteCount,
+		Hdop:           data.Hdop,
+		Valid:          data.Valid,
+		Fix3d:          data.Fix3d,
+		IsHistory:      data.History,
+		OdometerMeters: odometerMeters,
+		SentAt:         &sentTimestamp,
+		ReceivedAt:     receivedTimestamp,
+	})
+}
+
+func (p *Primary) GetGpsFilterSettings(providerId int32) (out.GpsFilterSettings, error) {
+	return p.Source.GetGpsFilterSettings(providerId)
+}
+
+func (p *Primary) AddRejectedLocation(data *other.PacketData, providerId int32, vehicleId *int32, rule other.GpsFilterRule, detail string) (int32, error) {
+	speed := int32(data.Speed)
+	altitude := int64(data.Altitude)
+	sentTimestamp := time.Unix(data.SentTimestamp, 0)
+	receivedTimestamp := time.Unix(data.ReceivedTimestamp, 0)
+
+	return p.Source.AddRejectedLocation(insert.RejectedLocation{
+		ProviderId: providerId,
+		OID:        int64(data.OID),
+		VehicleId:  vehicleId,
+		Rule:       rule,
+		Detail:     detail,
+		Latitude:   data.Latitude,
+		Longitude:  data.Longitude,
+		Altitude:   &altitude,
+		Speed:      &speed,
+		Hdop:       data.Hdop,
+		Valid:      data.Valid,
+		Fix3d:      data.Fix3d,
+		SentAt:     &sentTimestamp,
+		ReceivedAt: receivedTimestamp,
+	})
+}
+
+func (p *Primary) GetQuarantineGroups(providerId *int32, oid *int64) ([]out.QuarantineGroup, error) {
+	return p.Source.GetQuarantineGroups(filter.QuarantinedLocations{ProviderId: providerId, OID: oid})
+}
+
+func (p *Primary) GetQuarantinedLocations(providerId int32, oid int64, terminalIMEI string, limit int64) ([]out.QuarantinedLocation, error) {
+	return p.Source.GetQuarantinedLocations(filter.QuarantinedLocations{
+		ProviderId:   &providerId,
+		OID:          &oid,
+		TerminalIMEI: &terminalIMEI,
+		Limit:        limit,
+	})
+}
+
+func (p *Primary) DeleteQuarantinedLocationsByIds(ids []int32) (int64, error) {
+	return p.Source.DeleteQuarantinedLocationsByIds(ids)
+}
+
+func (p *Primary) DeleteQuarantinedLocations(providerId int32, oid int64, terminalIMEI string) (int64, error) {
+	return p.Source.DeleteQuarantinedLocations(filter.QuarantinedLocations{
+		ProviderId:   &providerId,
+		OID:          &oid,
+		TerminalIMEI: &terminalIMEI,
+	})
+}
+
+func (p *Primary) GetPendingTripVehicles() ([]out.PendingTripVehicle, error) {
+	return p.Source.GetPendingTripVehicles()
+}
+
+func (p *Primary) DeletePendingTripVehicle(vehicleId int32, version int64) error {
+	return p.Source.DeletePendingTripVehicle(vehicleId, version)
+}
+
+func (p *Primary) GetTripRebuildStart(vehicleId int32, sentAt time.Time) (time.Time, error) {
+	return p.Source.GetTripRebuildStart(vehicleId, sentAt)
+}
+
+func (p *Primary) GetTripPoints(vehicleId int32, from time.Time) ([]out.TripPoint, error) {
+	return p.Source.GetTripPoints(vehicleId, from)
+}
+
+func (p *Primary) ReplaceVehicleActivities(vehicleId int32, from time.Time, trips []out.Trip, stops []out.Stop) error {
+	tripInserts := make([]insert.Trip, 0, len(trips))
+	for _, trip := range trips {
+		tripInserts = append(tripInserts, insert.Trip{
+			VehicleId:      vehicleId,
+			StartedAt:      trip.StartedAt,
+			EndedAt:        trip.EndedAt,
+			StartLatitude:  trip.StartLatitude,
+			StartLongitude: trip.StartLongitude,
+			EndLatitude:    trip.EndLatitude,
+			EndLongitude:   trip.EndLongitude,
+			DistanceMeters: trip.DistanceMeters,
+			MaxSpeed:       trip.MaxSpeed,
+			PointCount:     trip.PointCount,
+		})
+	}
+
+	stopInserts := make([]insert.Stop, 0, len(stops))
+	for _, stop := range stops {
+		stopInserts = append(stopInserts, insert.Stop{
+			VehicleId:  vehicleId,
+			StartedAt:  stop.StartedAt,
+			EndedAt:    stop.EndedAt,
+			Latitude:   stop.Latitude,
+			Longitude:  stop.Longitude,
+			PointCount: stop.PointCount,
+		})
+	}
+
+	return p.Source.ReplaceVehicleActivities(vehicleId, from, tripInserts, stopInserts)
+}
+
+func (p *Primary) ReplaceDailyMileage(vehicleId int32, fromDay string, mileage []out.DailyMileage) error {
+	inserts := make([]insert.DailyMileage, 0, len(mileage))
+	for _, day := range mileage {
+		inserts = append(inserts, insert.DailyMileage{
+			VehicleId:           vehicleId,
+			Day:                 day.Day,
+			DistanceMeters:      day.DistanceMeters,
+			OdometerStartMeters: day.OdometerStartMeters,
+			OdometerEndMeters:   day.OdometerEndMeters,
+			MovingSeconds:       day.MovingSeconds,
+			IdleSeconds:         day.IdleSeconds,
+			PointCount:          day.PointCount,
+		})
+	}
+	return p.Source.ReplaceDailyMileage(vehicleId, fromDay, inserts)
+}
+
+func (p *Primary) GetAllGeofences() ([]out.Geofence, error) {
+	return p.Source.GetGeofences()
+}
+
+func (p *Primary) AddGeofenceEvents(vehicleId int32, events []out.GeofenceEvent) error {
+	inserts := make([]insert.GeofenceEvent, 0, len(events))
+	for _, event := range events {
+		inserts = append(inserts, insert.GeofenceEvent{
+			GeofenceId: event.GeofenceId,
+			VehicleId:  vehicleId,
+			Type:       event.Type,
+			LocationId: event.LocationId,
+			Latitude:   event.Latitude,
+			Longitude:  event.Longitude,
+			OccurredAt: event.OccurredAt,
+		})
+	}
+	return p.Source.AddGeofenceEvents(inserts)
+}
+
+func (p *Primary) OpenTerminalSession(providerId int32, remoteAddress string, connectedAt time.Time) (int32, error) {
+	return p.Source.AddTerminalSession(insert.TerminalSession{
+		ProviderId:    providerId,
+		RemoteAddress: remoteAddress,
+		ConnectedAt:   connectedAt,
+	})
+}
+
+func (p *Primary) SaveTerminalSession(session out.TerminalSession) error {
+	return p.Source.UpdateTerminalSession(session.ID, update.TerminalSession{
+		TerminalIMEI:     session.TerminalIMEI,
+		OID:              session.OID,
+		VehicleId:        session.VehicleId,
+		LastPacketAt:     session.LastPacketAt,
+		PacketCount:      session.PacketCount,
+		RecordCount:      session.RecordCount,
+		DecodeErrorCount: session.DecodeErrorCount,
+		DisconnectedAt:   session.DisconnectedAt,
+		CloseReason:      session.CloseReason,
+	})
+}
+
+func (p *Primary) SaveTerminalSessionVehicles(sessionId int32, vehicles []out.TerminalSessionVehicle) error {
+	inserts := make([]insert.TerminalSessionVehicle, 0, len(vehicles))
+	for _, vehicle := range vehicles {
+		inserts = append(inserts, insert.TerminalSessionVehicle{
+			SessionId:   sessionId,
+			VehicleId:   vehicle.VehicleId,
+			OID:         vehicle.OID,
+			FirstSeenAt: vehicle.FirstSeenAt,
+			LastSeenAt:  vehicle.LastSeenAt,
+			RecordCount: vehicle.RecordCount,
+		})
+	}
+	return p.Source.SaveTerminalSessionVehicles(inserts)
+}
+
+func (p *Primary) CloseOpenTerminalSessions(providerId int32, reason other.SessionCloseReason) (int64, error) {
+	return p.Source.CloseOpenTerminalSessions(providerId, reason)
+}
+
+func (p *Primary) Ping() error {
+	return p.Source.Ping()
+}
+
+func (p *Primary) GetMigrationVersion() (out.MigrationVersion, error) {
+	return p.Source.GetMigrationVersion()
+}
+
+func (p *Primary) SetProviderPort(providerId int32, port int32) error {
+	return p.Source.UpdateProvider(providerId, update.Provider{Port: &port})
+}
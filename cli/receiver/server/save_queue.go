@@ -0,0 +1,50 @@
+package server
+
+import (
+	"sync/atomic"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/other"
+)
+
+// saveQueueSize — количество местоположений соединения, ожидающих сохранения. Когда очередь
+// заполнена, чтение следующего пакета соединения ждет ее освобождения.
+const saveQueueSize = 1024
+
+// saveQueueDepth — количество местоположений во всех очередях соединений, ожидающих сохранения
+var saveQueueDepth atomic.Int64
+
+// SaveQueueDepth возвращает количество местоположений, принятых от терминалов и ожидающих сохранения
+func SaveQueueDepth() int {
+	return int(saveQueueDepth.Load())
+}
+
+// saveQueue сохраняет местоположения одного соединения по одному в порядке приема. Подтверждение
+// отправляется терминалу, не дожидаясь сохранения, но решение о том, является ли точка исторической
+// и повторяет ли она предыдущую, принимается для точек соединения последовательно.
+type saveQueue struct {
+	points chan other.PacketData
+	done   chan struct{}
+}
+
+func newSaveQueue(save func(point *other.PacketData)) *saveQueue {
+	q := &saveQueue{points: make(chan other.PacketData, saveQueueSize), done: make(chan struct{})}
+	go func() {
+		defer close(q.done)
+		for point := range q.points {
+			save(&point)
+			saveQueueDepth.Add(-1)
+		}
+	}()
+	return q
+}
+
+func (q *saveQueue) Push(point other.PacketData) {
+	saveQueueDepth.Add(1)
+	q.points <- point
+}
+
+// Close ждет сохранения местоположений, оставшихся в очереди
+func (q *saveQueue) Close() {
+	close(q.points)
+	<-q.done
+}
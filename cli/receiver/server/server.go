@@ -2,12 +2,18 @@ package server
 
 import (
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
 	"net"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/daniil11ru/egts/cli/receiver/archive"
 	"github.com/daniil11ru/egts/cli/receiver/dto/other"
+	"github.com/daniil11ru/egts/cli/receiver/metrics"
 	"github.com/daniil11ru/egts/cli/receiver/server/domain"
 	"github.com/daniil11ru/egts/libs/egts"
 	log "github.com/sirupsen/logrus"
@@ -17,34 +23,152 @@ const (
 	egtsPcOk         = 0
 	egtsPcSrvcDenied = 0x95
 	egtsPcUnsType    = 133
+	egtsPcAuthDenied = 151
 	headerLen        = 10
 )
 
+var errNotEgtsPacket = errors.New("пакет не соответствует формату EGTS")
+
+type connectionState struct {
+	terminalIMEI string
+	session      *domain.TerminalSession
+
+	// authRequired запрещает прием телематических данных до прохождения авторизации подзаписью
+	// EGTS_SR_TERM_IDENTITY
+	authRequired  bool
+	authenticated bool
+
+	// saves сохраняет местоположения соединения в порядке приема
+	saves *saveQueue
+}
+
 type Server struct {
 	Address    string
 	TTL        time.Duration
 	ProviderID int32
 	SavePacket *domain.SavePacket
+	Sessions   *domain.TerminalSessions
+	Archive    *archive.Writer
+	Health     *domain.Health
 	Listener   net.Listener
+
+	// AuthRequired — провайдер должен пройти авторизацию прежде, чем передавать данные
+	AuthRequired bool
+
+	mu      sync.Mutex
+	stopped bool
+
+	// allowedNetworks — сети, с адресов которых разрешено подключение; пустой список разрешает
+	// любой адрес. maxPacketsPerMinute ограничивает количество пакетов одного соединения, 0 —
+	// без ограничения. Меняются без перезапуска сервера и применяются к новым соединениям.
+	allowedNetworks     []*net.IPNet
+	maxPacketsPerMinute int32
+}
+
+func NewServer(addr string, ttl time.Duration, providerID int32, savePacket *domain.SavePacket, sessions *domain.TerminalSessions, frameArchive *archive.Writer, health *domain.Health) *Server {
+	return &Server{Address: addr, TTL: ttl, ProviderID: providerID, SavePacket: savePacket, Sessions: sessions, Archive: frameArchive, Health: health}
 }
 
-func NewServer(addr string, ttl time.Duration, providerID int32, savePacket *domain.SavePacket) *Server {
-	return &Server{Address: addr, TTL: ttl, ProviderID: providerID, SavePacket: savePacket}
+// closeReason определяет причину закрытия соединения по ошибке чтения пакета
+func closeReason(err error) other.SessionCloseReason {
+	var netErr net.Error
+	switch {
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return other.SessionCloseReasonTimeout
+	case errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF):
+		return other.SessionCloseReasonClientClosed
+	case errors.Is(err, errNotEgtsPacket):
+		return other.SessionCloseReasonInvalidPacket
+	default:
+		return other.SessionCloseReasonReadError
+	}
+}
+
+// decodeErrorReason возвращает причину ошибки разбора для метрик по коду результата
+func decodeErrorReason(resultCode uint8) string {
+	switch resultCode {
+	case 128:
+		return "unsupported_protocol"
+	case 129:
+		return "decrypt_error"
+	case 131:
+		return "header_format"
+	case 132:
+		return "data_format"
+	case 133:
+		return "unsupported_type"
+	case 137:
+		return "crc_error"
+	}
+	return "code_" + strconv.Itoa(int(resultCode))
+}
+
+func packetTypeName(packetType uint8) string {
+	switch packetType {
+	case egts.PtAppdataPacket:
+		return "appdata"
+	case egts.PtResponsePacket:
+		return "response"
+	}
+	return strconv.Itoa(int(packetType))
 }
 
+func (s *Server) providerLabel() string {
+	return strconv.Itoa(int(s.ProviderID))
+}
+
+func (s *Server) portLabel() string {
+	_, port, err := net.SplitHostPort(s.Address)
+	if err != nil {
+		return s.Address
+	}
+	return port
+}
+
+func recordCount(pkg *egts.Package) int {
+	if pkg.PacketType != egts.PtAppdataPacket {
+		return 0
+	}
+	records, ok := pkg.ServicesFrameData.(*egts.ServiceDataSet)
+	if !ok || records == nil {
+		return 0
+	}
+	return len(*records)
+}
+
+// Run открывает порт и обрабатывает соединения, пока сервер не остановлен методом Stop. После
+// остановки возвращает nil.
 func (server *Server) Run() error {
-	var err error
-	server.Listener, err = net.Listen("tcp", server.Address)
+	listener, err := net.Listen("tcp", server.Address)
+
+	server.mu.Lock()
+	if server.stopped {
+		server.mu.Unlock()
+		if err == nil {
+			listener.Close()
+		}
+		return nil
+	}
 	if err != nil {
-		return fmt.Errorf("не удалось открыть соединение: %w", err)
+		err = fmt.Errorf("не удалось открыть соединение: %w", err)
+		server.Health.SetListener(server.ProviderID, server.Address, other.ListenerStateFailed, err)
+		server.mu.Unlock()
+		return err
 	}
-	defer server.Listener.Close()
+	server.Listener = listener
+	server.Health.SetListener(server.ProviderID, server.Address, other.ListenerStateListening, nil)
+	server.mu.Unlock()
+	defer listener.Close()
 
 	log.WithField("addr", server.Address).Info("Запущен сервер для обработки пакетов от провайдера с ID ", server.ProviderID)
 
 	for {
-		conn, err := server.Listener.Accept()
+		conn, err := listener.Accept()
 		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				log.WithField("addr", server.Address).Info("Остановлен сервер для обработки пакетов от провайдера с ID ", server.ProviderID)
+				return nil
+			}
 			log.WithField("err", err).Error("Ошибка соединения")
 			continue
 		}
@@ -53,31 +177,166 @@ func (server *Server) Run() error {
 	}
 }
 
+// Stop закрывает порт сервера. Текущее соединение обрабатывается до конца.
+func (server *Server) Stop() {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	server.stopped = true
+	if server.Listener != nil {
+		server.Listener.Close()
+	}
+}
+
+// SetAdmission меняет разрешенные сети и ограничение на количество пакетов в минуту
+func (server *Server) SetAdmission(allowedNetworks []*net.IPNet, maxPacketsPerMinute int32) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	server.allowedNetworks = allowedNetworks
+	server.maxPacketsPerMinute = maxPacketsPerMinute
+}
+
+func (server *Server) admission() ([]*net.IPNet, int32) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	return server.allowedNetworks, server.maxPacketsPerMinute
+}
+
+func (server *Server) Stopped() bool {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	return server.stopped
+}
+
+func remoteIP(addr net.Addr) net.IP {
+	if tcpAddr, ok := addr.(*net.TCPAddr); ok {
+		return tcpAddr.IP
+	}
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return nil
+	}
+	return net.ParseIP(host)
+}
+
+// rejectConnection записывает соединение, отклоненное контролем доступа, как закрытую сессию
+func (s *Server) rejectConnection(connection net.Conn, reason other.SessionCloseReason) {
+	metrics.RejectedConnections.WithLabelValues(s.providerLabel(), string(reason)).Inc()
+	if s.Sessions == nil {
+		return
+	}
+	session, err := s.Sessions.Open(s.ProviderID, connection.RemoteAddr().String())
+	if err != nil {
+		log.WithField("err", err).Warn("Не удалось сохранить сессию соединения")
+		return
+	}
+	s.Sessions.Close(session, reason)
+}
+
 func (s *Server) handleConnection(connection net.Conn) {
 	defer connection.Close()
 
 	log.WithField("ip", connection.RemoteAddr()).Info("Установлено соединение")
 
+	provider, port := s.providerLabel(), s.portLabel()
+	metrics.Connections.WithLabelValues(provider, port).Inc()
+
+	allowedNetworks, maxPacketsPerMinute := s.admission()
+	if !domain.NetworkAllowed(allowedNetworks, remoteIP(connection.RemoteAddr())) {
+		log.WithField("ip", connection.RemoteAddr()).Warnf("Соединение отклонено: адрес не входит в разрешенные сети провайдера с ID %d", s.ProviderID)
+		s.rejectConnection(connection, other.SessionCloseReasonIpNotAllowed)
+		return
+	}
+	limiter := domain.NewPacketRateLimiter(maxPacketsPerMinute, time.Now())
+
+	activeConnections := metrics.ActiveConnections.WithLabelValues(provider, port)
+	activeConnections.Inc()
+	defer activeConnections.Dec()
+
+	state := &connectionState{authRequired: s.AuthRequired}
+	if s.Sessions != nil {
+		session, err := s.Sessions.Open(s.ProviderID, connection.RemoteAddr().String())
+		if err != nil {
+			log.WithField("err", err).Warn("Не удалось сохранить сессию соединения")
+		}
+		state.session = session
+	}
+
+	reason := other.SessionCloseReasonClientClosed
+	defer func() {
+		if s.Sessions != nil {
+			s.Sessions.Close(state.session, reason)
+		}
+	}()
+
+	session := state.session
+	state.saves = newSaveQueue(func(point *other.PacketData) { s.savePoint(session, point) })
+	defer state.saves.Close()
+
 	for {
 		packet, err := s.readPacket(connection)
 		if err != nil {
+			reason = closeReason(err)
+			if errors.Is(err, errNotEgtsPacket) {
+				metrics.DecodeErrors.WithLabelValues(provider, "invalid_packet").Inc()
+				s.archiveFrame(connection, state, archive.Frame{ReceivedAt: time.Now(), Data: packet}, err)
+			}
+			return
+		}
+		receivedAt := time.Now()
+		if !limiter.Allow(receivedAt) {
+			log.WithField("ip", connection.RemoteAddr()).Warnf("Соединение закрыто: превышено ограничение в %d пакетов в минуту для провайдера с ID %d", maxPacketsPerMinute, s.ProviderID)
+			metrics.RejectedConnections.WithLabelValues(provider, string(other.SessionCloseReasonRateLimited)).Inc()
+			reason = other.SessionCloseReasonRateLimited
 			return
 		}
 
 		pkg, receivedTimestamp, resultCode, err := s.decodePacket(packet)
 		if err != nil {
-			s.sendDecodeError(connection, pkg.PacketIdentifier, resultCode)
+			metrics.Packets.WithLabelValues(provider, "unknown", strconv.Itoa(int(resultCode))).Inc()
+			metrics.DecodeErrors.WithLabelValues(provider, decodeErrorReason(resultCode)).Inc()
+			state.session.RecordDecodeError(time.Now())
+			s.touchSession(state)
+			response := s.sendDecodeError(connection, pkg.PacketIdentifier, resultCode)
+			s.archiveFrame(connection, state, archive.Frame{ReceivedAt: receivedAt, Data: packet, ResultCode: resultCode, Response: response}, err)
 			continue
 		}
+		metrics.Packets.WithLabelValues(provider, packetTypeName(pkg.PacketType), strconv.Itoa(int(resultCode))).Inc()
+		state.session.RecordPacket(recordCount(pkg), time.Now())
+		s.touchSession(state)
 
+		var response []byte
 		switch pkg.PacketType {
 		case egts.PtAppdataPacket:
-			if err := s.handleAppData(connection, pkg, receivedTimestamp, resultCode); err != nil {
-				continue
-			}
+			response, _ = s.handleAppData(connection, state, pkg, receivedTimestamp, resultCode)
 		case egts.PtResponsePacket:
 			log.Debug("Тип пакета EGTS_PT_RESPONSE")
 		}
+		s.archiveFrame(connection, state, archive.Frame{ReceivedAt: receivedAt, Data: packet, ResultCode: resultCode, Response: response}, nil)
+	}
+}
+
+// archiveFrame сохраняет принятый кадр в архив. Ошибка архива не прерывает прием данных.
+func (s *Server) archiveFrame(conn net.Conn, state *connectionState, frame archive.Frame, decodeErr error) {
+	if s.Archive == nil {
+		return
+	}
+
+	frame.ProviderId = s.ProviderID
+	frame.SessionId = state.session.ID()
+	frame.RemoteAddress = conn.RemoteAddr().String()
+	if decodeErr != nil {
+		frame.DecodeError = decodeErr.Error()
+	}
+	if err := s.Archive.Write(frame); err != nil {
+		log.WithField("err", err).Warn("Не удалось сохранить кадр в архив")
+	}
+}
+
+func (s *Server) touchSession(state *connectionState) {
+	if s.Sessions != nil {
+		s.Sessions.Touch(state.session)
 	}
 }
 
@@ -105,7 +364,7 @@ func (s *Server) readPacket(conn net.Conn) ([]byte, error) {
 	if headerBuf[0] != 0x01 {
 		log.WithField("ip", conn.RemoteAddr()).Warn("Пакет не соответствует формату EGTS")
 		_ = conn.SetDeadline(time.Time{})
-		return nil, fmt.Errorf("invalid EGTS packet")
+		return headerBuf, errNotEgtsPacket
 	}
 
 	bodyLen := binary.LittleEndian.Uint16(headerBuf[5:7])
@@ -144,21 +403,61 @@ func (s *Server) decodePacket(packet []byte) (*egts.Package, int64, uint8, error
 	return &pkg, receivedTimestamp, resultCode, err
 }
 
-func (s *Server) sendDecodeError(conn net.Conn, packetIdentifier uint16, resultCode uint8) {
+func (s *Server) sendDecodeError(conn net.Conn, packetIdentifier uint16, resultCode uint8) []byte {
 	resp, err := createPtResponse(packetIdentifier, resultCode, 0, nil)
 	if err != nil {
 		log.WithField("err", err).Error("Ошибка сборки ответа EGTS_PT_RESPONSE с ошибкой")
-		return
+		return nil
 	}
 	_, _ = conn.Write(resp)
+	return resp
+}
+
+func handleTermIdentity(state *connectionState, rec egts.ServiceDataRecord) bool {
+	found := false
+	for _, subRec := range rec.RecordDataSet {
+		termIdentity, ok := subRec.SubrecordData.(*egts.SrTermIdentity)
+		if !ok {
+			continue
+		}
+		log.Debug("Разбор подзаписи EGTS_SR_TERM_IDENTITY")
+		found = true
+		state.authenticated = true
+		if termIdentity.IMEIE == "1" {
+			state.terminalIMEI = strings.TrimRight(termIdentity.IMEI, "\x00")
+			state.session.SetTerminalIMEI(state.terminalIMEI)
+			log.Debugf("TID: %d, IMEI: %s", termIdentity.TerminalIdentifier, state.terminalIMEI)
+		}
+	}
+	return found
 }
 
-func (s *Server) handleAppData(conn net.Conn, pkg *egts.Package, receivedTimestamp int64, resultCode uint8) error {
+type recordResult struct {
+	serviceType uint8
+	status      uint8
+}
+
+// appData — результат разбора пакета EGTS_PT_APPDATA
+type appData struct {
+	srResponsesRecord     egts.RecordDataSet
+	srResultCodePkg       []byte
+	serviceType           uint8
+	packets               []other.PacketData
+	records               []recordResult
+	unsupportedSubrecords []uint8
+}
+
+// parseAppData формирует подтверждения записей пакета и извлекает из него местоположения для
+// сохранения. Используется как при приеме данных, так и при повторной обработке архива.
+func parseAppData(state *connectionState, pkg *egts.Package, receivedTimestamp int64) appData {
 	var (
-		srResponsesRecord egts.RecordDataSet
-		srResultCodePkg   []byte
-		serviceType       uint8
-		client            uint32
+		srResponsesRecord     egts.RecordDataSet
+		srResultCodePkg       []byte
+		serviceType           uint8
+		client                uint32
+		packets               []other.PacketData
+		records               []recordResult
+		unsupportedSubrecords []uint8
 	)
 
 	for _, rec := range *pkg.ServicesFrameData.(*egts.ServiceDataSet) {
@@ -168,7 +467,42 @@ func (s *Server) handleAppData(conn net.Conn, pkg *egts.Package, receivedTimesta
 		serviceType = rec.SourceServiceType
 		log.Debug("Тип сервиса: ", serviceType)
 
-		if serviceType != 2 {
+		if serviceType == egts.AuthService && handleTermIdentity(state, rec) {
+			srResponsesRecord = append(srResponsesRecord, egts.RecordData{
+				SubrecordType:   egts.SrRecordResponseType,
+				SubrecordLength: 3,
+				SubrecordData: &egts.SrResponse{
+					ConfirmedRecordNumber: rec.RecordNumber,
+					RecordStatus:          egtsPcOk,
+				},
+			})
+			records = append(records, recordResult{serviceType: serviceType, status: egtsPcOk})
+
+			var err error
+			srResultCodePkg, err = createSrResultCode(pkg.PacketIdentifier, egtsPcOk)
+			if err != nil {
+				log.WithField("err", err).Error("Ошибка сборки пакета EGTS_SR_RESULT_CODE")
+			}
+
+			continue
+		}
+
+		if serviceType == egts.TeledataService && state.authRequired && !state.authenticated {
+			log.Warn("Телематические данные переданы до авторизации")
+			srResponsesRecord = append(srResponsesRecord, egts.RecordData{
+				SubrecordType:   egts.SrRecordResponseType,
+				SubrecordLength: 3,
+				SubrecordData: &egts.SrResponse{
+					ConfirmedRecordNumber: rec.RecordNumber,
+					RecordStatus:          egtsPcAuthDenied,
+				},
+			})
+			records = append(records, recordResult{serviceType: serviceType, status: egtsPcAuthDenied})
+
+			continue
+		}
+
+		if serviceType != egts.TeledataService {
 			log.Warn("Неподдерживаемый сервис")
 			srResponsesRecord = append(srResponsesRecord, egts.RecordData{
 				SubrecordType:   egts.SrRecordResponseType,
@@ -178,6 +512,7 @@ func (s *Server) handleAppData(conn net.Conn, pkg *egts.Package, receivedTimesta
 					RecordStatus:          egtsPcSrvcDenied,
 				},
 			})
+			records = append(records, recordResult{serviceType: serviceType, status: egtsPcSrvcDenied})
 
 			continue
 		}
@@ -226,13 +561,26 @@ func (s *Server) handleAppData(conn net.Conn, pkg *egts.Package, receivedTimesta
 				exportPacket.Altitude = subRecData.Altitude
 				exportPacket.Speed = subRecData.Speed
 				exportPacket.Direction = subRecData.Direction
+				exportPacket.Valid = subRecData.VLD == "1"
+				exportPacket.History = subRecData.BB == "1"
+				exportPacket.Fix3d = subRecData.FIX == "1"
+				if subRecData.Odometer != 0 {
+					odometer := subRecData.Odometer
+					exportPacket.Odometer = &odometer
+				}
 			case *egts.SrExtPosData:
 				log.Debug("Разбор подзаписи EGTS_SR_EXT_POS_DATA")
 				exportPacket.SatelliteCount = subRecData.Satellites
+				if subRecData.HdopFieldExists == "1" {
+					// HDOP передается умноженным на 100
+					hdop := float64(subRecData.HorizontalDilutionOfPrecision) / 100
+					exportPacket.Hdop = &hdop
+				}
 			default:
 				log.Warnf("Неподдерживаемая подзапись SRT=%d в записи RN=%d",
 					subRec.SubrecordType, rec.RecordNumber)
 				recStatus = egtsPcUnsType
+				unsupportedSubrecords = append(unsupportedSubrecords, subRec.SubrecordType)
 			}
 		}
 
@@ -244,32 +592,75 @@ func (s *Server) handleAppData(conn net.Conn, pkg *egts.Package, receivedTimesta
 				RecordStatus:          recStatus,
 			},
 		})
+		records = append(records, recordResult{serviceType: serviceType, status: recStatus})
 
 		exportPacket.OID = client
+		exportPacket.TerminalIMEI = state.terminalIMEI
 		if isPkgSave && recStatus == egtsPcOk {
-			pkt := exportPacket
-			go func() {
-				if err := s.SavePacket.Run(&pkt, s.ProviderID); err != nil {
-					log.Warnf("Телематические данные не были сохранены: %s", err)
-				}
-			}()
+			packets = append(packets, exportPacket)
 		}
 	}
 
-	resp, err := createPtResponse(pkg.PacketIdentifier, resultCode, serviceType, srResponsesRecord)
+	return appData{
+		srResponsesRecord:     srResponsesRecord,
+		srResultCodePkg:       srResultCodePkg,
+		serviceType:           serviceType,
+		packets:               packets,
+		records:               records,
+		unsupportedSubrecords: unsupportedSubrecords,
+	}
+}
+
+// savePoint сохраняет местоположение, принятое в соединении с сессией session
+func (s *Server) savePoint(session *domain.TerminalSession, point *other.PacketData) {
+	startedAt := time.Now()
+	vehicleID, err := s.SavePacket.Run(point, s.ProviderID)
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	metrics.SaveDuration.WithLabelValues(result).Observe(time.Since(startedAt).Seconds())
+
+	if vehicleID != 0 {
+		session.RecordVehicle(vehicleID, int64(point.OID), time.Now())
+	}
+	if err != nil {
+		log.Warnf("Телематические данные не были сохранены: %s", err)
+	}
+}
+
+// handleAppData передает местоположения пакета на сохранение, отправляет подтверждение и возвращает
+// отправленные байты
+func (s *Server) handleAppData(conn net.Conn, state *connectionState, pkg *egts.Package, receivedTimestamp int64, resultCode uint8) ([]byte, error) {
+	data := parseAppData(state, pkg, receivedTimestamp)
+
+	provider := s.providerLabel()
+	for _, record := range data.records {
+		metrics.Records.WithLabelValues(provider, strconv.Itoa(int(record.serviceType)), strconv.Itoa(int(record.status))).Inc()
+	}
+	for _, srt := range data.unsupportedSubrecords {
+		metrics.UnsupportedSubrecords.WithLabelValues(provider, strconv.Itoa(int(srt))).Inc()
+	}
+
+	for _, pkt := range data.packets {
+		state.saves.Push(pkt)
+	}
+
+	resp, err := createPtResponse(pkg.PacketIdentifier, resultCode, data.serviceType, data.srResponsesRecord)
 	if err != nil {
 		log.WithField("err", err).Error("Ошибка сборки ответа")
-		return err
+		return nil, err
 	}
 	_, _ = conn.Write(resp)
 	log.Debug("Отправлен пакет EGTS_PT_RESPONSE")
 
-	if len(srResultCodePkg) > 0 {
-		_, _ = conn.Write(srResultCodePkg)
+	if len(data.srResultCodePkg) > 0 {
+		_, _ = conn.Write(data.srResultCodePkg)
 		log.Debug("Отправлен пакет EGTS_SR_RESULT_CODE")
+		resp = append(resp, data.srResultCodePkg...)
 	}
 
-	return nil
+	return resp, nil
 }
 
 func createPtResponse(pid uint16, resultCode, serviceType uint8, srResponses egts.RecordDataSet) ([]byte, error) {
@@ -315,3 +706,50 @@ func createPtResponse(pid uint16, resultCode, serviceType uint8, srResponses egt
 
 	return respPkg.Encode()
 }
+
+func createSrResultCode(pid uint16, resultCode uint8) ([]byte, error) {
+	records := egts.RecordDataSet{
+		egts.RecordData{
+			SubrecordType:   egts.SrResultCodeType,
+			SubrecordLength: 1,
+			SubrecordData: &egts.SrResultCode{
+				ResultCode: resultCode,
+			},
+		},
+	}
+
+	sdr := egts.ServiceDataSet{
+		egts.ServiceDataRecord{
+			RecordLength:             records.Length(),
+			RecordNumber:             1,
+			SourceServiceOnDevice:    "0",
+			RecipientServiceOnDevice: "0",
+			Group:                    "1",
+			RecordProcessingPriority: "00",
+			TimeFieldExists:          "0",
+			EventIDFieldExists:       "0",
+			ObjectIDFieldExists:      "0",
+			SourceServiceType:        egts.AuthService,
+			RecipientServiceType:     egts.AuthService,
+			RecordDataSet:            records,
+		},
+	}
+
+	pkg := egts.Package{
+		ProtocolVersion:   1,
+		SecurityKeyID:     0,
+		Prefix:            "00",
+		Route:             "0",
+		EncryptionAlg:     "00",
+		Compression:       "0",
+		Priority:          "00",
+		HeaderLength:      11,
+		HeaderEncoding:    0,
+		FrameDataLength:   sdr.Length(),
+		PacketIdentifier:  pid + 2,
+		PacketType:        egts.PtAppdataPacket,
+		ServicesFrameData: &sdr,
+	}
+
+	return pkg.Encode()
+}
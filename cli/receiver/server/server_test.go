@@ -1 +1,188 @@
 package server
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	"github.com/daniil11ru/egts/cli/receiver/dto/other"
+	"github.com/daniil11ru/egts/cli/receiver/server/domain"
+	"github.com/daniil11ru/egts/cli/receiver/server/repository"
+	"github.com/daniil11ru/egts/libs/egts"
+	"github.com/stretchr/testify/assert"
+)
+
+// posDataPacket — пакет EGTS_PT_APPDATA с одной подзаписью EGTS_SR_POS_DATA для OID 133552
+var posDataPacket = []byte{0x01, 0x00, 0x03, 0x0B, 0x00, 0x23, 0x00, 0x8A, 0x00, 0x01, 0x49, 0x18, 0x00, 0x61,
+	0x00, 0x99, 0xB0, 0x09, 0x02, 0x00, 0x02, 0x02, 0x10, 0x15, 0x00, 0xD5, 0x3F, 0x01, 0x10, 0x6F, 0x1C, 0x05, 0x9E,
+	0x7A, 0xB5, 0x3C, 0x35, 0x01, 0xD0, 0x87, 0x2C, 0x01, 0x00, 0x00, 0x00, 0x00, 0xCC, 0x27}
+
+func TestParseAppData(t *testing.T) {
+	pkg := egts.Package{}
+	_, err := pkg.Decode(posDataPacket)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	state := &connectionState{terminalIMEI: "356307042441013"}
+	data := parseAppData(state, &pkg, 1700000000)
+
+	if assert.Len(t, data.packets, 1) {
+		packet := data.packets[0]
+		assert.Equal(t, uint32(133552), packet.OID)
+		assert.InDelta(t, 55.553894, packet.Latitude, 1e-6)
+		assert.InDelta(t, 37.432367, packet.Longitude, 1e-6)
+		assert.Equal(t, time.Date(2018, time.July, 5, 20, 8, 53, 0, time.UTC).Unix(), packet.SentTimestamp)
+		assert.Equal(t, int64(1700000000), packet.ReceivedTimestamp)
+		assert.Equal(t, "356307042441013", packet.TerminalIMEI)
+		assert.True(t, packet.Valid)
+	}
+	if assert.Len(t, data.srResponsesRecord, 1) {
+		response := data.srResponsesRecord[0].SubrecordData.(*egts.SrResponse)
+		assert.Equal(t, uint16(97), response.ConfirmedRecordNumber)
+		assert.Equal(t, uint8(egtsPcOk), response.RecordStatus)
+	}
+	assert.Empty(t, data.srResultCodePkg)
+}
+
+func TestParseAppDataRequiresAuth(t *testing.T) {
+	pkg := egts.Package{}
+	_, err := pkg.Decode(posDataPacket)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	data := parseAppData(&connectionState{authRequired: true}, &pkg, 1700000000)
+	assert.Empty(t, data.packets)
+	if assert.Len(t, data.srResponsesRecord, 1) {
+		response := data.srResponsesRecord[0].SubrecordData.(*egts.SrResponse)
+		assert.Equal(t, uint8(egtsPcAuthDenied), response.RecordStatus)
+	}
+
+	data = parseAppData(&connectionState{authRequired: true, authenticated: true}, &pkg, 1700000000)
+	assert.Len(t, data.packets, 1)
+}
+
+func TestSaveQueueKeepsOrder(t *testing.T) {
+	var saved []uint32
+	release := make(chan struct{})
+	queue := newSaveQueue(func(point *other.PacketData) {
+		<-release
+		saved = append(saved, point.OID)
+	})
+	for oid := uint32(1); oid <= 5; oid++ {
+		queue.Push(other.PacketData{OID: oid})
+	}
+	assert.Equal(t, 5, SaveQueueDepth())
+	close(release)
+	queue.Close()
+
+	assert.Equal(t, []uint32{1, 2, 3, 4, 5}, saved, "местоположения соединения сохраняются в порядке приема")
+	assert.Equal(t, 0, SaveQueueDepth())
+}
+
+func TestCloseReason(t *testing.T) {
+	assert.Equal(t, other.SessionCloseReasonClientClosed, closeReason(io.EOF))
+	assert.Equal(t, other.SessionCloseReasonClientClosed, closeReason(io.ErrUnexpectedEOF))
+	assert.Equal(t, other.SessionCloseReasonTimeout, closeReason(fmt.Errorf("read: %w", os.ErrDeadlineExceeded)))
+	assert.Equal(t, other.SessionCloseReasonInvalidPacket, closeReason(errNotEgtsPacket))
+	assert.Equal(t, other.SessionCloseReasonReadError, closeReason(fmt.Errorf("connection reset by peer")))
+}
+
+func TestProviderListenerSettings(t *testing.T) {
+	port := int32(7000)
+	otherPort := int32(7001)
+	maxPackets := int32(600)
+	settings := ProviderListenerSettings([]out.Provider{
+		{ID: 1, Port: &port, Enabled: true, AuthRequired: true, MaxPacketsPerMinute: &maxPackets},
+		{ID: 2, Port: &otherPort, Enabled: false},
+		{ID: 3, Enabled: true},
+	}, []out.ProviderAllowedNetwork{
+		{ID: 1, ProviderId: 1, Network: "10.1.0.0/16"},
+		{ID: 2, ProviderId: 2, Network: "10.2.0.0/16"},
+	}, "0.0.0.0")
+
+	if assert.Len(t, settings, 1) {
+		assert.Equal(t, int32(1), settings[0].ProviderID)
+		assert.Equal(t, "0.0.0.0:7000", settings[0].Address)
+		assert.True(t, settings[0].AuthRequired)
+		assert.Equal(t, int32(600), settings[0].MaxPacketsPerMinute)
+		if assert.Len(t, settings[0].AllowedNetworks, 1) {
+			assert.Equal(t, "10.1.0.0/16", settings[0].AllowedNetworks[0].String())
+		}
+	}
+}
+
+func TestHandleConnectionRejectsNotAllowedAddress(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer listener.Close()
+
+	office, _ := domain.ParseAllowedNetwork("10.1.0.0/16")
+	srv := &Server{Address: listener.Addr().String(), TTL: time.Second, ProviderID: 1}
+	srv.SetAdmission([]*net.IPNet{office}, 0)
+
+	client, err := net.Dial("tcp", listener.Addr().String())
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer client.Close()
+	conn, err := listener.Accept()
+	if !assert.NoError(t, err) {
+		return
+	}
+	srv.handleConnection(conn)
+
+	_ = client.SetReadDeadline(time.Now().Add(time.Second))
+	_, err = client.Read(make([]byte, 1))
+	assert.ErrorIs(t, err, io.EOF, "соединение с адреса вне разрешенных сетей закрывается")
+}
+
+func TestListenersApply(t *testing.T) {
+	health := domain.NewHealth(repository.Primary{}, domain.HealthPolicy{}, SaveQueueDepth)
+	listeners := &Listeners{Health: health, RetryInterval: 10 * time.Millisecond}
+
+	listenerState := func() []domain.ListenerHealth {
+		return health.Liveness(time.Now()).Listeners
+	}
+
+	settings := []ListenerSettings{{ProviderID: 1, Address: "127.0.0.1:0"}}
+	listeners.Apply(settings, time.Second)
+	assert.Eventually(t, func() bool {
+		state := listenerState()
+		return len(state) == 1 && state[0].State == other.ListenerStateListening
+	}, time.Second, 5*time.Millisecond)
+	first := listeners.servers[1]
+
+	listeners.Apply(settings, time.Second)
+	assert.Same(t, first, listeners.servers[1], "сервер с прежними настройками не перезапускается")
+
+	office, _ := domain.ParseAllowedNetwork("10.1.0.0/16")
+	settings[0].AllowedNetworks = []*net.IPNet{office}
+	settings[0].MaxPacketsPerMinute = 60
+	listeners.Apply(settings, time.Second)
+	assert.Same(t, first, listeners.servers[1], "разрешенные сети меняются без перезапуска")
+	networks, maxPackets := first.admission()
+	assert.Equal(t, []*net.IPNet{office}, networks)
+	assert.Equal(t, int32(60), maxPackets)
+
+	listeners.Apply(settings, 2*time.Second)
+	assert.True(t, first.Stopped())
+	second := listeners.servers[1]
+	assert.NotSame(t, first, second)
+
+	settings[0].AuthRequired = true
+	listeners.Apply(settings, 2*time.Second)
+	assert.True(t, second.Stopped())
+	assert.True(t, listeners.servers[1].AuthRequired)
+
+	listeners.Apply(nil, time.Second)
+	assert.Empty(t, listeners.servers)
+	assert.Empty(t, listenerState())
+}
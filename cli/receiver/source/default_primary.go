@@ -1,6 +1,7 @@
 package source
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
@@ -34,7 +35,11 @@ func NewDefaultPrimary(dsn string) (*DefaultPrimary, error) {
 func (s *DefaultPrimary) GetVehicles(filter filter.Vehicles) ([]out.Vehicle, error) {
 	var vehicles []out.Vehicle
 
-	q := s.db.Table("vehicle").Select(`id, imei, "oid", name, provider_id, moderation_status`)
+	q := s.db.Table("vehicle").Select(vehicleColumns)
+
+	if len(filter.IDs) > 0 {
+		q = q.Where("id IN ?", filter.IDs)
+	}
 
 	if filter.ProviderId != nil {
 		q = q.Where("provider_id = ?", *filter.ProviderId)
@@ -52,6 +57,17 @@ func (s *DefaultPrimary) GetVehicles(filter filter.Vehicles) ([]out.Vehicle, err
 		q = q.Where(`"oid" = ?`, *filter.OID)
 	}
 
+	if filter.VehicleGroupId != nil {
+		q = q.Where("vehicle_group_id = ?", *filter.VehicleGroupId)
+	}
+
+	if filter.Page != nil {
+		var err error
+		if q, err = applyPage(q, filter.Page, vehicleSortColumns); err != nil {
+			return nil, err
+		}
+	}
+
 	if err := q.Scan(&vehicles).Error; err != nil {
 		return nil, err
 	}
@@ -59,7 +75,7 @@ func (s *DefaultPrimary) GetVehicles(filter filter.Vehicles) ([]out.Vehicle, err
 	return vehicles, nil
 }
 
-func (s *DefaultPrimary) AddVehicle(v insert.Vehicle) (int32, error) {
+func (s *DefaultPrimary) AddVehicle(v insert.Vehicle, author insert.VehicleChangeAuthor) (int32, error) {
 	if v.IMEI == "" || v.ProviderId <= 0 || v.ModerationStatus == "" {
 		return 0, fmt.Errorf("IMEI, ID провайдера и статус модерации не могут быть пустыми")
 	}
@@ -67,15 +83,13 @@ func (s *DefaultPrimary) AddVehicle(v insert.Vehicle) (int32, error) {
 		v.Name = nil
 	}
 
-	const q = `
-		INSERT INTO vehicle (imei, "oid", name, provider_id, moderation_status)
-		VALUES ($1, $2, $3, $4, $5)
-		RETURNING id
-	`
-
 	var id int32
-	if err := s.db.Raw(q, v.IMEI, v.OID, v.Name, v.ProviderId, v.ModerationStatus).
-		Scan(&id).Error; err != nil {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		id, err = insertVehicle(tx, v, author)
+		return err
+	})
+	if err != nil {
 		return 0, err
 	}
 	return id, nil
@@ -84,7 +98,7 @@ func (s *DefaultPrimary) AddVehicle(v insert.Vehicle) (int32, error) {
 func (s *DefaultPrimary) GetVehicle(id int32) (out.Vehicle, error) {
 	var vehicle out.Vehicle
 
-	q := s.db.Table("vehicle").Select(`id, imei, "oid", name, provider_id, moderation_status`).Where("id = ?", id)
+	q := s.db.Table("vehicle").Select(vehicleColumns).Where("id = ?", id)
 
 	if err := q.Scan(&vehicle).Error; err != nil {
 		return out.Vehicle{}, err
@@ -93,15 +107,11 @@ func (s *DefaultPrimary) GetVehicle(id int32) (out.Vehicle, error) {
 	return vehicle, nil
 }
 
-func (s *DefaultPrimary) GetProviders() ([]out.Provider, error) {
-	var providers []out.Provider
-	if err := s.db.Table("provider").Select("id, name").Scan(&providers).Error; err != nil {
-		return nil, err
+func (s *DefaultPrimary) GetLocations(filter filter.Locations) ([]out.Location, error) {
+	if filter.Page != nil {
+		return s.getLocationsPage(filter)
 	}
-	return providers, nil
-}
 
-func (s *DefaultPrimary) GetLocations(filter filter.Locations) ([]out.Location, error) {
 	var locations []out.Location
 
 	sub := s.db.Table("location").Select(`
@@ -116,11 +126,15 @@ func (s *DefaultPrimary) GetLocations(filter filter.Locations) ([]out.Location,
                satellite_count,
                sent_at,
                received_at,
+               is_history,
                ROW_NUMBER() OVER (PARTITION BY vehicle_id ORDER BY sent_at DESC) AS rn`)
 
 	if filter.VehicleId != nil {
 		sub = sub.Where("vehicle_id = ?", *filter.VehicleId)
 	}
+	if filter.ProviderId != nil {
+		sub = sub.Where("vehicle_id IN (SELECT id FROM vehicle WHERE provider_id = ?)", *filter.ProviderId)
+	}
 	if filter.SentBefore != nil {
 		sub = sub.Where("sent_at < ?", *filter.SentBefore)
 	}
@@ -136,7 +150,7 @@ func (s *DefaultPrimary) GetLocations(filter filter.Locations) ([]out.Location,
 
 	q := s.db.Table("(?) AS ranked", sub).
 		Where("rn <= ?", filter.LocationsLimit).
-		Select(`id, vehicle_id, "oid", latitude, longitude, altitude, direction, speed, satellite_count, sent_at, received_at`).
+		Select(`id, vehicle_id, "oid", latitude, longitude, altitude, direction, speed, satellite_count, sent_at, received_at, is_history`).
 		Order("vehicle_id, sent_at DESC")
 
 	if err := q.Scan(&locations).Error; err != nil {
@@ -145,17 +159,53 @@ func (s *DefaultPrimary) GetLocations(filter filter.Locations) ([]out.Location,
 	return locations, nil
 }
 
-func (s *DefaultPrimary) GetApiKeys() ([]out.ApiKey, error) {
-	var apiKeys []out.ApiKey
+// getLocationsPage возвращает страницу общего списка местоположений. Порядок по ключу позволяет
+// использовать индексы по (vehicle_id, sent_at) и (vehicle_id, received_at) вместо нумерации всех
+// подходящих строк.
+func (s *DefaultPrimary) getLocationsPage(filter filter.Locations) ([]out.Location, error) {
+	var locations []out.Location
 
-	if err := s.db.Table("api_key").Select("id, name, hash").Scan(&apiKeys).Error; err != nil {
-		return nil, err
+	q := s.db.Table("location").
+		Select(`id, vehicle_id, "oid", latitude, longitude, altitude, direction, speed, satellite_count, sent_at, received_at, is_history`)
+
+	if filter.VehicleId != nil {
+		q = q.Where("vehicle_id = ?", *filter.VehicleId)
+	}
+	if filter.ProviderId != nil {
+		q = q.Where("vehicle_id IN (SELECT id FROM vehicle WHERE provider_id = ?)", *filter.ProviderId)
+	}
+	if filter.SentBefore != nil {
+		q = q.Where("sent_at < ?", *filter.SentBefore)
+	}
+	if filter.SentAfter != nil {
+		q = q.Where("sent_at > ?", *filter.SentAfter)
+	}
+	if filter.ReceivedBefore != nil {
+		q = q.Where("received_at < ?", *filter.ReceivedBefore)
+	}
+	if filter.ReceivedAfter != nil {
+		q = q.Where("received_at > ?", *filter.ReceivedAfter)
+	}
+	// Сравнение ключа страницы с NULL не выполняется, поэтому такие записи пропадали бы после
+	// первой страницы
+	switch filter.Page.Sort {
+	case "sent_at":
+		q = q.Where("sent_at IS NOT NULL")
+	case "received_at":
+		q = q.Where("received_at IS NOT NULL")
 	}
 
-	return apiKeys, nil
+	q, err := applyPage(q, filter.Page, locationSortColumns)
+	if err != nil {
+		return nil, err
+	}
+	if err := q.Scan(&locations).Error; err != nil {
+		return nil, err
+	}
+	return locations, nil
 }
 
-func (s *DefaultPrimary) UpdateVehicleByImei(imei string, update update.VehicleByImei) error {
+func (s *DefaultPrimary) UpdateVehicleByImei(imei string, update update.VehicleByImei, author insert.VehicleChangeAuthor) error {
 	updates := map[string]interface{}{}
 	if update.Name != nil {
 		updates["name"] = *update.Name
@@ -183,18 +233,12 @@ func (s *DefaultPrimary) UpdateVehicleByImei(imei string, update update.VehicleB
 		if len(ids) > 1 {
 			return fmt.Errorf("по заданному IMEI найдено более одной транспортной единицы")
 		}
-		res := tx.Table("vehicle").Where("id = ?", ids[0]).Updates(updates)
-		if res.Error != nil {
-			return res.Error
-		}
-		if res.RowsAffected != 1 {
-			return fmt.Errorf("обновление не удалось")
-		}
-		return nil
+		_, err := updateVehicle(tx, ids[0], updates, author)
+		return err
 	})
 }
 
-func (s *DefaultPrimary) UpdateVehicleById(id int32, update update.VehicleById) error {
+func (s *DefaultPrimary) UpdateVehicleById(id int32, update update.VehicleById, author insert.VehicleChangeAuthor) error {
 	updates := map[string]interface{}{}
 	if update.Name != nil {
 		updates["name"] = *update.Name
@@ -208,41 +252,87 @@ func (s *DefaultPrimary) UpdateVehicleById(id int32, update update.VehicleById)
 	if update.OID != nil {
 		updates["oid"] = *update.OID
 	}
+	if update.VehicleGroupId != nil {
+		if *update.VehicleGroupId == 0 {
+			updates["vehicle_group_id"] = nil
+		} else {
+			updates["vehicle_group_id"] = *update.VehicleGroupId
+		}
+	}
 	if len(updates) == 0 {
 		return nil
 	}
-	return s.db.Table("vehicle").Where("id = ?", id).Updates(updates).Error
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		_, err := updateVehicle(tx, id, updates, author)
+		return err
+	})
+}
+
+// ImportVehicles добавляет и обновляет транспорт из реестра в одной транзакции: при любой ошибке
+// не применяется ни одно изменение. Возвращает ID добавленного транспорта в порядке inserts.
+func (s *DefaultPrimary) ImportVehicles(inserts []insert.Vehicle, updates []update.VehicleImport, author insert.VehicleChangeAuthor) ([]int32, error) {
+	ids := make([]int32, 0, len(inserts))
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		for _, u := range updates {
+			values := map[string]interface{}{}
+			if u.Name != nil {
+				values["name"] = *u.Name
+			}
+			if u.OID != nil {
+				values["oid"] = *u.OID
+			}
+			if len(values) == 0 {
+				continue
+			}
+			if _, err := updateVehicle(tx, u.ID, values, author); err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return fmt.Errorf("транспорт с ID %d не найден", u.ID)
+				}
+				return err
+			}
+		}
+
+		for _, v := range inserts {
+			id, err := insertVehicle(tx, v, author)
+			if err != nil {
+				return fmt.Errorf("не удалось добавить транспорт с IMEI %s: %w", v.IMEI, err)
+			}
+			ids = append(ids, id)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
 }
 
 func (s *DefaultPrimary) AddLocation(in insert.Location) (int32, error) {
+	// Транспорт ставится в очередь построения поездок тем же запросом, чтобы точка не могла
+	// оказаться в базе без него
 	const q = `
-		INSERT INTO location (
-			vehicle_id, "oid", latitude, longitude, altitude, direction, speed,
-			satellite_count, sent_at, received_at
+		WITH inserted AS (
+			INSERT INTO location (
+				vehicle_id, "oid", latitude, longitude, altitude, direction, speed,
+				satellite_count, sent_at, received_at, is_history, odometer_meters
+			)
+			VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12)
+			RETURNING id, vehicle_id, sent_at
+		), queued AS (
+			INSERT INTO trip_detection_queue (vehicle_id, first_sent_at)
+			SELECT vehicle_id, sent_at FROM inserted WHERE sent_at IS NOT NULL
+			ON CONFLICT (vehicle_id) DO UPDATE SET
+				first_sent_at = LEAST(trip_detection_queue.first_sent_at, EXCLUDED.first_sent_at),
+				version = trip_detection_queue.version + 1
 		)
-		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10)
-		RETURNING id
+		SELECT id FROM inserted
 	`
 
-	loc, err := time.LoadLocation("Europe/Moscow")
-	if err != nil {
-		return 0, err
-	}
-
-	var sentAt any
-	if in.SentAt != nil {
-		s := in.SentAt.In(loc).Format("2006-01-02 15:04:05.999999")
-		sentAt = s
-	} else {
-		sentAt = nil
-	}
-	receivedAt := in.ReceivedAt.In(loc).Format("2006-01-02 15:04:05.999999")
-
 	var id int32
-	err = s.db.Raw(
+	err := s.db.Raw(
 		q,
 		in.VehicleId, in.OID, in.Latitude, in.Longitude, in.Altitude,
-		in.Direction, in.Speed, in.SatelliteCount, sentAt, receivedAt,
+		in.Direction, in.Speed, in.SatelliteCount, in.SentAt, in.ReceivedAt, in.IsHistory, in.OdometerMeters,
 	).Scan(&id).Error
 	if err != nil {
 		return 0, err
@@ -254,8 +344,8 @@ func (s *DefaultPrimary) GetLastVehiclePoint(id int32) (out.Point, error) {
 	var point out.Point
 
 	res := s.db.Table("location").
-		Select("id AS location_id, latitude, longitude, altitude").
-		Where("vehicle_id = ? AND sent_at IS NOT NULL", id).
+		Select("id AS location_id, latitude, longitude, altitude, sent_at").
+		Where("vehicle_id = ? AND sent_at IS NOT NULL AND NOT is_history", id).
 		Order("sent_at DESC").
 		Limit(1).
 		Take(&point)
@@ -266,12 +356,66 @@ func (s *DefaultPrimary) GetLastVehiclePoint(id int32) (out.Point, error) {
 	return point, nil
 }
 
-func (s *DefaultPrimary) GetTracks(after, before time.Time) ([]out.Track, error) {
-	rows, err := s.db.Table("location").
-		Select("id, vehicle_id, latitude, longitude, altitude").
-		Where("received_at BETWEEN ? AND ? AND latitude IS NOT NULL AND longitude IS NOT NULL", after, before).
-		Order("vehicle_id, received_at ASC").
-		Rows()
+// LocationExists проверяет, есть ли у транспорта точка с тем же временем отправки, в том числе
+// среди точек, перенесенных в архив при упрощении трека
+func (s *DefaultPrimary) LocationExists(vehicleId int32, sentAt time.Time) (bool, error) {
+	var exists bool
+	err := s.db.Raw(`
+		SELECT EXISTS (SELECT 1 FROM location WHERE vehicle_id = ? AND sent_at = ?)
+			OR EXISTS (SELECT 1 FROM archived_location WHERE vehicle_id = ? AND sent_at = ?)
+	`, vehicleId, sentAt, vehicleId, sentAt).Scan(&exists).Error
+	if err != nil {
+		return false, fmt.Errorf("ошибка выполнения запроса: %v", err)
+	}
+	return exists, nil
+}
+
+// StreamVehicleTrack передает в fn точки трека транспорта в порядке времени отправки по мере чтения
+// из базы данных, не загружая трек в память целиком. Ошибка fn прерывает чтение.
+func (s *DefaultPrimary) StreamVehicleTrack(filter filter.VehicleTrack, fn func(out.Location) error) error {
+	q := s.db.Table("location").
+		Select(`id, vehicle_id, "oid", latitude, longitude, altitude, direction, speed, satellite_count, sent_at, received_at, is_history`).
+		Where("vehicle_id = ? AND sent_at IS NOT NULL", filter.VehicleId)
+	if filter.SentAfter != nil {
+		q = q.Where("sent_at >= ?", *filter.SentAfter)
+	}
+	if filter.SentBefore != nil {
+		q = q.Where("sent_at <= ?", *filter.SentBefore)
+	}
+
+	rows, err := q.Order("sent_at, id").Rows()
+	if err != nil {
+		return fmt.Errorf("ошибка выполнения запроса: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var location out.Location
+		if err := s.db.ScanRows(rows, &location); err != nil {
+			return fmt.Errorf("ошибка чтения строки: %v", err)
+		}
+		if err := fn(location); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (s *DefaultPrimary) GetTracks(filter filter.Tracks) ([]out.Track, error) {
+	q := s.db.Table("location l").
+		Select("l.id, l.vehicle_id, v.provider_id, l.latitude, l.longitude, l.altitude, l.sent_at").
+		Joins("JOIN vehicle v ON v.id = l.vehicle_id").
+		Where("l.received_at BETWEEN ? AND ?", filter.ReceivedAfter, filter.ReceivedBefore).
+		Where("l.latitude IS NOT NULL AND l.longitude IS NOT NULL AND l.sent_at IS NOT NULL")
+
+	if filter.ProviderId != nil {
+		q = q.Where("v.provider_id = ?", *filter.ProviderId)
+	}
+	if filter.VehicleId != nil {
+		q = q.Where("l.vehicle_id = ?", *filter.VehicleId)
+	}
+
+	rows, err := q.Order("l.vehicle_id, l.sent_at ASC, l.id ASC").Rows()
 	if err != nil {
 		return nil, fmt.Errorf("ошибка выполнения запроса: %v", err)
 	}
@@ -282,13 +426,15 @@ func (s *DefaultPrimary) GetTracks(after, before time.Time) ([]out.Track, error)
 
 	for rows.Next() {
 		var (
-			id        int32
-			vehicleId int32
-			lat       float64
-			lon       float64
-			alt       *int64
+			id         int32
+			vehicleId  int32
+			providerId int32
+			lat        float64
+			lon        float64
+			alt        *int64
+			sentAt     time.Time
 		)
-		if err := rows.Scan(&id, &vehicleId, &lat, &lon, &alt); err != nil {
+		if err := rows.Scan(&id, &vehicleId, &providerId, &lat, &lon, &alt, &sentAt); err != nil {
 			return nil, fmt.Errorf("ошибка чтения строки: %v", err)
 		}
 
@@ -297,8 +443,9 @@ func (s *DefaultPrimary) GetTracks(after, before time.Time) ([]out.Track, error)
 				tracks = append(tracks, *currentTrack)
 			}
 			currentTrack = &out.Track{
-				VehicleId: vehicleId,
-				Points:    []out.Point{},
+				VehicleId:  vehicleId,
+				ProviderId: providerId,
+				Points:     []out.Point{},
 			}
 		}
 
@@ -307,6 +454,7 @@ func (s *DefaultPrimary) GetTracks(after, before time.Time) ([]out.Track, error)
 			Latitude:   lat,
 			Longitude:  lon,
 			Altitude:   alt,
+			SentAt:     &sentAt,
 		})
 	}
 
@@ -0,0 +1,125 @@
+package source
+
+import (
+	"fmt"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/filter"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/insert"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+)
+
+func (s *DefaultPrimary) GetVehicleGroups() ([]out.VehicleGroup, error) {
+	var groups []out.VehicleGroup
+	if err := s.db.Table("vehicle_group").Select("id, name").Order("id").Scan(&groups).Error; err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+func (s *DefaultPrimary) AddVehicleGroup(group insert.VehicleGroup) (int32, error) {
+	if group.Name == "" {
+		return 0, fmt.Errorf("название группы не может быть пустым")
+	}
+
+	var id int32
+	if err := s.db.Raw("INSERT INTO vehicle_group (name) VALUES ($1) RETURNING id", group.Name).
+		Scan(&id).Error; err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (s *DefaultPrimary) DeleteVehicleGroup(id int32) error {
+	res := s.db.Exec("DELETE FROM vehicle_group WHERE id = ?", id)
+	if res.Error != nil {
+		return fmt.Errorf("ошибка выполнения запроса удаления: %v", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("группа транспорта с ID %d не найдена", id)
+	}
+	return nil
+}
+
+func (s *DefaultPrimary) GetAcceptanceSchedules(filter filter.AcceptanceSchedules) ([]out.AcceptanceSchedule, error) {
+	var schedules []out.AcceptanceSchedule
+
+	q := s.db.Table("acceptance_schedule").Select(`
+		id,
+		provider_id,
+		vehicle_group_id,
+		TO_CHAR(date_from, 'YYYY-MM-DD') AS date_from,
+		TO_CHAR(date_to, 'YYYY-MM-DD') AS date_to,
+		recurring_yearly,
+		weekday_mask,
+		hour_mask,
+		time_zone,
+		time_basis
+	`)
+
+	if filter.ID != nil {
+		q = q.Where("id = ?", *filter.ID)
+	}
+	if filter.ProviderId != nil {
+		q = q.Where("provider_id = ?", *filter.ProviderId)
+	}
+	if filter.VehicleGroupId != nil {
+		q = q.Where("vehicle_group_id = ?", *filter.VehicleGroupId)
+	}
+
+	if err := q.Order("id").Scan(&schedules).Error; err != nil {
+		return nil, err
+	}
+
+	return schedules, nil
+}
+
+func (s *DefaultPrimary) AddAcceptanceSchedule(schedule insert.AcceptanceSchedule) (int32, error) {
+	const q = `
+		INSERT INTO acceptance_schedule (
+			provider_id, vehicle_group_id, date_from, date_to, recurring_yearly,
+			weekday_mask, hour_mask, time_zone, time_basis
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id
+	`
+
+	var id int32
+	if err := s.db.Raw(
+		q,
+		schedule.ProviderId, schedule.VehicleGroupId, schedule.DateFrom, schedule.DateTo, schedule.RecurringYearly,
+		schedule.WeekdayMask, schedule.HourMask, schedule.TimeZone, schedule.TimeBasis,
+	).Scan(&id).Error; err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (s *DefaultPrimary) ReplaceAcceptanceSchedule(id int32, schedule insert.AcceptanceSchedule) error {
+	res := s.db.Exec(`
+		UPDATE acceptance_schedule SET
+			provider_id = ?, vehicle_group_id = ?, date_from = ?, date_to = ?, recurring_yearly = ?,
+			weekday_mask = ?, hour_mask = ?, time_zone = ?, time_basis = ?
+		WHERE id = ?
+	`,
+		schedule.ProviderId, schedule.VehicleGroupId, schedule.DateFrom, schedule.DateTo, schedule.RecurringYearly,
+		schedule.WeekdayMask, schedule.HourMask, schedule.TimeZone, schedule.TimeBasis, id,
+	)
+	if res.Error != nil {
+		return fmt.Errorf("ошибка выполнения запроса обновления: %v", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("расписание приема данных с ID %d не найдено", id)
+	}
+	return nil
+}
+
+func (s *DefaultPrimary) DeleteAcceptanceSchedule(id int32) error {
+	res := s.db.Exec("DELETE FROM acceptance_schedule WHERE id = ?", id)
+	if res.Error != nil {
+		return fmt.Errorf("ошибка выполнения запроса удаления: %v", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("расписание приема данных с ID %d не найдено", id)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package source
+
+import (
+	"time"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/insert"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	"gorm.io/gorm"
+)
+
+const apiKeyColumns = `id, name, hash, array_to_string(scopes, ',') AS scopes, provider_id, expires_at, revoked_at, created_at, last_used_at`
+
+// GetApiKeys возвращает все ключи, включая отозванные и просроченные
+func (s *DefaultPrimary) GetApiKeys() ([]out.ApiKey, error) {
+	var apiKeys []out.ApiKey
+
+	if err := s.db.Table("api_key").Select(apiKeyColumns).Order("id").Scan(&apiKeys).Error; err != nil {
+		return nil, err
+	}
+
+	return apiKeys, nil
+}
+
+func (s *DefaultPrimary) AddApiKey(apiKey insert.ApiKey) (int32, error) {
+	var id int32
+	if err := s.db.Raw(`
+		INSERT INTO api_key (name, hash, scopes, provider_id, expires_at)
+		VALUES (?, ?, string_to_array(?, ','), ?, ?)
+		RETURNING id`,
+		apiKey.Name, apiKey.Hash, apiKey.Scopes, apiKey.ProviderId, apiKey.ExpiresAt,
+	).Scan(&id).Error; err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// RotateApiKey заменяет хеш действующего ключа, сохраняя его области доступа и срок действия
+func (s *DefaultPrimary) RotateApiKey(id int32, hash string) error {
+	res := s.db.Exec("UPDATE api_key SET hash = ? WHERE id = ? AND revoked_at IS NULL", hash, id)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (s *DefaultPrimary) RevokeApiKey(id int32, revokedAt time.Time) error {
+	res := s.db.Exec("UPDATE api_key SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL", revokedAt, id)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// TouchApiKeys сохраняет время последнего использования ключей. Время не сдвигается назад, если
+// несколько экземпляров API записывают его одновременно.
+func (s *DefaultPrimary) TouchApiKeys(lastUsed map[int32]time.Time) error {
+	if len(lastUsed) == 0 {
+		return nil
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		for id, usedAt := range lastUsed {
+			if err := tx.Exec(
+				"UPDATE api_key SET last_used_at = GREATEST(last_used_at, ?) WHERE id = ?", usedAt, id,
+			).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,58 @@
+package source
+
+import (
+	"fmt"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/filter"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/insert"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	"gorm.io/gorm"
+)
+
+// ReplaceDailyMileage заменяет пробег транспорта начиная с дня fromDay
+func (s *DefaultPrimary) ReplaceDailyMileage(vehicleId int32, fromDay string, mileage []insert.DailyMileage) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`DELETE FROM daily_mileage WHERE vehicle_id = ? AND "day" >= ?`, vehicleId, fromDay).Error; err != nil {
+			return err
+		}
+
+		for _, day := range mileage {
+			err := tx.Exec(`
+				INSERT INTO daily_mileage (
+					vehicle_id, "day", distance_meters, odometer_start_meters, odometer_end_meters,
+					moving_seconds, idle_seconds, point_count
+				)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+			`, day.VehicleId, day.Day, day.DistanceMeters, day.OdometerStartMeters, day.OdometerEndMeters,
+				day.MovingSeconds, day.IdleSeconds, day.PointCount).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s *DefaultPrimary) GetDailyMileage(filter filter.DailyMileage) ([]out.DailyMileage, error) {
+	var mileage []out.DailyMileage
+
+	q := s.db.Table("daily_mileage AS m").
+		Joins("JOIN vehicle AS v ON v.id = m.vehicle_id").
+		Select(`
+			m.vehicle_id, v.provider_id, TO_CHAR(m."day", 'YYYY-MM-DD') AS day, m.distance_meters,
+			m.odometer_start_meters, m.odometer_end_meters, m.moving_seconds, m.idle_seconds, m.point_count`).
+		Where(`m."day" BETWEEN ? AND ?`, filter.DayFrom, filter.DayTo)
+
+	if filter.ProviderId != nil {
+		q = q.Where("v.provider_id = ?", *filter.ProviderId)
+	}
+	if filter.VehicleId != nil {
+		q = q.Where("m.vehicle_id = ?", *filter.VehicleId)
+	}
+
+	if err := q.Order(`v.provider_id, m.vehicle_id, m."day"`).Scan(&mileage).Error; err != nil {
+		return nil, fmt.Errorf("ошибка выполнения запроса: %v", err)
+	}
+	return mileage, nil
+}
@@ -0,0 +1,184 @@
+package source
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/filter"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/insert"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	"github.com/daniil11ru/egts/cli/receiver/dto/other"
+	"gorm.io/gorm"
+)
+
+type geofenceRow struct {
+	ID              int32
+	Name            string
+	Type            other.GeofenceType
+	Polygon         *string
+	CenterLatitude  *float64
+	CenterLongitude *float64
+	RadiusMeters    *float64
+}
+
+func (r geofenceRow) toGeofence() (out.Geofence, error) {
+	geofence := out.Geofence{
+		ID:              r.ID,
+		Name:            r.Name,
+		Type:            r.Type,
+		CenterLatitude:  r.CenterLatitude,
+		CenterLongitude: r.CenterLongitude,
+		RadiusMeters:    r.RadiusMeters,
+	}
+	if r.Polygon != nil {
+		if err := json.Unmarshal([]byte(*r.Polygon), &geofence.Polygon); err != nil {
+			return out.Geofence{}, fmt.Errorf("не удалось разобрать полигон геозоны с ID %d: %w", r.ID, err)
+		}
+	}
+	return geofence, nil
+}
+
+func geofencePolygonValue(geofence insert.Geofence) (any, error) {
+	if geofence.Polygon == nil {
+		return nil, nil
+	}
+	polygon, err := json.Marshal(geofence.Polygon)
+	if err != nil {
+		return nil, err
+	}
+	return string(polygon), nil
+}
+
+const geofenceColumns = "id, name, type, polygon::text AS polygon, center_latitude, center_longitude, radius_meters"
+
+func (s *DefaultPrimary) GetGeofences() ([]out.Geofence, error) {
+	var rows []geofenceRow
+	if err := s.db.Table("geofence").Select(geofenceColumns).Order("id").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	geofences := make([]out.Geofence, 0, len(rows))
+	for _, row := range rows {
+		geofence, err := row.toGeofence()
+		if err != nil {
+			return nil, err
+		}
+		geofences = append(geofences, geofence)
+	}
+	return geofences, nil
+}
+
+func (s *DefaultPrimary) GetGeofence(id int32) (out.Geofence, error) {
+	var row geofenceRow
+	if err := s.db.Table("geofence").Select(geofenceColumns).Where("id = ?", id).Take(&row).Error; err != nil {
+		return out.Geofence{}, err
+	}
+	return row.toGeofence()
+}
+
+// AddGeofences добавляет геозоны в одной транзакции: при ошибке не сохраняется ни одна из них
+func (s *DefaultPrimary) AddGeofences(geofences []insert.Geofence) ([]int32, error) {
+	ids := make([]int32, 0, len(geofences))
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		for _, geofence := range geofences {
+			polygon, err := geofencePolygonValue(geofence)
+			if err != nil {
+				return err
+			}
+
+			var id int32
+			if err := tx.Raw(`
+				INSERT INTO geofence (name, type, polygon, center_latitude, center_longitude, radius_meters)
+				VALUES (?, ?, ?::jsonb, ?, ?, ?)
+				RETURNING id
+			`, geofence.Name, geofence.Type, polygon, geofence.CenterLatitude, geofence.CenterLongitude, geofence.RadiusMeters,
+			).Scan(&id).Error; err != nil {
+				return err
+			}
+			ids = append(ids, id)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (s *DefaultPrimary) ReplaceGeofence(id int32, geofence insert.Geofence) error {
+	polygon, err := geofencePolygonValue(geofence)
+	if err != nil {
+		return err
+	}
+
+	res := s.db.Exec(`
+		UPDATE geofence SET
+			name = ?, type = ?, polygon = ?::jsonb, center_latitude = ?, center_longitude = ?, radius_meters = ?
+		WHERE id = ?
+	`, geofence.Name, geofence.Type, polygon, geofence.CenterLatitude, geofence.CenterLongitude, geofence.RadiusMeters, id)
+	if res.Error != nil {
+		return fmt.Errorf("ошибка выполнения запроса обновления: %v", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("геозона с ID %d не найдена", id)
+	}
+	return nil
+}
+
+func (s *DefaultPrimary) DeleteGeofence(id int32) error {
+	res := s.db.Exec("DELETE FROM geofence WHERE id = ?", id)
+	if res.Error != nil {
+		return fmt.Errorf("ошибка выполнения запроса удаления: %v", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("геозона с ID %d не найдена", id)
+	}
+	return nil
+}
+
+func (s *DefaultPrimary) AddGeofenceEvents(events []insert.GeofenceEvent) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		for _, event := range events {
+			err := tx.Exec(`
+				INSERT INTO geofence_event (geofence_id, vehicle_id, type, location_id, latitude, longitude, occurred_at)
+				VALUES (?, ?, ?, ?, ?, ?, ?)
+			`, event.GeofenceId, event.VehicleId, event.Type, event.LocationId, event.Latitude, event.Longitude, event.OccurredAt).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *DefaultPrimary) GetGeofenceEvents(filter filter.GeofenceEvents) ([]out.GeofenceEvent, error) {
+	var events []out.GeofenceEvent
+
+	q := s.db.Table("geofence_event").
+		Select("id, geofence_id, vehicle_id, type, location_id, latitude, longitude, occurred_at")
+
+	if filter.GeofenceId != nil {
+		q = q.Where("geofence_id = ?", *filter.GeofenceId)
+	}
+	if filter.VehicleId != nil {
+		q = q.Where("vehicle_id = ?", *filter.VehicleId)
+	}
+	if filter.Type != nil {
+		q = q.Where("type = ?", *filter.Type)
+	}
+	if filter.After != nil {
+		q = q.Where("occurred_at >= ?", *filter.After)
+	}
+	if filter.Before != nil {
+		q = q.Where("occurred_at <= ?", *filter.Before)
+	}
+	if filter.Limit > 0 {
+		q = q.Limit(int(filter.Limit))
+	}
+
+	if err := q.Order("occurred_at ASC, id ASC").Scan(&events).Error; err != nil {
+		return nil, fmt.Errorf("ошибка выполнения запроса: %v", err)
+	}
+	return events, nil
+}
@@ -0,0 +1,99 @@
+package source
+
+import (
+	"fmt"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/filter"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/insert"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+)
+
+func (s *DefaultPrimary) GetGpsFilterSettings(providerId int32) (out.GpsFilterSettings, error) {
+	var settings out.GpsFilterSettings
+
+	res := s.db.Table("gps_filter_settings").
+		Select("provider_id, require_valid, require_3d_fix, max_hdop, max_future_seconds, max_speed_kmh").
+		Where("provider_id = ?", providerId).
+		Take(&settings)
+	if res.Error != nil {
+		return out.GpsFilterSettings{}, res.Error
+	}
+	return settings, nil
+}
+
+func (s *DefaultPrimary) SaveGpsFilterSettings(settings insert.GpsFilterSettings) error {
+	const q = `
+		INSERT INTO gps_filter_settings (
+			provider_id, require_valid, require_3d_fix, max_hdop, max_future_seconds, max_speed_kmh
+		)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (provider_id) DO UPDATE SET
+			require_valid = EXCLUDED.require_valid,
+			require_3d_fix = EXCLUDED.require_3d_fix,
+			max_hdop = EXCLUDED.max_hdop,
+			max_future_seconds = EXCLUDED.max_future_seconds,
+			max_speed_kmh = EXCLUDED.max_speed_kmh
+	`
+
+	return s.db.Exec(
+		q,
+		settings.ProviderId, settings.RequireValid, settings.Require3dFix,
+		settings.MaxHdop, settings.MaxFutureSeconds, settings.MaxSpeedKmh,
+	).Error
+}
+
+func (s *DefaultPrimary) DeleteGpsFilterSettings(providerId int32) error {
+	res := s.db.Exec("DELETE FROM gps_filter_settings WHERE provider_id = ?", providerId)
+	if res.Error != nil {
+		return fmt.Errorf("ошибка выполнения запроса удаления: %v", res.Error)
+	}
+	return nil
+}
+
+func (s *DefaultPrimary) AddRejectedLocation(in insert.RejectedLocation) (int32, error) {
+	const q = `
+		INSERT INTO rejected_location (
+			provider_id, "oid", vehicle_id, rule, detail, latitude, longitude,
+			altitude, speed, hdop, valid, fix_3d, sent_at, received_at
+		)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14)
+		RETURNING id
+	`
+
+	var id int32
+	err := s.db.Raw(
+		q,
+		in.ProviderId, in.OID, in.VehicleId, in.Rule, in.Detail, in.Latitude, in.Longitude,
+		in.Altitude, in.Speed, in.Hdop, in.Valid, in.Fix3d, in.SentAt, in.ReceivedAt,
+	).Scan(&id).Error
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (s *DefaultPrimary) GetRejectedLocations(filter filter.RejectedLocations) ([]out.RejectedLocation, error) {
+	var locations []out.RejectedLocation
+
+	q := s.db.Table("rejected_location").Select(`
+		id, provider_id, "oid", vehicle_id, rule, detail, latitude, longitude, altitude,
+		speed, hdop, valid, fix_3d, sent_at, received_at, rejected_at`)
+
+	if filter.ProviderId != nil {
+		q = q.Where("provider_id = ?", *filter.ProviderId)
+	}
+	if filter.VehicleId != nil {
+		q = q.Where("vehicle_id = ?", *filter.VehicleId)
+	}
+	if filter.Rule != nil {
+		q = q.Where("rule = ?", *filter.Rule)
+	}
+	if filter.Limit > 0 {
+		q = q.Limit(int(filter.Limit))
+	}
+
+	if err := q.Order("rejected_at DESC, id DESC").Scan(&locations).Error; err != nil {
+		return nil, err
+	}
+	return locations, nil
+}
@@ -0,0 +1,40 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+)
+
+const pingTimeout = 2 * time.Second
+
+func (s *DefaultPrimary) Ping() error {
+	db, err := s.db.DB()
+	if err != nil {
+		return fmt.Errorf("ошибка получения соединения с базой данных: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+	defer cancel()
+	if err := db.PingContext(ctx); err != nil {
+		return fmt.Errorf("база данных недоступна: %w", err)
+	}
+	return nil
+}
+
+func (s *DefaultPrimary) GetMigrationVersion() (out.MigrationVersion, error) {
+	var version out.MigrationVersion
+
+	ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+	defer cancel()
+	result := s.db.WithContext(ctx).Raw(`SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version)
+	if result.Error != nil {
+		return version, fmt.Errorf("ошибка получения версии миграций: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return version, fmt.Errorf("миграции не применялись")
+	}
+	return version, nil
+}
@@ -0,0 +1,41 @@
+package source
+
+import (
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/filter"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+)
+
+// GetHistoryBacklog собирает по каждому транспорту сведения о выгрузке черного ящика. Отставание
+// считается для последней полученной исторической точки: чем оно ближе к нулю, тем ближе терминал к
+// завершению выгрузки.
+func (s *DefaultPrimary) GetHistoryBacklog(filter filter.HistoryBacklog) ([]out.HistoryBacklog, error) {
+	var backlog []out.HistoryBacklog
+
+	q := s.db.Table("location AS l").
+		Joins("JOIN vehicle AS v ON v.id = l.vehicle_id").
+		Select(`
+			l.vehicle_id,
+			v.provider_id,
+			COUNT(*) AS count,
+			MIN(l.sent_at) AS oldest_sent_at,
+			MAX(l.sent_at) AS newest_sent_at,
+			MIN(l.received_at) AS first_received_at,
+			MAX(l.received_at) AS last_received_at,
+			(ARRAY_AGG(EXTRACT(EPOCH FROM l.received_at - l.sent_at) ORDER BY l.received_at DESC, l.sent_at DESC))[1] AS current_lag_seconds`).
+		Where("l.is_history AND l.sent_at IS NOT NULL")
+
+	if filter.ProviderId != nil {
+		q = q.Where("v.provider_id = ?", *filter.ProviderId)
+	}
+	if filter.VehicleId != nil {
+		q = q.Where("l.vehicle_id = ?", *filter.VehicleId)
+	}
+	if filter.ReceivedAfter != nil {
+		q = q.Where("l.received_at > ?", *filter.ReceivedAfter)
+	}
+
+	if err := q.Group("l.vehicle_id, v.provider_id").Order("last_received_at DESC").Scan(&backlog).Error; err != nil {
+		return nil, err
+	}
+	return backlog, nil
+}
@@ -0,0 +1,87 @@
+package source
+
+import (
+	"fmt"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/filter"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/insert"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/update"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+)
+
+func (s *DefaultPrimary) GetOidResolutionRules(filter filter.OidResolutionRules) ([]out.OidResolutionRule, error) {
+	var rules []out.OidResolutionRule
+
+	q := s.db.Table("oid_resolution_rule").Select("id, provider_id, priority, type, position, length")
+
+	if filter.ID != nil {
+		q = q.Where("id = ?", *filter.ID)
+	}
+	if filter.ProviderId != nil {
+		q = q.Where("provider_id = ?", *filter.ProviderId)
+	}
+
+	if err := q.Order("provider_id, priority, id").Scan(&rules).Error; err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+func (s *DefaultPrimary) AddOidResolutionRule(rule insert.OidResolutionRule) (int32, error) {
+	if rule.ProviderId <= 0 || rule.Type == "" {
+		return 0, fmt.Errorf("ID провайдера и тип правила не могут быть пустыми")
+	}
+
+	const q = `
+		INSERT INTO oid_resolution_rule (provider_id, priority, type, position, length)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`
+
+	var id int32
+	if err := s.db.Raw(q, rule.ProviderId, rule.Priority, rule.Type, rule.Position, rule.Length).
+		Scan(&id).Error; err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (s *DefaultPrimary) UpdateOidResolutionRule(id int32, update update.OidResolutionRule) error {
+	updates := map[string]interface{}{}
+	if update.Priority != nil {
+		updates["priority"] = *update.Priority
+	}
+	if update.Type != nil {
+		updates["type"] = *update.Type
+	}
+	if update.Position != nil {
+		if *update.Position == "" {
+			updates["position"] = nil
+		} else {
+			updates["position"] = *update.Position
+		}
+	}
+	if update.Length != nil {
+		if *update.Length == 0 {
+			updates["length"] = nil
+		} else {
+			updates["length"] = *update.Length
+		}
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+	return s.db.Table("oid_resolution_rule").Where("id = ?", id).Updates(updates).Error
+}
+
+func (s *DefaultPrimary) DeleteOidResolutionRule(id int32) error {
+	res := s.db.Exec("DELETE FROM oid_resolution_rule WHERE id = ?", id)
+	if res.Error != nil {
+		return fmt.Errorf("ошибка выполнения запроса удаления: %v", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("правило определения транспорта с ID %d не найдено", id)
+	}
+	return nil
+}
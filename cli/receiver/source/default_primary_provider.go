@@ -0,0 +1,155 @@
+package source
+
+import (
+	"fmt"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/filter"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/insert"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/update"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	"gorm.io/gorm"
+)
+
+const providerColumns = "id, name, port, transport, auth_required, enabled, max_packets_per_minute, max_new_vehicles_per_hour"
+
+func (s *DefaultPrimary) GetProviders() ([]out.Provider, error) {
+	var providers []out.Provider
+	if err := s.db.Table("provider").Select(providerColumns).Order("id").Scan(&providers).Error; err != nil {
+		return nil, err
+	}
+	return providers, nil
+}
+
+func (s *DefaultPrimary) GetProvider(id int32) (out.Provider, error) {
+	var provider out.Provider
+	if err := s.db.Table("provider").Select(providerColumns).Where("id = ?", id).Take(&provider).Error; err != nil {
+		return out.Provider{}, err
+	}
+	return provider, nil
+}
+
+// AddProvider добавляет провайдера вместе с правилами определения транспорта в одной транзакции
+func (s *DefaultPrimary) AddProvider(provider insert.Provider, rules []insert.OidResolutionRule) (int32, error) {
+	var id int32
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Raw(`
+			INSERT INTO provider (name, port, transport, auth_required, enabled, max_packets_per_minute, max_new_vehicles_per_hour)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+			RETURNING id
+		`, provider.Name, provider.Port, provider.Transport, provider.AuthRequired, provider.Enabled,
+			provider.MaxPacketsPerMinute, provider.MaxNewVehiclesPerHour,
+		).Scan(&id).Error; err != nil {
+			return err
+		}
+
+		for _, rule := range rules {
+			if err := tx.Exec(`
+				INSERT INTO oid_resolution_rule (provider_id, priority, type, position, length)
+				VALUES (?, ?, ?, ?, ?)
+			`, id, rule.Priority, rule.Type, rule.Position, rule.Length).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (s *DefaultPrimary) UpdateProvider(id int32, update update.Provider) error {
+	updates := map[string]interface{}{}
+	if update.Name != nil {
+		updates["name"] = *update.Name
+	}
+	if update.Port != nil {
+		if *update.Port == 0 {
+			updates["port"] = nil
+		} else {
+			updates["port"] = *update.Port
+		}
+	}
+	if update.Transport != nil {
+		updates["transport"] = *update.Transport
+	}
+	if update.AuthRequired != nil {
+		updates["auth_required"] = *update.AuthRequired
+	}
+	if update.Enabled != nil {
+		updates["enabled"] = *update.Enabled
+	}
+	if update.MaxPacketsPerMinute != nil {
+		if *update.MaxPacketsPerMinute == 0 {
+			updates["max_packets_per_minute"] = nil
+		} else {
+			updates["max_packets_per_minute"] = *update.MaxPacketsPerMinute
+		}
+	}
+	if update.MaxNewVehiclesPerHour != nil {
+		if *update.MaxNewVehiclesPerHour == 0 {
+			updates["max_new_vehicles_per_hour"] = nil
+		} else {
+			updates["max_new_vehicles_per_hour"] = *update.MaxNewVehiclesPerHour
+		}
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+	return s.db.Table("provider").Where("id = ?", id).Updates(updates).Error
+}
+
+func (s *DefaultPrimary) DeleteProvider(id int32) error {
+	res := s.db.Exec("DELETE FROM provider WHERE id = ?", id)
+	if res.Error != nil {
+		return fmt.Errorf("ошибка выполнения запроса удаления: %v", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("провайдер с ID %d не найден", id)
+	}
+	return nil
+}
+
+func (s *DefaultPrimary) GetProviderAllowedNetworks(filter filter.ProviderAllowedNetworks) ([]out.ProviderAllowedNetwork, error) {
+	var networks []out.ProviderAllowedNetwork
+
+	q := s.db.Table("provider_allowed_network").Select("id, provider_id, network::text AS network, description")
+
+	if filter.ID != nil {
+		q = q.Where("id = ?", *filter.ID)
+	}
+	if filter.ProviderId != nil {
+		q = q.Where("provider_id = ?", *filter.ProviderId)
+	}
+
+	if err := q.Order("provider_id, network").Scan(&networks).Error; err != nil {
+		return nil, err
+	}
+	return networks, nil
+}
+
+func (s *DefaultPrimary) AddProviderAllowedNetwork(network insert.ProviderAllowedNetwork) (int32, error) {
+	const q = `
+		INSERT INTO provider_allowed_network (provider_id, network, description)
+		VALUES ($1, $2::cidr, $3)
+		RETURNING id
+	`
+
+	var id int32
+	if err := s.db.Raw(q, network.ProviderId, network.Network, network.Description).Scan(&id).Error; err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (s *DefaultPrimary) DeleteProviderAllowedNetwork(id int32) error {
+	res := s.db.Exec("DELETE FROM provider_allowed_network WHERE id = ?", id)
+	if res.Error != nil {
+		return fmt.Errorf("ошибка выполнения запроса удаления: %v", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("разрешенная сеть с ID %d не найдена", id)
+	}
+	return nil
+}
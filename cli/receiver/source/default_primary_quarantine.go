@@ -0,0 +1,110 @@
+package source
+
+import (
+	"fmt"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/filter"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/insert"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	"gorm.io/gorm"
+)
+
+func applyQuarantinedLocationsFilter(q *gorm.DB, filter filter.QuarantinedLocations) *gorm.DB {
+	if filter.ProviderId != nil {
+		q = q.Where("provider_id = ?", *filter.ProviderId)
+	}
+	if filter.OID != nil {
+		q = q.Where(`"oid" = ?`, *filter.OID)
+	}
+	if filter.TerminalIMEI != nil {
+		q = q.Where("terminal_imei = ?", *filter.TerminalIMEI)
+	}
+	if filter.Reason != nil {
+		q = q.Where("reason = ?", *filter.Reason)
+	}
+	return q
+}
+
+func (s *DefaultPrimary) AddQuarantinedLocation(in insert.QuarantinedLocation) (int32, error) {
+	const q = `
+		INSERT INTO quarantined_location (
+			provider_id, "oid", terminal_imei, vehicle_id, reason, latitude, longitude,
+			altitude, direction, speed, satellite_count, hdop, valid, fix_3d, is_history,
+			odometer_meters, sent_at, received_at
+		)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18)
+		RETURNING id
+	`
+
+	var id int32
+	err := s.db.Raw(
+		q,
+		in.ProviderId, in.OID, in.TerminalIMEI, in.VehicleId, in.Reason, in.Latitude, in.Longitude,
+		in.Altitude, in.Direction, in.Speed, in.SatelliteCount, in.Hdop, in.Valid, in.Fix3d, in.IsHistory,
+		in.OdometerMeters, in.SentAt, in.ReceivedAt,
+	).Scan(&id).Error
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (s *DefaultPrimary) GetQuarantinedLocations(filter filter.QuarantinedLocations) ([]out.QuarantinedLocation, error) {
+	var locations []out.QuarantinedLocation
+
+	q := s.db.Table("quarantined_location").Select(`
+		id, provider_id, "oid", terminal_imei, vehicle_id, reason, latitude, longitude, altitude,
+		direction, speed, satellite_count, hdop, valid, fix_3d, is_history, odometer_meters,
+		sent_at, received_at, quarantined_at`)
+	q = applyQuarantinedLocationsFilter(q, filter)
+	if filter.Limit > 0 {
+		q = q.Limit(int(filter.Limit))
+	}
+
+	if err := q.Order("provider_id, \"oid\", received_at").Scan(&locations).Error; err != nil {
+		return nil, err
+	}
+	return locations, nil
+}
+
+func (s *DefaultPrimary) GetQuarantineGroups(filter filter.QuarantinedLocations) ([]out.QuarantineGroup, error) {
+	var groups []out.QuarantineGroup
+
+	q := s.db.Table("quarantined_location").Select(`
+		provider_id, "oid", terminal_imei, vehicle_id, reason, COUNT(*) AS count,
+		MIN(received_at) AS first_received_at, MAX(received_at) AS last_received_at`)
+	q = applyQuarantinedLocationsFilter(q, filter)
+	q = q.Group(`provider_id, "oid", terminal_imei, vehicle_id, reason`).Order(`provider_id, "oid"`)
+	if filter.Limit > 0 {
+		q = q.Limit(int(filter.Limit))
+	}
+
+	if err := q.Scan(&groups).Error; err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+func (s *DefaultPrimary) DeleteQuarantinedLocationsByIds(ids []int32) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	res := s.db.Exec("DELETE FROM quarantined_location WHERE id IN ?", ids)
+	if res.Error != nil {
+		return 0, fmt.Errorf("ошибка выполнения запроса удаления: %v", res.Error)
+	}
+	return res.RowsAffected, nil
+}
+
+func (s *DefaultPrimary) DeleteQuarantinedLocations(filter filter.QuarantinedLocations) (int64, error) {
+	if filter.ProviderId == nil || filter.OID == nil {
+		return 0, fmt.Errorf("для удаления данных из карантина необходимо указать провайдера и OID")
+	}
+
+	sub := applyQuarantinedLocationsFilter(s.db.Table("quarantined_location").Select("id"), filter)
+	res := s.db.Exec("DELETE FROM quarantined_location WHERE id IN (?)", sub)
+	if res.Error != nil {
+		return 0, fmt.Errorf("ошибка выполнения запроса удаления: %v", res.Error)
+	}
+	return res.RowsAffected, nil
+}
@@ -0,0 +1,146 @@
+package source
+
+import (
+	"fmt"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/filter"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/insert"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/update"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	"github.com/daniil11ru/egts/cli/receiver/dto/other"
+	"gorm.io/gorm"
+)
+
+func (s *DefaultPrimary) AddTerminalSession(session insert.TerminalSession) (int32, error) {
+	var id int32
+	if err := s.db.Raw(`
+		INSERT INTO terminal_session (provider_id, remote_address, connected_at)
+		VALUES (?, ?, ?)
+		RETURNING id
+	`, session.ProviderId, session.RemoteAddress, session.ConnectedAt).Scan(&id).Error; err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// UpdateTerminalSession записывает состояние сессии. Незаданные IMEI, OID и транспорт не
+// затирают ранее сохраненные значения.
+func (s *DefaultPrimary) UpdateTerminalSession(id int32, update update.TerminalSession) error {
+	res := s.db.Exec(`
+		UPDATE terminal_session SET
+			terminal_imei = COALESCE(?, terminal_imei),
+			oid = COALESCE(?, oid),
+			vehicle_id = COALESCE(?, vehicle_id),
+			last_packet_at = COALESCE(?, last_packet_at),
+			packet_count = ?,
+			record_count = ?,
+			decode_error_count = ?,
+			disconnected_at = COALESCE(?, disconnected_at),
+			close_reason = COALESCE(?, close_reason)
+		WHERE id = ?
+	`, update.TerminalIMEI, update.OID, update.VehicleId, update.LastPacketAt,
+		update.PacketCount, update.RecordCount, update.DecodeErrorCount,
+		update.DisconnectedAt, update.CloseReason, id)
+	if res.Error != nil {
+		return fmt.Errorf("ошибка выполнения запроса обновления: %v", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("сессия с ID %d не найдена", id)
+	}
+	return nil
+}
+
+// SaveTerminalSessionVehicles добавляет транспорт к сессии или продлевает время его последней
+// активности; количество записей прибавляется к сохраненному
+func (s *DefaultPrimary) SaveTerminalSessionVehicles(vehicles []insert.TerminalSessionVehicle) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		for _, vehicle := range vehicles {
+			err := tx.Exec(`
+				INSERT INTO terminal_session_vehicle (session_id, vehicle_id, oid, first_seen_at, last_seen_at, record_count)
+				VALUES (?, ?, ?, ?, ?, ?)
+				ON CONFLICT (session_id, vehicle_id) DO UPDATE SET
+					oid = EXCLUDED.oid,
+					first_seen_at = LEAST(terminal_session_vehicle.first_seen_at, EXCLUDED.first_seen_at),
+					last_seen_at = GREATEST(terminal_session_vehicle.last_seen_at, EXCLUDED.last_seen_at),
+					record_count = terminal_session_vehicle.record_count + EXCLUDED.record_count
+			`, vehicle.SessionId, vehicle.VehicleId, vehicle.OID, vehicle.FirstSeenAt, vehicle.LastSeenAt, vehicle.RecordCount).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// CloseOpenTerminalSessions закрывает сессии провайдера, оставшиеся открытыми после остановки
+// сервера, временем последнего пакета
+func (s *DefaultPrimary) CloseOpenTerminalSessions(providerId int32, reason other.SessionCloseReason) (int64, error) {
+	res := s.db.Exec(`
+		UPDATE terminal_session SET
+			disconnected_at = COALESCE(last_packet_at, connected_at),
+			close_reason = ?
+		WHERE provider_id = ? AND disconnected_at IS NULL
+	`, reason, providerId)
+	if res.Error != nil {
+		return 0, fmt.Errorf("ошибка выполнения запроса обновления: %v", res.Error)
+	}
+	return res.RowsAffected, nil
+}
+
+func (s *DefaultPrimary) GetTerminalSessions(filter filter.TerminalSessions) ([]out.TerminalSession, error) {
+	var sessions []out.TerminalSession
+
+	q := s.db.Table("terminal_session").Select(`
+		id, provider_id, remote_address, terminal_imei, oid, vehicle_id, connected_at, disconnected_at,
+		last_packet_at, packet_count, record_count, decode_error_count, close_reason`)
+
+	if filter.ProviderId != nil {
+		q = q.Where("provider_id = ?", *filter.ProviderId)
+	}
+	if filter.VehicleId != nil {
+		q = q.Where("id IN (SELECT session_id FROM terminal_session_vehicle WHERE vehicle_id = ?)", *filter.VehicleId)
+	}
+	if filter.Active != nil {
+		if *filter.Active {
+			q = q.Where("disconnected_at IS NULL")
+		} else {
+			q = q.Where("disconnected_at IS NOT NULL")
+		}
+	}
+	if filter.CloseReason != nil {
+		q = q.Where("close_reason = ?", *filter.CloseReason)
+	}
+	if filter.Limit > 0 {
+		q = q.Limit(int(filter.Limit))
+	}
+
+	if err := q.Order("connected_at DESC, id DESC").Scan(&sessions).Error; err != nil {
+		return nil, fmt.Errorf("ошибка выполнения запроса: %v", err)
+	}
+	return sessions, nil
+}
+
+func (s *DefaultPrimary) GetVehicleConnections(filter filter.VehicleConnections) ([]out.VehicleConnection, error) {
+	var connections []out.VehicleConnection
+
+	q := s.db.Table("vehicle AS v").
+		Select(`
+			v.id AS vehicle_id,
+			v.provider_id,
+			COALESCE(BOOL_OR(ts.disconnected_at IS NULL), FALSE) AS has_open_session,
+			MAX(tsv.last_seen_at) AS last_seen_at`).
+		Joins("LEFT JOIN terminal_session_vehicle AS tsv ON tsv.vehicle_id = v.id").
+		Joins("LEFT JOIN terminal_session AS ts ON ts.id = tsv.session_id")
+
+	if filter.ProviderId != nil {
+		q = q.Where("v.provider_id = ?", *filter.ProviderId)
+	}
+	if filter.VehicleId != nil {
+		q = q.Where("v.id = ?", *filter.VehicleId)
+	}
+
+	if err := q.Group("v.id, v.provider_id").Order("v.id").Scan(&connections).Error; err != nil {
+		return nil, fmt.Errorf("ошибка выполнения запроса: %v", err)
+	}
+	return connections, nil
+}
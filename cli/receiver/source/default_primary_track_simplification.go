@@ -0,0 +1,95 @@
+package source
+
+import (
+	"fmt"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/insert"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	"gorm.io/gorm"
+)
+
+func (s *DefaultPrimary) GetTrackSimplificationSettings(providerId int32) (out.TrackSimplificationSettings, error) {
+	var settings out.TrackSimplificationSettings
+
+	res := s.db.Table("track_simplification_settings").
+		Select("provider_id, enabled, tolerance_meters, max_gap_seconds, stop_radius_meters, stop_min_seconds").
+		Where("provider_id = ?", providerId).
+		Take(&settings)
+	if res.Error != nil {
+		return out.TrackSimplificationSettings{}, res.Error
+	}
+	return settings, nil
+}
+
+func (s *DefaultPrimary) SaveTrackSimplificationSettings(settings insert.TrackSimplificationSettings) error {
+	const q = `
+		INSERT INTO track_simplification_settings (
+			provider_id, enabled, tolerance_meters, max_gap_seconds, stop_radius_meters, stop_min_seconds
+		)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (provider_id) DO UPDATE SET
+			enabled = EXCLUDED.enabled,
+			tolerance_meters = EXCLUDED.tolerance_meters,
+			max_gap_seconds = EXCLUDED.max_gap_seconds,
+			stop_radius_meters = EXCLUDED.stop_radius_meters,
+			stop_min_seconds = EXCLUDED.stop_min_seconds
+	`
+
+	return s.db.Exec(
+		q,
+		settings.ProviderId, settings.Enabled, settings.ToleranceMeters,
+		settings.MaxGapSeconds, settings.StopRadiusMeters, settings.StopMinSeconds,
+	).Error
+}
+
+func (s *DefaultPrimary) DeleteTrackSimplificationSettings(providerId int32) error {
+	res := s.db.Exec("DELETE FROM track_simplification_settings WHERE provider_id = ?", providerId)
+	if res.Error != nil {
+		return fmt.Errorf("ошибка выполнения запроса удаления: %v", res.Error)
+	}
+	return nil
+}
+
+// ArchiveLocations переносит точки в archived_location одной транзакцией, чтобы точка не могла
+// оказаться удалённой без копии в архиве, и ставит их транспорт в очередь построения поездок
+func (s *DefaultPrimary) ArchiveLocations(ids []int32) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	var archived int64
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Exec(`
+			INSERT INTO archived_location (
+				id, vehicle_id, "oid", latitude, longitude, altitude, direction, speed,
+				satellite_count, sent_at, received_at, is_history, odometer_meters
+			)
+			SELECT id, vehicle_id, "oid", latitude, longitude, altitude, direction, speed,
+				satellite_count, sent_at, received_at, is_history, odometer_meters
+			FROM location
+			WHERE id IN ?
+			ON CONFLICT (id) DO NOTHING
+		`, ids)
+		if res.Error != nil {
+			return res.Error
+		}
+		archived = res.RowsAffected
+
+		// Транспорт ставится в очередь построения поездок тем же запросом, что удаляет точки, с
+		// самой ранней из них
+		return tx.Exec(`
+			WITH deleted AS (
+				DELETE FROM location WHERE id IN ? RETURNING vehicle_id, sent_at
+			)
+			INSERT INTO trip_detection_queue (vehicle_id, first_sent_at)
+			SELECT vehicle_id, MIN(sent_at) FROM deleted WHERE sent_at IS NOT NULL GROUP BY vehicle_id
+			ON CONFLICT (vehicle_id) DO UPDATE SET
+				first_sent_at = LEAST(trip_detection_queue.first_sent_at, EXCLUDED.first_sent_at),
+				version = trip_detection_queue.version + 1
+		`, ids).Error
+	})
+	if err != nil {
+		return 0, fmt.Errorf("ошибка переноса точек в архив: %w", err)
+	}
+	return archived, nil
+}
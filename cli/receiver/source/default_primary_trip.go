@@ -0,0 +1,155 @@
+package source
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/filter"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/insert"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	"gorm.io/gorm"
+)
+
+// queueTripDetection — запрос, ставящий транспорт в очередь построения поездок. Ожидает ID
+// транспорта и время навигации самой ранней новой точки.
+const queueTripDetection = `
+	INSERT INTO trip_detection_queue (vehicle_id, first_sent_at)
+	VALUES (?, ?)
+	ON CONFLICT (vehicle_id) DO UPDATE SET
+		first_sent_at = LEAST(trip_detection_queue.first_sent_at, EXCLUDED.first_sent_at),
+		version = trip_detection_queue.version + 1
+`
+
+func (s *DefaultPrimary) GetPendingTripVehicles() ([]out.PendingTripVehicle, error) {
+	var vehicles []out.PendingTripVehicle
+
+	err := s.db.Table("trip_detection_queue").
+		Select("vehicle_id, first_sent_at, version").
+		Order("vehicle_id").
+		Scan(&vehicles).Error
+	if err != nil {
+		return nil, err
+	}
+	return vehicles, nil
+}
+
+// DeletePendingTripVehicle убирает транспорт из очереди построения поездок, если с момента чтения
+// очереди по нему не добавилось точек
+func (s *DefaultPrimary) DeletePendingTripVehicle(vehicleId int32, version int64) error {
+	return s.db.Exec("DELETE FROM trip_detection_queue WHERE vehicle_id = ? AND version = ?", vehicleId, version).Error
+}
+
+// GetTripRebuildStart возвращает начало последней поездки или стоянки, начавшейся не позже sentAt.
+// С этого момента поездки и стоянки транспорта строятся заново. Если таких нет, возвращается sentAt.
+func (s *DefaultPrimary) GetTripRebuildStart(vehicleId int32, sentAt time.Time) (time.Time, error) {
+	var start sql.NullTime
+	err := s.db.Raw(`
+		SELECT MAX(started_at) FROM (
+			SELECT started_at FROM vehicle_trip WHERE vehicle_id = ? AND started_at <= ?
+			UNION ALL
+			SELECT started_at FROM vehicle_stop WHERE vehicle_id = ? AND started_at <= ?
+		) activity
+	`, vehicleId, sentAt, vehicleId, sentAt).Row().Scan(&start)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !start.Valid {
+		return sentAt, nil
+	}
+	return start.Time, nil
+}
+
+// GetTripPoints возвращает точки транспорта начиная с момента from по времени навигации, включая
+// перенесенные в архив при упрощении трека
+func (s *DefaultPrimary) GetTripPoints(vehicleId int32, from time.Time) ([]out.TripPoint, error) {
+	var points []out.TripPoint
+	err := s.db.Raw(`
+		SELECT id AS location_id, latitude, longitude, speed, odometer_meters, sent_at FROM (
+			SELECT id, latitude, longitude, speed, odometer_meters, sent_at FROM location
+			WHERE vehicle_id = ? AND sent_at >= ? AND latitude IS NOT NULL AND longitude IS NOT NULL
+			UNION ALL
+			SELECT id, latitude, longitude, speed, odometer_meters, sent_at FROM archived_location
+			WHERE vehicle_id = ? AND sent_at >= ? AND latitude IS NOT NULL AND longitude IS NOT NULL
+		) point
+		ORDER BY sent_at, id
+	`, vehicleId, from, vehicleId, from).Scan(&points).Error
+	if err != nil {
+		return nil, err
+	}
+	return points, nil
+}
+
+// ReplaceVehicleActivities заменяет поездки и стоянки транспорта, начавшиеся не раньше from
+func (s *DefaultPrimary) ReplaceVehicleActivities(vehicleId int32, from time.Time, trips []insert.Trip, stops []insert.Stop) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM vehicle_trip WHERE vehicle_id = ? AND started_at >= ?", vehicleId, from).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM vehicle_stop WHERE vehicle_id = ? AND started_at >= ?", vehicleId, from).Error; err != nil {
+			return err
+		}
+
+		for _, trip := range trips {
+			err := tx.Exec(`
+				INSERT INTO vehicle_trip (
+					vehicle_id, started_at, ended_at, start_latitude, start_longitude,
+					end_latitude, end_longitude, distance_meters, max_speed, point_count
+				)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			`, trip.VehicleId, trip.StartedAt, trip.EndedAt, trip.StartLatitude, trip.StartLongitude,
+				trip.EndLatitude, trip.EndLongitude, trip.DistanceMeters, trip.MaxSpeed, trip.PointCount).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, stop := range stops {
+			err := tx.Exec(`
+				INSERT INTO vehicle_stop (vehicle_id, started_at, ended_at, latitude, longitude, point_count)
+				VALUES (?, ?, ?, ?, ?, ?)
+			`, stop.VehicleId, stop.StartedAt, stop.EndedAt, stop.Latitude, stop.Longitude, stop.PointCount).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func applyTripsFilter(q *gorm.DB, filter filter.Trips) *gorm.DB {
+	q = q.Where("vehicle_id = ?", filter.VehicleId)
+	if filter.After != nil {
+		q = q.Where("ended_at >= ?", *filter.After)
+	}
+	if filter.Before != nil {
+		q = q.Where("started_at <= ?", *filter.Before)
+	}
+	if filter.Limit > 0 {
+		q = q.Limit(int(filter.Limit))
+	}
+	return q.Order("started_at ASC")
+}
+
+func (s *DefaultPrimary) GetTrips(filter filter.Trips) ([]out.Trip, error) {
+	var trips []out.Trip
+
+	q := s.db.Table("vehicle_trip").Select(`
+		id, vehicle_id, started_at, ended_at, start_latitude, start_longitude,
+		end_latitude, end_longitude, distance_meters, max_speed, point_count`)
+	if err := applyTripsFilter(q, filter).Scan(&trips).Error; err != nil {
+		return nil, fmt.Errorf("ошибка выполнения запроса: %v", err)
+	}
+	return trips, nil
+}
+
+func (s *DefaultPrimary) GetStops(filter filter.Trips) ([]out.Stop, error) {
+	var stops []out.Stop
+
+	q := s.db.Table("vehicle_stop").Select("id, vehicle_id, started_at, ended_at, latitude, longitude, point_count")
+	if err := applyTripsFilter(q, filter).Scan(&stops).Error; err != nil {
+		return nil, fmt.Errorf("ошибка выполнения запроса: %v", err)
+	}
+	return stops, nil
+}
@@ -0,0 +1,154 @@
+package source
+
+import (
+	"database/sql"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/insert"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	"github.com/daniil11ru/egts/cli/receiver/dto/other"
+	"gorm.io/gorm"
+)
+
+// ModerateVehicles устанавливает статус модерации транспорту из списка в одной транзакции и
+// возвращает ID транспорта, статус которого изменился
+func (s *DefaultPrimary) ModerateVehicles(ids []int32, status other.ModerationStatus, author insert.VehicleChangeAuthor) ([]int32, error) {
+	var changed []int32
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		for _, id := range ids {
+			ok, err := updateVehicle(tx, id, map[string]interface{}{"moderation_status": status}, author)
+			if err != nil {
+				return err
+			}
+			if ok {
+				changed = append(changed, id)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return changed, nil
+}
+
+// DeleteVehicle удаляет транспорт вместе с его местоположениями, поездками и пробегом. У точек
+// карантина, отклоненных точек и сессий терминалов ссылка на транспорт снимается.
+func (s *DefaultPrimary) DeleteVehicle(id int32, author insert.VehicleChangeAuthor) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		vehicle, err := lockVehicle(tx, id)
+		if err != nil {
+			return err
+		}
+		oldValues, newValues := diffVehicles(&vehicle, nil)
+		if err := addVehicleChange(tx, id, other.VehicleChangeActionDelete, author, oldValues, newValues); err != nil {
+			return err
+		}
+		return tx.Exec("DELETE FROM vehicle WHERE id = ?", id).Error
+	})
+}
+
+// MergeVehicles переносит данные транспорта duplicateId в транспорт targetId и удаляет дубликат.
+// Местоположения переносятся с прежними ID, кроме точек с временем отправки, которое у targetId уже
+// есть: такие точки удаляются вместе с дубликатом. Поездки, стоянки и пробег targetId
+// перестраиваются при следующем построении, а построенные для дубликата удаляются вместе с ним. OID
+// дубликата переходит к targetId, чтобы приемник не создал дубликат заново по следующему пакету.
+// Возвращает количество перенесенных и пропущенных точек.
+func (s *DefaultPrimary) MergeVehicles(targetId, duplicateId int32, author insert.VehicleChangeAuthor) (int64, int64, error) {
+	var moved, skipped int64
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// Транспорт блокируется в порядке ID, чтобы встречные слияния не взаимоблокировались
+		ids := []int32{targetId, duplicateId}
+		if targetId > duplicateId {
+			ids[0], ids[1] = duplicateId, targetId
+		}
+		locked := make(map[int32]out.Vehicle, 2)
+		for _, id := range ids {
+			vehicle, err := lockVehicle(tx, id)
+			if err != nil {
+				return err
+			}
+			locked[id] = vehicle
+		}
+		target, duplicate := locked[targetId], locked[duplicateId]
+
+		var firstSentAt sql.NullTime
+		err := tx.Raw(`
+			WITH moved AS (
+				UPDATE location d SET vehicle_id = ?
+				WHERE d.vehicle_id = ? AND NOT EXISTS (
+					SELECT 1 FROM location t WHERE t.vehicle_id = ? AND t.sent_at = d.sent_at
+				)
+				RETURNING d.sent_at
+			)
+			SELECT COUNT(*), MIN(sent_at) FROM moved
+		`, targetId, duplicateId, targetId).Row().Scan(&moved, &firstSentAt)
+		if err != nil {
+			return err
+		}
+		if firstSentAt.Valid {
+			if err := tx.Exec(queueTripDetection, targetId, firstSentAt.Time).Error; err != nil {
+				return err
+			}
+		}
+		// Оставшиеся у дубликата точки совпадают по времени отправки с точками targetId
+		if err := tx.Raw("SELECT COUNT(*) FROM location WHERE vehicle_id = ?", duplicateId).Row().Scan(&skipped); err != nil {
+			return err
+		}
+
+		statements := []string{
+			"UPDATE archived_location SET vehicle_id = ? WHERE vehicle_id = ?",
+			"UPDATE quarantined_location SET vehicle_id = ? WHERE vehicle_id = ?",
+			"UPDATE rejected_location SET vehicle_id = ? WHERE vehicle_id = ?",
+			"UPDATE terminal_session SET vehicle_id = ? WHERE vehicle_id = ?",
+		}
+		for _, statement := range statements {
+			if err := tx.Exec(statement, targetId, duplicateId).Error; err != nil {
+				return err
+			}
+		}
+		// Ссылка события на точку сбрасывается, только если точка не перенесена
+		if err := tx.Exec(`
+			UPDATE geofence_event SET
+				vehicle_id = ?,
+				location_id = CASE
+					WHEN location_id IN (SELECT id FROM location WHERE vehicle_id = ?) THEN NULL
+					ELSE location_id
+				END
+			WHERE vehicle_id = ?
+		`, targetId, duplicateId, duplicateId).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(`
+			UPDATE terminal_session_vehicle SET vehicle_id = ?
+			WHERE vehicle_id = ? AND session_id NOT IN (
+				SELECT session_id FROM terminal_session_vehicle WHERE vehicle_id = ?
+			)
+		`, targetId, duplicateId, targetId).Error; err != nil {
+			return err
+		}
+
+		oldValues, newValues := diffVehicles(&duplicate, nil)
+		newValues["merged_into_vehicle_id"] = targetId
+		if err := addVehicleChange(tx, duplicateId, other.VehicleChangeActionMerge, author, oldValues, newValues); err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM vehicle WHERE id = ?", duplicateId).Error; err != nil {
+			return err
+		}
+
+		after := target
+		if duplicate.OID != nil {
+			if err := tx.Table("vehicle").Where("id = ?", targetId).Update("oid", *duplicate.OID).Error; err != nil {
+				return err
+			}
+			after.OID = duplicate.OID
+		}
+		oldValues, newValues = diffVehicles(&target, &after)
+		newValues["merged_vehicle_id"] = duplicateId
+		return addVehicleChange(tx, targetId, other.VehicleChangeActionMerge, author, oldValues, newValues)
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	return moved, skipped, nil
+}
@@ -0,0 +1,167 @@
+package source
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/filter"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/insert"
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	"github.com/daniil11ru/egts/cli/receiver/dto/other"
+	"gorm.io/gorm"
+)
+
+const vehicleColumns = `id, imei, "oid", name, provider_id, moderation_status, vehicle_group_id`
+
+// vehicleChangeValues возвращает поля транспорта, которые попадают в журнал изменений
+func vehicleChangeValues(vehicle out.Vehicle) map[string]interface{} {
+	values := map[string]interface{}{
+		"imei":              vehicle.IMEI,
+		"oid":               nil,
+		"name":              nil,
+		"provider_id":       vehicle.ProviderId,
+		"moderation_status": vehicle.ModerationStatus.String(),
+		"vehicle_group_id":  nil,
+	}
+	if vehicle.OID != nil {
+		values["oid"] = *vehicle.OID
+	}
+	if vehicle.Name != nil {
+		values["name"] = *vehicle.Name
+	}
+	if vehicle.VehicleGroupId != nil {
+		values["vehicle_group_id"] = *vehicle.VehicleGroupId
+	}
+	return values
+}
+
+// diffVehicles оставляет только различающиеся поля. Для нового транспорта (before равен nil)
+// возвращаются все заполненные поля after, для удаленного (after равен nil) — все заполненные
+// поля before.
+func diffVehicles(before, after *out.Vehicle) (map[string]interface{}, map[string]interface{}) {
+	oldValues := map[string]interface{}{}
+	newValues := map[string]interface{}{}
+	if before != nil {
+		oldValues = vehicleChangeValues(*before)
+	}
+	if after != nil {
+		newValues = vehicleChangeValues(*after)
+	}
+
+	if before != nil && after != nil {
+		for field, value := range oldValues {
+			if newValues[field] == value {
+				delete(oldValues, field)
+				delete(newValues, field)
+			}
+		}
+		return oldValues, newValues
+	}
+	for _, values := range []map[string]interface{}{oldValues, newValues} {
+		for field, value := range values {
+			if value == nil {
+				delete(values, field)
+			}
+		}
+	}
+	return oldValues, newValues
+}
+
+// addVehicleChange записывает изменение транспорта в журнал в той же транзакции, что и само
+// изменение
+func addVehicleChange(tx *gorm.DB, vehicleId int32, action other.VehicleChangeAction, author insert.VehicleChangeAuthor, oldValues, newValues map[string]interface{}) error {
+	oldJSON, err := json.Marshal(oldValues)
+	if err != nil {
+		return err
+	}
+	newJSON, err := json.Marshal(newValues)
+	if err != nil {
+		return err
+	}
+
+	return tx.Exec(`
+		INSERT INTO vehicle_change (vehicle_id, action, source, api_key_id, old_values, new_values)
+		VALUES (?, ?, ?, ?, ?::jsonb, ?::jsonb)
+	`, vehicleId, action, author.Source, author.ApiKeyId, string(oldJSON), string(newJSON)).Error
+}
+
+func lockVehicle(tx *gorm.DB, id int32) (out.Vehicle, error) {
+	var vehicle out.Vehicle
+	if err := tx.Raw(`SELECT `+vehicleColumns+` FROM vehicle WHERE id = ? FOR UPDATE`, id).Scan(&vehicle).Error; err != nil {
+		return out.Vehicle{}, err
+	}
+	if vehicle.ID == 0 {
+		return out.Vehicle{}, gorm.ErrRecordNotFound
+	}
+	return vehicle, nil
+}
+
+// updateVehicle изменяет транспорт внутри транзакции tx и записывает изменение в журнал. Возвращает
+// false, если значения полей не изменились; такое изменение в журнал не попадает.
+func updateVehicle(tx *gorm.DB, id int32, updates map[string]interface{}, author insert.VehicleChangeAuthor) (bool, error) {
+	before, err := lockVehicle(tx, id)
+	if err != nil {
+		return false, err
+	}
+	if err := tx.Table("vehicle").Where("id = ?", id).Updates(updates).Error; err != nil {
+		return false, err
+	}
+	after, err := lockVehicle(tx, id)
+	if err != nil {
+		return false, err
+	}
+
+	oldValues, newValues := diffVehicles(&before, &after)
+	if len(newValues) == 0 {
+		return false, nil
+	}
+	return true, addVehicleChange(tx, id, other.VehicleChangeActionUpdate, author, oldValues, newValues)
+}
+
+func insertVehicle(tx *gorm.DB, v insert.Vehicle, author insert.VehicleChangeAuthor) (int32, error) {
+	const q = `
+		INSERT INTO vehicle (imei, "oid", name, provider_id, moderation_status, vehicle_group_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`
+
+	var id int32
+	if err := tx.Raw(q, v.IMEI, v.OID, v.Name, v.ProviderId, v.ModerationStatus, v.VehicleGroupId).Scan(&id).Error; err != nil {
+		return 0, err
+	}
+	oldValues, newValues := diffVehicles(nil, &out.Vehicle{
+		ID:               id,
+		IMEI:             v.IMEI,
+		OID:              v.OID,
+		Name:             v.Name,
+		ProviderId:       v.ProviderId,
+		ModerationStatus: v.ModerationStatus,
+		VehicleGroupId:   v.VehicleGroupId,
+	})
+	return id, addVehicleChange(tx, id, other.VehicleChangeActionCreate, author, oldValues, newValues)
+}
+
+func (s *DefaultPrimary) GetVehicleChanges(filter filter.VehicleChanges) ([]out.VehicleChange, error) {
+	var changes []out.VehicleChange
+
+	q := s.db.Table("vehicle_change AS c").
+		Select(`c.id, c.vehicle_id, c.action, c.source, c.api_key_id, k.name AS api_key_name,
+			c.old_values::text AS old_values, c.new_values::text AS new_values, c.changed_at`).
+		Joins("LEFT JOIN api_key AS k ON k.id = c.api_key_id").
+		Where("c.vehicle_id = ?", filter.VehicleId)
+
+	if filter.After != nil {
+		q = q.Where("c.changed_at >= ?", *filter.After)
+	}
+	if filter.Before != nil {
+		q = q.Where("c.changed_at <= ?", *filter.Before)
+	}
+	if filter.Limit > 0 {
+		q = q.Limit(int(filter.Limit))
+	}
+
+	if err := q.Order("c.changed_at ASC, c.id ASC").Scan(&changes).Error; err != nil {
+		return nil, fmt.Errorf("ошибка выполнения запроса: %v", err)
+	}
+	return changes, nil
+}
@@ -0,0 +1,65 @@
+package source
+
+import (
+	"fmt"
+
+	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/filter"
+	"gorm.io/gorm"
+)
+
+// sortColumn — выражение, по которому упорядочивается список, и тип, к которому приводится
+// значение ключа страницы
+type sortColumn struct {
+	expr string
+	cast string
+}
+
+var vehicleSortColumns = map[string]sortColumn{
+	"id":                {expr: "id", cast: "int4"},
+	"imei":              {expr: "imei", cast: "text"},
+	"name":              {expr: "COALESCE(name, '')", cast: "text"},
+	"provider_id":       {expr: "provider_id", cast: "int4"},
+	"moderation_status": {expr: "moderation_status::text", cast: "text"},
+}
+
+var locationSortColumns = map[string]sortColumn{
+	"id":          {expr: "id", cast: "int4"},
+	"sent_at":     {expr: "sent_at", cast: "timestamptz"},
+	"received_at": {expr: "received_at", cast: "timestamptz"},
+}
+
+// applyPage упорядочивает запрос по полю сортировки и ID и оставляет записи после ключа
+// page.After. Запрашивается на одну запись больше лимита, чтобы вызывающий мог определить, есть ли
+// следующая страница.
+func applyPage(q *gorm.DB, page *filter.Page, columns map[string]sortColumn) (*gorm.DB, error) {
+	column, ok := columns[page.Sort]
+	if !ok {
+		return nil, fmt.Errorf("сортировка по полю %q не поддерживается", page.Sort)
+	}
+
+	direction, comparison := "ASC", ">"
+	if page.Desc {
+		direction, comparison = "DESC", "<"
+	}
+
+	if page.After != nil {
+		if page.Sort == "id" {
+			q = q.Where("id "+comparison+" ?", page.After.ID)
+		} else {
+			q = q.Where(
+				fmt.Sprintf("(%s, id) %s (CAST(? AS %s), ?)", column.expr, comparison, column.cast),
+				page.After.Value, page.After.ID,
+			)
+		}
+	}
+
+	if page.Sort == "id" {
+		q = q.Order("id " + direction)
+	} else {
+		q = q.Order(column.expr + " " + direction).Order("id " + direction)
+	}
+	if page.Limit > 0 {
+		q = q.Limit(page.Limit + 1)
+	}
+	return q, nil
+}
@@ -7,22 +7,103 @@ import (
 	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/insert"
 	"github.com/daniil11ru/egts/cli/receiver/dto/db/in/update"
 	"github.com/daniil11ru/egts/cli/receiver/dto/db/out"
+	"github.com/daniil11ru/egts/cli/receiver/dto/other"
 )
 
 type Primary interface {
 	GetVehicles(filter filter.Vehicles) ([]out.Vehicle, error)
 	GetVehicle(id int32) (out.Vehicle, error)
-	UpdateVehicleByImei(imei string, update update.VehicleByImei) error
-	UpdateVehicleById(id int32, update update.VehicleById) error
-	AddVehicle(v insert.Vehicle) (int32, error)
+	UpdateVehicleByImei(imei string, update update.VehicleByImei, author insert.VehicleChangeAuthor) error
+	UpdateVehicleById(id int32, update update.VehicleById, author insert.VehicleChangeAuthor) error
+	AddVehicle(v insert.Vehicle, author insert.VehicleChangeAuthor) (int32, error)
+	ImportVehicles(inserts []insert.Vehicle, updates []update.VehicleImport, author insert.VehicleChangeAuthor) ([]int32, error)
+	ModerateVehicles(ids []int32, status other.ModerationStatus, author insert.VehicleChangeAuthor) ([]int32, error)
+	DeleteVehicle(id int32, author insert.VehicleChangeAuthor) error
+	MergeVehicles(targetId, duplicateId int32, author insert.VehicleChangeAuthor) (int64, int64, error)
+	GetVehicleChanges(filter filter.VehicleChanges) ([]out.VehicleChange, error)
 
 	GetLocations(filter filter.Locations) ([]out.Location, error)
 	GetLastVehiclePoint(id int32) (out.Point, error)
-	GetTracks(after, before time.Time) ([]out.Track, error)
+	LocationExists(vehicleId int32, sentAt time.Time) (bool, error)
+	GetTracks(filter filter.Tracks) ([]out.Track, error)
+	StreamVehicleTrack(filter filter.VehicleTrack, fn func(out.Location) error) error
 	AddLocation(insert insert.Location) (int32, error)
 	DeleteLocation(id int32) error
+	ArchiveLocations(ids []int32) (int64, error)
+	GetHistoryBacklog(filter filter.HistoryBacklog) ([]out.HistoryBacklog, error)
+
+	GetPendingTripVehicles() ([]out.PendingTripVehicle, error)
+	DeletePendingTripVehicle(vehicleId int32, version int64) error
+	GetTripRebuildStart(vehicleId int32, sentAt time.Time) (time.Time, error)
+	GetTripPoints(vehicleId int32, from time.Time) ([]out.TripPoint, error)
+	ReplaceVehicleActivities(vehicleId int32, from time.Time, trips []insert.Trip, stops []insert.Stop) error
+	GetTrips(filter filter.Trips) ([]out.Trip, error)
+	GetStops(filter filter.Trips) ([]out.Stop, error)
+	ReplaceDailyMileage(vehicleId int32, fromDay string, mileage []insert.DailyMileage) error
+	GetDailyMileage(filter filter.DailyMileage) ([]out.DailyMileage, error)
+
+	AddQuarantinedLocation(insert insert.QuarantinedLocation) (int32, error)
+	GetQuarantinedLocations(filter filter.QuarantinedLocations) ([]out.QuarantinedLocation, error)
+	GetQuarantineGroups(filter filter.QuarantinedLocations) ([]out.QuarantineGroup, error)
+	DeleteQuarantinedLocationsByIds(ids []int32) (int64, error)
+	DeleteQuarantinedLocations(filter filter.QuarantinedLocations) (int64, error)
+
+	AddRejectedLocation(insert insert.RejectedLocation) (int32, error)
+	GetRejectedLocations(filter filter.RejectedLocations) ([]out.RejectedLocation, error)
 
 	GetProviders() ([]out.Provider, error)
+	GetProvider(id int32) (out.Provider, error)
+	AddProvider(provider insert.Provider, rules []insert.OidResolutionRule) (int32, error)
+	UpdateProvider(id int32, update update.Provider) error
+	DeleteProvider(id int32) error
+
+	GetProviderAllowedNetworks(filter filter.ProviderAllowedNetworks) ([]out.ProviderAllowedNetwork, error)
+	AddProviderAllowedNetwork(network insert.ProviderAllowedNetwork) (int32, error)
+	DeleteProviderAllowedNetwork(id int32) error
+
+	GetGpsFilterSettings(providerId int32) (out.GpsFilterSettings, error)
+	SaveGpsFilterSettings(settings insert.GpsFilterSettings) error
+	DeleteGpsFilterSettings(providerId int32) error
+
+	GetTrackSimplificationSettings(providerId int32) (out.TrackSimplificationSettings, error)
+	SaveTrackSimplificationSettings(settings insert.TrackSimplificationSettings) error
+	DeleteTrackSimplificationSettings(providerId int32) error
+
+	GetOidResolutionRules(filter filter.OidResolutionRules) ([]out.OidResolutionRule, error)
+	AddOidResolutionRule(rule insert.OidResolutionRule) (int32, error)
+	UpdateOidResolutionRule(id int32, update update.OidResolutionRule) error
+	DeleteOidResolutionRule(id int32) error
+
+	GetVehicleGroups() ([]out.VehicleGroup, error)
+	AddVehicleGroup(group insert.VehicleGroup) (int32, error)
+	DeleteVehicleGroup(id int32) error
+
+	GetAcceptanceSchedules(filter filter.AcceptanceSchedules) ([]out.AcceptanceSchedule, error)
+	AddAcceptanceSchedule(schedule insert.AcceptanceSchedule) (int32, error)
+	ReplaceAcceptanceSchedule(id int32, schedule insert.AcceptanceSchedule) error
+	DeleteAcceptanceSchedule(id int32) error
+
+	GetGeofences() ([]out.Geofence, error)
+	GetGeofence(id int32) (out.Geofence, error)
+	AddGeofences(geofences []insert.Geofence) ([]int32, error)
+	ReplaceGeofence(id int32, geofence insert.Geofence) error
+	DeleteGeofence(id int32) error
+	AddGeofenceEvents(events []insert.GeofenceEvent) error
+	GetGeofenceEvents(filter filter.GeofenceEvents) ([]out.GeofenceEvent, error)
+
+	AddTerminalSession(session insert.TerminalSession) (int32, error)
+	UpdateTerminalSession(id int32, update update.TerminalSession) error
+	SaveTerminalSessionVehicles(vehicles []insert.TerminalSessionVehicle) error
+	CloseOpenTerminalSessions(providerId int32, reason other.SessionCloseReason) (int64, error)
+	GetTerminalSessions(filter filter.TerminalSessions) ([]out.TerminalSession, error)
+	GetVehicleConnections(filter filter.VehicleConnections) ([]out.VehicleConnection, error)
 
 	GetApiKeys() ([]out.ApiKey, error)
+	AddApiKey(apiKey insert.ApiKey) (int32, error)
+	RotateApiKey(id int32, hash string) error
+	RevokeApiKey(id int32, revokedAt time.Time) error
+	TouchApiKeys(lastUsed map[int32]time.Time) error
+
+	Ping() error
+	GetMigrationVersion() (out.MigrationVersion, error)
 }